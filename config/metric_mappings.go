@@ -75,6 +75,13 @@ var VendorMetricMappings = map[string][]MetricConfig{
 			FallbackKeys: []string{"temperature"},
 			Required:     true,
 		},
+		{
+			Name:         "bgpPeers",
+			Interval:     60 * time.Second,
+			DataKey:      "bgpPeers",
+			FallbackKeys: []string{"bgpPeers"},
+			Required:     false,
+		},
 	},
 	"think": {
 		{
@@ -129,6 +136,58 @@ var VendorMetricMappings = map[string][]MetricConfig{
 			Required:     false,
 		},
 	},
+	// Coletor da OLT Huawei; switches Huawei usam a mesma integração.
+	"olt:huawei": {
+		{
+			Name:         "uptime",
+			Interval:     10 * time.Second,
+			DataKey:      "system_uptime",
+			FallbackKeys: []string{"system_uptime"},
+			Required:     true,
+		},
+		{
+			Name:         "cpu_usage",
+			Interval:     10 * time.Second,
+			DataKey:      "cpu_usage_percent",
+			FallbackKeys: []string{"cpu", "processor_usage"},
+			Required:     true,
+		},
+		{
+			Name:         "memory_usage_percent",
+			Interval:     10 * time.Second,
+			DataKey:      "memory_usage_percent",
+			FallbackKeys: []string{"mem_used_percent", "memory_used_percent"},
+			Required:     true,
+		},
+		{
+			Name:         "temperature",
+			Interval:     10 * time.Second,
+			DataKey:      "temperature",
+			FallbackKeys: []string{"temperature"},
+			Required:     true,
+		},
+		{
+			Name:         "boards",
+			Interval:     60 * time.Second,
+			DataKey:      "boards",
+			FallbackKeys: []string{"boards"},
+			Required:     false,
+		},
+		{
+			Name:         "ponInterfaces",
+			Interval:     30 * time.Second,
+			DataKey:      "ponInterfaces",
+			FallbackKeys: []string{"ponInterfaces"},
+			Required:     false,
+		},
+		{
+			Name:         "onuInfo",
+			Interval:     60 * time.Second,
+			DataKey:      "onuInfo",
+			FallbackKeys: []string{"onuInfo"},
+			Required:     false,
+		},
+	},
 	"cisco": {
 		{
 			Name:         "cpu_usage",
@@ -174,6 +233,48 @@ var VendorMetricMappings = map[string][]MetricConfig{
 			FallbackKeys: []string{"used_memory_mb", "memory_usage"},
 			Required:     true,
 		},
+		{
+			Name:         "total_memory",
+			Interval:     120 * time.Second,
+			DataKey:      "total_memory_mb",
+			FallbackKeys: []string{"total_memory", "total_mem"},
+			Required:     false,
+		},
+		{
+			Name:         "uptime",
+			Interval:     10 * time.Second,
+			DataKey:      "system_uptime",
+			FallbackKeys: []string{"system_uptime"},
+			Required:     true,
+		},
+		{
+			Name:         "temperature",
+			Interval:     10 * time.Second,
+			DataKey:      "temperature",
+			FallbackKeys: []string{"temperature"},
+			Required:     false,
+		},
+		{
+			Name:         "routing_engines",
+			Interval:     30 * time.Second,
+			DataKey:      "routing_engines",
+			FallbackKeys: []string{"routing_engines"},
+			Required:     false,
+		},
+		{
+			Name:         "interface_stats",
+			Interval:     10 * time.Second,
+			DataKey:      "interface_stats",
+			FallbackKeys: []string{"port_statistics", "interfaces"},
+			Required:     false,
+		},
+		{
+			Name:         "bgpPeers",
+			Interval:     60 * time.Second,
+			DataKey:      "bgpPeers",
+			FallbackKeys: []string{"bgpPeers"},
+			Required:     false,
+		},
 	},
 }
 
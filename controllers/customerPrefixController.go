@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	"net/http"
+	models "net_monitor/models"
+	"net_monitor/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CustomerPrefixController struct {
+	Service services.CustomerPrefixService
+}
+
+func NewCustomerPrefixController(service services.CustomerPrefixService) *CustomerPrefixController {
+	return &CustomerPrefixController{Service: service}
+}
+
+func (c *CustomerPrefixController) GetAllCustomerPrefixes(goGin *gin.Context) {
+	prefixes, err := c.Service.GetAll()
+	if err != nil {
+		goGin.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	goGin.JSON(http.StatusOK, prefixes)
+}
+
+func (c *CustomerPrefixController) GetCustomerPrefix(goGin *gin.Context) {
+	id := goGin.Param("id")
+	prefix, err := c.Service.GetById(id)
+	if err != nil {
+		goGin.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if prefix == nil {
+		goGin.JSON(http.StatusNotFound, gin.H{"error": "Prefixo não encontrado"})
+		return
+	}
+	goGin.JSON(http.StatusOK, prefix)
+}
+
+func (c *CustomerPrefixController) CreateCustomerPrefix(goGin *gin.Context) {
+	var req models.CustomerPrefix
+	if errValidation := goGin.ShouldBindJSON(&req); errValidation != nil {
+		goGin.JSON(http.StatusBadRequest, gin.H{"error": "Dados inválidos"})
+		return
+	}
+	errCreate, apiErr := c.Service.Create(&req)
+	if errCreate != nil {
+		goGin.JSON(http.StatusInternalServerError, gin.H{"error": errCreate.Error()})
+		return
+	}
+	if apiErr != nil {
+		goGin.JSON(http.StatusBadRequest, gin.H{"error": apiErr})
+		return
+	}
+	goGin.JSON(http.StatusCreated, req)
+}
+
+func (c *CustomerPrefixController) UpdateCustomerPrefix(goGin *gin.Context) {
+	id := goGin.Param("id")
+	var req models.CustomerPrefix
+	if errValidation := goGin.ShouldBindJSON(&req); errValidation != nil {
+		goGin.JSON(http.StatusBadRequest, gin.H{"error": "Dados inválidos"})
+		return
+	}
+	errUpdate, apiErr := c.Service.Update(id, &req)
+	if errUpdate != nil {
+		goGin.JSON(http.StatusInternalServerError, gin.H{"error": errUpdate.Error()})
+		return
+	}
+	if apiErr != nil {
+		if apiErr.Code == "CUSTOMER_PREFIX_NOT_FOUND" {
+			goGin.JSON(http.StatusNotFound, gin.H{"error": apiErr})
+			return
+		}
+		goGin.JSON(http.StatusBadRequest, gin.H{"error": apiErr})
+		return
+	}
+	goGin.JSON(http.StatusOK, req)
+}
+
+func (c *CustomerPrefixController) DeleteCustomerPrefix(goGin *gin.Context) {
+	id := goGin.Param("id")
+	if err := c.Service.Delete(id); err != nil {
+		goGin.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	goGin.Status(http.StatusNoContent)
+}
@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	models "net_monitor/models"
+	services "net_monitor/services"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DeviceStatusController struct {
+	Service            services.DeviceStatusService
+	AggregationService services.MetricAggregationService
+}
+
+func NewDeviceStatusController(service services.DeviceStatusService, aggregationService services.MetricAggregationService) *DeviceStatusController {
+	return &DeviceStatusController{Service: service, AggregationService: aggregationService}
+}
+
+func (c *DeviceStatusController) GetAllDeviceStatuses(goGin *gin.Context) {
+	goGin.JSON(http.StatusOK, c.Service.GetAllStatuses())
+}
+
+func (c *DeviceStatusController) GetDeviceStatus(goGin *gin.Context) {
+	deviceId := goGin.Param("deviceId")
+	goGin.JSON(http.StatusOK, c.Service.GetStatus(deviceId))
+}
+
+func (c *DeviceStatusController) GetDeviceStatusHistory(goGin *gin.Context) {
+	deviceId := goGin.Param("deviceId")
+	from, to, ok := parsePeriod(goGin, 7*24*time.Hour)
+	if !ok {
+		return
+	}
+	transitions, err := c.Service.GetTransitions(deviceId, from, to)
+	if err != nil {
+		goGin.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	goGin.JSON(http.StatusOK, transitions)
+}
+
+func (c *DeviceStatusController) GetSLAReport(goGin *gin.Context) {
+	location, ok := requestLocation(goGin, c.AggregationService)
+	if !ok {
+		return
+	}
+
+	month := time.Now().In(location)
+	if value := goGin.Query("month"); value != "" {
+		parsed, err := time.Parse("2006-01", value)
+		if err != nil {
+			goGin.JSON(http.StatusBadRequest, gin.H{"error": "Parâmetro 'month' inválido, use AAAA-MM"})
+			return
+		}
+		month = parsed
+	}
+
+	format := goGin.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		goGin.JSON(http.StatusBadRequest, gin.H{"error": "Parâmetro 'format' deve ser json ou csv"})
+		return
+	}
+
+	report, err := c.Service.GetSLAReport(month, goGin.Query("site"), goGin.Query("deviceId"), location)
+	if err != nil {
+		goGin.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	filename := fmt.Sprintf("sla-%s.%s", report.Month, format)
+	goGin.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	if format == "json" {
+		goGin.JSON(http.StatusOK, report)
+		return
+	}
+
+	goGin.Status(http.StatusOK)
+	goGin.Header("Content-Type", "text/csv; charset=utf-8")
+	writer := csv.NewWriter(goGin.Writer)
+	writer.Write(services.SLAReportCSVHeader())
+	for _, entry := range report.Devices {
+		writer.Write(entry.CSVRecord())
+	}
+	writer.Flush()
+}
+
+func (c *DeviceStatusController) GetMaintenanceWindows(goGin *gin.Context) {
+	windows, err := c.Service.GetMaintenanceWindows()
+	if err != nil {
+		goGin.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	goGin.JSON(http.StatusOK, windows)
+}
+
+func (c *DeviceStatusController) CreateMaintenanceWindow(goGin *gin.Context) {
+	var req models.MaintenanceWindow
+	if errValidation := goGin.ShouldBindJSON(&req); errValidation != nil {
+		goGin.JSON(http.StatusBadRequest, gin.H{"error": "Dados inválidos"})
+		return
+	}
+	errCreate, apiErr := c.Service.CreateMaintenanceWindow(&req)
+	if errCreate != nil {
+		goGin.JSON(http.StatusInternalServerError, gin.H{"error": errCreate.Error()})
+		return
+	}
+	if apiErr != nil {
+		goGin.JSON(http.StatusBadRequest, gin.H{"error": apiErr})
+		return
+	}
+	goGin.JSON(http.StatusCreated, req)
+}
+
+func (c *DeviceStatusController) DeleteMaintenanceWindow(goGin *gin.Context) {
+	id := goGin.Param("id")
+	if err := c.Service.DeleteMaintenanceWindow(id); err != nil {
+		goGin.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	goGin.Status(http.StatusNoContent)
+}
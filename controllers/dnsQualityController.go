@@ -0,0 +1,136 @@
+package controllers
+
+import (
+	"net/http"
+	"net/netip"
+	"net_monitor/services"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DNSQualityController struct {
+	Service            services.DNSQualityService
+	AggregationService services.MetricAggregationService
+}
+
+func NewDNSQualityController(service services.DNSQualityService, aggregationService services.MetricAggregationService) *DNSQualityController {
+	return &DNSQualityController{Service: service, AggregationService: aggregationService}
+}
+
+func (c *DNSQualityController) GetServers(goGin *gin.Context) {
+	routerId, ok := optionalRouterId(goGin)
+	if !ok {
+		return
+	}
+	from, to, ok := parsePeriod(goGin, 24*time.Hour)
+	if !ok {
+		return
+	}
+
+	servers, err := c.Service.GetServers(routerId, from, to)
+	if err != nil {
+		goGin.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	goGin.JSON(http.StatusOK, servers)
+}
+
+func (c *DNSQualityController) GetLatency(goGin *gin.Context) {
+	serverIP, ok := dnsServerIP(goGin)
+	if !ok {
+		return
+	}
+	routerId, ok := optionalRouterId(goGin)
+	if !ok {
+		return
+	}
+	from, to, ok := parsePeriod(goGin, 24*time.Hour)
+	if !ok {
+		return
+	}
+
+	histogram, err := c.Service.GetLatency(serverIP, routerId, from, to)
+	if err != nil {
+		goGin.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	goGin.JSON(http.StatusOK, histogram)
+}
+
+func (c *DNSQualityController) GetTrend(goGin *gin.Context) {
+	serverIP, ok := dnsServerIP(goGin)
+	if !ok {
+		return
+	}
+	routerId, ok := optionalRouterId(goGin)
+	if !ok {
+		return
+	}
+	bucket := goGin.DefaultQuery("bucket", "hour")
+	if !slices.Contains(services.AggregationBuckets, bucket) {
+		goGin.JSON(http.StatusBadRequest, gin.H{"error": "Parâmetro 'bucket' inválido"})
+		return
+	}
+	location, ok := requestLocation(goGin, c.AggregationService)
+	if !ok {
+		return
+	}
+	from, to, ok := parsePeriod(goGin, 24*time.Hour)
+	if !ok {
+		return
+	}
+	if to.Sub(from) > maxAggregationPeriod[bucket] {
+		goGin.JSON(http.StatusBadRequest, gin.H{"error": "Período longo demais para o bucket '" + bucket + "'"})
+		return
+	}
+
+	trend, err := c.Service.GetTrend(serverIP, routerId, from, to, bucket, location)
+	if err != nil {
+		goGin.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	goGin.JSON(http.StatusOK, trend)
+}
+
+func (c *DNSQualityController) Compare(goGin *gin.Context) {
+	routerId, ok := optionalRouterId(goGin)
+	if !ok {
+		return
+	}
+	from, to, ok := parsePeriod(goGin, 24*time.Hour)
+	if !ok {
+		return
+	}
+
+	var servers []string
+	for _, value := range strings.Split(goGin.Query("servers"), ",") {
+		if value = strings.TrimSpace(value); value == "" {
+			continue
+		}
+		addr, err := netip.ParseAddr(value)
+		if err != nil {
+			goGin.JSON(http.StatusBadRequest, gin.H{"error": "Servidor DNS inválido: " + value})
+			return
+		}
+		servers = append(servers, addr.Unmap().String())
+	}
+
+	comparisons, err := c.Service.CompareByRouter(routerId, servers, from, to)
+	if err != nil {
+		goGin.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	goGin.JSON(http.StatusOK, comparisons)
+}
+
+func dnsServerIP(goGin *gin.Context) (string, bool) {
+	addr, err := netip.ParseAddr(goGin.Param("ip"))
+	if err != nil {
+		goGin.JSON(http.StatusBadRequest, gin.H{"error": "IP do servidor DNS inválido"})
+		return "", false
+	}
+	return addr.Unmap().String(), true
+}
@@ -0,0 +1,96 @@
+package controllers
+
+import (
+	"net/http"
+	models "net_monitor/models"
+	"net_monitor/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FlowExporterController struct {
+	Service services.ExporterRegistryService
+}
+
+func NewFlowExporterController(service services.ExporterRegistryService) *FlowExporterController {
+	return &FlowExporterController{Service: service}
+}
+
+func (c *FlowExporterController) GetAllFlowExporters(goGin *gin.Context) {
+	exporters, err := c.Service.GetAll()
+	if err != nil {
+		goGin.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	goGin.JSON(http.StatusOK, exporters)
+}
+
+func (c *FlowExporterController) GetFlowExporter(goGin *gin.Context) {
+	id := goGin.Param("id")
+	exporter, err := c.Service.GetById(id)
+	if err != nil {
+		goGin.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if exporter == nil {
+		goGin.JSON(http.StatusNotFound, gin.H{"error": "Exportador não encontrado"})
+		return
+	}
+	goGin.JSON(http.StatusOK, exporter)
+}
+
+// GetUnknownFlowExporters lista os exportadores que enviam flows sem
+// dispositivo associado; para adotar, basta cadastrá-los.
+func (c *FlowExporterController) GetUnknownFlowExporters(goGin *gin.Context) {
+	goGin.JSON(http.StatusOK, c.Service.GetUnknown())
+}
+
+func (c *FlowExporterController) CreateFlowExporter(goGin *gin.Context) {
+	var req models.FlowExporter
+	if errValidation := goGin.ShouldBindJSON(&req); errValidation != nil {
+		goGin.JSON(http.StatusBadRequest, gin.H{"error": "Dados inválidos"})
+		return
+	}
+	errCreate, apiErr := c.Service.Create(&req)
+	if errCreate != nil {
+		goGin.JSON(http.StatusInternalServerError, gin.H{"error": errCreate.Error()})
+		return
+	}
+	if apiErr != nil {
+		goGin.JSON(http.StatusBadRequest, gin.H{"error": apiErr})
+		return
+	}
+	goGin.JSON(http.StatusCreated, req)
+}
+
+func (c *FlowExporterController) UpdateFlowExporter(goGin *gin.Context) {
+	id := goGin.Param("id")
+	var req models.FlowExporter
+	if errValidation := goGin.ShouldBindJSON(&req); errValidation != nil {
+		goGin.JSON(http.StatusBadRequest, gin.H{"error": "Dados inválidos"})
+		return
+	}
+	errUpdate, apiErr := c.Service.Update(id, &req)
+	if errUpdate != nil {
+		goGin.JSON(http.StatusInternalServerError, gin.H{"error": errUpdate.Error()})
+		return
+	}
+	if apiErr != nil {
+		if apiErr.Code == "FLOW_EXPORTER_NOT_FOUND" {
+			goGin.JSON(http.StatusNotFound, gin.H{"error": apiErr})
+			return
+		}
+		goGin.JSON(http.StatusBadRequest, gin.H{"error": apiErr})
+		return
+	}
+	goGin.JSON(http.StatusOK, req)
+}
+
+func (c *FlowExporterController) DeleteFlowExporter(goGin *gin.Context) {
+	id := goGin.Param("id")
+	if err := c.Service.Delete(id); err != nil {
+		goGin.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	goGin.Status(http.StatusNoContent)
+}
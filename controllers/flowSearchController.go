@@ -0,0 +1,103 @@
+package controllers
+
+import (
+	"net/http"
+	"net_monitor/services"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FlowSearchController struct {
+	Service services.FlowSearchService
+}
+
+func NewFlowSearchController(service services.FlowSearchService) *FlowSearchController {
+	return &FlowSearchController{Service: service}
+}
+
+func (c *FlowSearchController) SearchFlows(goGin *gin.Context) {
+	from, to, ok := parsePeriod(goGin, time.Hour)
+	if !ok {
+		return
+	}
+
+	request := services.FlowSearchRequest{
+		From:     from,
+		To:       to,
+		SrcIP:    goGin.Query("srcIp"),
+		DstIP:    goGin.Query("dstIp"),
+		IP:       goGin.Query("ip"),
+		RouterID: goGin.Query("routerId"),
+		RouterIP: goGin.Query("routerIp"),
+	}
+
+	for _, param := range []struct {
+		name   string
+		target **uint16
+	}{
+		{"srcPort", &request.SrcPort},
+		{"dstPort", &request.DstPort},
+		{"port", &request.Port},
+	} {
+		if value := goGin.Query(param.name); value != "" {
+			port, err := strconv.ParseUint(value, 10, 16)
+			if err != nil {
+				goGin.JSON(http.StatusBadRequest, gin.H{"error": "Parâmetro '" + param.name + "' inválido"})
+				return
+			}
+			parsed := uint16(port)
+			*param.target = &parsed
+		}
+	}
+	if value := goGin.Query("protocol"); value != "" {
+		protocol, err := strconv.ParseUint(value, 10, 8)
+		if err != nil {
+			goGin.JSON(http.StatusBadRequest, gin.H{"error": "Parâmetro 'protocol' inválido"})
+			return
+		}
+		parsed := uint8(protocol)
+		request.Protocol = &parsed
+	}
+	if value := goGin.Query("interface"); value != "" {
+		ifIndex, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			goGin.JSON(http.StatusBadRequest, gin.H{"error": "Parâmetro 'interface' inválido"})
+			return
+		}
+		parsed := uint32(ifIndex)
+		request.Interface = &parsed
+	}
+	if value := goGin.Query("page"); value != "" {
+		page, err := strconv.Atoi(value)
+		if err != nil || page < 1 {
+			goGin.JSON(http.StatusBadRequest, gin.H{"error": "Parâmetro 'page' inválido"})
+			return
+		}
+		request.Page = page
+	}
+	if value := goGin.Query("pageSize"); value != "" {
+		pageSize, err := strconv.Atoi(value)
+		if err != nil || pageSize < 1 || pageSize > services.FlowSearchMaxPageSize {
+			goGin.JSON(http.StatusBadRequest, gin.H{"error": "Parâmetro 'pageSize' deve estar entre 1 e " + strconv.Itoa(services.FlowSearchMaxPageSize)})
+			return
+		}
+		request.PageSize = pageSize
+	}
+
+	result, err, apiErr := c.Service.Search(request)
+	if err != nil {
+		goGin.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if apiErr != nil {
+		if apiErr.Code == "QUERY_BUDGET_EXCEEDED" {
+			goGin.JSON(http.StatusUnprocessableEntity, gin.H{"error": apiErr})
+			return
+		}
+		goGin.JSON(http.StatusBadRequest, gin.H{"error": apiErr})
+		return
+	}
+	goGin.JSON(http.StatusOK, result)
+}
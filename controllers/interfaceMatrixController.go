@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"net/http"
+	"net_monitor/services"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type InterfaceMatrixController struct {
+	Service services.InterfaceMatrixService
+}
+
+func NewInterfaceMatrixController(service services.InterfaceMatrixService) *InterfaceMatrixController {
+	return &InterfaceMatrixController{Service: service}
+}
+
+func (c *InterfaceMatrixController) GetMatrix(goGin *gin.Context) {
+	routerId := goGin.Param("routerId")
+	if !primitive.IsValidObjectID(routerId) {
+		goGin.JSON(http.StatusBadRequest, gin.H{"error": "ID do roteador inválido"})
+		return
+	}
+
+	from, to, ok := parsePeriod(goGin, time.Hour)
+	if !ok {
+		return
+	}
+
+	limit := 100
+	if value := goGin.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > 10000 {
+			goGin.JSON(http.StatusBadRequest, gin.H{"error": "Parâmetro 'limit' inválido"})
+			return
+		}
+		limit = parsed
+	}
+
+	result, err := c.Service.GetMatrix(routerId, from, to, limit)
+	if err != nil {
+		goGin.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	goGin.JSON(http.StatusOK, result)
+}
+
+func (c *InterfaceMatrixController) GetRates(goGin *gin.Context) {
+	routerId := goGin.Param("routerId")
+	if !primitive.IsValidObjectID(routerId) {
+		goGin.JSON(http.StatusBadRequest, gin.H{"error": "ID do roteador inválido"})
+		return
+	}
+
+	from, to, ok := parsePeriod(goGin, time.Hour)
+	if !ok {
+		return
+	}
+	if to.Sub(from) > 24*time.Hour {
+		goGin.JSON(http.StatusBadRequest, gin.H{"error": "Período máximo é de 24 horas"})
+		return
+	}
+
+	var ifIndex *uint32
+	if value := goGin.Query("ifIndex"); value != "" {
+		parsed, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			goGin.JSON(http.StatusBadRequest, gin.H{"error": "Parâmetro 'ifIndex' inválido"})
+			return
+		}
+		index := uint32(parsed)
+		ifIndex = &index
+	}
+
+	result, err := c.Service.GetRates(routerId, from, to, ifIndex)
+	if err != nil {
+		goGin.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	goGin.JSON(http.StatusOK, result)
+}
@@ -5,47 +5,74 @@ import (
 	"net_monitor/services"
 
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type IPVersionMetricController struct {
-	Service       services.IPVersionMetricService
-	RouterService services.RoteadorService
+	Service            services.IPVersionMetricService
+	AggregationService services.MetricAggregationService
+	RouterService      services.RoteadorService
 }
 
-func NewIPVersionMetricController(service services.IPVersionMetricService, routerService services.RoteadorService) *IPVersionMetricController {
-	return &IPVersionMetricController{Service: service, RouterService: routerService}
+func NewIPVersionMetricController(service services.IPVersionMetricService, aggregationService services.MetricAggregationService, routerService services.RoteadorService) *IPVersionMetricController {
+	return &IPVersionMetricController{Service: service, AggregationService: aggregationService, RouterService: routerService}
 }
 
 func (c *IPVersionMetricController) GetIPVersionFlowsPercent(goGin *gin.Context) {
-	metrics, err := c.Service.GetIPVersionFlowsPercent()
-	if err != nil {
-		goGin.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	c.flowsPercent(goGin, "")
+}
+
+func (c *IPVersionMetricController) GetIPVersionFlowsPercentByRouter(goGin *gin.Context) {
+	routerId := goGin.Param("routerId")
+	if !c.routerExists(goGin, routerId) {
 		return
 	}
-	goGin.JSON(http.StatusOK, metrics)
+	c.flowsPercent(goGin, routerId)
 }
 
-func (c *IPVersionMetricController) GetIPVersionFlowsPercentByRouter(goGin *gin.Context) {
+func (c *IPVersionMetricController) GetIPVersionBytes(goGin *gin.Context) {
+	c.bytes(goGin, "")
+}
+
+func (c *IPVersionMetricController) GetIPVersionBytesByRouter(goGin *gin.Context) {
 	routerId := goGin.Param("routerId")
-	metrics, errMetric := c.Service.GetIPVersionFlowsPercentByRouter(routerId)
-	if errMetric != nil {
-		goGin.JSON(http.StatusInternalServerError, gin.H{"error": errMetric.Error()})
+	if !c.routerExists(goGin, routerId) {
 		return
 	}
-	router, errSearch := c.RouterService.GetById(routerId)
-	if errSearch != nil {
-		goGin.JSON(http.StatusInternalServerError, gin.H{"error": errSearch.Error()})
+	c.bytes(goGin, routerId)
+}
+
+func (c *IPVersionMetricController) GetIPVersionFlowsPercentByDay(goGin *gin.Context) {
+	date := goGin.Param("date")
+	routerId := goGin.Query("routerId")
+	if routerId != "" && !primitive.IsValidObjectID(routerId) {
+		goGin.JSON(http.StatusBadRequest, gin.H{"error": "ID do roteador inválido"})
 		return
 	}
-	if router == nil {
-		goGin.JSON(http.StatusNotFound, gin.H{"error": "Router not found"})
+	location, ok := requestLocation(goGin, c.AggregationService)
+	if !ok {
+		return
+	}
+
+	metrics, err := c.Service.GetIPVersionFlowsPercentByDay(date, routerId, location)
+	if err != nil {
+		goGin.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	goGin.JSON(http.StatusOK, metrics)
 }
 
-func (c *IPVersionMetricController) GetIPVersionBytes(goGin *gin.Context) {
-	metrics, err := c.Service.GetIPVersionBytes()
+func (c *IPVersionMetricController) flowsPercent(goGin *gin.Context, routerId string) {
+	location, ok := requestLocation(goGin, c.AggregationService)
+	if !ok {
+		return
+	}
+	from, to, ok := dailyPeriod(goGin, location, 30)
+	if !ok {
+		return
+	}
+
+	metrics, err := c.Service.GetIPVersionFlowsPercent(routerId, from, to, location)
 	if err != nil {
 		goGin.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -53,30 +80,37 @@ func (c *IPVersionMetricController) GetIPVersionBytes(goGin *gin.Context) {
 	goGin.JSON(http.StatusOK, metrics)
 }
 
-func (c *IPVersionMetricController) GetIPVersionBytesByRouter(goGin *gin.Context) {
-	routerId := goGin.Param("routerId")
-	metrics, errMetric := c.Service.GetIPVersionBytesByRouter(routerId)
-	if errMetric != nil {
-		goGin.JSON(http.StatusInternalServerError, gin.H{"error": errMetric.Error()})
+func (c *IPVersionMetricController) bytes(goGin *gin.Context, routerId string) {
+	location, ok := requestLocation(goGin, c.AggregationService)
+	if !ok {
 		return
 	}
-	router, errSearch := c.RouterService.GetById(routerId)
-	if errSearch != nil {
-		goGin.JSON(http.StatusInternalServerError, gin.H{"error": errSearch.Error()})
+	from, to, ok := dailyPeriod(goGin, location, 30)
+	if !ok {
 		return
 	}
-	if router == nil {
-		goGin.JSON(http.StatusNotFound, gin.H{"error": "Router not found"})
+
+	metrics, err := c.Service.GetIPVersionBytes(routerId, from, to, location)
+	if err != nil {
+		goGin.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	goGin.JSON(http.StatusOK, metrics)
 }
 
-func (c *IPVersionMetricController) GetIPVersionFlowsPercentByDay(goGin *gin.Context) {
-	date := goGin.Param("date")
-	metrics, err := c.Service.GetIPVersionFlowsPercentByDay(date)
+func (c *IPVersionMetricController) routerExists(goGin *gin.Context, routerId string) bool {
+	if !primitive.IsValidObjectID(routerId) {
+		goGin.JSON(http.StatusBadRequest, gin.H{"error": "ID do roteador inválido"})
+		return false
+	}
+	router, err := c.RouterService.GetById(routerId)
 	if err != nil {
 		goGin.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
 	}
-	goGin.JSON(http.StatusOK, metrics)
+	if router == nil {
+		goGin.JSON(http.StatusNotFound, gin.H{"error": "Router not found"})
+		return false
+	}
+	return true
 }
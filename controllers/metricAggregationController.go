@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"net/http"
+	"net_monitor/models"
+	"net_monitor/services"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Limite de buckets por consulta, para minutos não varrerem meses de dados.
+var maxAggregationPeriod = map[string]time.Duration{
+	"minute": 24 * time.Hour,
+	"hour":   31 * 24 * time.Hour,
+	"day":    366 * 24 * time.Hour,
+	"week":   2 * 366 * 24 * time.Hour,
+	"month":  5 * 366 * 24 * time.Hour,
+}
+
+type MetricAggregationController struct {
+	Service services.MetricAggregationService
+}
+
+func NewMetricAggregationController(service services.MetricAggregationService) *MetricAggregationController {
+	return &MetricAggregationController{Service: service}
+}
+
+func (c *MetricAggregationController) Aggregate(goGin *gin.Context) {
+	source := goGin.Param("source")
+	fields, err := services.ParseAggregationFields(source, goGin.Query("fields"))
+	if err != nil {
+		goGin.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	bucket := goGin.DefaultQuery("bucket", "hour")
+	if !slices.Contains(services.AggregationBuckets, bucket) {
+		goGin.JSON(http.StatusBadRequest, gin.H{"error": "Parâmetro 'bucket' inválido"})
+		return
+	}
+	groupBy := goGin.Query("groupBy")
+	if !services.AggregationGroupAllowed(source, groupBy) {
+		goGin.JSON(http.StatusBadRequest, gin.H{"error": "Parâmetro 'groupBy' inválido"})
+		return
+	}
+	routerId, ok := optionalRouterId(goGin)
+	if !ok {
+		return
+	}
+
+	location, ok := requestLocation(goGin, c.Service)
+	if !ok {
+		return
+	}
+	from, to, ok := parsePeriod(goGin, 24*time.Hour)
+	if !ok {
+		return
+	}
+	if to.Sub(from) > maxAggregationPeriod[bucket] {
+		goGin.JSON(http.StatusBadRequest, gin.H{"error": "Período longo demais para o bucket '" + bucket + "'"})
+		return
+	}
+
+	points, err := c.Service.Aggregate(services.AggregationQuery{
+		Source:   source,
+		From:     from,
+		To:       to,
+		Bucket:   bucket,
+		Location: location,
+		GroupBy:  groupBy,
+		RouterID: routerId,
+		Site:     goGin.Query("site"),
+		Fields:   fields,
+	})
+	if err != nil {
+		goGin.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	goGin.JSON(http.StatusOK, gin.H{"timezone": location.String(), "bucket": bucket, "points": points})
+}
+
+// requestLocation resolve o fuso pelo parâmetro 'tz', depois pelo usuário
+// autenticado e por fim pela configuração do sistema.
+func requestLocation(goGin *gin.Context, aggregation services.MetricAggregationService) (*time.Location, bool) {
+	var user *models.User
+	if value, ok := goGin.Get("user"); ok {
+		user, _ = value.(*models.User)
+	}
+	location, err := aggregation.Location(goGin.Query("tz"), user)
+	if err != nil {
+		goGin.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return location, true
+}
+
+// dailyPeriod usa 'from'/'to' quando informados; sem 'from' começa à
+// meia-noite local de days dias atrás.
+func dailyPeriod(goGin *gin.Context, location *time.Location, days int) (time.Time, time.Time, bool) {
+	from, to, ok := parsePeriod(goGin, 24*time.Hour)
+	if !ok {
+		return time.Time{}, time.Time{}, false
+	}
+	if goGin.Query("from") == "" {
+		start := to.In(location).AddDate(0, 0, -days)
+		from = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, location)
+	}
+	if to.Sub(from) > maxAggregationPeriod["day"] {
+		goGin.JSON(http.StatusBadRequest, gin.H{"error": "Período máximo é de 366 dias"})
+		return time.Time{}, time.Time{}, false
+	}
+	return from, to, true
+}
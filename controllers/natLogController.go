@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"net/http"
+	models "net_monitor/models"
+	"net_monitor/services"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NATLogController struct {
+	Service services.NATLogService
+}
+
+func NewNATLogController(service services.NATLogService) *NATLogController {
+	return &NATLogController{Service: service}
+}
+
+func (c *NATLogController) Lookup(goGin *gin.Context) {
+	publicIP := goGin.Query("ip")
+	if publicIP == "" {
+		goGin.JSON(http.StatusBadRequest, gin.H{"error": "Parâmetro 'ip' é obrigatório"})
+		return
+	}
+
+	port, err := strconv.ParseUint(goGin.Query("port"), 10, 16)
+	if err != nil {
+		goGin.JSON(http.StatusBadRequest, gin.H{"error": "Parâmetro 'port' inválido"})
+		return
+	}
+
+	at, err := time.Parse(time.RFC3339, goGin.Query("at"))
+	if err != nil {
+		goGin.JSON(http.StatusBadRequest, gin.H{"error": "Parâmetro 'at' inválido, use RFC3339"})
+		return
+	}
+
+	tolerance := 0
+	if value := goGin.Query("toleranceSeconds"); value != "" {
+		tolerance, err = strconv.Atoi(value)
+		if err != nil || tolerance < 0 || tolerance > 3600 {
+			goGin.JSON(http.StatusBadRequest, gin.H{"error": "Parâmetro 'toleranceSeconds' deve estar entre 0 e 3600"})
+			return
+		}
+	}
+
+	var user *models.User
+	if value, ok := goGin.Get("user"); ok {
+		user, _ = value.(*models.User)
+	}
+
+	matches, err := c.Service.Lookup(services.NATLogLookupRequest{
+		PublicIP:  publicIP,
+		Port:      uint16(port),
+		At:        at,
+		Tolerance: time.Duration(tolerance) * time.Second,
+		Reason:    goGin.Query("reason"),
+		ClientIP:  goGin.ClientIP(),
+	}, user)
+	if err != nil {
+		goGin.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	goGin.JSON(http.StatusOK, matches)
+}
+
+func (c *NATLogController) GetAudit(goGin *gin.Context) {
+	from, to, ok := parsePeriod(goGin, 30*24*time.Hour)
+	if !ok {
+		return
+	}
+
+	limit := 100
+	if value := goGin.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > 1000 {
+			goGin.JSON(http.StatusBadRequest, gin.H{"error": "Parâmetro 'limit' inválido"})
+			return
+		}
+		limit = parsed
+	}
+
+	audit, err := c.Service.GetAudit(from, to, limit)
+	if err != nil {
+		goGin.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	goGin.JSON(http.StatusOK, audit)
+}
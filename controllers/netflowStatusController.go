@@ -0,0 +1,26 @@
+package controllers
+
+import (
+	"net/http"
+	"net_monitor/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NetflowStatusController struct {
+	Service services.NetflowStatusService
+}
+
+func NewNetflowStatusController(service services.NetflowStatusService) *NetflowStatusController {
+	return &NetflowStatusController{Service: service}
+}
+
+// GetStatus responde 503 com o pipeline degradado, para servir de health check.
+func (c *NetflowStatusController) GetStatus(goGin *gin.Context) {
+	status := c.Service.GetStatus()
+	if status.Health != services.NetflowHealthOK {
+		goGin.JSON(http.StatusServiceUnavailable, status)
+		return
+	}
+	goGin.JSON(http.StatusOK, status)
+}
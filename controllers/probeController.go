@@ -0,0 +1,132 @@
+package controllers
+
+import (
+	"net/http"
+	models "net_monitor/models"
+	services "net_monitor/services"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ProbeController struct {
+	Service services.ProbeService
+}
+
+func NewProbeController(service services.ProbeService) *ProbeController {
+	return &ProbeController{Service: service}
+}
+
+func (c *ProbeController) GetAllProbeConfigs(goGin *gin.Context) {
+	configs, err := c.Service.GetAllConfigs()
+	if err != nil {
+		goGin.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	goGin.JSON(http.StatusOK, configs)
+}
+
+func (c *ProbeController) GetProbeConfig(goGin *gin.Context) {
+	deviceId := goGin.Param("deviceId")
+	probeConfig, err := c.Service.GetConfig(deviceId)
+	if err != nil {
+		goGin.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if probeConfig == nil {
+		goGin.JSON(http.StatusNotFound, gin.H{"error": "Configuração de probe não encontrada"})
+		return
+	}
+	goGin.JSON(http.StatusOK, probeConfig)
+}
+
+func (c *ProbeController) SaveProbeConfig(goGin *gin.Context) {
+	deviceId := goGin.Param("deviceId")
+	var req models.ProbeConfig
+	if errValidation := goGin.ShouldBindJSON(&req); errValidation != nil {
+		goGin.JSON(http.StatusBadRequest, gin.H{"error": "Dados inválidos"})
+		return
+	}
+	errSave, apiErr := c.Service.SaveConfig(deviceId, &req)
+	if errSave != nil {
+		goGin.JSON(http.StatusInternalServerError, gin.H{"error": errSave.Error()})
+		return
+	}
+	if apiErr != nil {
+		goGin.JSON(http.StatusBadRequest, gin.H{"error": apiErr})
+		return
+	}
+	goGin.JSON(http.StatusOK, req)
+}
+
+func (c *ProbeController) DeleteProbeConfig(goGin *gin.Context) {
+	deviceId := goGin.Param("deviceId")
+	if err := c.Service.DeleteConfig(deviceId); err != nil {
+		goGin.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	goGin.Status(http.StatusNoContent)
+}
+
+func (c *ProbeController) GetProbeResults(goGin *gin.Context) {
+	deviceId := goGin.Param("deviceId")
+	from, to, ok := parsePeriod(goGin, 24*time.Hour)
+	if !ok {
+		return
+	}
+	results, err := c.Service.GetResults(deviceId, from, to)
+	if err != nil {
+		goGin.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	goGin.JSON(http.StatusOK, results)
+}
+
+func (c *ProbeController) GetProbeAvailability(goGin *gin.Context) {
+	deviceId := goGin.Param("deviceId")
+	from, to, ok := parsePeriod(goGin, 30*24*time.Hour)
+	if !ok {
+		return
+	}
+	availability, err := c.Service.GetAvailability(deviceId, from, to)
+	if err != nil {
+		goGin.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	goGin.JSON(http.StatusOK, availability)
+}
+
+func (c *ProbeController) GetProbeStatus(goGin *gin.Context) {
+	deviceId := goGin.Param("deviceId")
+	goGin.JSON(http.StatusOK, c.Service.GetStatus(deviceId))
+}
+
+// parsePeriod lê ?from= e ?to= em RFC3339; sem parâmetros usa a janela padrão até agora.
+func parsePeriod(goGin *gin.Context, defaultWindow time.Duration) (time.Time, time.Time, bool) {
+	to := time.Now()
+	if value := goGin.Query("to"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			goGin.JSON(http.StatusBadRequest, gin.H{"error": "Parâmetro 'to' inválido, use RFC3339"})
+			return time.Time{}, time.Time{}, false
+		}
+		to = parsed
+	}
+
+	from := to.Add(-defaultWindow)
+	if value := goGin.Query("from"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			goGin.JSON(http.StatusBadRequest, gin.H{"error": "Parâmetro 'from' inválido, use RFC3339"})
+			return time.Time{}, time.Time{}, false
+		}
+		from = parsed
+	}
+
+	if !from.Before(to) {
+		goGin.JSON(http.StatusBadRequest, gin.H{"error": "'from' deve ser anterior a 'to'"})
+		return time.Time{}, time.Time{}, false
+	}
+
+	return from, to, true
+}
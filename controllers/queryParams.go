@@ -0,0 +1,28 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// optionalRouterId lê o filtro routerId da query; vazio significa todos os
+// roteadores. Responde 400 se o ID for inválido.
+func optionalRouterId(goGin *gin.Context) (string, bool) {
+	routerId := goGin.Query("routerId")
+	if !validRouterId(goGin, routerId) {
+		return "", false
+	}
+	return routerId, true
+}
+
+// validRouterId aceita vazio ou um ObjectID e responde 400 nos outros casos,
+// para IDs vindos de path ou de query.
+func validRouterId(goGin *gin.Context, routerId string) bool {
+	if routerId != "" && !primitive.IsValidObjectID(routerId) {
+		goGin.JSON(http.StatusBadRequest, gin.H{"error": "ID do roteador inválido"})
+		return false
+	}
+	return true
+}
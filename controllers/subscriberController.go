@@ -0,0 +1,158 @@
+package controllers
+
+import (
+	"net/http"
+	models "net_monitor/models"
+	"net_monitor/services"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SubscriberController struct {
+	Service            services.SubscriberService
+	AggregationService services.MetricAggregationService
+}
+
+func NewSubscriberController(service services.SubscriberService, aggregationService services.MetricAggregationService) *SubscriberController {
+	return &SubscriberController{Service: service, AggregationService: aggregationService}
+}
+
+func (c *SubscriberController) GetAllSubscribers(goGin *gin.Context) {
+	subscribers, err := c.Service.GetAll()
+	if err != nil {
+		goGin.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	goGin.JSON(http.StatusOK, subscribers)
+}
+
+func (c *SubscriberController) GetSubscriber(goGin *gin.Context) {
+	id := goGin.Param("id")
+	subscriber, err := c.Service.GetById(id)
+	if err != nil {
+		goGin.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if subscriber == nil {
+		goGin.JSON(http.StatusNotFound, gin.H{"error": "Assinante não encontrado"})
+		return
+	}
+	goGin.JSON(http.StatusOK, subscriber)
+}
+
+func (c *SubscriberController) CreateSubscriber(goGin *gin.Context) {
+	var req models.Subscriber
+	if errValidation := goGin.ShouldBindJSON(&req); errValidation != nil {
+		goGin.JSON(http.StatusBadRequest, gin.H{"error": "Dados inválidos"})
+		return
+	}
+	errCreate, apiErr := c.Service.Create(&req)
+	if errCreate != nil {
+		goGin.JSON(http.StatusInternalServerError, gin.H{"error": errCreate.Error()})
+		return
+	}
+	if apiErr != nil {
+		goGin.JSON(http.StatusBadRequest, gin.H{"error": apiErr})
+		return
+	}
+	goGin.JSON(http.StatusCreated, req)
+}
+
+func (c *SubscriberController) UpdateSubscriber(goGin *gin.Context) {
+	id := goGin.Param("id")
+	var req models.Subscriber
+	if errValidation := goGin.ShouldBindJSON(&req); errValidation != nil {
+		goGin.JSON(http.StatusBadRequest, gin.H{"error": "Dados inválidos"})
+		return
+	}
+	errUpdate, apiErr := c.Service.Update(id, &req)
+	if errUpdate != nil {
+		goGin.JSON(http.StatusInternalServerError, gin.H{"error": errUpdate.Error()})
+		return
+	}
+	if apiErr != nil {
+		if apiErr.Code == "SUBSCRIBER_NOT_FOUND" {
+			goGin.JSON(http.StatusNotFound, gin.H{"error": apiErr})
+			return
+		}
+		goGin.JSON(http.StatusBadRequest, gin.H{"error": apiErr})
+		return
+	}
+	goGin.JSON(http.StatusOK, req)
+}
+
+func (c *SubscriberController) DeleteSubscriber(goGin *gin.Context) {
+	id := goGin.Param("id")
+	if err := c.Service.Delete(id); err != nil {
+		goGin.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	goGin.Status(http.StatusNoContent)
+}
+
+func (c *SubscriberController) GetSubscriberUsage(goGin *gin.Context) {
+	id := goGin.Param("id")
+	granularity := goGin.DefaultQuery("granularity", "day")
+	if granularity != "hour" && granularity != "day" && granularity != "month" {
+		goGin.JSON(http.StatusBadRequest, gin.H{"error": "Parâmetro 'granularity' deve ser hour, day ou month"})
+		return
+	}
+
+	location, ok := requestLocation(goGin, c.AggregationService)
+	if !ok {
+		return
+	}
+	from, to, ok := parsePeriod(goGin, 30*24*time.Hour)
+	if !ok {
+		return
+	}
+
+	subscriber, err := c.Service.GetById(id)
+	if err != nil {
+		goGin.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if subscriber == nil {
+		goGin.JSON(http.StatusNotFound, gin.H{"error": "Assinante não encontrado"})
+		return
+	}
+
+	usage, err := c.Service.GetUsage(id, granularity, from, to, location)
+	if err != nil {
+		goGin.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	goGin.JSON(http.StatusOK, usage)
+}
+
+func (c *SubscriberController) GetTopConsumers(goGin *gin.Context) {
+	from, to, ok := parsePeriod(goGin, 24*time.Hour)
+	if !ok {
+		return
+	}
+
+	limit := 10
+	if value := goGin.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > 1000 {
+			goGin.JSON(http.StatusBadRequest, gin.H{"error": "Parâmetro 'limit' inválido"})
+			return
+		}
+		limit = parsed
+	}
+
+	direction := goGin.DefaultQuery("direction", "total")
+	if direction != "total" && direction != "upload" && direction != "download" {
+		goGin.JSON(http.StatusBadRequest, gin.H{"error": "Parâmetro 'direction' deve ser total, upload ou download"})
+		return
+	}
+
+	consumers, err := c.Service.GetTopConsumers(from, to, limit, direction)
+	if err != nil {
+		goGin.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	goGin.JSON(http.StatusOK, consumers)
+}
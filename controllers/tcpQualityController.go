@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"net/http"
+	"net_monitor/services"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TCPQualityController struct {
+	Service services.TCPQualityService
+}
+
+func NewTCPQualityController(service services.TCPQualityService) *TCPQualityController {
+	return &TCPQualityController{Service: service}
+}
+
+func (c *TCPQualityController) GetServices(goGin *gin.Context) {
+	routerId, ok := optionalRouterId(goGin)
+	if !ok {
+		return
+	}
+
+	from, to, ok := parsePeriod(goGin, time.Hour)
+	if !ok {
+		return
+	}
+
+	limit := 50
+	if value := goGin.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > 1000 {
+			goGin.JSON(http.StatusBadRequest, gin.H{"error": "Parâmetro 'limit' inválido"})
+			return
+		}
+		limit = parsed
+	}
+
+	orderBy := goGin.DefaultQuery("orderBy", "flows")
+	if !slices.Contains(services.TCPQualityOrderFields, orderBy) {
+		goGin.JSON(http.StatusBadRequest, gin.H{"error": "Parâmetro 'orderBy' inválido"})
+		return
+	}
+
+	result, err := c.Service.GetServices(routerId, from, to, limit, orderBy)
+	if err != nil {
+		goGin.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	goGin.JSON(http.StatusOK, result)
+}
+
+func (c *TCPQualityController) GetSeries(goGin *gin.Context) {
+	routerId, ok := optionalRouterId(goGin)
+	if !ok {
+		return
+	}
+
+	from, to, ok := parsePeriod(goGin, 6*time.Hour)
+	if !ok {
+		return
+	}
+	if to.Sub(from) > 7*24*time.Hour {
+		goGin.JSON(http.StatusBadRequest, gin.H{"error": "Período máximo é de 7 dias"})
+		return
+	}
+
+	var servicePort *uint16
+	if value := goGin.Query("port"); value != "" {
+		parsed, err := strconv.ParseUint(value, 10, 16)
+		if err != nil {
+			goGin.JSON(http.StatusBadRequest, gin.H{"error": "Parâmetro 'port' inválido"})
+			return
+		}
+		port := uint16(parsed)
+		servicePort = &port
+	}
+
+	result, err := c.Service.GetSeries(routerId, servicePort, from, to)
+	if err != nil {
+		goGin.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	goGin.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"net/http"
+	"net_monitor/services"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TopTalkersController struct {
+	Service services.TopTalkersService
+}
+
+func NewTopTalkersController(service services.TopTalkersService) *TopTalkersController {
+	return &TopTalkersController{Service: service}
+}
+
+func (c *TopTalkersController) GetTopTalkers(goGin *gin.Context) {
+	dimension := goGin.Param("dimension")
+	if !c.Service.IsValidDimension(dimension) {
+		goGin.JSON(http.StatusBadRequest, gin.H{"error": "Dimensão inválida"})
+		return
+	}
+
+	from, to, ok := parsePeriod(goGin, time.Hour)
+	if !ok {
+		return
+	}
+
+	limit := 10
+	if value := goGin.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > 1000 {
+			goGin.JSON(http.StatusBadRequest, gin.H{"error": "Parâmetro 'limit' inválido"})
+			return
+		}
+		limit = parsed
+	}
+
+	orderBy := goGin.DefaultQuery("orderBy", "bytes")
+	if orderBy != "bytes" && orderBy != "packets" {
+		goGin.JSON(http.StatusBadRequest, gin.H{"error": "Parâmetro 'orderBy' deve ser bytes ou packets"})
+		return
+	}
+
+	routerId, ok := optionalRouterId(goGin)
+	if !ok {
+		return
+	}
+
+	result, err := c.Service.GetTop(dimension, routerId, from, to, limit, orderBy)
+	if err != nil {
+		goGin.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	goGin.JSON(http.StatusOK, result)
+}
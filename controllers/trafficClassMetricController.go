@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"net/http"
+	"net_monitor/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TrafficClassMetricController struct {
+	Service            services.TrafficClassMetricService
+	AggregationService services.MetricAggregationService
+}
+
+func NewTrafficClassMetricController(service services.TrafficClassMetricService, aggregationService services.MetricAggregationService) *TrafficClassMetricController {
+	return &TrafficClassMetricController{Service: service, AggregationService: aggregationService}
+}
+
+func (c *TrafficClassMetricController) GetTrafficClassDaily(goGin *gin.Context) {
+	c.daily(goGin, goGin.Query("routerId"))
+}
+
+func (c *TrafficClassMetricController) GetTrafficClassDailyByRouter(goGin *gin.Context) {
+	c.daily(goGin, goGin.Param("routerId"))
+}
+
+func (c *TrafficClassMetricController) GetTrafficClassByDay(goGin *gin.Context) {
+	routerId, ok := optionalRouterId(goGin)
+	if !ok {
+		return
+	}
+	location, ok := requestLocation(goGin, c.AggregationService)
+	if !ok {
+		return
+	}
+
+	metrics, err := c.Service.GetTrafficClassByDay(goGin.Param("date"), routerId, location)
+	if err != nil {
+		goGin.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	goGin.JSON(http.StatusOK, metrics)
+}
+
+func (c *TrafficClassMetricController) daily(goGin *gin.Context, routerId string) {
+	if !validRouterId(goGin, routerId) {
+		return
+	}
+	location, ok := requestLocation(goGin, c.AggregationService)
+	if !ok {
+		return
+	}
+	from, to, ok := dailyPeriod(goGin, location, 30)
+	if !ok {
+		return
+	}
+
+	metrics, err := c.Service.GetTrafficClassDaily(routerId, from, to, location)
+	if err != nil {
+		goGin.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	goGin.JSON(http.StatusOK, metrics)
+}
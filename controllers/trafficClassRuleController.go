@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	"net/http"
+	models "net_monitor/models"
+	"net_monitor/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TrafficClassRuleController struct {
+	Service services.TrafficClassRuleService
+}
+
+func NewTrafficClassRuleController(service services.TrafficClassRuleService) *TrafficClassRuleController {
+	return &TrafficClassRuleController{Service: service}
+}
+
+func (c *TrafficClassRuleController) GetAllTrafficClassRules(goGin *gin.Context) {
+	rules, err := c.Service.GetAll()
+	if err != nil {
+		goGin.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	goGin.JSON(http.StatusOK, rules)
+}
+
+func (c *TrafficClassRuleController) GetTrafficClassRule(goGin *gin.Context) {
+	id := goGin.Param("id")
+	rule, err := c.Service.GetById(id)
+	if err != nil {
+		goGin.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if rule == nil {
+		goGin.JSON(http.StatusNotFound, gin.H{"error": "Regra não encontrada"})
+		return
+	}
+	goGin.JSON(http.StatusOK, rule)
+}
+
+func (c *TrafficClassRuleController) CreateTrafficClassRule(goGin *gin.Context) {
+	var req models.TrafficClassRule
+	if errValidation := goGin.ShouldBindJSON(&req); errValidation != nil {
+		goGin.JSON(http.StatusBadRequest, gin.H{"error": "Dados inválidos"})
+		return
+	}
+	errCreate, apiErr := c.Service.Create(&req)
+	if errCreate != nil {
+		goGin.JSON(http.StatusInternalServerError, gin.H{"error": errCreate.Error()})
+		return
+	}
+	if apiErr != nil {
+		goGin.JSON(http.StatusBadRequest, gin.H{"error": apiErr})
+		return
+	}
+	goGin.JSON(http.StatusCreated, req)
+}
+
+func (c *TrafficClassRuleController) UpdateTrafficClassRule(goGin *gin.Context) {
+	id := goGin.Param("id")
+	var req models.TrafficClassRule
+	if errValidation := goGin.ShouldBindJSON(&req); errValidation != nil {
+		goGin.JSON(http.StatusBadRequest, gin.H{"error": "Dados inválidos"})
+		return
+	}
+	errUpdate, apiErr := c.Service.Update(id, &req)
+	if errUpdate != nil {
+		goGin.JSON(http.StatusInternalServerError, gin.H{"error": errUpdate.Error()})
+		return
+	}
+	if apiErr != nil {
+		if apiErr.Code == "TRAFFIC_CLASS_RULE_NOT_FOUND" {
+			goGin.JSON(http.StatusNotFound, gin.H{"error": apiErr})
+			return
+		}
+		goGin.JSON(http.StatusBadRequest, gin.H{"error": apiErr})
+		return
+	}
+	goGin.JSON(http.StatusOK, req)
+}
+
+func (c *TrafficClassRuleController) DeleteTrafficClassRule(goGin *gin.Context) {
+	id := goGin.Param("id")
+	if err := c.Service.Delete(id); err != nil {
+		goGin.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	goGin.Status(http.StatusNoContent)
+}
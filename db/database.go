@@ -56,6 +56,24 @@ func InitDatabase() {
 	models.UserIndexes(db.Collection("user"))
 	models.OAuthProviderIndexes(db.Collection("oauth_providers"))
 	models.RefreshTokenIndexes(db.Collection("refresh_tokens"))
+	models.ProbeConfigIndexes(db.Collection("probe_configs"))
+	models.ProbeResultIndexes(db.Collection("probe_results"))
+	models.DeviceStatusTransitionIndexes(db.Collection("device_status_transitions"))
+	models.MaintenanceWindowIndexes(db.Collection("maintenance_windows"))
+	models.IPFIXTemplateIndexes(db.Collection("ipfix_templates"))
+	models.TopTalkersIndexes(db.Collection("top_talkers"))
+	models.CustomerPrefixIndexes(db.Collection("customer_prefixes"))
+	models.SubscriberIndexes(db.Collection("subscribers"))
+	models.SubscriberUsageIndexes(db.Collection("subscriber_usage"))
+	models.NATLogIndexes(db.Collection("nat_logs"))
+	models.NATLogAuditIndexes(db.Collection("nat_log_audit"))
+	models.DDoSEventIndexes(db.Collection("ddos_events"))
+	models.FlowExporterIndexes(db.Collection("flow_exporters"))
+	models.RawFlowIndexes(db.Collection("raw_flows"))
+	models.InterfaceMatrixIndexes(db.Collection("interface_matrix"))
+	models.TrafficClassRuleIndexes(db.Collection("traffic_class_rules"))
+	models.TrafficClassMetricIndexes(db.Collection("traffic_class_metrics"))
+	models.TCPQualityMetricIndexes(db.Collection("tcp_quality_metrics"))
 }
 
 func GetCollection(collectionName string) *mongo.Collection {
@@ -10,4 +10,6 @@ type NetworkDevice interface {
 	GetAccessUser() string
 	GetAccessPassword() string
 	IsActive() bool
+	GetSite() string
+	GetDeviceType() string
 }
@@ -5,6 +5,13 @@ type SNMPCollector interface {
 	GetVendor() string
 }
 
+// DeviceTypeScoped é implementado por coletores e handlers de trap que valem
+// só para um tipo de equipamento, quando o fabricante também é usado por
+// outros tipos (OLT e switch Huawei, por exemplo).
+type DeviceTypeScoped interface {
+	GetDeviceType() string
+}
+
 type ExtendedSNMPCollector interface {
 	SNMPCollector
 	CollectMetric(device NetworkDevice, metricName string) (interface{}, error)
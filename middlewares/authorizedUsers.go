@@ -0,0 +1,43 @@
+package middlewares
+
+import (
+	"log"
+	"net/http"
+	models "net_monitor/models"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuthorizedUsersMiddleware restringe a rota aos usuários (username ou email)
+// listados na variável de ambiente informada. Lista vazia nega todo mundo.
+// Deve ser usado depois do AuthMiddleware.
+func AuthorizedUsersMiddleware(envVar string) gin.HandlerFunc {
+	allowed := make(map[string]bool)
+	for _, value := range strings.Split(os.Getenv(envVar), ",") {
+		if value = strings.ToLower(strings.TrimSpace(value)); value != "" {
+			allowed[value] = true
+		}
+	}
+	if len(allowed) == 0 {
+		log.Printf("Aviso: %s vazio, rota bloqueada para todos os usuários", envVar)
+	}
+
+	return func(c *gin.Context) {
+		value, _ := c.Get("user")
+		user, ok := value.(*models.User)
+		if !ok || user == nil ||
+			!(allowed[strings.ToLower(user.Username)] || (user.Email != "" && allowed[strings.ToLower(user.Email)])) {
+			username := ""
+			if user != nil {
+				username = user.Username
+			}
+			log.Printf("Acesso negado a %s para usuário '%s' (%s)", c.FullPath(), username, c.ClientIP())
+			c.JSON(http.StatusForbidden, gin.H{"error": "Usuário sem permissão"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
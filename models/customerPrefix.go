@@ -0,0 +1,16 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// CustomerPrefix associa um bloco de endereços a um cliente para o
+// enriquecimento de flows.
+type CustomerPrefix struct {
+	ID           primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	Prefix       string             `json:"prefix" bson:"prefix" binding:"required"`
+	CustomerName string             `json:"customerName" bson:"customerName" binding:"required"`
+	CustomerCode string             `json:"customerCode,omitempty" bson:"customerCode,omitempty"`
+	Description  string             `json:"description,omitempty" bson:"description,omitempty"`
+	Active       bool               `json:"active" bson:"active"`
+	Created_At   primitive.DateTime `json:"created_at" bson:"created_at"`
+	Updated_At   primitive.DateTime `json:"updated_at" bson:"updated_at"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func CustomerPrefixIndexes(collection *mongo.Collection) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	indexModel := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "prefix", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("_prefix"),
+		},
+		{
+			Keys:    bson.D{{Key: "customerName", Value: 1}},
+			Options: options.Index().SetName("_customerName"),
+		},
+	}
+
+	_, err := collection.Indexes().CreateMany(ctx, indexModel)
+	if err != nil {
+		log.Fatalf("Error creating indexes for CustomerPrefix: %v", err)
+	}
+}
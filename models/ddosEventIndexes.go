@@ -0,0 +1,36 @@
+package models
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func DDoSEventIndexes(collection *mongo.Collection) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	indexModel := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "start", Value: -1}},
+			Options: options.Index().SetName("_start"),
+		},
+		{
+			Keys:    bson.D{{Key: "victimIp", Value: 1}, {Key: "start", Value: -1}},
+			Options: options.Index().SetName("_victimIp_start"),
+		},
+		{
+			Keys:    bson.D{{Key: "routerIp", Value: 1}, {Key: "start", Value: -1}},
+			Options: options.Index().SetName("_routerIp_start"),
+		},
+	}
+
+	_, err := collection.Indexes().CreateMany(ctx, indexModel)
+	if err != nil {
+		log.Fatalf("Error creating indexes for DDoSEvents: %v", err)
+	}
+}
@@ -0,0 +1,39 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+type DeviceOperationalStatus string
+
+const (
+	DeviceStatusUp       DeviceOperationalStatus = "up"
+	DeviceStatusDegraded DeviceOperationalStatus = "degraded"
+	DeviceStatusDown     DeviceOperationalStatus = "down"
+	DeviceStatusUnknown  DeviceOperationalStatus = "unknown"
+)
+
+type DeviceStatusTransition struct {
+	ID             primitive.ObjectID      `json:"id,omitempty" bson:"_id,omitempty"`
+	DeviceID       primitive.ObjectID      `json:"deviceId" bson:"deviceId"`
+	DeviceName     string                  `json:"deviceName" bson:"deviceName"`
+	DeviceType     string                  `json:"deviceType" bson:"deviceType"`
+	Site           string                  `json:"site,omitempty" bson:"site,omitempty"`
+	PreviousStatus DeviceOperationalStatus `json:"previousStatus" bson:"previousStatus"`
+	Status         DeviceOperationalStatus `json:"status" bson:"status"`
+	Reason         string                  `json:"reason" bson:"reason"`
+	Timestamp      primitive.DateTime      `json:"timestamp" bson:"timestamp"`
+	// LastSeen é a última avaliação que confirmou o status. Sem coletas
+	// depois dele, o período seguinte conta como unknown no SLA.
+	LastSeen primitive.DateTime `json:"lastSeen,omitempty" bson:"lastSeen,omitempty"`
+}
+
+// Sem DeviceID e sem Site a janela vale para todos os dispositivos.
+type MaintenanceWindow struct {
+	ID          primitive.ObjectID  `json:"id,omitempty" bson:"_id,omitempty"`
+	DeviceID    *primitive.ObjectID `json:"deviceId,omitempty" bson:"deviceId,omitempty"`
+	Site        string              `json:"site,omitempty" bson:"site,omitempty"`
+	Description string              `json:"description" bson:"description"`
+	Start       primitive.DateTime  `json:"start" bson:"start"`
+	End         primitive.DateTime  `json:"end" bson:"end"`
+	Created_At  primitive.DateTime  `json:"created_at" bson:"created_at"`
+	Updated_At  primitive.DateTime  `json:"updated_at" bson:"updated_at"`
+}
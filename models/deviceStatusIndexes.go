@@ -0,0 +1,49 @@
+package models
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func DeviceStatusTransitionIndexes(collection *mongo.Collection) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	indexModel := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "deviceId", Value: 1}, {Key: "timestamp", Value: 1}},
+			Options: options.Index().SetName("_deviceId_timestamp"),
+		},
+		{
+			Keys:    bson.D{{Key: "site", Value: 1}, {Key: "timestamp", Value: 1}},
+			Options: options.Index().SetName("_site_timestamp"),
+		},
+	}
+
+	_, err := collection.Indexes().CreateMany(ctx, indexModel)
+	if err != nil {
+		log.Fatalf("Error creating indexes for DeviceStatusTransition: %v", err)
+	}
+}
+
+func MaintenanceWindowIndexes(collection *mongo.Collection) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	indexModel := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "start", Value: 1}, {Key: "end", Value: 1}},
+			Options: options.Index().SetName("_start_end"),
+		},
+	}
+
+	_, err := collection.Indexes().CreateMany(ctx, indexModel)
+	if err != nil {
+		log.Fatalf("Error creating indexes for MaintenanceWindow: %v", err)
+	}
+}
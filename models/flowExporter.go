@@ -0,0 +1,17 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// FlowExporter associa um exportador de flows (IP de origem e, opcionalmente,
+// observation domain) a um dispositivo cadastrado, para quando o IP de
+// exportação não é o IP de gerência.
+type FlowExporter struct {
+	ID                primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	ExporterIP        string             `json:"exporterIp" bson:"exporterIp" binding:"required"`
+	ObservationDomain *uint32            `json:"observationDomain,omitempty" bson:"observationDomain,omitempty"`
+	DeviceType        string             `json:"deviceType" bson:"deviceType" binding:"required"`
+	DeviceID          string             `json:"deviceId" bson:"deviceId" binding:"required"`
+	Description       string             `json:"description,omitempty" bson:"description,omitempty"`
+	Created_At        primitive.DateTime `json:"created_at" bson:"created_at"`
+	Updated_At        primitive.DateTime `json:"updated_at" bson:"updated_at"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func InterfaceMatrixIndexes(collection *mongo.Collection) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	indexModel := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "routerId", Value: 1}, {Key: "timestamp", Value: -1}},
+			Options: options.Index().SetName("_routerId_timestamp"),
+		},
+		{
+			Keys:    bson.D{{Key: "routerIp", Value: 1}, {Key: "timestamp", Value: -1}},
+			Options: options.Index().SetName("_routerIp_timestamp"),
+		},
+	}
+
+	_, err := collection.Indexes().CreateMany(ctx, indexModel)
+	if err != nil {
+		log.Fatalf("Error creating indexes for InterfaceMatrix: %v", err)
+	}
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func IPFIXTemplateIndexes(collection *mongo.Collection) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	indexModel := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "exporterIp", Value: 1},
+				{Key: "exporterPort", Value: 1},
+				{Key: "observationDomain", Value: 1},
+				{Key: "templateId", Value: 1},
+			},
+			Options: options.Index().SetUnique(true).SetName("_exporter_template"),
+		},
+	}
+
+	_, err := collection.Indexes().CreateMany(ctx, indexModel)
+	if err != nil {
+		log.Fatalf("Error creating indexes for IPFIXTemplate: %v", err)
+	}
+}
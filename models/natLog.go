@@ -0,0 +1,41 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+const (
+	NATLogSourceEvent = "nat_event"
+	NATLogSourceFlow  = "flow"
+)
+
+// NATLogRecord é uma tradução NAT44 (sessão ou bloco de portas) guardada
+// para atender pedidos de identificação de IP público/porta. ExpiresAt
+// controla a retenção via índice TTL.
+type NATLogRecord struct {
+	ID               primitive.ObjectID  `json:"id,omitempty" bson:"_id,omitempty"`
+	RouterIP         string              `json:"routerIp" bson:"routerIp"`
+	InsideIP         string              `json:"insideIp" bson:"insideIp"`
+	InsidePort       uint16              `json:"insidePort,omitempty" bson:"insidePort,omitempty"`
+	OutsideIP        string              `json:"outsideIp" bson:"outsideIp"`
+	OutsidePortStart uint16              `json:"outsidePortStart" bson:"outsidePortStart"`
+	OutsidePortEnd   uint16              `json:"outsidePortEnd" bson:"outsidePortEnd"`
+	Protocol         uint8               `json:"protocol,omitempty" bson:"protocol,omitempty"`
+	Start            primitive.DateTime  `json:"start" bson:"start"`
+	End              *primitive.DateTime `json:"end,omitempty" bson:"end,omitempty"`
+	Source           string              `json:"source" bson:"source"`
+	ExpiresAt        primitive.DateTime  `json:"expiresAt" bson:"expiresAt"`
+}
+
+// NATLogAudit registra cada consulta ao log de NAT: quem, o quê e quando.
+type NATLogAudit struct {
+	ID          primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	UserID      primitive.ObjectID `json:"userId" bson:"userId"`
+	Username    string             `json:"username" bson:"username"`
+	ClientIP    string             `json:"clientIp" bson:"clientIp"`
+	PublicIP    string             `json:"publicIp" bson:"publicIp"`
+	Port        uint16             `json:"port" bson:"port"`
+	At          primitive.DateTime `json:"at" bson:"at"`
+	Reason      string             `json:"reason,omitempty" bson:"reason,omitempty"`
+	ResultCount int                `json:"resultCount" bson:"resultCount"`
+	Error       string             `json:"error,omitempty" bson:"error,omitempty"`
+	Timestamp   primitive.DateTime `json:"timestamp" bson:"timestamp"`
+}
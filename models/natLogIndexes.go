@@ -0,0 +1,64 @@
+package models
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func NATLogIndexes(collection *mongo.Collection) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	indexModel := []mongo.IndexModel{
+		{
+			// Consulta por (IP público, porta, instante): igualdade no IP,
+			// ordenação por início e faixa de portas no final.
+			Keys: bson.D{
+				{Key: "outsideIp", Value: 1},
+				{Key: "start", Value: -1},
+				{Key: "outsidePortStart", Value: 1},
+				{Key: "outsidePortEnd", Value: 1},
+			},
+			Options: options.Index().SetName("_outsideIp_start_ports"),
+		},
+		{
+			Keys:    bson.D{{Key: "insideIp", Value: 1}, {Key: "start", Value: -1}},
+			Options: options.Index().SetName("_insideIp_start"),
+		},
+		{
+			Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0).SetName("_expiresAt_ttl"),
+		},
+	}
+
+	_, err := collection.Indexes().CreateMany(ctx, indexModel)
+	if err != nil {
+		log.Fatalf("Error creating indexes for NATLog: %v", err)
+	}
+}
+
+func NATLogAuditIndexes(collection *mongo.Collection) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	indexModel := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "timestamp", Value: -1}},
+			Options: options.Index().SetName("_timestamp"),
+		},
+		{
+			Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "timestamp", Value: -1}},
+			Options: options.Index().SetName("_userId_timestamp"),
+		},
+	}
+
+	_, err := collection.Indexes().CreateMany(ctx, indexModel)
+	if err != nil {
+		log.Fatalf("Error creating indexes for NATLogAudit: %v", err)
+	}
+}
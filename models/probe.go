@@ -0,0 +1,51 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+type ProbeProtocol string
+
+const (
+	ProbeICMP ProbeProtocol = "icmp"
+	ProbeUDP  ProbeProtocol = "udp"
+)
+
+type ProbeStatus string
+
+const (
+	ProbeStatusUp      ProbeStatus = "up"
+	ProbeStatusDown    ProbeStatus = "down"
+	ProbeStatusUnknown ProbeStatus = "unknown"
+)
+
+type ProbeConfig struct {
+	ID              primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	DeviceID        primitive.ObjectID `json:"deviceId" bson:"deviceId"`
+	DeviceType      string             `json:"deviceType" bson:"deviceType"`
+	Active          bool               `json:"active" bson:"active"`
+	Protocol        ProbeProtocol      `json:"protocol" bson:"protocol"`
+	UDPPort         int                `json:"udpPort,omitempty" bson:"udpPort,omitempty"`
+	IntervalSeconds int                `json:"intervalSeconds" bson:"intervalSeconds"`
+	PacketCount     int                `json:"packetCount" bson:"packetCount"`
+	TimeoutMs       int                `json:"timeoutMs" bson:"timeoutMs"`
+	DownThreshold   int                `json:"downThreshold" bson:"downThreshold"`
+	UpThreshold     int                `json:"upThreshold" bson:"upThreshold"`
+	Created_At      primitive.DateTime `json:"created_at" bson:"created_at"`
+	Updated_At      primitive.DateTime `json:"updated_at" bson:"updated_at"`
+}
+
+type ProbeResult struct {
+	ID          primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	DeviceID    primitive.ObjectID `json:"deviceId" bson:"deviceId"`
+	DeviceIP    string             `json:"deviceIp" bson:"deviceIp"`
+	Protocol    ProbeProtocol      `json:"protocol" bson:"protocol"`
+	Timestamp   primitive.DateTime `json:"timestamp" bson:"timestamp"`
+	Sent        int                `json:"sent" bson:"sent"`
+	Received    int                `json:"received" bson:"received"`
+	LossPercent float64            `json:"lossPercent" bson:"lossPercent"`
+	RttMinMs    float64            `json:"rttMinMs" bson:"rttMinMs"`
+	RttAvgMs    float64            `json:"rttAvgMs" bson:"rttAvgMs"`
+	RttMaxMs    float64            `json:"rttMaxMs" bson:"rttMaxMs"`
+	JitterMs    float64            `json:"jitterMs" bson:"jitterMs"`
+	Status      ProbeStatus        `json:"status" bson:"status"`
+	Error       string             `json:"error,omitempty" bson:"error,omitempty"`
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func ProbeConfigIndexes(collection *mongo.Collection) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	indexModel := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "deviceId", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("_deviceId"),
+		},
+		{
+			Keys:    bson.D{{Key: "active", Value: 1}},
+			Options: options.Index().SetName("_active"),
+		},
+	}
+
+	_, err := collection.Indexes().CreateMany(ctx, indexModel)
+	if err != nil {
+		log.Fatalf("Error creating indexes for ProbeConfig: %v", err)
+	}
+}
+
+func ProbeResultIndexes(collection *mongo.Collection) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	indexModel := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "deviceId", Value: 1}, {Key: "timestamp", Value: -1}},
+			Options: options.Index().SetName("_deviceId_timestamp"),
+		},
+		{
+			Keys:    bson.D{{Key: "timestamp", Value: 1}},
+			Options: options.Index().SetName("_timestamp"),
+		},
+	}
+
+	_, err := collection.Indexes().CreateMany(ctx, indexModel)
+	if err != nil {
+		log.Fatalf("Error creating indexes for ProbeResult: %v", err)
+	}
+}
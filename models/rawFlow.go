@@ -0,0 +1,42 @@
+package models
+
+import (
+	"net/netip"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RawFlow é um flow guardado como chegou, para investigação. Os IPs também
+// ficam em 16 bytes (IPv4 mapeado em IPv6) para que a busca por CIDR vire
+// uma faixa no índice. Com amostragem do armazenamento, SampleRate diz
+// quantos flows cada documento representa.
+type RawFlow struct {
+	ID               primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	Timestamp        primitive.DateTime `json:"timestamp" bson:"timestamp"`
+	Start            primitive.DateTime `json:"start,omitempty" bson:"start,omitempty"`
+	End              primitive.DateTime `json:"end,omitempty" bson:"end,omitempty"`
+	RouterIP         string             `json:"routerIp" bson:"routerIp"`
+	RouterID         primitive.ObjectID `json:"routerId,omitempty" bson:"routerId,omitempty"`
+	SrcIP            string             `json:"srcIp" bson:"srcIp"`
+	DstIP            string             `json:"dstIp" bson:"dstIp"`
+	SrcIPKey         []byte             `json:"-" bson:"srcIpKey"`
+	DstIPKey         []byte             `json:"-" bson:"dstIpKey"`
+	SrcPort          uint16             `json:"srcPort" bson:"srcPort"`
+	DstPort          uint16             `json:"dstPort" bson:"dstPort"`
+	Protocol         uint8              `json:"protocol" bson:"protocol"`
+	Bytes            uint64             `json:"bytes" bson:"bytes"`
+	Packets          uint64             `json:"packets" bson:"packets"`
+	IngressInterface uint32             `json:"ingressInterface,omitempty" bson:"ingressInterface,omitempty"`
+	EgressInterface  uint32             `json:"egressInterface,omitempty" bson:"egressInterface,omitempty"`
+	TOS              uint8              `json:"tos,omitempty" bson:"tos,omitempty"`
+	SrcAS            uint32             `json:"srcAs,omitempty" bson:"srcAs,omitempty"`
+	DstAS            uint32             `json:"dstAs,omitempty" bson:"dstAs,omitempty"`
+	SampleRate       uint32             `json:"sampleRate" bson:"sampleRate"`
+	ExpiresAt        primitive.DateTime `json:"-" bson:"expiresAt"`
+}
+
+// RawFlowIPKey devolve o endereço em 16 bytes, na ordem do índice.
+func RawFlowIPKey(addr netip.Addr) []byte {
+	key := addr.As16()
+	return key[:]
+}
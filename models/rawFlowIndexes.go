@@ -0,0 +1,57 @@
+package models
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Toda busca tem faixa de tempo, então o timestamp fecha cada índice composto.
+func RawFlowIndexes(collection *mongo.Collection) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	indexModel := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "timestamp", Value: -1}},
+			Options: options.Index().SetName("_timestamp"),
+		},
+		{
+			Keys:    bson.D{{Key: "srcIpKey", Value: 1}, {Key: "timestamp", Value: -1}},
+			Options: options.Index().SetName("_srcIpKey_timestamp"),
+		},
+		{
+			Keys:    bson.D{{Key: "dstIpKey", Value: 1}, {Key: "timestamp", Value: -1}},
+			Options: options.Index().SetName("_dstIpKey_timestamp"),
+		},
+		{
+			Keys:    bson.D{{Key: "srcPort", Value: 1}, {Key: "timestamp", Value: -1}},
+			Options: options.Index().SetName("_srcPort_timestamp"),
+		},
+		{
+			Keys:    bson.D{{Key: "dstPort", Value: 1}, {Key: "timestamp", Value: -1}},
+			Options: options.Index().SetName("_dstPort_timestamp"),
+		},
+		{
+			Keys:    bson.D{{Key: "protocol", Value: 1}, {Key: "timestamp", Value: -1}},
+			Options: options.Index().SetName("_protocol_timestamp"),
+		},
+		{
+			Keys:    bson.D{{Key: "routerIp", Value: 1}, {Key: "timestamp", Value: -1}},
+			Options: options.Index().SetName("_routerIp_timestamp"),
+		},
+		{
+			Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0).SetName("_expiresAt_ttl"),
+		},
+	}
+
+	_, err := collection.Indexes().CreateMany(ctx, indexModel)
+	if err != nil {
+		log.Fatalf("Error creating indexes for RawFlow: %v", err)
+	}
+}
@@ -38,6 +38,7 @@ type Roteador struct {
 	Integration             RoteadorIntegracaoType `json:"integration" bson:"integration"`
 	Name                    string                 `json:"name" bson:"name"`
 	Description             string                 `json:"description" bson:"description"`
+	Site                    string                 `json:"site" bson:"site"`
 	AccessUser              string                 `json:"accessUser" bson:"accessUser"`
 	AccessPassword          string                 `json:"accessPassword" bson:"accessPassword"`
 	IPAddress               string                 `json:"ipAddress" bson:"ipAddress"`
@@ -0,0 +1,22 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// CGNATMapping é o bloco de portas de um IP público alocado ao assinante.
+type CGNATMapping struct {
+	PublicIP  string `json:"publicIp" bson:"publicIp" binding:"required"`
+	PortStart uint16 `json:"portStart" bson:"portStart"`
+	PortEnd   uint16 `json:"portEnd" bson:"portEnd"`
+}
+
+type Subscriber struct {
+	ID            primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	Name          string             `json:"name" bson:"name" binding:"required"`
+	Code          string             `json:"code,omitempty" bson:"code,omitempty"`
+	Plan          string             `json:"plan,omitempty" bson:"plan,omitempty"`
+	Addresses     []string           `json:"addresses" bson:"addresses"`
+	CGNATMappings []CGNATMapping     `json:"cgnatMappings,omitempty" bson:"cgnatMappings,omitempty"`
+	Active        bool               `json:"active" bson:"active"`
+	Created_At    primitive.DateTime `json:"created_at" bson:"created_at"`
+	Updated_At    primitive.DateTime `json:"updated_at" bson:"updated_at"`
+}
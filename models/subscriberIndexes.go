@@ -0,0 +1,57 @@
+package models
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func SubscriberIndexes(collection *mongo.Collection) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	indexModel := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "code", Value: 1}},
+			Options: options.Index().SetUnique(true).SetSparse(true).SetName("_code"),
+		},
+		{
+			Keys:    bson.D{{Key: "addresses", Value: 1}},
+			Options: options.Index().SetName("_addresses"),
+		},
+		{
+			Keys:    bson.D{{Key: "cgnatMappings.publicIp", Value: 1}},
+			Options: options.Index().SetName("_cgnatMappings_publicIp"),
+		},
+	}
+
+	_, err := collection.Indexes().CreateMany(ctx, indexModel)
+	if err != nil {
+		log.Fatalf("Error creating indexes for Subscriber: %v", err)
+	}
+}
+
+func SubscriberUsageIndexes(collection *mongo.Collection) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	indexModel := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "subscriberId", Value: 1}, {Key: "timestamp", Value: -1}},
+			Options: options.Index().SetUnique(true).SetName("_subscriberId_timestamp"),
+		},
+		{
+			Keys:    bson.D{{Key: "timestamp", Value: -1}},
+			Options: options.Index().SetName("_timestamp"),
+		},
+	}
+
+	_, err := collection.Indexes().CreateMany(ctx, indexModel)
+	if err != nil {
+		log.Fatalf("Error creating indexes for SubscriberUsage: %v", err)
+	}
+}
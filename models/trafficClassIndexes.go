@@ -0,0 +1,57 @@
+package models
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TrafficClassRuleIndexes(collection *mongo.Collection) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	indexModel := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "name", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("_name"),
+		},
+		{
+			Keys:    bson.D{{Key: "priority", Value: 1}},
+			Options: options.Index().SetName("_priority"),
+		},
+	}
+
+	_, err := collection.Indexes().CreateMany(ctx, indexModel)
+	if err != nil {
+		log.Fatalf("Error creating indexes for TrafficClassRule: %v", err)
+	}
+}
+
+func TrafficClassMetricIndexes(collection *mongo.Collection) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	indexModel := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "routerIp", Value: 1}, {Key: "timestamp", Value: -1}, {Key: "class", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("_routerIp_timestamp_class"),
+		},
+		{
+			Keys:    bson.D{{Key: "timestamp", Value: -1}},
+			Options: options.Index().SetName("_timestamp"),
+		},
+		{
+			Keys:    bson.D{{Key: "routerId", Value: 1}, {Key: "timestamp", Value: -1}},
+			Options: options.Index().SetName("_routerId_timestamp"),
+		},
+	}
+
+	_, err := collection.Indexes().CreateMany(ctx, indexModel)
+	if err != nil {
+		log.Fatalf("Error creating indexes for TrafficClassMetric: %v", err)
+	}
+}
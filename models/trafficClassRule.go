@@ -0,0 +1,50 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+const (
+	TrafficClassStreaming = "streaming"
+	TrafficClassGaming    = "gaming"
+	TrafficClassVoIP      = "voip"
+	TrafficClassWeb       = "web"
+	TrafficClassP2P       = "p2p"
+	// TrafficClassOther recebe os flows que não casam com nenhuma regra.
+	TrafficClassOther = "other"
+)
+
+type PortRange struct {
+	Start uint16 `json:"start" bson:"start"`
+	End   uint16 `json:"end" bson:"end"`
+}
+
+// TrafficClassRule classifica flows em uma aplicação. Dentro de uma lista
+// basta um item casar; entre listas preenchidas todas precisam casar. Portas,
+// prefixos e ASNs valem tanto para a origem quanto para o destino. O flow fica
+// com a classe da primeira regra ativa, em ordem crescente de prioridade.
+type TrafficClassRule struct {
+	ID         primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	Name       string             `json:"name" bson:"name" binding:"required"`
+	Class      string             `json:"class" bson:"class" binding:"required"`
+	Priority   int                `json:"priority" bson:"priority"`
+	Protocols  []uint8            `json:"protocols,omitempty" bson:"protocols,omitempty"`
+	Ports      []PortRange        `json:"ports,omitempty" bson:"ports,omitempty"`
+	Prefixes   []string           `json:"prefixes,omitempty" bson:"prefixes,omitempty"`
+	ASNs       []uint32           `json:"asns,omitempty" bson:"asns,omitempty"`
+	DSCP       []uint8            `json:"dscp,omitempty" bson:"dscp,omitempty"`
+	Active     bool               `json:"active" bson:"active"`
+	Created_At primitive.DateTime `json:"created_at" bson:"created_at"`
+	Updated_At primitive.DateTime `json:"updated_at" bson:"updated_at"`
+}
+
+// DefaultTrafficClassRules são gravadas quando não há nenhuma regra
+// cadastrada, como ponto de partida editável.
+func DefaultTrafficClassRules() []TrafficClassRule {
+	return []TrafficClassRule{
+		{Name: "SIP/RTP com DSCP EF", Class: TrafficClassVoIP, Priority: 10, DSCP: []uint8{46}, Active: true},
+		{Name: "SIP", Class: TrafficClassVoIP, Priority: 11, Protocols: []uint8{6, 17}, Ports: []PortRange{{5060, 5061}}, Active: true},
+		{Name: "Consoles e Steam", Class: TrafficClassGaming, Priority: 20, Protocols: []uint8{17}, Ports: []PortRange{{3074, 3074}, {3478, 3480}, {27015, 27050}}, Active: true},
+		{Name: "Netflix e YouTube", Class: TrafficClassStreaming, Priority: 30, ASNs: []uint32{2906, 40027, 36040}, Active: true},
+		{Name: "BitTorrent", Class: TrafficClassP2P, Priority: 40, Protocols: []uint8{6, 17}, Ports: []PortRange{{6881, 6889}, {51413, 51413}}, Active: true},
+		{Name: "HTTP, HTTPS e QUIC", Class: TrafficClassWeb, Priority: 100, Protocols: []uint8{6, 17}, Ports: []PortRange{{80, 80}, {443, 443}, {8080, 8080}}, Active: true},
+	}
+}
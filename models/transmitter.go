@@ -18,6 +18,7 @@ type TransmissorFibra struct {
 	Integration    TransmissorFibraIntegracaoType `json:"integration" bson:"integration"`
 	Name           string                         `json:"name" bson:"name"`
 	Description    string                         `json:"description" bson:"description"`
+	Site           string                         `json:"site" bson:"site"`
 	AccessUser     string                         `json:"accessUser" bson:"accessUser"`
 	AccessPassword string                         `json:"accessPassword" bson:"accessPassword"`
 	IPAddress      string                         `json:"ipAddress" bson:"ipAddress"`
@@ -8,6 +8,7 @@ type User struct {
 	Username   string             `json:"username" bson:"username"`
 	Email      string             `json:"email" bson:"email"`
 	Password   string             `json:"password" bson:"password"`
+	Timezone   string             `json:"timezone,omitempty" bson:"timezone,omitempty"`
 	Created_At primitive.DateTime `json:"created_at" bson:"created_at"`
 	Updated_At primitive.DateTime `json:"updated_at" bson:"updated_at"`
 }
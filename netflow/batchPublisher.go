@@ -0,0 +1,130 @@
+package netflow
+
+import (
+	"log"
+	utils "net_monitor/utils"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	QueueEncodingJSON   = "json"
+	QueueEncodingBinary = "binary"
+)
+
+// GetQueueEncoding lê QUEUE_ENCODING. O padrão é o lote binário; json mantém
+// uma mensagem por pacote, compatível com consumidores antigos.
+func GetQueueEncoding() string {
+	if os.Getenv("QUEUE_ENCODING") == QueueEncodingJSON {
+		return QueueEncodingJSON
+	}
+	return QueueEncodingBinary
+}
+
+// BatchPublisher junta várias mensagens em uma só publicação, enviada quando
+// o lote enche ou a cada intervalo. Publicações que falham são descartadas,
+// como já acontecia com os datagramas UDP, e contadas por mensagem nas
+// estatísticas do listener.
+type BatchPublisher[T any] struct {
+	queue       Queue
+	contentType string
+	encode      func([]T) []byte
+	publishJSON func(Queue, T) error
+	maxSize     int
+
+	mu      sync.Mutex
+	pending []T
+}
+
+func NewPacketPublisher(queue Queue) *BatchPublisher[PacketMessage] {
+	return newBatchPublisher(queue, ContentTypePacketBatch, EncodePacketBatch, PublishPacket)
+}
+
+func NewDecodedPublisher(queue Queue) *BatchPublisher[DecodedIPFIXMessage] {
+	return newBatchPublisher(queue, ContentTypeDecodedBatch, EncodeDecodedBatch, PublishDecodedIPFIX)
+}
+
+func newBatchPublisher[T any](queue Queue, contentType string, encode func([]T) []byte, publishJSON func(Queue, T) error) *BatchPublisher[T] {
+	p := &BatchPublisher[T]{
+		queue:       queue,
+		contentType: contentType,
+		publishJSON: publishJSON,
+		maxSize:     utils.EnvPositiveInt("QUEUE_BATCH_SIZE", 64),
+	}
+	if GetQueueEncoding() == QueueEncodingBinary {
+		p.encode = encode
+		go p.flushLoop(time.Duration(utils.EnvPositiveInt("QUEUE_BATCH_INTERVAL_MS", 50)) * time.Millisecond)
+	}
+	return p
+}
+
+func (p *BatchPublisher[T]) Add(msg T) error {
+	if p.encode == nil {
+		if err := p.publishJSON(p.queue, msg); err != nil {
+			stats.listenerPublishError(1)
+			return err
+		}
+		return nil
+	}
+
+	p.mu.Lock()
+	p.pending = append(p.pending, msg)
+	if len(p.pending) < p.maxSize {
+		p.mu.Unlock()
+		return nil
+	}
+	batch := p.pending
+	p.pending = make([]T, 0, p.maxSize)
+	p.mu.Unlock()
+
+	return p.publish(batch)
+}
+
+func (p *BatchPublisher[T]) Flush() error {
+	p.mu.Lock()
+	batch := p.pending
+	p.pending = make([]T, 0, p.maxSize)
+	p.mu.Unlock()
+
+	if len(batch) == 0 {
+		return nil
+	}
+	return p.publish(batch)
+}
+
+func (p *BatchPublisher[T]) publish(batch []T) error {
+	if err := p.queue.Publish(p.contentType, p.encode(batch)); err != nil {
+		stats.listenerPublishError(len(batch))
+		return err
+	}
+	return nil
+}
+
+func (p *BatchPublisher[T]) flushLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := p.Flush(); err != nil {
+			log.Printf("Erro publicando lote na fila, mensagens descartadas: %v", err)
+		}
+	}
+}
+
+// PublishDecodedBatch publica de uma vez o resultado de uma mensagem
+// consumida, para que o Ack/Nack continue valendo para o lote inteiro.
+func PublishDecodedBatch(queue Queue, msgs []DecodedIPFIXMessage) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+	if GetQueueEncoding() == QueueEncodingJSON {
+		for i := range msgs {
+			if err := PublishDecodedIPFIX(queue, msgs[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return queue.Publish(ContentTypeDecodedBatch, EncodeDecodedBatch(msgs))
+}
@@ -0,0 +1,551 @@
+package netflow
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Content types das mensagens na fila. Mensagens sem content type são
+// tratadas como JSON, que era o único formato antes dos lotes binários.
+const (
+	ContentTypeJSON         = "application/json"
+	ContentTypePacketBatch  = "application/vnd.netmonitor.packet-batch"
+	ContentTypeDecodedBatch = "application/vnd.netmonitor.decoded-batch"
+
+	codecVersion = 1
+)
+
+// Tags dos valores de RawFields. O tipo Go original é preservado, ao
+// contrário do JSON, que transforma todo número em float64.
+const (
+	rawTagNil byte = iota
+	rawTagUint8
+	rawTagUint16
+	rawTagUint32
+	rawTagUint64
+	rawTagInt64
+	rawTagFloat64
+	rawTagBool
+	rawTagString
+	rawTagBytes
+	rawTagTime
+)
+
+var errCodecTruncated = errors.New("mensagem binária truncada")
+
+// UnmarshalPackets aceita um lote binário ou um PacketMessage em JSON.
+func UnmarshalPackets(contentType string, body []byte) ([]PacketMessage, error) {
+	switch contentType {
+	case ContentTypePacketBatch:
+		return DecodePacketBatch(body)
+	case ContentTypeJSON, "":
+		var msg PacketMessage
+		if err := json.Unmarshal(body, &msg); err != nil {
+			return nil, err
+		}
+		return []PacketMessage{msg}, nil
+	default:
+		return nil, fmt.Errorf("content type não suportado: %s", contentType)
+	}
+}
+
+// UnmarshalDecoded aceita um lote binário ou um DecodedIPFIXMessage em JSON.
+func UnmarshalDecoded(contentType string, body []byte) ([]DecodedIPFIXMessage, error) {
+	switch contentType {
+	case ContentTypeDecodedBatch:
+		return DecodeDecodedBatch(body)
+	case ContentTypeJSON, "":
+		var msg DecodedIPFIXMessage
+		if err := json.Unmarshal(body, &msg); err != nil {
+			return nil, err
+		}
+		return []DecodedIPFIXMessage{msg}, nil
+	default:
+		return nil, fmt.Errorf("content type não suportado: %s", contentType)
+	}
+}
+
+func EncodePacketBatch(msgs []PacketMessage) []byte {
+	size := 8
+	for i := range msgs {
+		size += len(msgs[i].Raw) + len(msgs[i].SrcIP) + 24
+	}
+	w := codecWriter{b: make([]byte, 0, size)}
+	w.byte(codecVersion)
+	w.uvarint(uint64(len(msgs)))
+	for i := range msgs {
+		w.bytes(msgs[i].Raw)
+		w.string(msgs[i].SrcIP)
+		w.uvarint(uint64(msgs[i].SrcPort))
+		w.time(msgs[i].Received)
+	}
+	return w.b
+}
+
+func DecodePacketBatch(b []byte) ([]PacketMessage, error) {
+	r := codecReader{b: b}
+	if err := r.version(); err != nil {
+		return nil, err
+	}
+	count := r.count()
+	msgs := make([]PacketMessage, 0, count)
+	for i := 0; i < count && r.err == nil; i++ {
+		msgs = append(msgs, PacketMessage{
+			Raw:      r.bytes(),
+			SrcIP:    r.string(),
+			SrcPort:  int(r.uvarint()),
+			Received: r.time(),
+		})
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return msgs, nil
+}
+
+func EncodeDecodedBatch(msgs []DecodedIPFIXMessage) []byte {
+	w := codecWriter{b: make([]byte, 0, 512)}
+	w.byte(codecVersion)
+	w.uvarint(uint64(len(msgs)))
+	for i := range msgs {
+		w.decodedMessage(&msgs[i])
+	}
+	return w.b
+}
+
+func DecodeDecodedBatch(b []byte) ([]DecodedIPFIXMessage, error) {
+	r := codecReader{b: b}
+	if err := r.version(); err != nil {
+		return nil, err
+	}
+	count := r.count()
+	msgs := make([]DecodedIPFIXMessage, count)
+	for i := 0; i < count && r.err == nil; i++ {
+		r.decodedMessage(&msgs[i])
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return msgs, nil
+}
+
+type codecWriter struct {
+	b []byte
+}
+
+func (w *codecWriter) byte(v byte) {
+	w.b = append(w.b, v)
+}
+
+func (w *codecWriter) uvarint(v uint64) {
+	w.b = binary.AppendUvarint(w.b, v)
+}
+
+func (w *codecWriter) varint(v int64) {
+	w.b = binary.AppendVarint(w.b, v)
+}
+
+func (w *codecWriter) bool(v bool) {
+	if v {
+		w.byte(1)
+	} else {
+		w.byte(0)
+	}
+}
+
+func (w *codecWriter) bytes(v []byte) {
+	w.uvarint(uint64(len(v)))
+	w.b = append(w.b, v...)
+}
+
+func (w *codecWriter) string(v string) {
+	w.uvarint(uint64(len(v)))
+	w.b = append(w.b, v...)
+}
+
+// Tempo zero vira 0 para não confundir com a época Unix.
+func (w *codecWriter) time(v time.Time) {
+	if v.IsZero() {
+		w.byte(0)
+		return
+	}
+	w.byte(1)
+	w.varint(v.UnixNano())
+}
+
+func (w *codecWriter) decodedMessage(m *DecodedIPFIXMessage) {
+	w.string(m.Protocol)
+	w.string(m.SrcIP)
+	w.uvarint(uint64(m.SrcPort))
+	w.time(m.Received)
+
+	w.uvarint(uint64(m.Header.Version))
+	w.uvarint(uint64(m.Header.Length))
+	w.uvarint(uint64(m.Header.ExportTime))
+	w.uvarint(uint64(m.Header.SequenceNumber))
+	w.uvarint(uint64(m.Header.ObservationDomain))
+	w.uvarint(uint64(m.Header.SysUptime))
+
+	w.uvarint(uint64(len(m.Templates)))
+	for i := range m.Templates {
+		t := &m.Templates[i]
+		w.uvarint(uint64(t.TemplateID))
+		w.uvarint(uint64(t.FieldCount))
+		w.uvarint(uint64(t.ScopeFieldCount))
+		w.uvarint(uint64(len(t.Fields)))
+		for _, f := range t.Fields {
+			w.uvarint(uint64(f.FieldID))
+			w.uvarint(uint64(f.FieldLength))
+			w.uvarint(uint64(f.EnterpriseNum))
+			w.string(f.FieldName)
+			w.string(string(f.DataType))
+		}
+	}
+
+	w.flowRecords(m.FlowRecords)
+	w.flowRecords(m.OptionRecords)
+
+	w.uvarint(uint64(len(m.InterfaceCounters)))
+	for i := range m.InterfaceCounters {
+		c := &m.InterfaceCounters[i]
+		for _, v := range []uint64{
+			uint64(c.SourceID), uint64(c.IfIndex), uint64(c.IfType), c.IfSpeed, uint64(c.IfDirection),
+			c.InOctets, uint64(c.InUcastPkts), uint64(c.InMcastPkts), uint64(c.InBcastPkts), uint64(c.InDiscards), uint64(c.InErrors),
+			c.OutOctets, uint64(c.OutUcastPkts), uint64(c.OutMcastPkts), uint64(c.OutBcastPkts), uint64(c.OutDiscards), uint64(c.OutErrors),
+			uint64(c.SampleSeqNum),
+		} {
+			w.uvarint(v)
+		}
+		w.bool(c.IfAdminUp)
+		w.bool(c.IfOperUp)
+	}
+}
+
+func (w *codecWriter) flowRecords(records []FlowRecord) {
+	w.uvarint(uint64(len(records)))
+	for i := range records {
+		r := &records[i]
+		w.uvarint(uint64(r.TemplateID))
+		w.string(r.SourceIPv4Address)
+		w.string(r.DestinationIPv4Address)
+		w.string(r.SourceIPv6Address)
+		w.string(r.DestinationIPv6Address)
+		w.uvarint(uint64(r.SourceTransportPort))
+		w.uvarint(uint64(r.DestinationTransportPort))
+		w.uvarint(uint64(r.ProtocolIdentifier))
+		w.uvarint(r.OctetDeltaCount)
+		w.uvarint(r.PacketDeltaCount)
+		w.uvarint(r.FlowStartMilliseconds)
+		w.uvarint(r.FlowEndMilliseconds)
+		w.uvarint(uint64(r.IngressInterface))
+		w.uvarint(uint64(r.EgressInterface))
+		w.uvarint(uint64(r.IPClassOfService))
+		w.uvarint(uint64(r.FlowDirection))
+		w.uvarint(uint64(r.IPVersion))
+		w.uvarint(r.SamplingInterval)
+		w.uvarint(uint64(r.SourceAS))
+		w.uvarint(uint64(r.DestinationAS))
+		w.string(r.SourceASName)
+		w.string(r.DestinationASName)
+		w.string(r.SourceCountry)
+		w.string(r.DestinationCountry)
+		w.string(r.SourceCustomer)
+		w.string(r.DestinationCustomer)
+
+		w.uvarint(uint64(len(r.RawFields)))
+		for name, value := range r.RawFields {
+			w.string(name)
+			w.rawValue(value)
+		}
+	}
+}
+
+func (w *codecWriter) rawValue(value interface{}) {
+	switch v := value.(type) {
+	case nil:
+		w.byte(rawTagNil)
+	case uint8:
+		w.byte(rawTagUint8)
+		w.uvarint(uint64(v))
+	case uint16:
+		w.byte(rawTagUint16)
+		w.uvarint(uint64(v))
+	case uint32:
+		w.byte(rawTagUint32)
+		w.uvarint(uint64(v))
+	case uint64:
+		w.byte(rawTagUint64)
+		w.uvarint(v)
+	case int64:
+		w.byte(rawTagInt64)
+		w.varint(v)
+	case int:
+		w.byte(rawTagInt64)
+		w.varint(int64(v))
+	case float64:
+		w.byte(rawTagFloat64)
+		w.b = binary.BigEndian.AppendUint64(w.b, math.Float64bits(v))
+	case bool:
+		w.byte(rawTagBool)
+		w.bool(v)
+	case string:
+		w.byte(rawTagString)
+		w.string(v)
+	case []byte:
+		w.byte(rawTagBytes)
+		w.bytes(v)
+	case time.Time:
+		w.byte(rawTagTime)
+		w.time(v)
+	default:
+		w.byte(rawTagString)
+		w.string(fmt.Sprint(v))
+	}
+}
+
+type codecReader struct {
+	b   []byte
+	off int
+	err error
+}
+
+func (r *codecReader) version() error {
+	if v := r.byte(); r.err == nil && v != codecVersion {
+		return fmt.Errorf("versão do codec não suportada: %d", v)
+	}
+	return r.err
+}
+
+// count limita o tamanho das alocações a partir de dados corrompidos.
+func (r *codecReader) count() int {
+	n := r.uvarint()
+	if n > uint64(len(r.b)-r.off) {
+		r.fail()
+		return 0
+	}
+	return int(n)
+}
+
+func (r *codecReader) fail() {
+	if r.err == nil {
+		r.err = errCodecTruncated
+	}
+}
+
+func (r *codecReader) byte() byte {
+	if r.err != nil || r.off >= len(r.b) {
+		r.fail()
+		return 0
+	}
+	v := r.b[r.off]
+	r.off++
+	return v
+}
+
+func (r *codecReader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(r.b[r.off:])
+	if n <= 0 {
+		r.fail()
+		return 0
+	}
+	r.off += n
+	return v
+}
+
+func (r *codecReader) varint() int64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(r.b[r.off:])
+	if n <= 0 {
+		r.fail()
+		return 0
+	}
+	r.off += n
+	return v
+}
+
+func (r *codecReader) bool() bool {
+	return r.byte() == 1
+}
+
+func (r *codecReader) raw() []byte {
+	n := r.count()
+	if r.err != nil {
+		return nil
+	}
+	v := r.b[r.off : r.off+n]
+	r.off += n
+	return v
+}
+
+func (r *codecReader) bytes() []byte {
+	return append([]byte(nil), r.raw()...)
+}
+
+func (r *codecReader) string() string {
+	return string(r.raw())
+}
+
+func (r *codecReader) time() time.Time {
+	if r.byte() == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, r.varint())
+}
+
+func (r *codecReader) decodedMessage(m *DecodedIPFIXMessage) {
+	m.Protocol = r.string()
+	m.SrcIP = r.string()
+	m.SrcPort = int(r.uvarint())
+	m.Received = r.time()
+
+	m.Header = IPFIXHeader{
+		Version:           uint16(r.uvarint()),
+		Length:            uint16(r.uvarint()),
+		ExportTime:        uint32(r.uvarint()),
+		SequenceNumber:    uint32(r.uvarint()),
+		ObservationDomain: uint32(r.uvarint()),
+		SysUptime:         uint32(r.uvarint()),
+	}
+
+	m.Templates = make([]Template, r.count())
+	for i := range m.Templates {
+		t := &m.Templates[i]
+		t.TemplateID = uint16(r.uvarint())
+		t.FieldCount = uint16(r.uvarint())
+		t.ScopeFieldCount = uint16(r.uvarint())
+		t.Fields = make([]TemplateField, r.count())
+		for j := range t.Fields {
+			t.Fields[j] = TemplateField{
+				FieldID:       uint16(r.uvarint()),
+				FieldLength:   uint16(r.uvarint()),
+				EnterpriseNum: uint32(r.uvarint()),
+				FieldName:     r.string(),
+				DataType:      IEDataType(r.string()),
+			}
+		}
+	}
+
+	m.FlowRecords = r.flowRecords()
+	if options := r.flowRecords(); len(options) > 0 {
+		m.OptionRecords = options
+	}
+
+	if n := r.count(); n > 0 {
+		m.InterfaceCounters = make([]InterfaceCounters, n)
+		for i := range m.InterfaceCounters {
+			c := &m.InterfaceCounters[i]
+			c.SourceID = uint32(r.uvarint())
+			c.IfIndex = uint32(r.uvarint())
+			c.IfType = uint32(r.uvarint())
+			c.IfSpeed = r.uvarint()
+			c.IfDirection = uint32(r.uvarint())
+			c.InOctets = r.uvarint()
+			c.InUcastPkts = uint32(r.uvarint())
+			c.InMcastPkts = uint32(r.uvarint())
+			c.InBcastPkts = uint32(r.uvarint())
+			c.InDiscards = uint32(r.uvarint())
+			c.InErrors = uint32(r.uvarint())
+			c.OutOctets = r.uvarint()
+			c.OutUcastPkts = uint32(r.uvarint())
+			c.OutMcastPkts = uint32(r.uvarint())
+			c.OutBcastPkts = uint32(r.uvarint())
+			c.OutDiscards = uint32(r.uvarint())
+			c.OutErrors = uint32(r.uvarint())
+			c.SampleSeqNum = uint32(r.uvarint())
+			c.IfAdminUp = r.bool()
+			c.IfOperUp = r.bool()
+		}
+	}
+}
+
+func (r *codecReader) flowRecords() []FlowRecord {
+	records := make([]FlowRecord, r.count())
+	for i := range records {
+		rec := &records[i]
+		rec.TemplateID = uint16(r.uvarint())
+		rec.SourceIPv4Address = r.string()
+		rec.DestinationIPv4Address = r.string()
+		rec.SourceIPv6Address = r.string()
+		rec.DestinationIPv6Address = r.string()
+		rec.SourceTransportPort = uint16(r.uvarint())
+		rec.DestinationTransportPort = uint16(r.uvarint())
+		rec.ProtocolIdentifier = uint8(r.uvarint())
+		rec.OctetDeltaCount = r.uvarint()
+		rec.PacketDeltaCount = r.uvarint()
+		rec.FlowStartMilliseconds = r.uvarint()
+		rec.FlowEndMilliseconds = r.uvarint()
+		rec.IngressInterface = uint32(r.uvarint())
+		rec.EgressInterface = uint32(r.uvarint())
+		rec.IPClassOfService = uint8(r.uvarint())
+		rec.FlowDirection = uint8(r.uvarint())
+		rec.IPVersion = uint8(r.uvarint())
+		rec.SamplingInterval = r.uvarint()
+		rec.SourceAS = uint32(r.uvarint())
+		rec.DestinationAS = uint32(r.uvarint())
+		rec.SourceASName = r.string()
+		rec.DestinationASName = r.string()
+		rec.SourceCountry = r.string()
+		rec.DestinationCountry = r.string()
+		rec.SourceCustomer = r.string()
+		rec.DestinationCustomer = r.string()
+
+		if n := r.count(); n > 0 {
+			rec.RawFields = make(map[string]interface{}, n)
+			for j := 0; j < n && r.err == nil; j++ {
+				name := r.string()
+				rec.RawFields[name] = r.rawValue()
+			}
+		}
+		if r.err != nil {
+			return nil
+		}
+	}
+	return records
+}
+
+func (r *codecReader) rawValue() interface{} {
+	switch tag := r.byte(); tag {
+	case rawTagNil:
+		return nil
+	case rawTagUint8:
+		return uint8(r.uvarint())
+	case rawTagUint16:
+		return uint16(r.uvarint())
+	case rawTagUint32:
+		return uint32(r.uvarint())
+	case rawTagUint64:
+		return r.uvarint()
+	case rawTagInt64:
+		return r.varint()
+	case rawTagFloat64:
+		if r.err != nil || r.off+8 > len(r.b) {
+			r.fail()
+			return nil
+		}
+		v := math.Float64frombits(binary.BigEndian.Uint64(r.b[r.off:]))
+		r.off += 8
+		return v
+	case rawTagBool:
+		return r.bool()
+	case rawTagString:
+		return r.string()
+	case rawTagBytes:
+		return r.bytes()
+	case rawTagTime:
+		return r.time()
+	default:
+		if r.err == nil {
+			r.err = fmt.Errorf("tag de valor desconhecida: %d", tag)
+		}
+		return nil
+	}
+}
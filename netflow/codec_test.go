@@ -0,0 +1,211 @@
+package netflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const codecBatchSize = 64
+
+// Instante sem leitura monotônica, para comparar com o que o codec devolve.
+var codecTestTime = time.Unix(1767000000, 123456789)
+
+func samplePackets() []PacketMessage {
+	packets := make([]PacketMessage, codecBatchSize)
+	for i := range packets {
+		raw := make([]byte, 1400)
+		for j := range raw {
+			raw[j] = byte(i + j)
+		}
+		packets[i] = PacketMessage{
+			Raw:      raw,
+			SrcIP:    "10.0.0.1",
+			SrcPort:  2055,
+			Received: codecTestTime,
+		}
+	}
+	return packets
+}
+
+// Um pacote IPFIX típico tem ~30 registros com uma dezena de campos extras.
+func sampleDecoded() []DecodedIPFIXMessage {
+	msgs := make([]DecodedIPFIXMessage, codecBatchSize)
+	for i := range msgs {
+		records := make([]FlowRecord, 30)
+		for j := range records {
+			records[j] = FlowRecord{
+				TemplateID:               256,
+				SourceIPv4Address:        fmt.Sprintf("100.64.%d.%d", i, j),
+				DestinationIPv4Address:   "142.250.79.46",
+				SourceTransportPort:      uint16(40000 + j),
+				DestinationTransportPort: 443,
+				ProtocolIdentifier:       6,
+				OctetDeltaCount:          uint64(1500 * (j + 1)),
+				PacketDeltaCount:         uint64(j + 1),
+				FlowStartMilliseconds:    uint64(codecTestTime.UnixMilli()),
+				FlowEndMilliseconds:      uint64(codecTestTime.UnixMilli()),
+				IngressInterface:         3,
+				EgressInterface:          7,
+				IPVersion:                4,
+				SourceAS:                 64512,
+				DestinationAS:            15169,
+				DestinationCountry:       "US",
+				RawFields: map[string]interface{}{
+					"tcpControlBits":              uint64(0x18),
+					"ipTTL":                       uint8(64),
+					"postNATSourceIPv4Address":    "200.160.0.10",
+					"postNAPTSourceTransportPort": uint16(1024 + j),
+					"flowDirection":               uint8(0),
+					"bgpNextHopIPv4Address":       "10.255.0.1",
+					"vlanId":                      uint64(100),
+					"sourceMacAddress":            "00:11:22:33:44:55",
+					"flowEndReason":               uint8(3),
+					"samplerId":                   uint64(1),
+				},
+			}
+		}
+		msgs[i] = DecodedIPFIXMessage{
+			Protocol:    ProtocolIPFIX,
+			SrcIP:       "10.0.0.1",
+			SrcPort:     2055,
+			Received:    codecTestTime,
+			Header:      IPFIXHeader{Version: 10, Length: 1400, ExportTime: uint32(codecTestTime.Unix()), ObservationDomain: 1},
+			Templates:   []Template{},
+			FlowRecords: records,
+		}
+	}
+	return msgs
+}
+
+func TestPacketBatchRoundTrip(t *testing.T) {
+	packets := samplePackets()
+	packets[1].Received = time.Time{}
+
+	got, err := UnmarshalPackets(ContentTypePacketBatch, EncodePacketBatch(packets))
+	if err != nil {
+		t.Fatalf("UnmarshalPackets: %v", err)
+	}
+	if !reflect.DeepEqual(got, packets) {
+		t.Fatalf("lote de pacotes diferente após ida e volta")
+	}
+	if !got[1].Received.IsZero() {
+		t.Errorf("horário zero virou %v", got[1].Received)
+	}
+}
+
+// Os processadores fazem type assertions sobre RawFields (ex.: .(uint64) na
+// perda de pacotes), então o codec precisa devolver exatamente os mesmos tipos.
+func TestDecodedBatchRoundTrip(t *testing.T) {
+	msgs := sampleDecoded()[:2]
+	msgs[0].Templates = []Template{{
+		TemplateID: 256,
+		FieldCount: 2,
+		Fields: []TemplateField{
+			{FieldID: 8, FieldLength: 4, FieldName: "sourceIPv4Address", DataType: "ipv4Address"},
+			{FieldID: 1, FieldLength: 8, FieldName: "octetDeltaCount", DataType: "unsigned64"},
+		},
+	}}
+	msgs[0].OptionRecords = []FlowRecord{{TemplateID: 257, RawFields: map[string]interface{}{"samplingInterval": uint32(1000)}}}
+	msgs[0].InterfaceCounters = []InterfaceCounters{{SourceID: 1, IfIndex: 3, IfSpeed: 10_000_000_000, InOctets: 1 << 40, IfAdminUp: true, IfOperUp: true}}
+	msgs[1].FlowRecords[0].RawFields = map[string]interface{}{
+		"uint8":   uint8(200),
+		"uint16":  uint16(65000),
+		"uint32":  uint32(4_000_000_000),
+		"uint64":  uint64(1 << 63),
+		"int64":   int64(-42),
+		"float64": 0.25,
+		"bool":    true,
+		"string":  "texto",
+		"bytes":   []byte{0xde, 0xad},
+		"time":    codecTestTime,
+		"nil":     nil,
+	}
+
+	got, err := UnmarshalDecoded(ContentTypeDecodedBatch, EncodeDecodedBatch(msgs))
+	if err != nil {
+		t.Fatalf("UnmarshalDecoded: %v", err)
+	}
+	if len(got) != len(msgs) {
+		t.Fatalf("%d mensagens, esperado %d", len(got), len(msgs))
+	}
+	for i := range msgs {
+		if !reflect.DeepEqual(got[i], msgs[i]) {
+			t.Errorf("mensagem %d diferente após ida e volta:\n%+v\n%+v", i, got[i], msgs[i])
+		}
+	}
+
+	for name, want := range msgs[1].FlowRecords[0].RawFields {
+		value := got[1].FlowRecords[0].RawFields[name]
+		if reflect.TypeOf(value) != reflect.TypeOf(want) {
+			t.Errorf("RawFields[%q] voltou como %T, esperado %T", name, value, want)
+		}
+	}
+	if _, ok := got[0].FlowRecords[0].RawFields["tcpControlBits"].(uint64); !ok {
+		t.Error("tcpControlBits deveria continuar uint64")
+	}
+}
+
+func TestDecodeRejectsTruncatedBatch(t *testing.T) {
+	body := EncodeDecodedBatch(sampleDecoded()[:1])
+	if _, err := DecodeDecodedBatch(body[:len(body)/2]); err == nil {
+		t.Error("lote truncado deveria falhar")
+	}
+	if _, err := DecodePacketBatch(nil); err == nil {
+		t.Error("lote vazio deveria falhar")
+	}
+}
+
+// Compara o caminho JSON (uma mensagem por pacote) com os lotes binários da
+// fila. O tamanho serializado de cada lote sai como "bytes/lote".
+func BenchmarkJSONCodec(b *testing.B) {
+	packets := samplePackets()
+	decoded := sampleDecoded()
+
+	b.Run("packets", func(b *testing.B) {
+		body, _ := json.Marshal(packets[0])
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for j := range packets {
+				body, _ := json.Marshal(packets[j])
+				var msg PacketMessage
+				json.Unmarshal(body, &msg)
+			}
+		}
+		b.ReportMetric(float64(len(body)*len(packets)), "bytes/lote")
+	})
+	b.Run("decoded", func(b *testing.B) {
+		body, _ := json.Marshal(decoded[0])
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for j := range decoded {
+				body, _ := json.Marshal(decoded[j])
+				var msg DecodedIPFIXMessage
+				json.Unmarshal(body, &msg)
+			}
+		}
+		b.ReportMetric(float64(len(body)*len(decoded)), "bytes/lote")
+	})
+}
+
+func BenchmarkBinaryCodec(b *testing.B) {
+	packets := samplePackets()
+	decoded := sampleDecoded()
+
+	b.Run("packets", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			UnmarshalPackets(ContentTypePacketBatch, EncodePacketBatch(packets))
+		}
+		b.ReportMetric(float64(len(EncodePacketBatch(packets))), "bytes/lote")
+	})
+	b.Run("decoded", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			UnmarshalDecoded(ContentTypeDecodedBatch, EncodeDecodedBatch(decoded))
+		}
+		b.ReportMetric(float64(len(EncodeDecodedBatch(decoded))), "bytes/lote")
+	})
+}
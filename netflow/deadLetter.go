@@ -0,0 +1,73 @@
+package netflow
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+)
+
+// DeadLetter embrulha a mensagem original, com o content type dela, para
+// que possa ser inspecionada ou reprocessada depois.
+type DeadLetter struct {
+	Source      string    `json:"source"`
+	Reason      string    `json:"reason"`
+	ContentType string    `json:"contentType"`
+	Body        []byte    `json:"body"`
+	FailedAt    time.Time `json:"failedAt"`
+}
+
+var (
+	deadLetterMu    sync.RWMutex
+	deadLetterQueue Queue
+)
+
+// SetDeadLetterQueue define para onde vão as mensagens que não podem ser
+// processadas. Sem fila, elas são apenas contadas e descartadas.
+func SetDeadLetterQueue(queue Queue) {
+	deadLetterMu.Lock()
+	defer deadLetterMu.Unlock()
+	deadLetterQueue = queue
+}
+
+func deadLetter(source, reason, contentType string, body []byte) {
+	letter := DeadLetter{
+		Source:      source,
+		Reason:      reason,
+		ContentType: contentType,
+		Body:        body,
+		FailedAt:    time.Now(),
+	}
+	stats.deadLettered(DeadLetterSummary{
+		Source:   source,
+		Reason:   reason,
+		Size:     len(body),
+		FailedAt: letter.FailedAt,
+	})
+
+	deadLetterMu.RLock()
+	queue := deadLetterQueue
+	deadLetterMu.RUnlock()
+	if queue == nil {
+		return
+	}
+
+	payload, err := json.Marshal(letter)
+	if err != nil {
+		log.Printf("Erro serializando dead letter de %s: %v", source, err)
+		return
+	}
+	if err := queue.Publish(ContentTypeJSON, payload); err != nil {
+		log.Printf("Erro publicando dead letter de %s: %v", source, err)
+	}
+}
+
+// Os pacotes vão para a dead letter sempre no lote binário, que preserva os
+// tipos dos campos independente de QUEUE_ENCODING.
+func deadLetterPacket(reason string, msg PacketMessage) {
+	deadLetter("decoder", reason, ContentTypePacketBatch, EncodePacketBatch([]PacketMessage{msg}))
+}
+
+func deadLetterDecoded(reason string, msg DecodedIPFIXMessage) {
+	deadLetter("metrics", reason, ContentTypeDecodedBatch, EncodeDecodedBatch([]DecodedIPFIXMessage{msg}))
+}
@@ -1,48 +1,68 @@
 package netflow
 
 import (
-	"encoding/json"
 	"log"
 )
 
-func StartDecoderWorkers(rawRabbit *RabbitMQ, decodedRabbit *RabbitMQ, workerCount int) error {
-	deliveries, err := rawRabbit.Consume()
+// O cache é compartilhado entre os workers e com o que for persistido em store.
+func StartDecoderWorkers(rawQueue Queue, decodedQueue Queue, store TemplateStore, workerCount int) error {
+	deliveries, err := rawQueue.Consume()
 	if err != nil {
 		return err
 	}
 
-	cache := NewTemplateCache()
+	cache := NewTemplateCache(GetTemplateTTL(), store)
+	if loaded, err := cache.Load(); err != nil {
+		log.Printf("Erro carregando templates persistidos: %v", err)
+	} else if loaded > 0 {
+		log.Printf("%d templates IPFIX/NetFlow recarregados", loaded)
+	}
 
 	for i := 0; i < workerCount; i++ {
 		go func(workerId int) {
 			log.Printf("Decoder worker %d iniciado", workerId)
 			for d := range deliveries {
-				var pm PacketMessage
-				if err := json.Unmarshal(d.Body, &pm); err != nil {
+				packets, err := UnmarshalPackets(d.ContentType, d.Body)
+				if err != nil {
 					log.Printf("decoder worker %d erro unmarshal msg: %v", workerId, err)
-					d.Nack(false, false)
+					stats.decoderUnmarshalError()
+					deadLetter("decoder", err.Error(), d.ContentType, d.Body)
+					d.Ack()
 					continue
 				}
 
-				ipfix, err := ParseIPFIX(pm.Raw)
-				if err != nil {
-					log.Printf("decoder worker %d parse IPFIX falhou: %v", workerId, err)
-					d.Ack(false)
-					continue
+				// Um pacote inválido não derruba o lote inteiro.
+				batch := make([]DecodedIPFIXMessage, 0, len(packets))
+				for _, pm := range packets {
+					decoded, err := DecodePacket(pm.Raw, pm.SrcIP, pm.SrcPort, cache)
+					if err != nil {
+						log.Printf("decoder worker %d descartando pacote de %s:%d: %v", workerId, pm.SrcIP, pm.SrcPort, err)
+						stats.parseError(pm.SrcIP)
+						deadLetterPacket(err.Error(), pm)
+						continue
+					}
+					stats.packetDecoded(pm.SrcIP, pm.SrcPort, decoded)
+
+					decoded.SrcIP = pm.SrcIP
+					decoded.SrcPort = pm.SrcPort
+					decoded.Received = pm.Received
+					batch = append(batch, *decoded)
 				}
 
-				decoded := DecodeIPFIX(ipfix, cache)
-				decoded.SrcIP = pm.SrcIP
-				decoded.SrcPort = pm.SrcPort
-				decoded.Received = pm.Received
+				// Todos os pacotes falharam e já foram para a dead letter.
+				if len(batch) == 0 {
+					d.Ack()
+					continue
+				}
 
-				if err := decodedRabbit.PublishDecodedIPFIX(*decoded); err != nil {
+				if err := PublishDecodedBatch(decodedQueue, batch); err != nil {
 					log.Printf("decoder worker %d erro publicando IPFIX decodificado: %v", workerId, err)
-					d.Nack(false, true)
+					stats.decoderPublishError()
+					d.Nack(true)
 					continue
 				}
 
-				d.Ack(false)
+				d.Ack()
 			}
 		}(i)
 	}
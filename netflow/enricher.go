@@ -0,0 +1,17 @@
+package netflow
+
+// FlowEnricher completa os registros decodificados (ASN, país, cliente)
+// antes de chegarem aos MetricProcessors.
+type FlowEnricher interface {
+	Enrich(decoded *DecodedIPFIXMessage)
+}
+
+var flowEnricher FlowEnricher
+
+func SetFlowEnricher(enricher FlowEnricher) {
+	flowEnricher = enricher
+}
+
+func GetFlowEnricher() FlowEnricher {
+	return flowEnricher
+}
@@ -0,0 +1,165 @@
+package enrichment
+
+import (
+	"log"
+	"net/netip"
+	models "net_monitor/models"
+	"net_monitor/netflow"
+	repository "net_monitor/repository"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const defaultReloadInterval = time.Minute
+
+// Enricher adiciona ASN, nome do AS, país e cliente aos flows. As bases ficam
+// atrás de ponteiros atômicos para que o reload não bloqueie os workers.
+type Enricher struct {
+	asn       atomic.Pointer[geoSource[ASInfo]]
+	country   atomic.Pointer[geoSource[string]]
+	customers atomic.Pointer[PrefixTrie[string]]
+
+	asnFiles       []string
+	countryFiles   []string
+	prefixRepo     *repository.MongoRepository[models.CustomerPrefix]
+	reloadInterval time.Duration
+	reloadMu       sync.Mutex
+}
+
+// NewEnricher lê GEOIP_ASN_DB e GEOIP_COUNTRY_DB (listas de arquivos .mmdb ou
+// .csv separadas por vírgula) e ENRICHMENT_RELOAD_SECONDS.
+func NewEnricher(prefixRepo *repository.MongoRepository[models.CustomerPrefix]) *Enricher {
+	interval := defaultReloadInterval
+	if seconds, err := strconv.Atoi(os.Getenv("ENRICHMENT_RELOAD_SECONDS")); err == nil && seconds > 0 {
+		interval = time.Duration(seconds) * time.Second
+	}
+
+	return &Enricher{
+		asnFiles:       splitFiles(os.Getenv("GEOIP_ASN_DB")),
+		countryFiles:   splitFiles(os.Getenv("GEOIP_COUNTRY_DB")),
+		prefixRepo:     prefixRepo,
+		reloadInterval: interval,
+	}
+}
+
+func (e *Enricher) Start() {
+	e.reloadDatabases(true)
+	e.ReloadPrefixes()
+
+	go func() {
+		ticker := time.NewTicker(e.reloadInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			e.reloadDatabases(false)
+			e.ReloadPrefixes()
+		}
+	}()
+}
+
+// reloadDatabases só relê os arquivos que mudaram; em caso de erro a base
+// anterior continua valendo.
+func (e *Enricher) reloadDatabases(force bool) {
+	e.reloadMu.Lock()
+	defer e.reloadMu.Unlock()
+
+	if len(e.asnFiles) > 0 {
+		if current := e.asn.Load(); force || current == nil || current.changed() {
+			if source, err := loadGeoSource(e.asnFiles, parseASNRow); err != nil {
+				log.Printf("[Enrichment] Erro carregando base de ASN: %v", err)
+			} else {
+				e.asn.Store(source)
+				log.Printf("[Enrichment] Base de ASN carregada (%d arquivos)", len(e.asnFiles))
+			}
+		}
+	}
+
+	if len(e.countryFiles) > 0 {
+		if current := e.country.Load(); force || current == nil || current.changed() {
+			if source, err := loadGeoSource(e.countryFiles, parseCountryRow); err != nil {
+				log.Printf("[Enrichment] Erro carregando base de países: %v", err)
+			} else {
+				e.country.Store(source)
+				log.Printf("[Enrichment] Base de países carregada (%d arquivos)", len(e.countryFiles))
+			}
+		}
+	}
+}
+
+func (e *Enricher) ReloadPrefixes() {
+	if e.prefixRepo == nil {
+		return
+	}
+
+	prefixes, err := e.prefixRepo.GetByFilter(bson.M{"active": true})
+	if err != nil {
+		log.Printf("[Enrichment] Erro carregando prefixos de clientes: %v", err)
+		return
+	}
+
+	trie := NewPrefixTrie[string]()
+	for _, customerPrefix := range prefixes {
+		prefix, err := netip.ParsePrefix(customerPrefix.Prefix)
+		if err != nil || !trie.Insert(prefix, customerPrefix.CustomerName) {
+			log.Printf("[Enrichment] Prefixo inválido ignorado: %s", customerPrefix.Prefix)
+		}
+	}
+	e.customers.Store(trie)
+}
+
+func (e *Enricher) Enrich(decoded *netflow.DecodedIPFIXMessage) {
+	asn := e.asn.Load()
+	country := e.country.Load()
+	customers := e.customers.Load()
+
+	for i := range decoded.FlowRecords {
+		record := &decoded.FlowRecords[i]
+
+		src, dst := record.SourceIPv4Address, record.DestinationIPv4Address
+		if src == "" && dst == "" {
+			src, dst = record.SourceIPv6Address, record.DestinationIPv6Address
+		}
+
+		if addr, err := netip.ParseAddr(src); err == nil {
+			record.SourceAS, record.SourceASName = resolveAS(asn, addr, record.RawFields["bgpSourceAsNumber"])
+			record.SourceCountry, _ = lookupCountry(country, addr)
+			record.SourceCustomer = lookupCustomer(customers, addr)
+		}
+		if addr, err := netip.ParseAddr(dst); err == nil {
+			record.DestinationAS, record.DestinationASName = resolveAS(asn, addr, record.RawFields["bgpDestinationAsNumber"])
+			record.DestinationCountry, _ = lookupCountry(country, addr)
+			record.DestinationCustomer = lookupCustomer(customers, addr)
+		}
+	}
+}
+
+// O ASN informado pelo exportador (BGP) tem prioridade sobre a base local;
+// o nome só é usado quando os dois concordam.
+func resolveAS(source *geoSource[ASInfo], addr netip.Addr, exported interface{}) (uint32, string) {
+	info, found := lookupASN(source, addr)
+
+	number, _ := netflow.RawUint(exported)
+
+	if number != 0 {
+		if found && uint64(info.Number) == number {
+			return info.Number, info.Name
+		}
+		return uint32(number), ""
+	}
+	if found {
+		return info.Number, info.Name
+	}
+	return 0, ""
+}
+
+func lookupCustomer(customers *PrefixTrie[string], addr netip.Addr) string {
+	if customers == nil {
+		return ""
+	}
+	customer, _ := customers.Lookup(addr)
+	return customer
+}
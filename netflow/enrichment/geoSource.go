@@ -0,0 +1,189 @@
+package enrichment
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+type ASInfo struct {
+	Number uint32
+	Name   string
+}
+
+// geoSource é uma base carregada de um ou mais arquivos: .mmdb (formato
+// MaxMind, lido inteiro em memória para permitir troca sem mmap) ou CSV.
+type geoSource[T any] struct {
+	files    []string
+	modTimes map[string]time.Time
+	readers  []*maxminddb.Reader
+	trie     *PrefixTrie[T]
+}
+
+type mmdbASNRecord struct {
+	Number       uint32 `maxminddb:"autonomous_system_number"`
+	Organization string `maxminddb:"autonomous_system_organization"`
+}
+
+type mmdbCountryRecord struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+}
+
+func splitFiles(value string) []string {
+	var files []string
+	for _, file := range strings.Split(value, ",") {
+		if file = strings.TrimSpace(file); file != "" {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// changed indica se algum dos arquivos mudou desde a última carga.
+func (s *geoSource[T]) changed() bool {
+	for _, file := range s.files {
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		if !info.ModTime().Equal(s.modTimes[file]) {
+			return true
+		}
+	}
+	return false
+}
+
+func loadGeoSource[T any](files []string, parseCSV func(row []string) (netip.Prefix, T, bool)) (*geoSource[T], error) {
+	source := &geoSource[T]{
+		files:    files,
+		modTimes: make(map[string]time.Time),
+		trie:     NewPrefixTrie[T](),
+	}
+
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, err
+		}
+		source.modTimes[file] = info.ModTime()
+
+		if strings.EqualFold(filepath.Ext(file), ".mmdb") {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+			reader, err := maxminddb.FromBytes(data)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", file, err)
+			}
+			source.readers = append(source.readers, reader)
+			continue
+		}
+
+		if err := loadCSV(file, source.trie, parseCSV); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+	}
+
+	return source, nil
+}
+
+func loadCSV[T any](file string, trie *PrefixTrie[T], parse func(row []string) (netip.Prefix, T, bool)) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		// Linhas inválidas (inclusive o cabeçalho) são ignoradas.
+		if prefix, value, ok := parse(row); ok {
+			trie.Insert(prefix, value)
+		}
+	}
+}
+
+// Formato do GeoLite2-ASN-Blocks: network,autonomous_system_number,autonomous_system_organization
+func parseASNRow(row []string) (netip.Prefix, ASInfo, bool) {
+	if len(row) < 2 {
+		return netip.Prefix{}, ASInfo{}, false
+	}
+	prefix, err := netip.ParsePrefix(strings.TrimSpace(row[0]))
+	if err != nil {
+		return netip.Prefix{}, ASInfo{}, false
+	}
+	number, err := strconv.ParseUint(strings.TrimPrefix(strings.TrimSpace(row[1]), "AS"), 10, 32)
+	if err != nil {
+		return netip.Prefix{}, ASInfo{}, false
+	}
+	info := ASInfo{Number: uint32(number)}
+	if len(row) > 2 {
+		info.Name = strings.TrimSpace(row[2])
+	}
+	return prefix, info, true
+}
+
+// Formato: network,country_iso_code
+func parseCountryRow(row []string) (netip.Prefix, string, bool) {
+	if len(row) < 2 {
+		return netip.Prefix{}, "", false
+	}
+	prefix, err := netip.ParsePrefix(strings.TrimSpace(row[0]))
+	country := strings.ToUpper(strings.TrimSpace(row[1]))
+	if err != nil || country == "" {
+		return netip.Prefix{}, "", false
+	}
+	return prefix, country, true
+}
+
+func (s *geoSource[T]) lookupCSV(addr netip.Addr) (T, bool) {
+	return s.trie.Lookup(addr)
+}
+
+func lookupASN(source *geoSource[ASInfo], addr netip.Addr) (ASInfo, bool) {
+	if source == nil {
+		return ASInfo{}, false
+	}
+	for _, reader := range source.readers {
+		var record mmdbASNRecord
+		if err := reader.Lookup(net.IP(addr.AsSlice()), &record); err == nil && record.Number != 0 {
+			return ASInfo{Number: record.Number, Name: record.Organization}, true
+		}
+	}
+	return source.lookupCSV(addr)
+}
+
+func lookupCountry(source *geoSource[string], addr netip.Addr) (string, bool) {
+	if source == nil {
+		return "", false
+	}
+	for _, reader := range source.readers {
+		var record mmdbCountryRecord
+		if err := reader.Lookup(net.IP(addr.AsSlice()), &record); err == nil && record.Country.ISOCode != "" {
+			return record.Country.ISOCode, true
+		}
+	}
+	return source.lookupCSV(addr)
+}
@@ -0,0 +1,97 @@
+package enrichment
+
+import "net/netip"
+
+// PrefixTrie faz longest-prefix match sobre IPv4 e IPv6 com uma árvore
+// binária por família.
+type PrefixTrie[T any] struct {
+	v4   *trieNode[T]
+	v6   *trieNode[T]
+	size int
+}
+
+type trieNode[T any] struct {
+	children [2]*trieNode[T]
+	value    T
+	hasValue bool
+}
+
+func NewPrefixTrie[T any]() *PrefixTrie[T] {
+	return &PrefixTrie[T]{v4: &trieNode[T]{}, v6: &trieNode[T]{}}
+}
+
+// NormalizePrefix mascara o prefixo e converte IPv4 mapeado em IPv6
+// (::ffff:10.0.0.0/104) para o IPv4 equivalente (10.0.0.0/8), já que o Lookup
+// desfaz o mapeamento dos endereços. Prefixos mapeados menores que /96 não têm
+// equivalente IPv4 e são recusados.
+func NormalizePrefix(prefix netip.Prefix) (netip.Prefix, bool) {
+	if !prefix.IsValid() {
+		return netip.Prefix{}, false
+	}
+	if prefix.Addr().Is4In6() {
+		if prefix.Bits() < 96 {
+			return netip.Prefix{}, false
+		}
+		prefix = netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()-96)
+	}
+	return prefix.Masked(), true
+}
+
+// Insert devolve false quando o prefixo não pode ser indexado.
+func (t *PrefixTrie[T]) Insert(prefix netip.Prefix, value T) bool {
+	prefix, ok := NormalizePrefix(prefix)
+	if !ok {
+		return false
+	}
+	addr := prefix.Addr()
+	node := t.root(addr)
+	bytes := addr.AsSlice()
+	if prefix.Bits() > len(bytes)*8 {
+		return false
+	}
+
+	for i := 0; i < prefix.Bits(); i++ {
+		bit := (bytes[i/8] >> (7 - uint(i%8))) & 1
+		if node.children[bit] == nil {
+			node.children[bit] = &trieNode[T]{}
+		}
+		node = node.children[bit]
+	}
+	if !node.hasValue {
+		t.size++
+	}
+	node.value = value
+	node.hasValue = true
+	return true
+}
+
+func (t *PrefixTrie[T]) Lookup(addr netip.Addr) (T, bool) {
+	addr = addr.Unmap()
+	node := t.root(addr)
+	bytes := addr.AsSlice()
+
+	var best T
+	found := false
+	for i := 0; node != nil; i++ {
+		if node.hasValue {
+			best, found = node.value, true
+		}
+		if i == len(bytes)*8 {
+			break
+		}
+		bit := (bytes[i/8] >> (7 - uint(i%8))) & 1
+		node = node.children[bit]
+	}
+	return best, found
+}
+
+func (t *PrefixTrie[T]) Len() int {
+	return t.size
+}
+
+func (t *PrefixTrie[T]) root(addr netip.Addr) *trieNode[T] {
+	if addr.Is4() {
+		return t.v4
+	}
+	return t.v6
+}
@@ -0,0 +1,46 @@
+package enrichment
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestPrefixTrieIPv4MappedPrefix(t *testing.T) {
+	trie := NewPrefixTrie[string]()
+	if !trie.Insert(netip.MustParsePrefix("::ffff:10.0.0.0/104"), "cliente") {
+		t.Fatal("prefixo mapeado /104 deveria ser aceito")
+	}
+	if trie.Insert(netip.MustParsePrefix("::ffff:0:0/80"), "curto") {
+		t.Error("prefixo mapeado menor que /96 deveria ser recusado")
+	}
+
+	for _, address := range []string{"10.1.2.3", "::ffff:10.1.2.3"} {
+		if value, ok := trie.Lookup(netip.MustParseAddr(address)); !ok || value != "cliente" {
+			t.Errorf("Lookup(%s) = %q, %v", address, value, ok)
+		}
+	}
+	if _, ok := trie.Lookup(netip.MustParseAddr("11.0.0.1")); ok {
+		t.Error("11.0.0.1 não deveria casar com 10.0.0.0/8")
+	}
+	if trie.Len() != 1 {
+		t.Errorf("Len = %d, esperado 1", trie.Len())
+	}
+}
+
+func TestNormalizePrefix(t *testing.T) {
+	cases := map[string]string{
+		"10.0.0.1/24":         "10.0.0.0/24",
+		"::ffff:10.0.0.0/104": "10.0.0.0/8",
+		"::ffff:10.0.0.1/128": "10.0.0.1/32",
+		"2001:db8::1/32":      "2001:db8::/32",
+	}
+	for input, want := range cases {
+		got, ok := NormalizePrefix(netip.MustParsePrefix(input))
+		if !ok || got.String() != want {
+			t.Errorf("NormalizePrefix(%s) = %s, %v; esperado %s", input, got, ok, want)
+		}
+	}
+	if _, ok := NormalizePrefix(netip.Prefix{}); ok {
+		t.Error("prefixo zero deveria ser recusado")
+	}
+}
@@ -0,0 +1,19 @@
+package netflow
+
+import "net_monitor/interfaces"
+
+// ExporterResolver identifica o dispositivo que exporta os flows pelo IP de
+// origem e pelo observation domain. Devolve nil para exportadores desconhecidos.
+type ExporterResolver interface {
+	Resolve(exporterIP string, observationDomain uint32) interfaces.NetworkDevice
+}
+
+var exporterResolver ExporterResolver
+
+func SetExporterResolver(resolver ExporterResolver) {
+	exporterResolver = resolver
+}
+
+func GetExporterResolver() ExporterResolver {
+	return exporterResolver
+}
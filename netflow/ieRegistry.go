@@ -0,0 +1,322 @@
+package netflow
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// IEDataType segue os "abstract data types" da RFC 7012 / registro IANA.
+type IEDataType string
+
+const (
+	IETypeUnsigned8            IEDataType = "unsigned8"
+	IETypeUnsigned16           IEDataType = "unsigned16"
+	IETypeUnsigned32           IEDataType = "unsigned32"
+	IETypeUnsigned64           IEDataType = "unsigned64"
+	IETypeSigned8              IEDataType = "signed8"
+	IETypeSigned16             IEDataType = "signed16"
+	IETypeSigned32             IEDataType = "signed32"
+	IETypeSigned64             IEDataType = "signed64"
+	IETypeFloat32              IEDataType = "float32"
+	IETypeFloat64              IEDataType = "float64"
+	IETypeBoolean              IEDataType = "boolean"
+	IETypeMacAddress           IEDataType = "macAddress"
+	IETypeOctetArray           IEDataType = "octetArray"
+	IETypeString               IEDataType = "string"
+	IETypeDateTimeSeconds      IEDataType = "dateTimeSeconds"
+	IETypeDateTimeMilliseconds IEDataType = "dateTimeMilliseconds"
+	IETypeDateTimeMicroseconds IEDataType = "dateTimeMicroseconds"
+	IETypeDateTimeNanoseconds  IEDataType = "dateTimeNanoseconds"
+	IETypeIPv4Address          IEDataType = "ipv4Address"
+	IETypeIPv6Address          IEDataType = "ipv6Address"
+)
+
+type InformationElement struct {
+	ElementID     uint16     `json:"elementId"`
+	EnterpriseNum uint32     `json:"enterpriseNum,omitempty"`
+	Name          string     `json:"name"`
+	DataType      IEDataType `json:"dataType"`
+}
+
+type ieKey struct {
+	enterpriseNum uint32
+	elementID     uint16
+}
+
+type IERegistry struct {
+	mu       sync.RWMutex
+	elements map[ieKey]InformationElement
+}
+
+func NewIERegistry() *IERegistry {
+	registry := &IERegistry{elements: make(map[ieKey]InformationElement)}
+	for _, ie := range ianaElements {
+		registry.Register(ie)
+	}
+	for _, ie := range enterpriseElements {
+		registry.Register(ie)
+	}
+	return registry
+}
+
+var defaultIERegistry = NewIERegistry()
+
+func GetIERegistry() *IERegistry {
+	return defaultIERegistry
+}
+
+func (r *IERegistry) Register(ie InformationElement) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.elements[ieKey{enterpriseNum: ie.EnterpriseNum, elementID: ie.ElementID}] = ie
+}
+
+func (r *IERegistry) Lookup(enterpriseNum uint32, elementID uint16) (InformationElement, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ie, ok := r.elements[ieKey{enterpriseNum: enterpriseNum, elementID: elementID}]
+	return ie, ok
+}
+
+// Describe devolve nome e tipo do elemento. Elementos desconhecidos ganham um
+// nome sintético e tipo vazio, decodificados pela heurística antiga.
+func (r *IERegistry) Describe(enterpriseNum uint32, elementID uint16) (string, IEDataType) {
+	if ie, ok := r.Lookup(enterpriseNum, elementID); ok {
+		return ie.Name, ie.DataType
+	}
+	if enterpriseNum != 0 {
+		return fmt.Sprintf("enterprise_%d_field_%d", enterpriseNum, elementID), ""
+	}
+	return fmt.Sprintf("field_%d", elementID), ""
+}
+
+// LoadIANACSV carrega o ipfix-information-elements.csv publicado pela IANA
+// (colunas ElementID, Name, Abstract Data Type, ...). Faixas reservadas são ignoradas.
+func (r *IERegistry) LoadIANACSV(path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.LazyQuotes = true
+	reader.FieldsPerRecord = -1
+
+	count := 0
+	for line := 0; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return count, err
+		}
+		if line == 0 || len(row) < 3 {
+			continue
+		}
+
+		elementID, err := strconv.ParseUint(strings.TrimSpace(row[0]), 10, 16)
+		name := strings.TrimSpace(row[1])
+		if err != nil || name == "" || name == "Reserved" || name == "Unassigned" {
+			continue
+		}
+
+		r.Register(InformationElement{
+			ElementID: uint16(elementID),
+			Name:      name,
+			DataType:  IEDataType(strings.TrimSpace(row[2])),
+		})
+		count++
+	}
+
+	return count, nil
+}
+
+// LoadEnterpriseJSON carrega definições de fabricantes a partir de uma lista
+// JSON de InformationElement.
+func (r *IERegistry) LoadEnterpriseJSON(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	var elements []InformationElement
+	if err := json.Unmarshal(data, &elements); err != nil {
+		return 0, err
+	}
+
+	for _, ie := range elements {
+		if ie.Name == "" {
+			continue
+		}
+		r.Register(ie)
+	}
+	return len(elements), nil
+}
+
+// LoadIERegistryFromEnv aplica IPFIX_IANA_REGISTRY (CSV da IANA) e
+// IPFIX_ENTERPRISE_ELEMENTS (JSON) sobre as definições embutidas.
+func LoadIERegistryFromEnv() {
+	if path := os.Getenv("IPFIX_IANA_REGISTRY"); path != "" {
+		if count, err := defaultIERegistry.LoadIANACSV(path); err != nil {
+			log.Printf("Erro carregando registro IANA de %s: %v", path, err)
+		} else {
+			log.Printf("%d elementos IANA carregados de %s", count, path)
+		}
+	}
+	if path := os.Getenv("IPFIX_ENTERPRISE_ELEMENTS"); path != "" {
+		if count, err := defaultIERegistry.LoadEnterpriseJSON(path); err != nil {
+			log.Printf("Erro carregando elementos enterprise de %s: %v", path, err)
+		} else {
+			log.Printf("%d elementos enterprise carregados de %s", count, path)
+		}
+	}
+}
+
+func getFieldName(fieldID uint16) string {
+	name, _ := defaultIERegistry.Describe(0, fieldID)
+	return name
+}
+
+var ianaElements = []InformationElement{
+	{ElementID: 1, Name: "octetDeltaCount", DataType: IETypeUnsigned64},
+	{ElementID: 2, Name: "packetDeltaCount", DataType: IETypeUnsigned64},
+	{ElementID: 4, Name: "protocolIdentifier", DataType: IETypeUnsigned8},
+	{ElementID: 5, Name: "ipClassOfService", DataType: IETypeUnsigned8},
+	{ElementID: 6, Name: "tcpControlBits", DataType: IETypeUnsigned16},
+	{ElementID: 7, Name: "sourceTransportPort", DataType: IETypeUnsigned16},
+	{ElementID: 8, Name: "sourceIPv4Address", DataType: IETypeIPv4Address},
+	{ElementID: 9, Name: "sourceIPv4PrefixLength", DataType: IETypeUnsigned8},
+	{ElementID: 10, Name: "ingressInterface", DataType: IETypeUnsigned32},
+	{ElementID: 11, Name: "destinationTransportPort", DataType: IETypeUnsigned16},
+	{ElementID: 12, Name: "destinationIPv4Address", DataType: IETypeIPv4Address},
+	{ElementID: 13, Name: "destinationIPv4PrefixLength", DataType: IETypeUnsigned8},
+	{ElementID: 14, Name: "egressInterface", DataType: IETypeUnsigned32},
+	{ElementID: 15, Name: "ipNextHopIPv4Address", DataType: IETypeIPv4Address},
+	{ElementID: 16, Name: "bgpSourceAsNumber", DataType: IETypeUnsigned32},
+	{ElementID: 17, Name: "bgpDestinationAsNumber", DataType: IETypeUnsigned32},
+	{ElementID: 21, Name: "flowEndSysUpTime", DataType: IETypeUnsigned32},
+	{ElementID: 22, Name: "flowStartSysUpTime", DataType: IETypeUnsigned32},
+	{ElementID: 27, Name: "sourceIPv6Address", DataType: IETypeIPv6Address},
+	{ElementID: 28, Name: "destinationIPv6Address", DataType: IETypeIPv6Address},
+	{ElementID: 29, Name: "sourceIPv6PrefixLength", DataType: IETypeUnsigned8},
+	{ElementID: 30, Name: "destinationIPv6PrefixLength", DataType: IETypeUnsigned8},
+	{ElementID: 31, Name: "flowLabelIPv6", DataType: IETypeUnsigned32},
+	{ElementID: 32, Name: "icmpTypeCodeIPv4", DataType: IETypeUnsigned16},
+	{ElementID: 33, Name: "igmpType", DataType: IETypeUnsigned8},
+	{ElementID: 34, Name: "samplingInterval", DataType: IETypeUnsigned32},
+	{ElementID: 35, Name: "samplingAlgorithm", DataType: IETypeUnsigned8},
+	{ElementID: 48, Name: "samplerId", DataType: IETypeUnsigned8},
+	{ElementID: 49, Name: "samplerMode", DataType: IETypeUnsigned8},
+	{ElementID: 50, Name: "samplerRandomInterval", DataType: IETypeUnsigned32},
+	{ElementID: 56, Name: "sourceMacAddress", DataType: IETypeMacAddress},
+	{ElementID: 57, Name: "postDestinationMacAddress", DataType: IETypeMacAddress},
+	{ElementID: 58, Name: "vlanId", DataType: IETypeUnsigned16},
+	{ElementID: 60, Name: "ipVersion", DataType: IETypeUnsigned8},
+	{ElementID: 61, Name: "flowDirection", DataType: IETypeUnsigned8},
+	{ElementID: 62, Name: "ipNextHopIPv6Address", DataType: IETypeIPv6Address},
+	{ElementID: 80, Name: "destinationMacAddress", DataType: IETypeMacAddress},
+	{ElementID: 81, Name: "postSourceMacAddress", DataType: IETypeMacAddress},
+	{ElementID: 82, Name: "interfaceName", DataType: IETypeString},
+	{ElementID: 83, Name: "interfaceDescription", DataType: IETypeString},
+	{ElementID: 85, Name: "octetTotalCount", DataType: IETypeUnsigned64},
+	{ElementID: 86, Name: "packetTotalCount", DataType: IETypeUnsigned64},
+	{ElementID: 94, Name: "applicationDescription", DataType: IETypeString},
+	{ElementID: 95, Name: "applicationId", DataType: IETypeOctetArray},
+	{ElementID: 96, Name: "applicationName", DataType: IETypeString},
+	{ElementID: 130, Name: "exporterIPv4Address", DataType: IETypeIPv4Address},
+	{ElementID: 131, Name: "exporterIPv6Address", DataType: IETypeIPv6Address},
+	{ElementID: 132, Name: "droppedOctetDeltaCount", DataType: IETypeUnsigned64},
+	{ElementID: 133, Name: "droppedPacketDeltaCount", DataType: IETypeUnsigned64},
+	{ElementID: 134, Name: "droppedOctetTotalCount", DataType: IETypeUnsigned64},
+	{ElementID: 135, Name: "droppedPacketTotalCount", DataType: IETypeUnsigned64},
+	{ElementID: 136, Name: "flowEndReason", DataType: IETypeUnsigned8},
+	{ElementID: 139, Name: "icmpTypeCodeIPv6", DataType: IETypeUnsigned16},
+	{ElementID: 144, Name: "exportingProcessId", DataType: IETypeUnsigned32},
+	{ElementID: 148, Name: "flowId", DataType: IETypeUnsigned64},
+	{ElementID: 149, Name: "observationDomainId", DataType: IETypeUnsigned32},
+	{ElementID: 150, Name: "flowStartSeconds", DataType: IETypeDateTimeSeconds},
+	{ElementID: 151, Name: "flowEndSeconds", DataType: IETypeDateTimeSeconds},
+	{ElementID: 152, Name: "flowStartMilliseconds", DataType: IETypeDateTimeMilliseconds},
+	{ElementID: 153, Name: "flowEndMilliseconds", DataType: IETypeDateTimeMilliseconds},
+	{ElementID: 154, Name: "flowStartMicroseconds", DataType: IETypeDateTimeMicroseconds},
+	{ElementID: 155, Name: "flowEndMicroseconds", DataType: IETypeDateTimeMicroseconds},
+	{ElementID: 156, Name: "flowStartNanoseconds", DataType: IETypeDateTimeNanoseconds},
+	{ElementID: 157, Name: "flowEndNanoseconds", DataType: IETypeDateTimeNanoseconds},
+	{ElementID: 160, Name: "systemInitTimeMilliseconds", DataType: IETypeDateTimeMilliseconds},
+	{ElementID: 176, Name: "icmpTypeIPv4", DataType: IETypeUnsigned8},
+	{ElementID: 177, Name: "icmpCodeIPv4", DataType: IETypeUnsigned8},
+	{ElementID: 178, Name: "icmpTypeIPv6", DataType: IETypeUnsigned8},
+	{ElementID: 179, Name: "icmpCodeIPv6", DataType: IETypeUnsigned8},
+	{ElementID: 184, Name: "tcpSequenceNumber", DataType: IETypeUnsigned32},
+	{ElementID: 185, Name: "tcpAcknowledgementNumber", DataType: IETypeUnsigned32},
+	{ElementID: 186, Name: "tcpWindowSize", DataType: IETypeUnsigned16},
+	{ElementID: 189, Name: "ipHeaderLength", DataType: IETypeUnsigned8},
+	{ElementID: 192, Name: "ipTTL", DataType: IETypeUnsigned8},
+	{ElementID: 205, Name: "udpMessageLength", DataType: IETypeUnsigned16},
+	{ElementID: 206, Name: "isMulticast", DataType: IETypeUnsigned8},
+	{ElementID: 224, Name: "ipTotalLength", DataType: IETypeUnsigned64},
+	{ElementID: 225, Name: "postNATSourceIPv4Address", DataType: IETypeIPv4Address},
+	{ElementID: 226, Name: "postNATDestinationIPv4Address", DataType: IETypeIPv4Address},
+	{ElementID: 227, Name: "postNAPTSourceTransportPort", DataType: IETypeUnsigned16},
+	{ElementID: 228, Name: "postNAPTDestinationTransportPort", DataType: IETypeUnsigned16},
+	{ElementID: 230, Name: "natEvent", DataType: IETypeUnsigned8},
+	{ElementID: 234, Name: "ingressVRFID", DataType: IETypeUnsigned32},
+	{ElementID: 235, Name: "egressVRFID", DataType: IETypeUnsigned32},
+	{ElementID: 281, Name: "postNATSourceIPv6Address", DataType: IETypeIPv6Address},
+	{ElementID: 282, Name: "postNATDestinationIPv6Address", DataType: IETypeIPv6Address},
+	{ElementID: 302, Name: "selectorId", DataType: IETypeUnsigned64},
+	{ElementID: 305, Name: "samplingPacketInterval", DataType: IETypeUnsigned32},
+	{ElementID: 306, Name: "samplingPacketSpace", DataType: IETypeUnsigned32},
+	{ElementID: 309, Name: "samplingSize", DataType: IETypeUnsigned32},
+	{ElementID: 310, Name: "samplingPopulation", DataType: IETypeUnsigned32},
+	{ElementID: 322, Name: "observationTimeSeconds", DataType: IETypeDateTimeSeconds},
+	{ElementID: 323, Name: "observationTimeMilliseconds", DataType: IETypeDateTimeMilliseconds},
+	{ElementID: 361, Name: "portRangeStart", DataType: IETypeUnsigned16},
+	{ElementID: 362, Name: "portRangeEnd", DataType: IETypeUnsigned16},
+	{ElementID: 363, Name: "portRangeStepSize", DataType: IETypeUnsigned16},
+	{ElementID: 364, Name: "portRangeNumPorts", DataType: IETypeUnsigned16},
+	{ElementID: 457, Name: "httpStatusCode", DataType: IETypeUnsigned16},
+	{ElementID: 458, Name: "httpRequestMethod", DataType: IETypeString},
+	{ElementID: 459, Name: "httpRequestHost", DataType: IETypeString},
+	{ElementID: 460, Name: "httpRequestTarget", DataType: IETypeString},
+}
+
+// Definições de fabricantes vistas com frequência; outras entram via
+// IPFIX_ENTERPRISE_ELEMENTS.
+const (
+	enterpriseBarracuda uint32 = 10704
+	enterpriseVMware    uint32 = 6876
+)
+
+var enterpriseElements = []InformationElement{
+	{EnterpriseNum: enterpriseBarracuda, ElementID: 1, Name: "barracudaTimestamp", DataType: IETypeDateTimeSeconds},
+	{EnterpriseNum: enterpriseBarracuda, ElementID: 2, Name: "barracudaLogOp", DataType: IETypeUnsigned8},
+	{EnterpriseNum: enterpriseBarracuda, ElementID: 3, Name: "barracudaFWRule", DataType: IETypeString},
+	{EnterpriseNum: enterpriseBarracuda, ElementID: 4, Name: "barracudaServiceName", DataType: IETypeString},
+	{EnterpriseNum: enterpriseBarracuda, ElementID: 5, Name: "barracudaReason", DataType: IETypeUnsigned32},
+	{EnterpriseNum: enterpriseBarracuda, ElementID: 6, Name: "barracudaReasonText", DataType: IETypeString},
+	{EnterpriseNum: enterpriseBarracuda, ElementID: 7, Name: "barracudaBindIPv4Address", DataType: IETypeIPv4Address},
+	{EnterpriseNum: enterpriseBarracuda, ElementID: 8, Name: "barracudaBindTransportPort", DataType: IETypeUnsigned16},
+	{EnterpriseNum: enterpriseBarracuda, ElementID: 9, Name: "barracudaConnIPv4Address", DataType: IETypeIPv4Address},
+	{EnterpriseNum: enterpriseBarracuda, ElementID: 10, Name: "barracudaConnTransportPort", DataType: IETypeUnsigned16},
+	{EnterpriseNum: enterpriseBarracuda, ElementID: 11, Name: "barracudaAuditCounter", DataType: IETypeUnsigned32},
+	{EnterpriseNum: enterpriseVMware, ElementID: 880, Name: "tenantProtocol", DataType: IETypeUnsigned8},
+	{EnterpriseNum: enterpriseVMware, ElementID: 881, Name: "tenantSourceIPv4", DataType: IETypeIPv4Address},
+	{EnterpriseNum: enterpriseVMware, ElementID: 882, Name: "tenantDestIPv4", DataType: IETypeIPv4Address},
+	{EnterpriseNum: enterpriseVMware, ElementID: 883, Name: "tenantSourceIPv6", DataType: IETypeIPv6Address},
+	{EnterpriseNum: enterpriseVMware, ElementID: 884, Name: "tenantDestIPv6", DataType: IETypeIPv6Address},
+	{EnterpriseNum: enterpriseVMware, ElementID: 886, Name: "tenantSourcePort", DataType: IETypeUnsigned16},
+	{EnterpriseNum: enterpriseVMware, ElementID: 887, Name: "tenantDestPort", DataType: IETypeUnsigned16},
+	{EnterpriseNum: enterpriseVMware, ElementID: 888, Name: "egressInterfaceAttr", DataType: IETypeUnsigned16},
+	{EnterpriseNum: enterpriseVMware, ElementID: 889, Name: "vxlanExportRole", DataType: IETypeUnsigned8},
+	{EnterpriseNum: enterpriseVMware, ElementID: 890, Name: "ingressInterfaceAttr", DataType: IETypeUnsigned16},
+}
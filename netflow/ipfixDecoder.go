@@ -3,100 +3,17 @@ package netflow
 import (
 	"encoding/binary"
 	"fmt"
+	"math"
 	"net"
+	"strings"
+	"time"
 	"unicode"
 )
 
-var ipfixFieldNames = map[uint16]string{
-	1:   "octetDeltaCount",
-	2:   "packetDeltaCount",
-	4:   "protocolIdentifier",
-	5:   "ipClassOfService",
-	6:   "tcpControlBits",
-	7:   "sourceTransportPort",
-	8:   "sourceIPv4Address",
-	9:   "sourceIPv4PrefixLength",
-	10:  "ingressInterface",
-	11:  "destinationTransportPort",
-	12:  "destinationIPv4Address",
-	13:  "destinationIPv4PrefixLength",
-	14:  "egressInterface",
-	15:  "ipNextHopIPv4Address",
-	16:  "bgpSourceAsNumber",
-	17:  "bgpDestinationAsNumber",
-	21:  "flowEndSysUpTime",
-	22:  "flowStartSysUpTime",
-	27:  "sourceIPv6Address",
-	28:  "destinationIPv6Address",
-	29:  "sourceIPv6PrefixLength",
-	30:  "destinationIPv6PrefixLength",
-	31:  "flowLabelIPv6",
-	33:  "igmpType",
-	56:  "sourceMacAddress",
-	57:  "postDestinationMacAddress",
-	60:  "ipVersion",
-	61:  "flowDirection",
-	62:  "ipNextHopIPv6Address",
-	80:  "destinationMacAddress",
-	81:  "postSourceMacAddress",
-	85:  "octetTotalCount",
-	86:  "packetTotalCount",
-	132: "droppedOctetDeltaCount",
-	133: "droppedPacketDeltaCount",
-	134: "droppedOctetTotalCount",
-	135: "droppedPacketTotalCount",
-	136: "flowEndReason",
-	148: "flowId",
-	150: "flowStartSeconds",
-	151: "flowEndSeconds",
-	152: "flowStartMilliseconds",
-	153: "flowEndMilliseconds",
-	154: "flowStartMicroseconds",
-	155: "flowEndMicroseconds",
-	160: "systemInitTimeMilliseconds",
-	176: "icmpTypeIPv4",
-	177: "icmpCodeIPv4",
-	178: "icmpTypeIPv6",
-	179: "icmpCodeIPv6",
-	184: "tcpSequenceNumber",
-	185: "tcpAcknowledgementNumber",
-	186: "tcpWindowSize",
-	189: "ipHeaderLength",
-	192: "ipTTL",
-	205: "udpMessageLength",
-	206: "isMulticast",
-	224: "ipTotalLength",
-	225: "postNATSourceIPv4Address",
-	227: "postNAPTSourceTransportPort",
-	226: "postNATDestinationIPv4Address",
-	228: "postNAPTDestinationTransportPort",
-}
-
-type TemplateCache struct {
-	templates map[uint32]map[uint16]*Template
-}
-
-func NewTemplateCache() *TemplateCache {
-	return &TemplateCache{
-		templates: make(map[uint32]map[uint16]*Template),
-	}
-}
-
-func (tc *TemplateCache) AddTemplate(obsDomain uint32, template *Template) {
-	if tc.templates[obsDomain] == nil {
-		tc.templates[obsDomain] = make(map[uint16]*Template)
-	}
-	tc.templates[obsDomain][template.TemplateID] = template
-}
-
-func (tc *TemplateCache) GetTemplate(obsDomain uint32, templateID uint16) *Template {
-	if domainTemplates, ok := tc.templates[obsDomain]; ok {
-		return domainTemplates[templateID]
-	}
-	return nil
-}
+// Campos com comprimento 65535 no template têm tamanho variável (RFC 7011 7).
+const variableLengthField = 65535
 
-func DecodeIPFIX(msg *IPFIXMessage, cache *TemplateCache) *DecodedIPFIXMessage {
+func DecodeIPFIX(msg *IPFIXMessage, cache *TemplateCache, key TemplateKey) *DecodedIPFIXMessage {
 	decoded := &DecodedIPFIXMessage{
 		Header:      msg.Header,
 		Templates:   []Template{},
@@ -104,24 +21,61 @@ func DecodeIPFIX(msg *IPFIXMessage, cache *TemplateCache) *DecodedIPFIXMessage {
 	}
 
 	for _, fs := range msg.FlowSets {
-		if fs.FlowSetID == 2 {
-			templates := parseTemplateFlowSet(fs.Payload)
+		switch {
+		case fs.FlowSetID == 2 || fs.FlowSetID == 3:
+			var templates []Template
+			if fs.FlowSetID == 2 {
+				templates = parseTemplateFlowSet(fs.Payload)
+			} else {
+				templates = parseOptionsTemplateFlowSet(fs.Payload)
+			}
 			for _, tmpl := range templates {
-				cache.AddTemplate(msg.Header.ObservationDomain, &tmpl)
+				if tmpl.FieldCount == 0 {
+					withdrawTemplate(cache, key, fs.FlowSetID, tmpl.TemplateID)
+					continue
+				}
+				cache.AddTemplate(key, &tmpl)
 				decoded.Templates = append(decoded.Templates, tmpl)
+
+				for _, pending := range cache.TakePendingFlowSets(key, tmpl.TemplateID) {
+					appendDataRecords(decoded, cache, key, &tmpl, parseDataFlowSet(pending.Payload, &tmpl))
+				}
 			}
-		} else if fs.FlowSetID >= 256 {
-			template := cache.GetTemplate(msg.Header.ObservationDomain, fs.FlowSetID)
-			if template != nil {
-				records := parseDataFlowSet(fs.Payload, template)
-				decoded.FlowRecords = append(decoded.FlowRecords, records...)
+		case fs.FlowSetID >= 256:
+			template := cache.GetTemplate(key, fs.FlowSetID)
+			if template == nil {
+				cache.BufferFlowSet(key, fs.FlowSetID, fs.Payload, msg.Header)
+				decoded.templatesMissing++
+				continue
 			}
+			records := parseDataFlowSet(fs.Payload, template)
+			decoded.dataRecords += len(records)
+			appendDataRecords(decoded, cache, key, template, records)
 		}
 	}
 
 	return decoded
 }
 
+func appendDataRecords(decoded *DecodedIPFIXMessage, cache *TemplateCache, key TemplateKey, template *Template, records []FlowRecord) {
+	if template.ScopeFieldCount > 0 {
+		cache.StoreOptionRecords(key, template.TemplateID, records)
+		decoded.OptionRecords = append(decoded.OptionRecords, records...)
+		return
+	}
+	decoded.FlowRecords = append(decoded.FlowRecords, records...)
+}
+
+// Um template com field count 0 é uma retirada (RFC 7011 8.1). Se o ID for o
+// próprio set ID (2 ou 3), todos os templates daquele tipo são retirados.
+func withdrawTemplate(cache *TemplateCache, key TemplateKey, setID uint16, templateID uint16) {
+	if templateID == setID {
+		cache.WithdrawAllTemplates(key, setID == 3)
+		return
+	}
+	cache.WithdrawTemplate(key, templateID)
+}
+
 func parseTemplateFlowSet(payload []byte) []Template {
 	templates := []Template{}
 	offset := 0
@@ -129,32 +83,50 @@ func parseTemplateFlowSet(payload []byte) []Template {
 	for offset+4 <= len(payload) {
 		templateID := binary.BigEndian.Uint16(payload[offset : offset+2])
 		fieldCount := binary.BigEndian.Uint16(payload[offset+2 : offset+4])
+		if templateID < 256 && !(templateID == 2 && fieldCount == 0) {
+			break
+		}
 		offset += 4
 
 		template := Template{
 			TemplateID: templateID,
 			FieldCount: fieldCount,
-			Fields:     []TemplateField{},
 		}
+		template.Fields, offset = parseFieldSpecifiers(payload, offset, int(fieldCount))
 
-		for i := 0; i < int(fieldCount) && offset+4 <= len(payload); i++ {
-			fieldID := binary.BigEndian.Uint16(payload[offset : offset+2])
-			fieldLength := binary.BigEndian.Uint16(payload[offset+2 : offset+4])
-			offset += 4
+		templates = append(templates, template)
+	}
 
-			field := TemplateField{
-				FieldID:     fieldID & 0x7FFF,
-				FieldLength: fieldLength,
-				FieldName:   getFieldName(fieldID & 0x7FFF),
-			}
+	return templates
+}
 
-			if fieldID&0x8000 != 0 && offset+4 <= len(payload) {
-				field.EnterpriseNum = binary.BigEndian.Uint32(payload[offset : offset+4])
-				offset += 4
-			}
+func parseOptionsTemplateFlowSet(payload []byte) []Template {
+	templates := []Template{}
+	offset := 0
+
+	for offset+4 <= len(payload) {
+		templateID := binary.BigEndian.Uint16(payload[offset : offset+2])
+		fieldCount := binary.BigEndian.Uint16(payload[offset+2 : offset+4])
+		if templateID < 256 && !(templateID == 3 && fieldCount == 0) {
+			break
+		}
+		if fieldCount == 0 {
+			templates = append(templates, Template{TemplateID: templateID})
+			offset += 4
+			continue
+		}
+		if offset+6 > len(payload) {
+			break
+		}
+		scopeFieldCount := binary.BigEndian.Uint16(payload[offset+4 : offset+6])
+		offset += 6
 
-			template.Fields = append(template.Fields, field)
+		template := Template{
+			TemplateID:      templateID,
+			FieldCount:      fieldCount,
+			ScopeFieldCount: scopeFieldCount,
 		}
+		template.Fields, offset = parseFieldSpecifiers(payload, offset, int(fieldCount))
 
 		templates = append(templates, template)
 	}
@@ -162,146 +134,158 @@ func parseTemplateFlowSet(payload []byte) []Template {
 	return templates
 }
 
+func parseFieldSpecifiers(payload []byte, offset int, fieldCount int) ([]TemplateField, int) {
+	fields := []TemplateField{}
+
+	for i := 0; i < fieldCount && offset+4 <= len(payload); i++ {
+		fieldID := binary.BigEndian.Uint16(payload[offset : offset+2])
+		fieldLength := binary.BigEndian.Uint16(payload[offset+2 : offset+4])
+		offset += 4
+
+		field := TemplateField{
+			FieldID:     fieldID & 0x7FFF,
+			FieldLength: fieldLength,
+		}
+
+		if fieldID&0x8000 != 0 && offset+4 <= len(payload) {
+			field.EnterpriseNum = binary.BigEndian.Uint32(payload[offset : offset+4])
+			offset += 4
+		}
+		field.FieldName, field.DataType = defaultIERegistry.Describe(field.EnterpriseNum, field.FieldID)
+
+		fields = append(fields, field)
+	}
+
+	return fields, offset
+}
+
 func parseDataFlowSet(payload []byte, template *Template) []FlowRecord {
 	records := []FlowRecord{}
-	offset := 0
 
-	recordSize := 0
+	// Campos variáveis ocupam ao menos o byte de comprimento; o que sobrar
+	// abaixo do tamanho mínimo é padding.
+	minRecordSize := 0
 	for _, field := range template.Fields {
-		recordSize += int(field.FieldLength)
+		if field.FieldLength == variableLengthField {
+			minRecordSize++
+		} else {
+			minRecordSize += int(field.FieldLength)
+		}
 	}
-
-	if recordSize == 0 {
+	if minRecordSize == 0 {
 		return records
 	}
 
-	for offset+recordSize <= len(payload) {
+	offset := 0
+	for offset+minRecordSize <= len(payload) {
 		record := FlowRecord{
 			TemplateID: template.TemplateID,
 			RawFields:  make(map[string]interface{}),
 		}
 
 		fieldOffset := offset
-		for _, field := range template.Fields {
-			fieldData := payload[fieldOffset : fieldOffset+int(field.FieldLength)]
-			fieldOffset += int(field.FieldLength)
-			decodeField(&record, field.FieldID, field.FieldName, fieldData)
+		complete := true
+		for i, field := range template.Fields {
+			fieldData, next, ok := readFieldData(payload, fieldOffset, field.FieldLength)
+			if !ok {
+				complete = false
+				break
+			}
+			fieldOffset = next
+			if i < int(template.ScopeFieldCount) {
+				if field.DataType == "" {
+					record.RawFields[field.FieldName] = readUintN(fieldData)
+				} else {
+					record.RawFields[field.FieldName] = decodeTypedValue(field.DataType, fieldData)
+				}
+				continue
+			}
+			decodeField(&record, field, fieldData)
+		}
+		if !complete {
+			break
 		}
 
 		records = append(records, record)
-		offset += recordSize
+		offset = fieldOffset
 	}
 
 	return records
 }
 
-func decodeField(record *FlowRecord, fieldID uint16, fieldName string, data []byte) {
-	switch fieldID {
+func readFieldData(payload []byte, offset int, fieldLength uint16) ([]byte, int, bool) {
+	size := int(fieldLength)
+	if fieldLength == variableLengthField {
+		if offset >= len(payload) {
+			return nil, offset, false
+		}
+		size = int(payload[offset])
+		offset++
+		if size == 255 {
+			if offset+2 > len(payload) {
+				return nil, offset, false
+			}
+			size = int(binary.BigEndian.Uint16(payload[offset : offset+2]))
+			offset += 2
+		}
+	}
+	if offset+size > len(payload) {
+		return nil, offset, false
+	}
+	return payload[offset : offset+size], offset + size, true
+}
+
+func decodeField(record *FlowRecord, field TemplateField, data []byte) {
+	if len(data) == 0 {
+		return
+	}
+
+	fieldName := field.FieldName
+	if field.EnterpriseNum != 0 {
+		record.RawFields[fieldName] = decodeTypedValue(field.DataType, data)
+		return
+	}
+
+	// Só os campos da struct são tratados aqui; o resto segue o tipo do
+	// registro, que aceita codificação reduzida (RFC 7011 §6.2).
+	switch field.FieldID {
 	case 1:
 		record.OctetDeltaCount = readUintN(data)
 	case 2:
 		record.PacketDeltaCount = readUintN(data)
 	case 4:
-		record.ProtocolIdentifier = data[0]
+		record.ProtocolIdentifier = uint8(readUintN(data))
 	case 5:
-		record.IPClassOfService = data[0]
-	// case 6:
-	// 	record.RawFields[fieldName] = binary.BigEndian.Uint16(data)
+		record.IPClassOfService = uint8(readUintN(data))
 	case 7:
-		record.SourceTransportPort = binary.BigEndian.Uint16(data)
+		record.SourceTransportPort = uint16(readUintN(data))
 	case 8:
 		record.SourceIPv4Address = decodeIPv4(data)
 	case 10:
-		record.IngressInterface = binary.BigEndian.Uint32(data)
+		record.IngressInterface = uint32(readUintN(data))
 	case 11:
-		record.DestinationTransportPort = binary.BigEndian.Uint16(data)
+		record.DestinationTransportPort = uint16(readUintN(data))
 	case 12:
 		record.DestinationIPv4Address = decodeIPv4(data)
 	case 14:
-		record.EgressInterface = binary.BigEndian.Uint32(data)
-	case 15:
-		record.RawFields[fieldName] = decodeIPv4(data)
+		record.EgressInterface = uint32(readUintN(data))
 	case 27:
 		record.SourceIPv6Address = decodeIPv6(data)
 	case 28:
 		record.DestinationIPv6Address = decodeIPv6(data)
-	case 29:
-		record.RawFields[fieldName] = data[0]
-	case 30:
-		record.RawFields[fieldName] = data[0]
-	case 31:
-		record.RawFields[fieldName] = binary.BigEndian.Uint32(data)
-	case 33:
-		record.RawFields[fieldName] = data[0]
-	case 56:
-		record.RawFields[fieldName] = decodeMacAddress(data)
-	case 57:
-		record.RawFields[fieldName] = decodeMacAddress(data)
 	case 60:
-		record.IPVersion = data[0]
+		record.IPVersion = uint8(readUintN(data))
 	case 61:
-		record.FlowDirection = data[0]
-	case 62:
-		record.RawFields[fieldName] = decodeIPv6(data)
-	case 80:
-		record.RawFields[fieldName] = decodeMacAddress(data)
-	case 81:
-		record.RawFields[fieldName] = decodeMacAddress(data)
-	case 132:
-		record.RawFields[fieldName] = binary.BigEndian.Uint64(data)
-	case 133:
-		record.RawFields[fieldName] = binary.BigEndian.Uint64(data)
-	case 134:
-		record.RawFields[fieldName] = binary.BigEndian.Uint64(data)
-	case 135:
-		record.RawFields[fieldName] = binary.BigEndian.Uint64(data)
+		record.FlowDirection = uint8(readUintN(data))
 	case 152:
 		record.FlowStartMilliseconds = readUintN(data)
 	case 153:
 		record.FlowEndMilliseconds = readUintN(data)
 	case 160:
+		// Mantido em ms numéricos: é somado ao uptime para achar o início do flow.
 		record.RawFields[fieldName] = readUintN(data)
-	case 176:
-		record.RawFields[fieldName] = data[0]
-	case 177:
-		record.RawFields[fieldName] = data[0]
-	case 178:
-		record.RawFields[fieldName] = data[0]
-	case 179:
-		record.RawFields[fieldName] = data[0]
-	case 184:
-		record.RawFields[fieldName] = binary.BigEndian.Uint32(data)
-	case 185:
-		record.RawFields[fieldName] = binary.BigEndian.Uint32(data)
-	case 186:
-		record.RawFields[fieldName] = binary.BigEndian.Uint16(data)
-	case 189:
-		record.RawFields[fieldName] = data[0]
-	case 192:
-		record.RawFields[fieldName] = data[0]
-	case 205:
-		record.RawFields[fieldName] = binary.BigEndian.Uint16(data)
-	case 206:
-		record.RawFields[fieldName] = data[0]
-	case 224:
-		record.RawFields[fieldName] = binary.BigEndian.Uint64(data)
-	case 225:
-		record.RawFields[fieldName] = decodeIPv4(data)
-	case 226:
-		record.RawFields[fieldName] = decodeIPv4(data)
-	case 227:
-		record.RawFields[fieldName] = binary.BigEndian.Uint16(data)
-	case 228:
-		record.RawFields[fieldName] = binary.BigEndian.Uint16(data)
 	default:
-		if len(data) <= 8 {
-			val := readUintN(data)
-			record.RawFields[fieldName] = val
-		} else if isPrintable(data) {
-			record.RawFields[fieldName] = string(data)
-		} else {
-			record.RawFields[fieldName] = fmt.Sprintf("%x", data)
-		}
+		record.RawFields[fieldName] = decodeTypedValue(field.DataType, data)
 	}
 }
 
@@ -327,6 +311,66 @@ func decodeMacAddress(data []byte) string {
 		data[0], data[1], data[2], data[3], data[4], data[5])
 }
 
+// decodeTypedValue converte o valor conforme o tipo do registro. Tipos
+// desconhecidos mantêm a heurística de inteiro/texto/hex.
+func decodeTypedValue(dataType IEDataType, data []byte) interface{} {
+	switch dataType {
+	case IETypeUnsigned8, IETypeUnsigned16, IETypeUnsigned32, IETypeUnsigned64:
+		return readUintN(data)
+	case IETypeSigned8, IETypeSigned16, IETypeSigned32, IETypeSigned64:
+		if len(data) > 8 {
+			break
+		}
+		shift := uint(64 - 8*len(data))
+		return int64(readUintN(data)<<shift) >> shift
+	case IETypeFloat32:
+		if len(data) == 4 {
+			return float64(math.Float32frombits(binary.BigEndian.Uint32(data)))
+		}
+	case IETypeFloat64:
+		if len(data) == 8 {
+			return math.Float64frombits(binary.BigEndian.Uint64(data))
+		}
+	case IETypeBoolean:
+		return len(data) == 1 && data[0] == 1
+	case IETypeMacAddress:
+		return decodeMacAddress(data)
+	case IETypeString:
+		return strings.ToValidUTF8(strings.TrimRight(string(data), "\x00"), "")
+	case IETypeIPv4Address:
+		return decodeIPv4(data)
+	case IETypeIPv6Address:
+		return decodeIPv6(data)
+	case IETypeDateTimeSeconds:
+		return time.Unix(int64(readUintN(data)), 0).UTC()
+	case IETypeDateTimeMilliseconds:
+		return time.UnixMilli(int64(readUintN(data))).UTC()
+	case IETypeDateTimeMicroseconds, IETypeDateTimeNanoseconds:
+		if len(data) == 8 {
+			return decodeNTPTimestamp(data)
+		}
+	case IETypeOctetArray:
+		return fmt.Sprintf("%x", data)
+	}
+
+	if len(data) <= 8 {
+		return readUintN(data)
+	} else if isPrintable(data) {
+		return string(data)
+	}
+	return fmt.Sprintf("%x", data)
+}
+
+// Micro e nanossegundos usam o formato NTP de 64 bits: segundos desde 1900
+// e fração de segundo.
+func decodeNTPTimestamp(data []byte) time.Time {
+	const ntpEpochOffset = 2208988800
+	seconds := int64(binary.BigEndian.Uint32(data[0:4])) - ntpEpochOffset
+	fraction := uint64(binary.BigEndian.Uint32(data[4:8]))
+	nanos := int64((fraction * 1e9) >> 32)
+	return time.Unix(seconds, nanos).UTC()
+}
+
 func isPrintable(data []byte) bool {
 	for _, b := range data {
 		if !unicode.IsPrint(rune(b)) && b != 0 {
@@ -355,10 +399,3 @@ func readUintN(data []byte) uint64 {
 		return 0
 	}
 }
-
-func getFieldName(fieldID uint16) string {
-	if name, ok := ipfixFieldNames[fieldID]; ok {
-		return name
-	}
-	return fmt.Sprintf("field_%d", fieldID)
-}
@@ -0,0 +1,145 @@
+package netflow
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+type testField struct {
+	id    uint16
+	value []byte
+}
+
+// Campos com codificação reduzida (RFC 7011 §6.2) e tamanhos comuns no v9.
+var reducedSizeFields = []testField{
+	{8, []byte{10, 0, 0, 1}},
+	{12, []byte{192, 168, 0, 1}},
+	{4, []byte{6}},
+	{7, []byte{0x30, 0x39}},
+	{11, []byte{0x01, 0xbb}},
+	{1, []byte{0x01, 0x00, 0x00}},
+	{2, []byte{0x0a}},
+	{6, []byte{0x12}},
+	{31, []byte{0x0a, 0xbc, 0xde}},
+	{132, []byte{0x00, 0x00, 0x10, 0x00}},
+	{133, []byte{0x07}},
+	{184, []byte{0x01, 0x02}},
+	{185, []byte{0x03}},
+	{186, []byte{0x40}},
+	{205, []byte{0x05}},
+	{224, []byte{0x00, 0x00, 0x05, 0xdc}},
+	{227, []byte{0x50}},
+	{228, []byte{0x1f}},
+}
+
+func buildTemplateAndData(templateSetID uint16, fields []testField) []byte {
+	template := binary.BigEndian.AppendUint16(nil, 256)
+	template = binary.BigEndian.AppendUint16(template, uint16(len(fields)))
+	var data []byte
+	for _, field := range fields {
+		template = binary.BigEndian.AppendUint16(template, field.id)
+		template = binary.BigEndian.AppendUint16(template, uint16(len(field.value)))
+		data = append(data, field.value...)
+	}
+
+	set := func(id uint16, payload []byte) []byte {
+		for (len(payload)+4)%4 != 0 {
+			payload = append(payload, 0)
+		}
+		out := binary.BigEndian.AppendUint16(nil, id)
+		out = binary.BigEndian.AppendUint16(out, uint16(len(payload)+4))
+		return append(out, payload...)
+	}
+	return append(set(templateSetID, template), set(256, data)...)
+}
+
+func buildV9Packet(fields []testField) []byte {
+	header := binary.BigEndian.AppendUint16(nil, 9)
+	header = binary.BigEndian.AppendUint16(header, 2)
+	header = binary.BigEndian.AppendUint32(header, 1000)
+	header = binary.BigEndian.AppendUint32(header, uint32(time.Now().Unix()))
+	header = binary.BigEndian.AppendUint32(header, 1)
+	header = binary.BigEndian.AppendUint32(header, 0)
+	return append(header, buildTemplateAndData(0, fields)...)
+}
+
+func buildIPFIXPacket(fields []testField) []byte {
+	body := buildTemplateAndData(2, fields)
+	header := binary.BigEndian.AppendUint16(nil, 10)
+	header = binary.BigEndian.AppendUint16(header, uint16(16+len(body)))
+	header = binary.BigEndian.AppendUint32(header, uint32(time.Now().Unix()))
+	header = binary.BigEndian.AppendUint32(header, 1)
+	header = binary.BigEndian.AppendUint32(header, 0)
+	return append(header, body...)
+}
+
+func TestDecodePacketReducedSizeEncoding(t *testing.T) {
+	packets := map[string][]byte{
+		"v9":    buildV9Packet(reducedSizeFields),
+		"ipfix": buildIPFIXPacket(reducedSizeFields),
+	}
+
+	for name, packet := range packets {
+		t.Run(name, func(t *testing.T) {
+			decoded, err := DecodePacket(packet, "192.0.2.1", 2055, NewTemplateCache(time.Hour, nil))
+			if err != nil {
+				t.Fatalf("DecodePacket: %v", err)
+			}
+			if len(decoded.FlowRecords) != 1 {
+				t.Fatalf("esperado 1 registro, veio %d", len(decoded.FlowRecords))
+			}
+			record := decoded.FlowRecords[0]
+
+			if record.SourceIPv4Address != "10.0.0.1" || record.DestinationIPv4Address != "192.168.0.1" {
+				t.Errorf("endereços: %s -> %s", record.SourceIPv4Address, record.DestinationIPv4Address)
+			}
+			if record.ProtocolIdentifier != 6 || record.SourceTransportPort != 12345 || record.DestinationTransportPort != 443 {
+				t.Errorf("protocolo/portas: %d %d %d", record.ProtocolIdentifier, record.SourceTransportPort, record.DestinationTransportPort)
+			}
+			if record.OctetDeltaCount != 0x010000 || record.PacketDeltaCount != 10 {
+				t.Errorf("contadores: %d bytes, %d pacotes", record.OctetDeltaCount, record.PacketDeltaCount)
+			}
+
+			expected := map[string]uint64{
+				"tcpControlBits":                   0x12,
+				"flowLabelIPv6":                    0x0abcde,
+				"droppedOctetDeltaCount":           0x1000,
+				"droppedPacketDeltaCount":          7,
+				"tcpSequenceNumber":                0x0102,
+				"tcpAcknowledgementNumber":         3,
+				"tcpWindowSize":                    0x40,
+				"udpMessageLength":                 5,
+				"ipTotalLength":                    1500,
+				"postNAPTSourceTransportPort":      0x50,
+				"postNAPTDestinationTransportPort": 0x1f,
+			}
+			for field, want := range expected {
+				got, ok := record.RawFields[field].(uint64)
+				if !ok || got != want {
+					t.Errorf("%s = %#v, esperado %d", field, record.RawFields[field], want)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeTypedValueReducedSize(t *testing.T) {
+	cases := []struct {
+		dataType IEDataType
+		data     []byte
+		want     interface{}
+	}{
+		{IETypeUnsigned64, []byte{0x01, 0x00, 0x00}, uint64(0x010000)},
+		{IETypeUnsigned32, []byte{0xff}, uint64(0xff)},
+		{IETypeUnsigned16, []byte{0x01}, uint64(1)},
+		{IETypeSigned32, []byte{0xff, 0xfe}, int64(-2)},
+		{IETypeSigned64, []byte{0x7f}, int64(127)},
+		{IETypeBoolean, []byte{1}, true},
+	}
+	for _, c := range cases {
+		if got := decodeTypedValue(c.dataType, c.data); got != c.want {
+			t.Errorf("decodeTypedValue(%v, %x) = %#v, esperado %#v", c.dataType, c.data, got, c.want)
+		}
+	}
+}
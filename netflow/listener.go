@@ -8,7 +8,7 @@ import (
 	"time"
 )
 
-func StartListener(rabbit *RabbitMQ, listenAddr string) error {
+func StartListener(rawQueue Queue, listenAddr string) error {
 	udpAddr, err := net.ResolveUDPAddr("udp", listenAddr)
 	if err != nil {
 		return err
@@ -18,6 +18,7 @@ func StartListener(rabbit *RabbitMQ, listenAddr string) error {
 		return err
 	}
 	log.Printf("IPFIX listener iniciado em %s", listenAddr)
+	publisher := NewPacketPublisher(rawQueue)
 	go func() {
 		defer conn.Close()
 		buf := make([]byte, 65535)
@@ -25,6 +26,7 @@ func StartListener(rabbit *RabbitMQ, listenAddr string) error {
 			n, addr, err := conn.ReadFromUDP(buf)
 			if err != nil {
 				log.Printf("Erro lendo UDP: %v", err)
+				stats.listenerReadError()
 				continue
 			}
 			payload := make([]byte, n)
@@ -39,8 +41,9 @@ func StartListener(rabbit *RabbitMQ, listenAddr string) error {
 				SrcPort:  srcPort,
 				Received: time.Now(),
 			}
-			if err := rabbit.PublishPacket(msg); err != nil {
-				log.Printf("Erro publicando no rabbit: %v", err)
+			stats.packetReceived(msg.SrcIP, n)
+			if err := publisher.Add(msg); err != nil {
+				log.Printf("Erro publicando na fila: %v", err)
 			} else {
 				//log.Printf("Packet recebido de %s:%d - tamanho %d publicado na fila", msg.SrcIP, msg.SrcPort, len(payload))
 			}
@@ -0,0 +1,119 @@
+package netflow
+
+import (
+	"sync"
+	"time"
+)
+
+// MemoryQueue é uma fila em processo com capacidade limitada, para
+// instalações de um nó só e para rodar o pipeline sem broker. Publish bloqueia
+// quando a fila está cheia; as mensagens se perdem se o processo cair.
+// Um Nack com requeue tenta devolver a mensagem algumas vezes e, se a fila
+// continuar cheia, manda para a dead letter em vez de bloquear o worker.
+type MemoryQueue struct {
+	messages chan memoryMessage
+	done     chan struct{}
+	once     sync.Once
+
+	mu         sync.Mutex
+	deliveries chan Delivery
+}
+
+const (
+	memoryRequeueAttempts = 3
+	memoryRequeueDelay    = 10 * time.Millisecond
+)
+
+type memoryMessage struct {
+	contentType string
+	body        []byte
+}
+
+func NewMemoryQueue(capacity int) *MemoryQueue {
+	return &MemoryQueue{
+		messages: make(chan memoryMessage, capacity),
+		done:     make(chan struct{}),
+	}
+}
+
+func (q *MemoryQueue) Publish(contentType string, body []byte) error {
+	select {
+	case <-q.done:
+		return ErrQueueUnavailable
+	default:
+	}
+
+	select {
+	case q.messages <- memoryMessage{contentType: contentType, body: body}:
+		return nil
+	case <-q.done:
+		return ErrQueueUnavailable
+	}
+}
+
+func (q *MemoryQueue) Consume() (<-chan Delivery, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.deliveries != nil {
+		return q.deliveries, nil
+	}
+	q.deliveries = make(chan Delivery)
+
+	go func() {
+		defer close(q.deliveries)
+		for {
+			select {
+			case message := <-q.messages:
+				delivery := Delivery{
+					ContentType: message.contentType,
+					Body:        message.body,
+					nack: func(requeue bool) error {
+						if requeue {
+							q.requeue(message)
+						}
+						return nil
+					},
+				}
+				select {
+				case q.deliveries <- delivery:
+				case <-q.done:
+					return
+				}
+			case <-q.done:
+				return
+			}
+		}
+	}()
+
+	return q.deliveries, nil
+}
+
+func (q *MemoryQueue) requeue(message memoryMessage) {
+	for attempt := 0; attempt < memoryRequeueAttempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(memoryRequeueDelay * time.Duration(attempt)):
+			case <-q.done:
+				return
+			}
+		}
+		select {
+		case q.messages <- message:
+			return
+		case <-q.done:
+			return
+		default:
+		}
+	}
+	deadLetter("queue", "fila em memória cheia ao reenfileirar", message.contentType, message.body)
+}
+
+// Depth devolve quantas mensagens aguardam consumo.
+func (q *MemoryQueue) Depth() (int, error) {
+	return len(q.messages), nil
+}
+
+func (q *MemoryQueue) Close() {
+	q.once.Do(func() { close(q.done) })
+}
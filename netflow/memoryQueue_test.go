@@ -0,0 +1,119 @@
+package netflow
+
+import (
+	"encoding/json"
+	"net_monitor/interfaces"
+	"testing"
+	"time"
+)
+
+type captureProcessor struct {
+	messages chan DecodedIPFIXMessage
+}
+
+func (p *captureProcessor) Name() string { return "capture" }
+
+func (p *captureProcessor) Process(_ interfaces.NetworkDevice, decoded *DecodedIPFIXMessage) error {
+	p.messages <- *decoded
+	return nil
+}
+
+// Listener -> decoder -> metric workers, só com filas em memória.
+func TestPipelineWithMemoryQueue(t *testing.T) {
+	rawQueue := NewMemoryQueue(16)
+	decodedQueue := NewMemoryQueue(16)
+	t.Cleanup(rawQueue.Close)
+	t.Cleanup(decodedQueue.Close)
+
+	previous := metricProcessors
+	t.Cleanup(func() { metricProcessors = previous })
+	capture := &captureProcessor{messages: make(chan DecodedIPFIXMessage, 4)}
+	metricProcessors = []MetricProcessor{capture}
+
+	if err := StartDecoderWorkers(rawQueue, decodedQueue, nil, 2); err != nil {
+		t.Fatalf("StartDecoderWorkers: %v", err)
+	}
+	if err := StartMetricWorkers(decodedQueue, 2); err != nil {
+		t.Fatalf("StartMetricWorkers: %v", err)
+	}
+
+	received := time.Unix(1767000000, 0)
+	packet := PacketMessage{Raw: buildIPFIXPacket(reducedSizeFields), SrcIP: "192.0.2.1", SrcPort: 2055, Received: received}
+	if err := rawQueue.Publish(ContentTypePacketBatch, EncodePacketBatch([]PacketMessage{packet})); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	select {
+	case decoded := <-capture.messages:
+		if decoded.SrcIP != "192.0.2.1" || !decoded.Received.Equal(received) {
+			t.Errorf("exportador %s em %v", decoded.SrcIP, decoded.Received)
+		}
+		if len(decoded.FlowRecords) != 1 {
+			t.Fatalf("esperado 1 registro, veio %d", len(decoded.FlowRecords))
+		}
+		if bits, ok := decoded.FlowRecords[0].RawFields["tcpControlBits"].(uint64); !ok || bits != 0x12 {
+			t.Errorf("tcpControlBits = %#v", decoded.FlowRecords[0].RawFields["tcpControlBits"])
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("o processador não recebeu o pacote")
+	}
+}
+
+func TestMemoryQueueRequeueWhenFull(t *testing.T) {
+	queue := NewMemoryQueue(1)
+	deadLetters := NewMemoryQueue(4)
+	t.Cleanup(queue.Close)
+	t.Cleanup(deadLetters.Close)
+	SetDeadLetterQueue(deadLetters)
+	t.Cleanup(func() { SetDeadLetterQueue(nil) })
+
+	deliveries, err := queue.Consume()
+	if err != nil {
+		t.Fatalf("Consume: %v", err)
+	}
+	queue.Publish(ContentTypeJSON, []byte("a"))
+	first := <-deliveries
+
+	// "b" fica com o consumidor e "c" ocupa o buffer.
+	queue.Publish(ContentTypeJSON, []byte("b"))
+	queue.Publish(ContentTypeJSON, []byte("c"))
+
+	done := make(chan struct{})
+	go func() {
+		first.Nack(true)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Nack bloqueou com a fila cheia")
+	}
+
+	letters, _ := deadLetters.Consume()
+	select {
+	case d := <-letters:
+		var letter DeadLetter
+		if err := json.Unmarshal(d.Body, &letter); err != nil {
+			t.Fatalf("dead letter inválida: %v", err)
+		}
+		if letter.Source != "queue" || string(letter.Body) != "a" {
+			t.Errorf("dead letter %s com corpo %q", letter.Source, letter.Body)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("mensagem não foi para a dead letter")
+	}
+
+	// Com espaço, o requeue devolve a mensagem ao fim da fila.
+	second := <-deliveries
+	second.Nack(true)
+	for _, want := range []string{"c", "b"} {
+		select {
+		case d := <-deliveries:
+			if string(d.Body) != want {
+				t.Errorf("recebido %q, esperado %q", d.Body, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("%q não foi entregue", want)
+		}
+	}
+}
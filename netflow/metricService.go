@@ -1,13 +1,14 @@
 package netflow
 
 import (
-	models "net_monitor/models"
+	"net_monitor/interfaces"
 
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type MetricProcessor interface {
-	Process(router *models.Roteador, decoded *DecodedIPFIXMessage) error
+	// device é o dispositivo associado ao exportador, ou nil se desconhecido.
+	Process(device interfaces.NetworkDevice, decoded *DecodedIPFIXMessage) error
 	Name() string
 }
 
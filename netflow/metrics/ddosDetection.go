@@ -0,0 +1,488 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net_monitor/interfaces"
+	"net_monitor/netflow"
+	utils "net_monitor/utils"
+	"net_monitor/websocket"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	DDoSVectorUDPFlood          = "udp_flood"
+	DDoSVectorSYNFlood          = "syn_flood"
+	DDoSVectorDNSAmplification  = "dns_amplification"
+	DDoSVectorNTPAmplification  = "ntp_amplification"
+	DDoSVectorSSDPAmplification = "ssdp_amplification"
+	DDoSVectorTrafficShift      = "traffic_shift"
+
+	DDoSStatusActive = "active"
+	DDoSStatusEnded  = "ended"
+
+	ddosMinWindow     = 10 * time.Second
+	ddosBaselineAlpha = 0.1
+	// Janelas necessárias antes de confiar no baseline.
+	ddosBaselineWarmup  = 30
+	ddosBaselineIdle    = time.Hour
+	ddosShiftSigmas     = 4
+	maxDDoSDestinations = 200000
+
+	tcpFlagSYN = 0x02
+	tcpFlagACK = 0x10
+)
+
+// Portas de origem dos refletores mais usados em ataques de amplificação.
+var amplificationVectors = map[uint16]string{
+	53:   DDoSVectorDNSAmplification,
+	123:  DDoSVectorNTPAmplification,
+	1900: DDoSVectorSSDPAmplification,
+}
+
+// DDoSEvent é um ataque detectado contra um destino visto por um roteador.
+// Os picos são atualizados enquanto o ataque está ativo.
+type DDoSEvent struct {
+	ID          primitive.ObjectID  `bson:"_id,omitempty" json:"id,omitempty"`
+	RouterID    primitive.ObjectID  `bson:"routerId,omitempty" json:"routerId,omitempty"`
+	RouterIP    string              `bson:"routerIp" json:"routerIp"`
+	RouterName  string              `bson:"routerName,omitempty" json:"routerName,omitempty"`
+	VictimIP    string              `bson:"victimIp" json:"victimIp"`
+	Vector      string              `bson:"vector" json:"vector"`
+	Status      string              `bson:"status" json:"status"`
+	Start       primitive.DateTime  `bson:"start" json:"start"`
+	End         *primitive.DateTime `bson:"end,omitempty" json:"end,omitempty"`
+	PeakPPS     float64             `bson:"peakPps" json:"peakPps"`
+	PeakBPS     float64             `bson:"peakBps" json:"peakBps"`
+	PeakFPS     float64             `bson:"peakFps" json:"peakFps"`
+	BaselinePPS float64             `bson:"baselinePps" json:"baselinePps"`
+	BaselineBPS float64             `bson:"baselineBps" json:"baselineBps"`
+	CreatedAt   primitive.DateTime  `bson:"createdAt" json:"createdAt"`
+	UpdatedAt   primitive.DateTime  `bson:"updatedAt" json:"updatedAt"`
+}
+
+type ddosDestinationKey struct {
+	routerIP string
+	dstIP    string
+}
+
+type ddosAttackKey struct {
+	ddosDestinationKey
+	vector string
+}
+
+type ddosCounters struct {
+	routerID   primitive.ObjectID
+	routerName string
+	deviceType string
+	vendor     string
+	bytes      uint64
+	packets    uint64
+	flows      uint64
+	udpPackets uint64
+	tcpPackets uint64
+	synPackets uint64
+	ampBytes   map[string]uint64
+}
+
+// ddosBaseline é a média móvel exponencial das taxas do destino. A variância
+// de bps serve para detectar mudanças bruscas que não batem com assinaturas.
+type ddosBaseline struct {
+	pps      float64
+	bps      float64
+	fps      float64
+	bpsVar   float64
+	samples  int
+	lastSeen time.Time
+}
+
+type ddosRates struct {
+	pps float64
+	bps float64
+	fps float64
+}
+
+type ddosAttack struct {
+	id           primitive.ObjectID
+	counters     *ddosCounters
+	start        time.Time
+	lastDetected time.Time
+	peak         ddosRates
+	baseline     ddosRates
+}
+
+// DDoSDetectionMetricProcessor acumula o tráfego por (roteador, destino) em
+// janelas e compara com o baseline e com as assinaturas de ataque. Cada
+// registro entra inteiro na janela em que chega, por isso a janela não pode
+// ser menor que o active timeout dos exportadores: um registro de 60s contado
+// numa janela de 10s multiplica a taxa por seis.
+type DDoSDetectionMetricProcessor struct {
+	collection     *mongo.Collection
+	hub            *websocket.Hub
+	window         time.Duration
+	cooldown       time.Duration
+	minPPS         float64
+	minBPS         float64
+	baselineFactor float64
+
+	mu      sync.Mutex
+	current map[ddosDestinationKey]*ddosCounters
+
+	// Só acessados pela goroutine de avaliação.
+	baselines map[ddosDestinationKey]*ddosBaseline
+	attacks   map[ddosAttackKey]*ddosAttack
+}
+
+// NewDDoSDetectionMetricProcessor lê DDOS_WINDOW_SECONDS (padrão 60, mínimo
+// 10), que deve acompanhar o maior active timeout configurado nos exportadores.
+// Um ataque termina depois de duas janelas, ou um minuto, sem detecção.
+func NewDDoSDetectionMetricProcessor(hub *websocket.Hub) *DDoSDetectionMetricProcessor {
+	window := time.Duration(utils.EnvPositiveInt("DDOS_WINDOW_SECONDS", 60)) * time.Second
+	if window < ddosMinWindow {
+		window = ddosMinWindow
+	}
+	cooldown := 2 * window
+	if cooldown < time.Minute {
+		cooldown = time.Minute
+	}
+
+	p := &DDoSDetectionMetricProcessor{
+		hub:            hub,
+		window:         window,
+		cooldown:       cooldown,
+		minPPS:         float64(utils.EnvPositiveInt("DDOS_MIN_PPS", 20000)),
+		minBPS:         float64(utils.EnvPositiveInt("DDOS_MIN_MBPS", 200)) * 1e6,
+		baselineFactor: float64(utils.EnvPositiveInt("DDOS_BASELINE_FACTOR", 5)),
+		current:        make(map[ddosDestinationKey]*ddosCounters),
+		baselines:      make(map[ddosDestinationKey]*ddosBaseline),
+		attacks:        make(map[ddosAttackKey]*ddosAttack),
+	}
+
+	ctx := netflow.GetMetricContext()
+	if ctx == nil || ctx.DB == nil {
+		log.Printf("[DDoSDetection] Aviso: MetricContext não inicializado")
+		return p
+	}
+	p.collection = ctx.DB.Collection("ddos_events")
+
+	go p.evaluateLoop()
+	return p
+}
+
+func (p *DDoSDetectionMetricProcessor) Name() string {
+	return "ddos_detection"
+}
+
+func (p *DDoSDetectionMetricProcessor) Process(device interfaces.NetworkDevice, decoded *netflow.DecodedIPFIXMessage) error {
+	if p.collection == nil || len(decoded.FlowRecords) == 0 {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, record := range decoded.FlowRecords {
+		_, dstIP := flowAddresses(record)
+		if dstIP == "" {
+			continue
+		}
+
+		key := ddosDestinationKey{routerIP: decoded.SrcIP, dstIP: dstIP}
+		counters, ok := p.current[key]
+		if !ok {
+			if len(p.current) >= maxDDoSDestinations {
+				continue
+			}
+			counters = &ddosCounters{
+				ampBytes: make(map[string]uint64),
+			}
+			if device != nil {
+				counters.routerID = deviceObjectID(device)
+				counters.routerName = device.GetName()
+				counters.deviceType = device.GetDeviceType()
+				counters.vendor = device.GetIntegration()
+			}
+			p.current[key] = counters
+		}
+
+		counters.bytes += record.OctetDeltaCount
+		counters.packets += record.PacketDeltaCount
+		counters.flows++
+
+		switch record.ProtocolIdentifier {
+		case 6:
+			counters.tcpPackets += record.PacketDeltaCount
+			flags := rawNumber(record.RawFields["tcpControlBits"])
+			if flags&tcpFlagSYN != 0 && flags&tcpFlagACK == 0 {
+				counters.synPackets += record.PacketDeltaCount
+			}
+		case 17:
+			counters.udpPackets += record.PacketDeltaCount
+			if vector, ok := amplificationVectors[record.SourceTransportPort]; ok {
+				counters.ampBytes[vector] += record.OctetDeltaCount
+			}
+		}
+	}
+
+	return nil
+}
+
+func (p *DDoSDetectionMetricProcessor) evaluateLoop() {
+	ticker := time.NewTicker(p.window)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		p.mu.Lock()
+		window := p.current
+		p.current = make(map[ddosDestinationKey]*ddosCounters)
+		p.mu.Unlock()
+
+		p.evaluate(window, time.Now())
+	}
+}
+
+func (p *DDoSDetectionMetricProcessor) evaluate(window map[ddosDestinationKey]*ddosCounters, now time.Time) {
+	seconds := p.window.Seconds()
+
+	for key, counters := range window {
+		rates := ddosRates{
+			pps: float64(counters.packets) / seconds,
+			bps: float64(counters.bytes) * 8 / seconds,
+			fps: float64(counters.flows) / seconds,
+		}
+		baseline := p.baselines[key]
+
+		vectors := p.detectVectors(counters, rates, baseline, seconds)
+		for _, vector := range vectors {
+			p.raise(ddosAttackKey{ddosDestinationKey: key, vector: vector}, counters, rates, baseline, now)
+		}
+
+		// O tráfego do ataque não entra no baseline.
+		if len(vectors) > 0 {
+			if baseline != nil {
+				baseline.lastSeen = now
+			}
+			continue
+		}
+		if baseline == nil {
+			// Destinos com pouco tráfego não ganham baseline, senão qualquer
+			// varredura enche a memória.
+			if rates.pps < p.minPPS/20 && rates.bps < p.minBPS/20 {
+				continue
+			}
+			if len(p.baselines) >= maxDDoSDestinations {
+				continue
+			}
+			baseline = &ddosBaseline{}
+			p.baselines[key] = baseline
+		}
+		baseline.update(rates)
+		baseline.lastSeen = now
+	}
+
+	// Destinos sem tráfego na janela contam como zero até ficarem ociosos.
+	for key, baseline := range p.baselines {
+		if _, seen := window[key]; seen {
+			continue
+		}
+		if now.Sub(baseline.lastSeen) > ddosBaselineIdle {
+			delete(p.baselines, key)
+			continue
+		}
+		baseline.update(ddosRates{})
+	}
+
+	for key, attack := range p.attacks {
+		if now.Sub(attack.lastDetected) > p.cooldown {
+			p.finish(key, attack, now)
+		}
+	}
+}
+
+func (p *DDoSDetectionMetricProcessor) detectVectors(counters *ddosCounters, rates ddosRates, baseline *ddosBaseline, seconds float64) []string {
+	var vectors []string
+
+	synPPS := float64(counters.synPackets) / seconds
+	if synPPS >= p.minPPS && counters.synPackets*10 >= counters.tcpPackets*7 && p.aboveBaseline(rates.pps, baseline, func(b *ddosBaseline) float64 { return b.pps }) {
+		vectors = append(vectors, DDoSVectorSYNFlood)
+	}
+
+	for _, vector := range []string{DDoSVectorDNSAmplification, DDoSVectorNTPAmplification, DDoSVectorSSDPAmplification} {
+		ampBPS := float64(counters.ampBytes[vector]) * 8 / seconds
+		if ampBPS >= p.minBPS && counters.ampBytes[vector]*2 >= counters.bytes && p.aboveBaseline(rates.bps, baseline, func(b *ddosBaseline) float64 { return b.bps }) {
+			vectors = append(vectors, vector)
+		}
+	}
+
+	// Amplificação já é um UDP flood; só reporta o vetor mais específico.
+	udpPPS := float64(counters.udpPackets) / seconds
+	if len(vectors) == 0 && udpPPS >= p.minPPS && counters.udpPackets*10 >= counters.packets*8 && p.aboveBaseline(rates.pps, baseline, func(b *ddosBaseline) float64 { return b.pps }) {
+		vectors = append(vectors, DDoSVectorUDPFlood)
+	}
+
+	// Mudança brusca sem assinatura conhecida: exige baseline aquecido.
+	if len(vectors) == 0 && baseline != nil && baseline.samples >= ddosBaselineWarmup && rates.bps >= p.minBPS {
+		threshold := math.Max(baseline.bps*p.baselineFactor, baseline.bps+ddosShiftSigmas*math.Sqrt(baseline.bpsVar))
+		if rates.bps > threshold {
+			vectors = append(vectors, DDoSVectorTrafficShift)
+		}
+	}
+
+	return vectors
+}
+
+// aboveBaseline só compara com o baseline depois do aquecimento; antes disso
+// valem apenas os limites absolutos.
+func (p *DDoSDetectionMetricProcessor) aboveBaseline(rate float64, baseline *ddosBaseline, value func(*ddosBaseline) float64) bool {
+	if baseline == nil || baseline.samples < ddosBaselineWarmup {
+		return true
+	}
+	return rate > value(baseline)*p.baselineFactor
+}
+
+func (b *ddosBaseline) update(rates ddosRates) {
+	if b.samples == 0 {
+		b.pps, b.bps, b.fps = rates.pps, rates.bps, rates.fps
+		b.samples = 1
+		return
+	}
+	diff := rates.bps - b.bps
+	b.pps += ddosBaselineAlpha * (rates.pps - b.pps)
+	b.bps += ddosBaselineAlpha * diff
+	b.fps += ddosBaselineAlpha * (rates.fps - b.fps)
+	b.bpsVar = (1 - ddosBaselineAlpha) * (b.bpsVar + ddosBaselineAlpha*diff*diff)
+	b.samples++
+}
+
+func (p *DDoSDetectionMetricProcessor) raise(key ddosAttackKey, counters *ddosCounters, rates ddosRates, baseline *ddosBaseline, now time.Time) {
+	attack, ok := p.attacks[key]
+	if ok {
+		attack.lastDetected = now
+		if rates.pps <= attack.peak.pps && rates.bps <= attack.peak.bps && rates.fps <= attack.peak.fps {
+			return
+		}
+		attack.peak.pps = math.Max(attack.peak.pps, rates.pps)
+		attack.peak.bps = math.Max(attack.peak.bps, rates.bps)
+		attack.peak.fps = math.Max(attack.peak.fps, rates.fps)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_, err := p.collection.UpdateByID(ctx, attack.id, bson.M{"$set": bson.M{
+			"peakPps":   attack.peak.pps,
+			"peakBps":   attack.peak.bps,
+			"peakFps":   attack.peak.fps,
+			"updatedAt": primitive.NewDateTimeFromTime(now),
+		}})
+		if err != nil {
+			log.Printf("[DDoSDetection] Erro ao atualizar pico do ataque contra %s: %v", key.dstIP, err)
+		}
+		return
+	}
+
+	attack = &ddosAttack{
+		id:           primitive.NewObjectID(),
+		counters:     counters,
+		start:        now,
+		lastDetected: now,
+		peak:         rates,
+	}
+	if baseline != nil {
+		attack.baseline = ddosRates{pps: baseline.pps, bps: baseline.bps, fps: baseline.fps}
+	}
+	p.attacks[key] = attack
+
+	nowDateTime := primitive.NewDateTimeFromTime(now)
+	event := DDoSEvent{
+		ID:          attack.id,
+		RouterID:    counters.routerID,
+		RouterIP:    key.routerIP,
+		RouterName:  counters.routerName,
+		VictimIP:    key.dstIP,
+		Vector:      key.vector,
+		Status:      DDoSStatusActive,
+		Start:       nowDateTime,
+		PeakPPS:     rates.pps,
+		PeakBPS:     rates.bps,
+		PeakFPS:     rates.fps,
+		BaselinePPS: attack.baseline.pps,
+		BaselineBPS: attack.baseline.bps,
+		CreatedAt:   nowDateTime,
+		UpdatedAt:   nowDateTime,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := p.collection.InsertOne(ctx, event); err != nil {
+		log.Printf("[DDoSDetection] Erro ao salvar ataque contra %s: %v", key.dstIP, err)
+	}
+
+	p.broadcast(key, attack, "ddos_attack_start",
+		fmt.Sprintf("Possível ataque %s contra %s via %s (%.0f pps, %.1f Mbps)", key.vector, key.dstIP, key.routerIP, rates.pps, rates.bps/1e6),
+		now)
+}
+
+func (p *DDoSDetectionMetricProcessor) finish(key ddosAttackKey, attack *ddosAttack, now time.Time) {
+	delete(p.attacks, key)
+
+	nowDateTime := primitive.NewDateTimeFromTime(now)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := p.collection.UpdateByID(ctx, attack.id, bson.M{"$set": bson.M{
+		"status":    DDoSStatusEnded,
+		"end":       primitive.NewDateTimeFromTime(attack.lastDetected),
+		"updatedAt": nowDateTime,
+	}})
+	if err != nil {
+		log.Printf("[DDoSDetection] Erro ao encerrar ataque contra %s: %v", key.dstIP, err)
+	}
+
+	p.broadcast(key, attack, "ddos_attack_end",
+		fmt.Sprintf("Ataque %s contra %s encerrado (pico %.0f pps, %.1f Mbps)", key.vector, key.dstIP, attack.peak.pps, attack.peak.bps/1e6),
+		now)
+}
+
+func (p *DDoSDetectionMetricProcessor) broadcast(key ddosAttackKey, attack *ddosAttack, eventType, message string, now time.Time) {
+	log.Printf("[DDOS EVENT] %s", message)
+	if p.hub == nil {
+		return
+	}
+
+	event := interfaces.TrapEvent{
+		DeviceName: attack.counters.routerName,
+		DeviceIP:   key.routerIP,
+		DeviceType: attack.counters.deviceType,
+		Vendor:     attack.counters.vendor,
+		EventType:  eventType,
+		Message:    message,
+		Data: map[string]interface{}{
+			"event_id":     attack.id.Hex(),
+			"victim_ip":    key.dstIP,
+			"vector":       key.vector,
+			"peak_pps":     attack.peak.pps,
+			"peak_bps":     attack.peak.bps,
+			"peak_fps":     attack.peak.fps,
+			"baseline_pps": attack.baseline.pps,
+			"baseline_bps": attack.baseline.bps,
+			"start":        attack.start,
+		},
+		Timestamp: now,
+	}
+	if !attack.counters.routerID.IsZero() {
+		event.DeviceID = attack.counters.routerID.Hex()
+	}
+
+	jsonData, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("[DDoSDetection] Erro ao serializar evento: %v", err)
+		return
+	}
+	p.hub.Broadcast(jsonData)
+}
@@ -0,0 +1,20 @@
+package metrics
+
+import (
+	"net_monitor/interfaces"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// deviceObjectID converte o ID do dispositivo do exportador para gravar junto
+// das métricas. Dispositivos desconhecidos ficam com o ID zero.
+func deviceObjectID(device interfaces.NetworkDevice) primitive.ObjectID {
+	if device == nil {
+		return primitive.NilObjectID
+	}
+	id, err := primitive.ObjectIDFromHex(device.GetID())
+	if err != nil {
+		return primitive.NilObjectID
+	}
+	return id
+}
@@ -3,7 +3,7 @@ package metrics
 import (
 	"context"
 	"log"
-	models "net_monitor/models"
+	"net_monitor/interfaces"
 	"net_monitor/netflow"
 	"time"
 
@@ -73,12 +73,22 @@ type DNSStats struct {
 }
 
 type DNSFlow struct {
-	ServerIP  string
-	ClientIP  string
-	Timestamp uint64
-	IsQuery   bool
+	ServerIP   string
+	ClientIP   string
+	ClientPort uint16
+	Timestamp  uint64
+	IsQuery    bool
 }
 
+// Respostas mais lentas que isso são tratadas como pares errados, não latência.
+const maxDNSResponseTime = 5000
+
+// Versão dos contadores gravada em dns_quality_metrics e dns_servers. Na
+// versão 1 (sem o campo) timeoutCount guardava só o último lote do bucket e
+// totalQueries do servidor contava lotes; a partir da 2 ambos acumulam
+// consultas. migrateCounters converte os documentos antigos na inicialização.
+const dnsCountersVersion = 2
+
 func NewDNSQualityMetricProcessor() *DNSQualityMetricProcessor {
 	ctx := netflow.GetMetricContext()
 	if ctx == nil || ctx.DB == nil {
@@ -92,6 +102,7 @@ func NewDNSQualityMetricProcessor() *DNSQualityMetricProcessor {
 	}
 
 	processor.createIndexes()
+	processor.migrateCounters()
 
 	return processor
 }
@@ -118,14 +129,85 @@ func (p *DNSQualityMetricProcessor) createIndexes() {
 			},
 		}
 		p.metricsCollection.Indexes().CreateOne(ctx, indexModel)
+
+		p.metricsCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+			{Keys: bson.D{{Key: "timestamp", Value: -1}}},
+			{Keys: bson.D{{Key: "routerId", Value: 1}, {Key: "timestamp", Value: -1}}},
+		})
 	}
 }
 
+// migrateCounters recalcula timeoutCount dos buckets antigos a partir de
+// consultas e respostas, que sempre foram acumuladas, e refaz o totalQueries
+// dos servidores somando os buckets ainda retidos. É idempotente: só toca em
+// documentos sem countersVersion.
+func (p *DNSQualityMetricProcessor) migrateCounters() {
+	if p.metricsCollection == nil || p.serversCollection == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	legacy := bson.M{"countersVersion": bson.M{"$exists": false}}
+	result, err := p.metricsCollection.UpdateMany(ctx, legacy, mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"timeoutCount": bson.M{"$max": bson.A{0, bson.M{"$subtract": bson.A{"$totalQueries", "$totalResponses"}}}},
+		}}},
+		{{Key: "$set", Value: bson.M{
+			"timeoutPercentage": bson.M{"$cond": bson.A{
+				bson.M{"$gt": bson.A{"$totalQueries", 0}},
+				bson.M{"$multiply": bson.A{bson.M{"$divide": bson.A{"$timeoutCount", "$totalQueries"}}, 100}},
+				0,
+			}},
+			"countersVersion": dnsCountersVersion,
+		}}},
+	})
+	if err != nil {
+		log.Printf("[DNSQualityMetric] Erro migrando contadores de timeout: %v", err)
+		return
+	}
+	if result.ModifiedCount > 0 {
+		log.Printf("[DNSQualityMetric] timeoutCount recalculado em %d buckets", result.ModifiedCount)
+	}
+
+	pending, err := p.serversCollection.CountDocuments(ctx, legacy, options.Count().SetLimit(1))
+	if err != nil || pending == 0 {
+		return
+	}
+	cursor, err := p.metricsCollection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{"_id": "$dnsServerIp", "queries": bson.M{"$sum": "$totalQueries"}}}},
+	})
+	if err != nil {
+		log.Printf("[DNSQualityMetric] Erro somando consultas por servidor: %v", err)
+		return
+	}
+	var totals []struct {
+		IPAddress string `bson:"_id"`
+		Queries   int64  `bson:"queries"`
+	}
+	if err := cursor.All(ctx, &totals); err != nil {
+		log.Printf("[DNSQualityMetric] Erro somando consultas por servidor: %v", err)
+		return
+	}
+	for _, total := range totals {
+		filter := bson.M{"ipAddress": total.IPAddress, "countersVersion": bson.M{"$exists": false}}
+		update := bson.M{"$set": bson.M{"totalQueries": total.Queries, "countersVersion": dnsCountersVersion}}
+		if _, err := p.serversCollection.UpdateOne(ctx, filter, update); err != nil {
+			log.Printf("[DNSQualityMetric] Erro migrando servidor DNS %s: %v", total.IPAddress, err)
+			return
+		}
+	}
+
+	// Servidores sem buckets retidos não têm como ser recontados.
+	p.serversCollection.UpdateMany(ctx, legacy, bson.M{"$set": bson.M{"totalQueries": 0, "countersVersion": dnsCountersVersion}})
+}
+
 func (p *DNSQualityMetricProcessor) Name() string {
 	return "dns_quality_analyzer"
 }
 
-func (p *DNSQualityMetricProcessor) Process(router *models.Roteador, decoded *netflow.DecodedIPFIXMessage) error {
+func (p *DNSQualityMetricProcessor) Process(device interfaces.NetworkDevice, decoded *netflow.DecodedIPFIXMessage) error {
 	if len(decoded.FlowRecords) == 0 {
 		return nil
 	}
@@ -142,6 +224,7 @@ func (p *DNSQualityMetricProcessor) Process(router *models.Roteador, decoded *ne
 		}
 
 		var dnsServerIP, clientIP string
+		var clientPort uint16
 		var timestamp uint64
 
 		if isDNSQuery {
@@ -153,6 +236,7 @@ func (p *DNSQualityMetricProcessor) Process(router *models.Roteador, decoded *ne
 			if clientIP == "" {
 				clientIP = record.SourceIPv6Address
 			}
+			clientPort = record.SourceTransportPort
 
 			if postNATSrc, ok := record.RawFields["postNATSourceIPv4Address"]; ok {
 				if srcIP, ok := postNATSrc.(string); ok && srcIP != "" {
@@ -173,6 +257,7 @@ func (p *DNSQualityMetricProcessor) Process(router *models.Roteador, decoded *ne
 			if clientIP == "" {
 				clientIP = record.DestinationIPv6Address
 			}
+			clientPort = record.DestinationTransportPort
 
 			if postNATDest, ok := record.RawFields["postNATDestinationIPv4Address"]; ok {
 				if destIP, ok := postNATDest.(string); ok && destIP != "" {
@@ -224,10 +309,11 @@ func (p *DNSQualityMetricProcessor) Process(router *models.Roteador, decoded *ne
 
 		if timestamp > 0 && clientIP != "" {
 			dnsFlows = append(dnsFlows, DNSFlow{
-				ServerIP:  dnsServerIP,
-				ClientIP:  clientIP,
-				Timestamp: timestamp,
-				IsQuery:   isDNSQuery,
+				ServerIP:   dnsServerIP,
+				ClientIP:   clientIP,
+				ClientPort: clientPort,
+				Timestamp:  timestamp,
+				IsQuery:    isDNSQuery,
 			})
 		}
 	}
@@ -238,24 +324,88 @@ func (p *DNSQualityMetricProcessor) Process(router *models.Roteador, decoded *ne
 		return nil
 	}
 
-	// for _, stats := range dnsServerStats {
-	// 	log.Printf("[DNSQualityMetric DEBUG] Stats para %s - ResponseTimeCount: %d, TotalResponseTime: %d, Under50ms: %d",
-	// 		stats.ServerIP, stats.ResponseTimeCount, stats.TotalResponseTime, stats.Under50ms)
+	if p.metricsCollection == nil {
+		return nil
+	}
 
-	// 	dnsServerID, err := p.ensureDNSServer(stats.ServerIP)
-	// 	if err != nil {
-	// 		log.Printf("[DNSQualityMetric] Erro ao registrar servidor DNS %s: %v", stats.ServerIP, err)
-	// 		continue
-	// 	}
+	p.measureResponseTimes(dnsFlows, dnsServerStats)
 
-	// 	if err := p.saveMetrics(router, decoded.SrcIP, dnsServerID, stats); err != nil {
-	// 		log.Printf("[DNSQualityMetric] Erro ao salvar métricas para DNS %s: %v", stats.ServerIP, err)
-	// 	}
-	// }
+	for _, stats := range dnsServerStats {
+		dnsServerID, err := p.ensureDNSServer(stats.ServerIP, stats.QueryCount)
+		if err != nil {
+			log.Printf("[DNSQualityMetric] Erro ao registrar servidor DNS %s: %v", stats.ServerIP, err)
+			continue
+		}
+
+		if err := p.saveMetrics(device, decoded.SrcIP, dnsServerID, stats); err != nil {
+			log.Printf("[DNSQualityMetric] Erro ao salvar métricas para DNS %s: %v", stats.ServerIP, err)
+		}
+	}
 
 	return nil
 }
 
+// measureResponseTimes casa cada resposta com a consulta mais recente do mesmo
+// cliente e porta ao mesmo servidor dentro do lote, e distribui a diferença
+// entre as faixas de latência.
+func (p *DNSQualityMetricProcessor) measureResponseTimes(dnsFlows []DNSFlow, dnsServerStats map[string]*DNSStats) {
+	type queryKey struct {
+		serverIP   string
+		clientIP   string
+		clientPort uint16
+	}
+
+	queries := make(map[queryKey][]uint64)
+	for _, flow := range dnsFlows {
+		if flow.IsQuery {
+			key := queryKey{flow.ServerIP, flow.ClientIP, flow.ClientPort}
+			queries[key] = append(queries[key], flow.Timestamp)
+		}
+	}
+
+	for _, flow := range dnsFlows {
+		if flow.IsQuery {
+			continue
+		}
+		key := queryKey{flow.ServerIP, flow.ClientIP, flow.ClientPort}
+		sent := queries[key]
+		best := -1
+		for i, queryTime := range sent {
+			if queryTime <= flow.Timestamp && (best < 0 || queryTime > sent[best]) {
+				best = i
+			}
+		}
+		if best < 0 {
+			continue
+		}
+		responseTime := flow.Timestamp - sent[best]
+		queries[key] = append(sent[:best], sent[best+1:]...)
+		if responseTime > maxDNSResponseTime {
+			continue
+		}
+
+		stats := dnsServerStats[flow.ServerIP]
+		stats.TotalResponseTime += responseTime
+		stats.ResponseTimeCount++
+		if responseTime < stats.MinResponse {
+			stats.MinResponse = responseTime
+		}
+		if responseTime > stats.MaxResponse {
+			stats.MaxResponse = responseTime
+		}
+		switch {
+		case responseTime < 50:
+			stats.Under50ms++
+		case responseTime < 100:
+			stats.Between50And100ms++
+		case responseTime < 500:
+			stats.Between100And500ms++
+		default:
+			stats.Over500ms++
+		}
+	}
+}
+
 func (p *DNSQualityMetricProcessor) calculateAbsoluteTime(rawFields map[string]interface{}, sysUpTimeField, systemInitField string) uint64 {
 	systemInitTime := uint64(0)
 	if val, ok := rawFields[systemInitField]; ok {
@@ -287,7 +437,7 @@ func (p *DNSQualityMetricProcessor) calculateAbsoluteTime(rawFields map[string]i
 	return 0
 }
 
-func (p *DNSQualityMetricProcessor) ensureDNSServer(ipAddress string) (primitive.ObjectID, error) {
+func (p *DNSQualityMetricProcessor) ensureDNSServer(ipAddress string, queries uint64) (primitive.ObjectID, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -300,12 +450,13 @@ func (p *DNSQualityMetricProcessor) ensureDNSServer(ipAddress string) (primitive
 			"updatedAt": now,
 		},
 		"$setOnInsert": bson.M{
-			"ipAddress": ipAddress,
-			"firstSeen": now,
-			"createdAt": now,
+			"ipAddress":       ipAddress,
+			"firstSeen":       now,
+			"createdAt":       now,
+			"countersVersion": dnsCountersVersion,
 		},
 		"$inc": bson.M{
-			"totalQueries": 1,
+			"totalQueries": queries,
 		},
 	}
 
@@ -322,7 +473,7 @@ func (p *DNSQualityMetricProcessor) ensureDNSServer(ipAddress string) (primitive
 	return server.ID, nil
 }
 
-func (p *DNSQualityMetricProcessor) saveMetrics(router *models.Roteador, routerIP string,
+func (p *DNSQualityMetricProcessor) saveMetrics(device interfaces.NetworkDevice, routerIP string,
 	dnsServerID primitive.ObjectID, stats *DNSStats) error {
 
 	now := time.Now()
@@ -355,17 +506,18 @@ func (p *DNSQualityMetricProcessor) saveMetrics(router *models.Roteador, routerI
 			"responses50to100ms":  stats.Between50And100ms,
 			"responses100to500ms": stats.Between100And500ms,
 			"responsesOver500ms":  stats.Over500ms,
+			"timeoutCount":        timeout,
 		},
 		"$set": bson.M{
-			"updatedAt":    nowDateTime,
-			"timeoutCount": timeout,
+			"updatedAt": nowDateTime,
 		},
 		"$setOnInsert": bson.M{
-			"routerIp":    routerIP,
-			"dnsServerIp": stats.ServerIP,
-			"dnsServerId": dnsServerID,
-			"timestamp":   primitive.NewDateTimeFromTime(timestampMinute),
-			"createdAt":   nowDateTime,
+			"routerIp":        routerIP,
+			"dnsServerIp":     stats.ServerIP,
+			"dnsServerId":     dnsServerID,
+			"timestamp":       primitive.NewDateTimeFromTime(timestampMinute),
+			"createdAt":       nowDateTime,
+			"countersVersion": dnsCountersVersion,
 		},
 	}
 
@@ -376,8 +528,8 @@ func (p *DNSQualityMetricProcessor) saveMetrics(router *models.Roteador, routerI
 		update["$max"] = bson.M{"maxResponseTime": stats.MaxResponse}
 	}
 
-	if router != nil {
-		update["$setOnInsert"].(bson.M)["routerId"] = router.ID
+	if device != nil {
+		update["$setOnInsert"].(bson.M)["routerId"] = deviceObjectID(device)
 	}
 
 	opts := options.Update().SetUpsert(true)
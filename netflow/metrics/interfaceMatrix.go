@@ -0,0 +1,353 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net_monitor/interfaces"
+	"net_monitor/netflow"
+	"net_monitor/snmp"
+	utils "net_monitor/utils"
+	"net_monitor/websocket"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const interfaceMatrixBucket = time.Minute
+
+// InterfaceResolver dá nome aos ifIndex de um dispositivo.
+type InterfaceResolver interface {
+	Lookup(device interfaces.NetworkDevice, ifIndex uint32) (snmp.InterfaceInfo, bool)
+}
+
+// InterfaceMatrixMetric é o tráfego de um roteador em 1 minuto, por interface
+// e por par entrada/saída. O ifIndex 0 é o que o exportador usa para tráfego
+// local ou descartado. Nomes são os do SNMP no momento da gravação.
+type InterfaceMatrixMetric struct {
+	ID             primitive.ObjectID     `bson:"_id,omitempty" json:"id,omitempty"`
+	RouterID       primitive.ObjectID     `bson:"routerId,omitempty" json:"routerId,omitempty"`
+	RouterIP       string                 `bson:"routerIp" json:"routerIp"`
+	Timestamp      primitive.DateTime     `bson:"timestamp" json:"timestamp"`
+	Interfaces     []InterfaceFlowTraffic `bson:"interfaces" json:"interfaces"`
+	Pairs          []InterfacePairTraffic `bson:"pairs" json:"pairs"`
+	TruncatedBytes uint64                 `bson:"truncatedBytes,omitempty" json:"truncatedBytes,omitempty"`
+	CreatedAt      primitive.DateTime     `bson:"createdAt" json:"createdAt"`
+}
+
+type InterfaceFlowTraffic struct {
+	IfIndex     uint32 `bson:"ifIndex" json:"ifIndex"`
+	Name        string `bson:"name,omitempty" json:"name,omitempty"`
+	Description string `bson:"description,omitempty" json:"description,omitempty"`
+	InBytes     uint64 `bson:"inBytes" json:"inBytes"`
+	OutBytes    uint64 `bson:"outBytes" json:"outBytes"`
+	InPackets   uint64 `bson:"inPackets" json:"inPackets"`
+	OutPackets  uint64 `bson:"outPackets" json:"outPackets"`
+}
+
+type InterfacePairTraffic struct {
+	Ingress     uint32 `bson:"ingress" json:"ingress"`
+	Egress      uint32 `bson:"egress" json:"egress"`
+	IngressName string `bson:"ingressName,omitempty" json:"ingressName,omitempty"`
+	EgressName  string `bson:"egressName,omitempty" json:"egressName,omitempty"`
+	Bytes       uint64 `bson:"bytes" json:"bytes"`
+	Packets     uint64 `bson:"packets" json:"packets"`
+	Flows       uint64 `bson:"flows" json:"flows"`
+}
+
+// InterfaceRate é a taxa de uma interface derivada dos flows de um bucket.
+type InterfaceRate struct {
+	IfIndex     uint32  `json:"ifIndex"`
+	Name        string  `json:"name,omitempty"`
+	Description string  `json:"description,omitempty"`
+	InBps       float64 `json:"inBps"`
+	OutBps      float64 `json:"outBps"`
+	InPps       float64 `json:"inPps"`
+	OutPps      float64 `json:"outPps"`
+}
+
+// interfaceTrafficMessage segue o formato das mensagens de métrica SNMP do
+// websocket, para que o cliente trate as taxas por flow como mais uma métrica.
+type interfaceTrafficMessage struct {
+	DeviceID   string          `json:"device_id"`
+	DeviceName string          `json:"device_name"`
+	DeviceType string          `json:"device_type"`
+	Vendor     string          `json:"vendor"`
+	Metric     string          `json:"metric"`
+	Value      []InterfaceRate `json:"value"`
+	Timestamp  time.Time       `json:"timestamp"`
+}
+
+type interfacePair struct {
+	ingress uint32
+	egress  uint32
+}
+
+type interfaceCounters struct {
+	inBytes, outBytes     uint64
+	inPackets, outPackets uint64
+}
+
+type pairCounters struct {
+	bytes, packets, flows uint64
+}
+
+type interfaceMatrixBucketState struct {
+	device         interfaces.NetworkDevice
+	routerIP       string
+	start          time.Time
+	interfaces     map[uint32]*interfaceCounters
+	pairs          map[interfacePair]*pairCounters
+	truncatedBytes uint64
+}
+
+type InterfaceMatrixMetricProcessor struct {
+	collection *mongo.Collection
+	hub        *websocket.Hub
+	resolver   InterfaceResolver
+	maxPairs   int
+	mu         sync.Mutex
+	buckets    map[bucketKey]*interfaceMatrixBucketState
+}
+
+// NewInterfaceMatrixMetricProcessor lê INTERFACE_MATRIX_MAX_PAIRS (padrão
+// 10000), o limite de pares por roteador e minuto. O tráfego de pares acima
+// do limite ainda conta por interface.
+func NewInterfaceMatrixMetricProcessor(hub *websocket.Hub, resolver InterfaceResolver) *InterfaceMatrixMetricProcessor {
+	p := &InterfaceMatrixMetricProcessor{
+		hub:      hub,
+		resolver: resolver,
+		maxPairs: utils.EnvPositiveInt("INTERFACE_MATRIX_MAX_PAIRS", 10000),
+		buckets:  make(map[bucketKey]*interfaceMatrixBucketState),
+	}
+
+	ctx := netflow.GetMetricContext()
+	if ctx == nil || ctx.DB == nil {
+		log.Printf("[InterfaceMatrix] Aviso: MetricContext não inicializado")
+		return p
+	}
+	p.collection = ctx.DB.Collection("interface_matrix")
+
+	go p.flushLoop()
+	return p
+}
+
+func (p *InterfaceMatrixMetricProcessor) Name() string {
+	return "interface_matrix"
+}
+
+func (p *InterfaceMatrixMetricProcessor) Process(device interfaces.NetworkDevice, decoded *netflow.DecodedIPFIXMessage) error {
+	if len(decoded.FlowRecords) == 0 {
+		return nil
+	}
+
+	received := decoded.Received
+	if received.IsZero() {
+		received = time.Now()
+	}
+	key := bucketKey{routerIP: decoded.SrcIP, start: received.Truncate(interfaceMatrixBucket)}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	bucket, ok := p.buckets[key]
+	if !ok {
+		bucket = &interfaceMatrixBucketState{
+			routerIP:   decoded.SrcIP,
+			start:      key.start,
+			interfaces: make(map[uint32]*interfaceCounters),
+			pairs:      make(map[interfacePair]*pairCounters),
+		}
+		p.buckets[key] = bucket
+	}
+	if device != nil {
+		bucket.device = device
+	}
+
+	for _, record := range decoded.FlowRecords {
+		if record.IngressInterface == 0 && record.EgressInterface == 0 {
+			continue
+		}
+		bytes, packets := record.OctetDeltaCount, record.PacketDeltaCount
+
+		if record.IngressInterface != 0 {
+			counters := bucket.counters(record.IngressInterface)
+			counters.inBytes += bytes
+			counters.inPackets += packets
+		}
+		if record.EgressInterface != 0 {
+			counters := bucket.counters(record.EgressInterface)
+			counters.outBytes += bytes
+			counters.outPackets += packets
+		}
+
+		pair := interfacePair{ingress: record.IngressInterface, egress: record.EgressInterface}
+		counters, ok := bucket.pairs[pair]
+		if !ok {
+			if len(bucket.pairs) >= p.maxPairs {
+				bucket.truncatedBytes += bytes
+				continue
+			}
+			counters = &pairCounters{}
+			bucket.pairs[pair] = counters
+		}
+		counters.bytes += bytes
+		counters.packets += packets
+		counters.flows++
+	}
+
+	return nil
+}
+
+func (b *interfaceMatrixBucketState) counters(ifIndex uint32) *interfaceCounters {
+	counters, ok := b.interfaces[ifIndex]
+	if !ok {
+		counters = &interfaceCounters{}
+		b.interfaces[ifIndex] = counters
+	}
+	return counters
+}
+
+// flushLoop grava e transmite os buckets de minutos já encerrados. Como os
+// exportadores mandam flows longos a cada active timeout, taxas em janelas
+// menores que um minuto oscilariam demais.
+func (p *InterfaceMatrixMetricProcessor) flushLoop() {
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		current := time.Now().Truncate(interfaceMatrixBucket)
+
+		p.mu.Lock()
+		var ready []*interfaceMatrixBucketState
+		for key, bucket := range p.buckets {
+			if key.start.Before(current) {
+				ready = append(ready, bucket)
+				delete(p.buckets, key)
+			}
+		}
+		p.mu.Unlock()
+
+		for _, bucket := range ready {
+			metric := p.build(bucket)
+			if err := p.save(metric); err != nil {
+				log.Printf("[InterfaceMatrix] Erro ao salvar bucket de %s: %v", bucket.routerIP, err)
+			}
+			p.broadcast(bucket, metric)
+		}
+	}
+}
+
+func (p *InterfaceMatrixMetricProcessor) build(bucket *interfaceMatrixBucketState) InterfaceMatrixMetric {
+	metric := InterfaceMatrixMetric{
+		RouterID:       deviceObjectID(bucket.device),
+		RouterIP:       bucket.routerIP,
+		Timestamp:      primitive.NewDateTimeFromTime(bucket.start),
+		Interfaces:     make([]InterfaceFlowTraffic, 0, len(bucket.interfaces)),
+		Pairs:          make([]InterfacePairTraffic, 0, len(bucket.pairs)),
+		TruncatedBytes: bucket.truncatedBytes,
+		CreatedAt:      primitive.NewDateTimeFromTime(time.Now()),
+	}
+
+	for ifIndex, counters := range bucket.interfaces {
+		info := p.lookup(bucket.device, ifIndex)
+		metric.Interfaces = append(metric.Interfaces, InterfaceFlowTraffic{
+			IfIndex:     ifIndex,
+			Name:        info.Name,
+			Description: info.Description,
+			InBytes:     counters.inBytes,
+			OutBytes:    counters.outBytes,
+			InPackets:   counters.inPackets,
+			OutPackets:  counters.outPackets,
+		})
+	}
+	sort.Slice(metric.Interfaces, func(i, j int) bool {
+		return metric.Interfaces[i].IfIndex < metric.Interfaces[j].IfIndex
+	})
+
+	for pair, counters := range bucket.pairs {
+		metric.Pairs = append(metric.Pairs, InterfacePairTraffic{
+			Ingress:     pair.ingress,
+			Egress:      pair.egress,
+			IngressName: p.lookup(bucket.device, pair.ingress).Name,
+			EgressName:  p.lookup(bucket.device, pair.egress).Name,
+			Bytes:       counters.bytes,
+			Packets:     counters.packets,
+			Flows:       counters.flows,
+		})
+	}
+	sort.Slice(metric.Pairs, func(i, j int) bool {
+		return metric.Pairs[i].Bytes > metric.Pairs[j].Bytes
+	})
+
+	return metric
+}
+
+func (p *InterfaceMatrixMetricProcessor) lookup(device interfaces.NetworkDevice, ifIndex uint32) snmp.InterfaceInfo {
+	if p.resolver == nil || device == nil || ifIndex == 0 {
+		return snmp.InterfaceInfo{IfIndex: ifIndex}
+	}
+	info, _ := p.resolver.Lookup(device, ifIndex)
+	return info
+}
+
+func (p *InterfaceMatrixMetricProcessor) save(metric InterfaceMatrixMetric) error {
+	if p.collection == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := p.collection.InsertOne(ctx, metric)
+	return err
+}
+
+// broadcast só transmite dispositivos cadastrados, já que o cliente do
+// websocket filtra as mensagens pelo ID do dispositivo.
+func (p *InterfaceMatrixMetricProcessor) broadcast(bucket *interfaceMatrixBucketState, metric InterfaceMatrixMetric) {
+	if p.hub == nil || bucket.device == nil {
+		return
+	}
+
+	message := interfaceTrafficMessage{
+		DeviceID:   bucket.device.GetID(),
+		DeviceName: bucket.device.GetName(),
+		DeviceType: bucket.device.GetDeviceType(),
+		Vendor:     bucket.device.GetIntegration(),
+		Metric:     "interface_traffic",
+		Value:      InterfaceRates(metric.Interfaces, interfaceMatrixBucket),
+		Timestamp:  bucket.start.Add(interfaceMatrixBucket),
+	}
+
+	jsonData, err := json.Marshal(message)
+	if err != nil {
+		log.Printf("[InterfaceMatrix] Erro ao serializar taxas de %s: %v", bucket.routerIP, err)
+		return
+	}
+	p.hub.Broadcast(jsonData)
+}
+
+// InterfaceRates converte o volume acumulado em um período para bps e pps.
+func InterfaceRates(traffic []InterfaceFlowTraffic, period time.Duration) []InterfaceRate {
+	seconds := period.Seconds()
+	rates := make([]InterfaceRate, 0, len(traffic))
+	if seconds <= 0 {
+		return rates
+	}
+	for _, entry := range traffic {
+		rates = append(rates, InterfaceRate{
+			IfIndex:     entry.IfIndex,
+			Name:        entry.Name,
+			Description: entry.Description,
+			InBps:       float64(entry.InBytes) * 8 / seconds,
+			OutBps:      float64(entry.OutBytes) * 8 / seconds,
+			InPps:       float64(entry.InPackets) / seconds,
+			OutPps:      float64(entry.OutPackets) / seconds,
+		})
+	}
+	return rates
+}
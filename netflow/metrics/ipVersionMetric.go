@@ -3,7 +3,7 @@ package metrics
 import (
 	"context"
 	"log"
-	models "net_monitor/models"
+	"net_monitor/interfaces"
 	"net_monitor/netflow"
 	"time"
 
@@ -50,7 +50,7 @@ func (p *IPVersionMetricProcessor) Name() string {
 	return "ip_version_analyzer"
 }
 
-func (p *IPVersionMetricProcessor) Process(router *models.Roteador, decoded *netflow.DecodedIPFIXMessage) error {
+func (p *IPVersionMetricProcessor) Process(device interfaces.NetworkDevice, decoded *netflow.DecodedIPFIXMessage) error {
 	if len(decoded.FlowRecords) == 0 {
 		return nil
 	}
@@ -109,8 +109,8 @@ func (p *IPVersionMetricProcessor) Process(router *models.Roteador, decoded *net
 		},
 	}
 
-	if router != nil {
-		update["$setOnInsert"].(bson.M)["routerId"] = router.ID
+	if device != nil {
+		update["$setOnInsert"].(bson.M)["routerId"] = deviceObjectID(device)
 	}
 
 	opts := options.Update().SetUpsert(true)
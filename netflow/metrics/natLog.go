@@ -0,0 +1,324 @@
+package metrics
+
+import (
+	"context"
+	"log"
+	"net_monitor/interfaces"
+	models "net_monitor/models"
+	"net_monitor/netflow"
+	utils "net_monitor/utils"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Valores de natEvent (IANA, RFC 8158) usados aqui.
+const (
+	natEventSessionCreate    = 1
+	natEventSessionDelete    = 2
+	natEventBindingCreate    = 12
+	natEventBindingDelete    = 13
+	natEventPortBlockAlloc   = 14
+	natEventPortBlockDealloc = 15
+
+	maxNATFlowSessions = 500000
+)
+
+type natSessionKey struct {
+	routerIP    string
+	insideIP    string
+	insidePort  uint16
+	outsideIP   string
+	outsidePort uint16
+	protocol    uint8
+}
+
+type natSession struct {
+	start    time.Time
+	end      time.Time
+	lastSeen time.Time
+}
+
+// NATLogMetricProcessor grava o log de NAT44 a partir de eventos natEvent e,
+// para exportadores que só mandam os campos pós-NAT nos flows, de sessões
+// agregadas em memória até ficarem ociosas.
+type NATLogMetricProcessor struct {
+	collection  *mongo.Collection
+	retention   time.Duration
+	idleTimeout time.Duration
+	mu          sync.Mutex
+	sessions    map[natSessionKey]*natSession
+}
+
+func NewNATLogMetricProcessor() *NATLogMetricProcessor {
+	p := &NATLogMetricProcessor{
+		retention:   time.Duration(utils.EnvPositiveInt("NAT_LOG_RETENTION_DAYS", 365)) * 24 * time.Hour,
+		idleTimeout: time.Duration(utils.EnvPositiveInt("NAT_LOG_FLOW_IDLE_SECONDS", 120)) * time.Second,
+		sessions:    make(map[natSessionKey]*natSession),
+	}
+
+	ctx := netflow.GetMetricContext()
+	if ctx == nil || ctx.DB == nil {
+		log.Printf("[NATLog] Aviso: MetricContext não inicializado")
+		return p
+	}
+	p.collection = ctx.DB.Collection("nat_logs")
+
+	go p.flushLoop()
+	return p
+}
+
+func (p *NATLogMetricProcessor) Name() string {
+	return "nat_log"
+}
+
+// Process não devolve erro de gravação: o worker repetiria o lote inteiro e
+// fecharia registros e gravaria criações de novo. Falhas parciais só são
+// registradas no log.
+func (p *NATLogMetricProcessor) Process(device interfaces.NetworkDevice, decoded *netflow.DecodedIPFIXMessage) error {
+	if p.collection == nil {
+		return nil
+	}
+
+	received := decoded.Received
+	if received.IsZero() {
+		received = time.Now()
+	}
+
+	// Alguns CGNATs mandam os eventos em options templates.
+	records := make([]netflow.FlowRecord, 0, len(decoded.FlowRecords)+len(decoded.OptionRecords))
+	records = append(records, decoded.FlowRecords...)
+	records = append(records, decoded.OptionRecords...)
+
+	var created []interface{}
+	var closed []natClose
+	for _, record := range records {
+		outsideIP, _ := record.RawFields["postNATSourceIPv4Address"].(string)
+		if outsideIP == "" {
+			outsideIP, _ = record.RawFields["postNATSourceIPv6Address"].(string)
+		}
+		insideIP, _ := flowAddresses(record)
+		if outsideIP == "" || insideIP == "" {
+			continue
+		}
+
+		entry := models.NATLogRecord{
+			RouterIP:  decoded.SrcIP,
+			InsideIP:  insideIP,
+			OutsideIP: outsideIP,
+			Protocol:  record.ProtocolIdentifier,
+			Source:    models.NATLogSourceEvent,
+		}
+		timestamp := natEventTime(record, received)
+
+		switch event := rawNumber(record.RawFields["natEvent"]); event {
+		case natEventSessionCreate, natEventSessionDelete:
+			entry.InsidePort = record.SourceTransportPort
+			port := uint16(rawNumber(record.RawFields["postNAPTSourceTransportPort"]))
+			entry.OutsidePortStart, entry.OutsidePortEnd = port, port
+			if event == natEventSessionCreate {
+				created = append(created, p.withTimes(entry, timestamp, nil))
+			} else {
+				closed = append(closed, natClose{entry: entry, end: timestamp, session: true})
+			}
+		case natEventPortBlockAlloc, natEventPortBlockDealloc:
+			entry.OutsidePortStart, entry.OutsidePortEnd = portBlock(record.RawFields)
+			if event == natEventPortBlockAlloc {
+				created = append(created, p.withTimes(entry, timestamp, nil))
+			} else {
+				closed = append(closed, natClose{entry: entry, end: timestamp})
+			}
+		case natEventBindingCreate, natEventBindingDelete:
+			entry.OutsidePortStart, entry.OutsidePortEnd = 0, 65535
+			if event == natEventBindingCreate {
+				created = append(created, p.withTimes(entry, timestamp, nil))
+			} else {
+				closed = append(closed, natClose{entry: entry, end: timestamp})
+			}
+		case 0:
+			p.trackFlowSession(entry, record, received)
+		}
+	}
+
+	// As criações vão antes dos fechamentos, que podem se referir a elas.
+	if len(created) > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		_, err := p.collection.InsertMany(ctx, created, options.InsertMany().SetOrdered(false))
+		cancel()
+		if err != nil {
+			log.Printf("[NATLog] Erro ao gravar %d registros de %s: %v", len(created), decoded.SrcIP, err)
+		}
+	}
+	for _, c := range closed {
+		p.closeRecord(c)
+	}
+	return nil
+}
+
+type natClose struct {
+	entry   models.NATLogRecord
+	end     time.Time
+	session bool
+}
+
+func (p *NATLogMetricProcessor) withTimes(entry models.NATLogRecord, start time.Time, end *time.Time) models.NATLogRecord {
+	entry.Start = primitive.NewDateTimeFromTime(start)
+	expiresFrom := start
+	if end != nil {
+		endDateTime := primitive.NewDateTimeFromTime(*end)
+		entry.End = &endDateTime
+		expiresFrom = *end
+	}
+	entry.ExpiresAt = primitive.NewDateTimeFromTime(expiresFrom.Add(p.retention))
+	return entry
+}
+
+// closeRecord fecha o registro aberto correspondente. Se o evento de criação
+// não foi visto, grava um registro com início igual ao fim. Eventos de sessão
+// também casam pela porta interna.
+func (p *NATLogMetricProcessor) closeRecord(c natClose) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	entry := c.entry
+	filter := bson.M{
+		"routerIp":         entry.RouterIP,
+		"insideIp":         entry.InsideIP,
+		"outsideIp":        entry.OutsideIP,
+		"outsidePortStart": entry.OutsidePortStart,
+		"protocol":         omittedField(uint64(entry.Protocol)),
+		"end":              bson.M{"$exists": false},
+	}
+	if c.session {
+		filter["insidePort"] = omittedField(uint64(entry.InsidePort))
+	}
+	update := bson.M{"$set": bson.M{
+		"end":       primitive.NewDateTimeFromTime(c.end),
+		"expiresAt": primitive.NewDateTimeFromTime(c.end.Add(p.retention)),
+	}}
+	opts := options.FindOneAndUpdate().SetSort(bson.M{"start": -1})
+
+	err := p.collection.FindOneAndUpdate(ctx, filter, update, opts).Err()
+	if err == mongo.ErrNoDocuments {
+		_, err = p.collection.InsertOne(ctx, p.withTimes(entry, c.end, &c.end))
+	}
+	if err != nil {
+		log.Printf("[NATLog] Erro ao fechar registro de %s:%d: %v", entry.OutsideIP, entry.OutsidePortStart, err)
+	}
+}
+
+// omittedField casa campos gravados com omitempty, que não existem quando zero.
+func omittedField(value uint64) interface{} {
+	if value == 0 {
+		return bson.M{"$exists": false}
+	}
+	return value
+}
+
+func (p *NATLogMetricProcessor) trackFlowSession(entry models.NATLogRecord, record netflow.FlowRecord, received time.Time) {
+	key := natSessionKey{
+		routerIP:    entry.RouterIP,
+		insideIP:    entry.InsideIP,
+		insidePort:  record.SourceTransportPort,
+		outsideIP:   entry.OutsideIP,
+		outsidePort: uint16(rawNumber(record.RawFields["postNAPTSourceTransportPort"])),
+		protocol:    record.ProtocolIdentifier,
+	}
+	start, end := received, received
+	if record.FlowStartMilliseconds > 0 {
+		start = time.UnixMilli(int64(record.FlowStartMilliseconds))
+	}
+	if record.FlowEndMilliseconds > 0 {
+		end = time.UnixMilli(int64(record.FlowEndMilliseconds))
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	session, ok := p.sessions[key]
+	if !ok {
+		p.sessions[key] = &natSession{start: start, end: end, lastSeen: time.Now()}
+		return
+	}
+	if start.Before(session.start) {
+		session.start = start
+	}
+	if end.After(session.end) {
+		session.end = end
+	}
+	session.lastSeen = time.Now()
+}
+
+func (p *NATLogMetricProcessor) flushLoop() {
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		now := time.Now()
+		var records []interface{}
+
+		p.mu.Lock()
+		forceAll := len(p.sessions) > maxNATFlowSessions
+		for key, session := range p.sessions {
+			if !forceAll && now.Sub(session.lastSeen) < p.idleTimeout {
+				continue
+			}
+			end := session.end
+			records = append(records, p.withTimes(models.NATLogRecord{
+				RouterIP:         key.routerIP,
+				InsideIP:         key.insideIP,
+				InsidePort:       key.insidePort,
+				OutsideIP:        key.outsideIP,
+				OutsidePortStart: key.outsidePort,
+				OutsidePortEnd:   key.outsidePort,
+				Protocol:         key.protocol,
+				Source:           models.NATLogSourceFlow,
+			}, session.start, &end))
+			delete(p.sessions, key)
+		}
+		p.mu.Unlock()
+
+		if len(records) == 0 {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		if _, err := p.collection.InsertMany(ctx, records); err != nil {
+			log.Printf("[NATLog] Erro ao gravar %d sessões: %v", len(records), err)
+		}
+		cancel()
+	}
+}
+
+func portBlock(fields map[string]interface{}) (uint16, uint16) {
+	start := uint16(rawNumber(fields["portRangeStart"]))
+	end := uint16(rawNumber(fields["portRangeEnd"]))
+	if end == 0 {
+		if ports := rawNumber(fields["portRangeNumPorts"]); ports > 0 {
+			end = start + uint16(ports-1)
+		} else {
+			end = start
+		}
+	}
+	return start, end
+}
+
+// natEventTime usa observationTimeMilliseconds (texto RFC 3339 pelo JSON,
+// time.Time pelo lote binário), depois o início do flow e por fim o recebimento.
+func natEventTime(record netflow.FlowRecord, received time.Time) time.Time {
+	switch value := record.RawFields["observationTimeMilliseconds"].(type) {
+	case time.Time:
+		return value
+	case string:
+		if parsed, err := time.Parse(time.RFC3339Nano, value); err == nil {
+			return parsed
+		}
+	}
+	if record.FlowStartMilliseconds > 0 {
+		return time.UnixMilli(int64(record.FlowStartMilliseconds))
+	}
+	return received
+}
@@ -3,7 +3,7 @@ package metrics
 import (
 	"context"
 	"log"
-	models "net_monitor/models"
+	"net_monitor/interfaces"
 	"net_monitor/netflow"
 	"time"
 
@@ -49,7 +49,7 @@ func (p *PacketLossMetricProcessor) Name() string {
 	return "packet_loss_analyzer"
 }
 
-func (p *PacketLossMetricProcessor) Process(router *models.Roteador, decoded *netflow.DecodedIPFIXMessage) error {
+func (p *PacketLossMetricProcessor) Process(device interfaces.NetworkDevice, decoded *netflow.DecodedIPFIXMessage) error {
 	if len(decoded.FlowRecords) == 0 {
 		return nil
 	}
@@ -78,13 +78,13 @@ func (p *PacketLossMetricProcessor) Process(router *models.Roteador, decoded *ne
 	}
 
 	if p.collection == nil {
-		return p.logPacketLossStats(router, decoded.SrcIP, droppedPackets, droppedOctets, totalPackets, totalOctets)
+		return p.logPacketLossStats(device, decoded.SrcIP, droppedPackets, droppedOctets, totalPackets, totalOctets)
 	}
 
-	return p.saveMetrics(router, decoded.SrcIP, droppedPackets, droppedOctets, totalPackets, totalOctets)
+	return p.saveMetrics(device, decoded.SrcIP, droppedPackets, droppedOctets, totalPackets, totalOctets)
 }
 
-func (p *PacketLossMetricProcessor) saveMetrics(router *models.Roteador, srcIP string,
+func (p *PacketLossMetricProcessor) saveMetrics(device interfaces.NetworkDevice, srcIP string,
 	droppedPackets, droppedOctets, totalPackets, totalOctets uint64) error {
 
 	now := time.Now()
@@ -116,8 +116,8 @@ func (p *PacketLossMetricProcessor) saveMetrics(router *models.Roteador, srcIP s
 		},
 	}
 
-	if router != nil {
-		update["$setOnInsert"].(bson.M)["routerId"] = router.ID
+	if device != nil {
+		update["$setOnInsert"].(bson.M)["routerId"] = deviceObjectID(device)
 	}
 
 	opts := options.Update().SetUpsert(true)
@@ -157,12 +157,12 @@ func (p *PacketLossMetricProcessor) updateLossPercentages(ctx context.Context, f
 	p.collection.UpdateOne(ctx, filter, update)
 }
 
-func (p *PacketLossMetricProcessor) logPacketLossStats(router *models.Roteador, srcIP string,
+func (p *PacketLossMetricProcessor) logPacketLossStats(device interfaces.NetworkDevice, srcIP string,
 	droppedPackets, droppedOctets, totalPackets, totalOctets uint64) error {
 
 	routerName := "Desconhecido"
-	if router != nil {
-		routerName = router.Name
+	if device != nil {
+		routerName = device.GetName()
 	}
 
 	log.Printf("════════════════════════════════════════════════════════════")
@@ -0,0 +1,198 @@
+package metrics
+
+import (
+	"context"
+	"log"
+	"math/rand"
+	"net/netip"
+	"net_monitor/interfaces"
+	models "net_monitor/models"
+	"net_monitor/netflow"
+	utils "net_monitor/utils"
+	"os"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const rawFlowFlushInterval = time.Second
+
+// Flows com início antes disso vêm de exportadores sem relógio acertado.
+var minRawFlowTime = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// RawFlowStoreEnabled indica se o armazenamento de flows brutos foi ligado
+// (RAW_FLOW_STORE_ENABLED=true). Ele é opcional por causa do volume.
+func RawFlowStoreEnabled() bool {
+	return os.Getenv("RAW_FLOW_STORE_ENABLED") == "true"
+}
+
+// RawFlowStoreMetricProcessor guarda 1 de cada RAW_FLOW_SAMPLE_RATE flows em
+// raw_flows, com retenção de RAW_FLOW_RETENTION_HOURS. A escrita é em lote;
+// se o Mongo não acompanhar, o buffer (RAW_FLOW_BUFFER) descarta o excesso
+// em vez de segurar os workers.
+type RawFlowStoreMetricProcessor struct {
+	collection *mongo.Collection
+	sampleRate int
+	retention  time.Duration
+	batchSize  int
+	maxPending int
+
+	mu      sync.Mutex
+	pending []interface{}
+	dropped uint64
+	flushCh chan struct{}
+}
+
+func NewRawFlowStoreMetricProcessor() *RawFlowStoreMetricProcessor {
+	p := &RawFlowStoreMetricProcessor{
+		sampleRate: utils.EnvPositiveInt("RAW_FLOW_SAMPLE_RATE", 1),
+		retention:  time.Duration(utils.EnvPositiveInt("RAW_FLOW_RETENTION_HOURS", 72)) * time.Hour,
+		batchSize:  utils.EnvPositiveInt("RAW_FLOW_BATCH_SIZE", 1000),
+		maxPending: utils.EnvPositiveInt("RAW_FLOW_BUFFER", 50000),
+		flushCh:    make(chan struct{}, 1),
+	}
+
+	ctx := netflow.GetMetricContext()
+	if ctx == nil || ctx.DB == nil {
+		log.Printf("[RawFlowStore] Aviso: MetricContext não inicializado")
+		return p
+	}
+	p.collection = ctx.DB.Collection("raw_flows")
+
+	go p.flushLoop()
+	return p
+}
+
+func (p *RawFlowStoreMetricProcessor) Name() string {
+	return "raw_flow_store"
+}
+
+func (p *RawFlowStoreMetricProcessor) Process(device interfaces.NetworkDevice, decoded *netflow.DecodedIPFIXMessage) error {
+	if p.collection == nil || len(decoded.FlowRecords) == 0 {
+		return nil
+	}
+
+	received := decoded.Received
+	if received.IsZero() {
+		received = time.Now()
+	}
+	expiresAt := primitive.NewDateTimeFromTime(received.Add(p.retention))
+	routerID := deviceObjectID(device)
+
+	docs := make([]interface{}, 0, len(decoded.FlowRecords)/p.sampleRate+1)
+	for _, record := range decoded.FlowRecords {
+		if p.sampleRate > 1 && rand.Intn(p.sampleRate) != 0 {
+			continue
+		}
+
+		srcIP, dstIP := flowAddresses(record)
+		src, errSrc := netip.ParseAddr(srcIP)
+		dst, errDst := netip.ParseAddr(dstIP)
+		if errSrc != nil || errDst != nil {
+			continue
+		}
+		src, dst = src.Unmap(), dst.Unmap()
+
+		flow := models.RawFlow{
+			Timestamp:        primitive.NewDateTimeFromTime(received),
+			RouterIP:         decoded.SrcIP,
+			RouterID:         routerID,
+			SrcIP:            src.String(),
+			DstIP:            dst.String(),
+			SrcIPKey:         models.RawFlowIPKey(src),
+			DstIPKey:         models.RawFlowIPKey(dst),
+			SrcPort:          record.SourceTransportPort,
+			DstPort:          record.DestinationTransportPort,
+			Protocol:         record.ProtocolIdentifier,
+			Bytes:            record.OctetDeltaCount,
+			Packets:          record.PacketDeltaCount,
+			IngressInterface: record.IngressInterface,
+			EgressInterface:  record.EgressInterface,
+			TOS:              record.IPClassOfService,
+			SrcAS:            record.SourceAS,
+			DstAS:            record.DestinationAS,
+			SampleRate:       uint32(p.sampleRate),
+			ExpiresAt:        expiresAt,
+		}
+		// O timestamp é o fim do flow quando o exportador informa; é por
+		// ele que a busca filtra o período.
+		if start := time.UnixMilli(int64(record.FlowStartMilliseconds)); record.FlowStartMilliseconds > 0 && start.After(minRawFlowTime) {
+			flow.Start = primitive.NewDateTimeFromTime(start)
+		}
+		if end := time.UnixMilli(int64(record.FlowEndMilliseconds)); record.FlowEndMilliseconds > 0 && end.After(minRawFlowTime) {
+			flow.End = primitive.NewDateTimeFromTime(end)
+			flow.Timestamp = flow.End
+		}
+		docs = append(docs, flow)
+	}
+	if len(docs) == 0 {
+		return nil
+	}
+
+	p.mu.Lock()
+	if room := p.maxPending - len(p.pending); room < len(docs) {
+		if room < 0 {
+			room = 0
+		}
+		if p.dropped == 0 {
+			log.Printf("[RawFlowStore] Buffer cheio, descartando flows")
+		}
+		p.dropped += uint64(len(docs) - room)
+		docs = docs[:room]
+	}
+	p.pending = append(p.pending, docs...)
+	full := len(p.pending) >= p.batchSize
+	p.mu.Unlock()
+
+	if full {
+		select {
+		case p.flushCh <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+func (p *RawFlowStoreMetricProcessor) flushLoop() {
+	ticker := time.NewTicker(rawFlowFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-p.flushCh:
+		}
+		p.flush()
+	}
+}
+
+func (p *RawFlowStoreMetricProcessor) flush() {
+	for {
+		p.mu.Lock()
+		if len(p.pending) == 0 {
+			p.mu.Unlock()
+			return
+		}
+		n := min(len(p.pending), p.batchSize)
+		batch := p.pending[:n:n]
+		p.pending = p.pending[n:]
+		dropped := p.dropped
+		p.dropped = 0
+		p.mu.Unlock()
+
+		if dropped > 0 {
+			log.Printf("[RawFlowStore] %d flows descartados por buffer cheio", dropped)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		_, err := p.collection.InsertMany(ctx, batch, options.InsertMany().SetOrdered(false))
+		cancel()
+		if err != nil {
+			log.Printf("[RawFlowStore] Erro gravando %d flows: %v", len(batch), err)
+			return
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package metrics
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// HeavyHitterEntry é um contador do Space-Saving. Error é o limite superior
+// de superestimação de Bytes herdado do item que foi despejado; Packets e
+// Flows contam só a partir da entrada da chave.
+type HeavyHitterEntry struct {
+	Key     string `bson:"key" json:"key"`
+	Bytes   uint64 `bson:"bytes" json:"bytes"`
+	Packets uint64 `bson:"packets" json:"packets"`
+	Flows   uint64 `bson:"flows" json:"flows"`
+	Error   uint64 `bson:"error,omitempty" json:"error,omitempty"`
+	index   int
+}
+
+// SpaceSaving mantém no máximo capacity chaves (Metwally et al.). Quando
+// cheio, a chave de menor volume é substituída pela nova, o que garante
+// memória limitada e que os heavy hitters reais nunca são perdidos.
+type SpaceSaving struct {
+	capacity int
+	entries  map[string]*HeavyHitterEntry
+	minHeap  heavyHitterHeap
+}
+
+func NewSpaceSaving(capacity int) *SpaceSaving {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &SpaceSaving{
+		capacity: capacity,
+		entries:  make(map[string]*HeavyHitterEntry, capacity),
+	}
+}
+
+func (s *SpaceSaving) Add(key string, bytes, packets uint64) {
+	if entry, ok := s.entries[key]; ok {
+		entry.Bytes += bytes
+		entry.Packets += packets
+		entry.Flows++
+		heap.Fix(&s.minHeap, entry.index)
+		return
+	}
+
+	if len(s.entries) < s.capacity {
+		entry := &HeavyHitterEntry{Key: key, Bytes: bytes, Packets: packets, Flows: 1}
+		s.entries[key] = entry
+		heap.Push(&s.minHeap, entry)
+		return
+	}
+
+	evicted := s.minHeap[0]
+	delete(s.entries, evicted.Key)
+	evicted.Error = evicted.Bytes
+	evicted.Key = key
+	evicted.Bytes += bytes
+	evicted.Packets = packets
+	evicted.Flows = 1
+	s.entries[key] = evicted
+	heap.Fix(&s.minHeap, 0)
+}
+
+func (s *SpaceSaving) Len() int {
+	return len(s.entries)
+}
+
+// Top devolve até n entradas ordenadas por bytes (n <= 0 devolve todas).
+func (s *SpaceSaving) Top(n int) []HeavyHitterEntry {
+	result := make([]HeavyHitterEntry, 0, len(s.entries))
+	for _, entry := range s.entries {
+		result = append(result, *entry)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Bytes == result[j].Bytes {
+			return result[i].Key < result[j].Key
+		}
+		return result[i].Bytes > result[j].Bytes
+	})
+	if n > 0 && len(result) > n {
+		result = result[:n]
+	}
+	return result
+}
+
+type heavyHitterHeap []*HeavyHitterEntry
+
+func (h heavyHitterHeap) Len() int           { return len(h) }
+func (h heavyHitterHeap) Less(i, j int) bool { return h[i].Bytes < h[j].Bytes }
+func (h heavyHitterHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *heavyHitterHeap) Push(x interface{}) {
+	entry := x.(*HeavyHitterEntry)
+	entry.index = len(*h)
+	*h = append(*h, entry)
+}
+
+func (h *heavyHitterHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	entry := old[n-1]
+	*h = old[:n-1]
+	return entry
+}
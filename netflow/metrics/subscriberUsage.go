@@ -0,0 +1,300 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/netip"
+	"net_monitor/interfaces"
+	models "net_monitor/models"
+	"net_monitor/netflow"
+	"net_monitor/netflow/enrichment"
+	utils "net_monitor/utils"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SubscriberUsageMetric acumula o tráfego de um assinante em uma hora.
+// Dia e mês são agregados a partir dessas horas.
+type SubscriberUsageMetric struct {
+	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	SubscriberID    primitive.ObjectID `bson:"subscriberId" json:"subscriberId"`
+	SubscriberName  string             `bson:"subscriberName" json:"subscriberName"`
+	Timestamp       primitive.DateTime `bson:"timestamp" json:"timestamp"`
+	UploadBytes     uint64             `bson:"uploadBytes" json:"uploadBytes"`
+	DownloadBytes   uint64             `bson:"downloadBytes" json:"downloadBytes"`
+	UploadPackets   uint64             `bson:"uploadPackets" json:"uploadPackets"`
+	DownloadPackets uint64             `bson:"downloadPackets" json:"downloadPackets"`
+	CreatedAt       primitive.DateTime `bson:"createdAt" json:"createdAt"`
+	UpdatedAt       primitive.DateTime `bson:"updatedAt" json:"updatedAt"`
+}
+
+type subscriberRef struct {
+	id   primitive.ObjectID
+	name string
+}
+
+type portRange struct {
+	start, end uint16
+	subscriber subscriberRef
+}
+
+// subscriberIndex resolve um endereço (e porta, no caso de CGNAT) para o assinante.
+type subscriberIndex struct {
+	addresses *enrichment.PrefixTrie[subscriberRef]
+	cgnat     map[netip.Addr][]portRange
+}
+
+func (idx *subscriberIndex) match(ip string, port uint16) (subscriberRef, bool) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return subscriberRef{}, false
+	}
+	addr = addr.Unmap()
+	if ref, ok := idx.addresses.Lookup(addr); ok {
+		return ref, true
+	}
+
+	ranges := idx.cgnat[addr]
+	i := sort.Search(len(ranges), func(i int) bool { return ranges[i].end >= port })
+	if i < len(ranges) && ranges[i].start <= port {
+		return ranges[i].subscriber, true
+	}
+	return subscriberRef{}, false
+}
+
+type usageKey struct {
+	subscriberID primitive.ObjectID
+	hour         time.Time
+}
+
+type usageCounters struct {
+	name            string
+	uploadBytes     uint64
+	downloadBytes   uint64
+	uploadPackets   uint64
+	downloadPackets uint64
+}
+
+type SubscriberUsageMetricProcessor struct {
+	subscribers *mongo.Collection
+	collection  *mongo.Collection
+	index       *subscriberIndex
+	pending     map[usageKey]*usageCounters
+	mu          sync.Mutex
+	reloadMu    sync.Mutex
+}
+
+func NewSubscriberUsageMetricProcessor() *SubscriberUsageMetricProcessor {
+	p := &SubscriberUsageMetricProcessor{
+		index:   &subscriberIndex{addresses: enrichment.NewPrefixTrie[subscriberRef](), cgnat: map[netip.Addr][]portRange{}},
+		pending: make(map[usageKey]*usageCounters),
+	}
+
+	ctx := netflow.GetMetricContext()
+	if ctx == nil || ctx.DB == nil {
+		log.Printf("[SubscriberUsage] Aviso: MetricContext não inicializado")
+		return p
+	}
+	p.subscribers = ctx.DB.Collection("subscribers")
+	p.collection = ctx.DB.Collection("subscriber_usage")
+
+	p.Reload()
+	go p.reloadLoop(time.Duration(utils.EnvPositiveInt("SUBSCRIBER_RELOAD_SECONDS", 60)) * time.Second)
+	go p.flushLoop()
+	return p
+}
+
+func (p *SubscriberUsageMetricProcessor) Name() string {
+	return "subscriber_usage_accounting"
+}
+
+// Reload reconstrói o índice de endereços e faixas CGNAT dos assinantes ativos.
+func (p *SubscriberUsageMetricProcessor) Reload() {
+	if p.subscribers == nil {
+		return
+	}
+	p.reloadMu.Lock()
+	defer p.reloadMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := p.subscribers.Find(ctx, bson.M{"active": true})
+	if err != nil {
+		log.Printf("[SubscriberUsage] Erro carregando assinantes: %v", err)
+		return
+	}
+	var subscribers []models.Subscriber
+	if err := cursor.All(ctx, &subscribers); err != nil {
+		log.Printf("[SubscriberUsage] Erro decodificando assinantes: %v", err)
+		return
+	}
+
+	index := &subscriberIndex{addresses: enrichment.NewPrefixTrie[subscriberRef](), cgnat: map[netip.Addr][]portRange{}}
+	for _, subscriber := range subscribers {
+		ref := subscriberRef{id: subscriber.ID, name: subscriber.Name}
+		for _, address := range subscriber.Addresses {
+			if prefix, err := parseAddressOrPrefix(address); err == nil {
+				index.addresses.Insert(prefix, ref)
+			}
+		}
+		for _, mapping := range subscriber.CGNATMappings {
+			addr, err := netip.ParseAddr(mapping.PublicIP)
+			if err != nil {
+				continue
+			}
+			addr = addr.Unmap()
+			index.cgnat[addr] = append(index.cgnat[addr], portRange{start: mapping.PortStart, end: mapping.PortEnd, subscriber: ref})
+		}
+	}
+	for addr := range index.cgnat {
+		ranges := index.cgnat[addr]
+		sort.Slice(ranges, func(i, j int) bool { return ranges[i].start < ranges[j].start })
+	}
+
+	p.mu.Lock()
+	p.index = index
+	p.mu.Unlock()
+}
+
+func (p *SubscriberUsageMetricProcessor) reloadLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		p.Reload()
+	}
+}
+
+func (p *SubscriberUsageMetricProcessor) Process(device interfaces.NetworkDevice, decoded *netflow.DecodedIPFIXMessage) error {
+	if len(decoded.FlowRecords) == 0 {
+		return nil
+	}
+
+	received := decoded.Received
+	if received.IsZero() {
+		received = time.Now()
+	}
+	hour := received.Truncate(time.Hour)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, record := range decoded.FlowRecords {
+		src, dst := flowAddresses(record)
+
+		// Upload: o assinante é a origem, pelo endereço interno ou pelo par
+		// IP/porta pós-NAT. Download: o assinante é o destino.
+		if ref, ok := p.matchEndpoint(src, record.SourceTransportPort, record.RawFields, "postNATSourceIPv4Address", "postNAPTSourceTransportPort"); ok {
+			counters := p.counters(ref, hour)
+			counters.uploadBytes += record.OctetDeltaCount
+			counters.uploadPackets += record.PacketDeltaCount
+		}
+		if ref, ok := p.matchEndpoint(dst, record.DestinationTransportPort, record.RawFields, "postNATDestinationIPv4Address", "postNAPTDestinationTransportPort"); ok {
+			counters := p.counters(ref, hour)
+			counters.downloadBytes += record.OctetDeltaCount
+			counters.downloadPackets += record.PacketDeltaCount
+		}
+	}
+
+	return nil
+}
+
+func (p *SubscriberUsageMetricProcessor) matchEndpoint(ip string, port uint16, fields map[string]interface{}, natIPField, natPortField string) (subscriberRef, bool) {
+	if ip != "" {
+		if ref, ok := p.index.match(ip, port); ok {
+			return ref, true
+		}
+	}
+	if natIP, ok := fields[natIPField].(string); ok && natIP != "" {
+		return p.index.match(natIP, uint16(rawNumber(fields[natPortField])))
+	}
+	return subscriberRef{}, false
+}
+
+func (p *SubscriberUsageMetricProcessor) counters(ref subscriberRef, hour time.Time) *usageCounters {
+	key := usageKey{subscriberID: ref.id, hour: hour}
+	counters, ok := p.pending[key]
+	if !ok {
+		counters = &usageCounters{name: ref.name}
+		p.pending[key] = counters
+	}
+	return counters
+}
+
+func (p *SubscriberUsageMetricProcessor) flushLoop() {
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		p.mu.Lock()
+		pending := p.pending
+		p.pending = make(map[usageKey]*usageCounters)
+		p.mu.Unlock()
+
+		for key, counters := range pending {
+			if err := p.save(key, counters); err != nil {
+				log.Printf("[SubscriberUsage] Erro ao salvar uso de %s: %v", counters.name, err)
+			}
+		}
+	}
+}
+
+func (p *SubscriberUsageMetricProcessor) save(key usageKey, counters *usageCounters) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := primitive.NewDateTimeFromTime(time.Now())
+	filter := bson.M{
+		"subscriberId": key.subscriberID,
+		"timestamp":    primitive.NewDateTimeFromTime(key.hour),
+	}
+	update := bson.M{
+		"$inc": bson.M{
+			"uploadBytes":     counters.uploadBytes,
+			"downloadBytes":   counters.downloadBytes,
+			"uploadPackets":   counters.uploadPackets,
+			"downloadPackets": counters.downloadPackets,
+		},
+		"$set": bson.M{
+			"subscriberName": counters.name,
+			"updatedAt":      now,
+		},
+		"$setOnInsert": bson.M{
+			"createdAt": now,
+		},
+	}
+
+	_, err := p.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	return err
+}
+
+// parseAddressOrPrefix aceita tanto um IP isolado quanto um prefixo CIDR;
+// prefixos IPv4 mapeados em IPv6 saem como IPv4.
+func parseAddressOrPrefix(value string) (netip.Prefix, error) {
+	if prefix, err := netip.ParsePrefix(value); err == nil {
+		normalized, ok := enrichment.NormalizePrefix(prefix)
+		if !ok {
+			return netip.Prefix{}, fmt.Errorf("prefixo IPv4 mapeado menor que /96: %s", value)
+		}
+		return normalized, nil
+	}
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// rawNumber devolve 0 para campos ausentes ou não numéricos.
+func rawNumber(value interface{}) uint64 {
+	number, _ := netflow.RawUint(value)
+	return number
+}
@@ -0,0 +1,416 @@
+package metrics
+
+import (
+	"context"
+	"log"
+	"net_monitor/interfaces"
+	"net_monitor/netflow"
+	utils "net_monitor/utils"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	tcpQualityBucket = 5 * time.Minute
+
+	tcpFlagRST = 0x04
+
+	// Um SYN espera pelo SYN-ACK do sentido contrário por até handshakeWait.
+	handshakeWait       = time.Minute
+	maxHandshakeRTT     = 10 * time.Second
+	maxPendingHandshake = 200000
+)
+
+// TCPQualityMetric acumula indicadores de qualidade TCP de um serviço
+// (porta do servidor) visto por um roteador em 5 minutos. As razões são
+// calculadas na consulta; os denominadores contam só flows que trouxeram o
+// campo correspondente.
+type TCPQualityMetric struct {
+	ID                   primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	RouterID             primitive.ObjectID `bson:"routerId,omitempty" json:"routerId,omitempty"`
+	RouterIP             string             `bson:"routerIp" json:"routerIp"`
+	Timestamp            primitive.DateTime `bson:"timestamp" json:"timestamp"`
+	ServicePort          uint16             `bson:"servicePort" json:"servicePort"`
+	Flows                uint64             `bson:"flows" json:"flows"`
+	Packets              uint64             `bson:"packets" json:"packets"`
+	Bytes                uint64             `bson:"bytes" json:"bytes"`
+	FlagFlows            uint64             `bson:"flagFlows" json:"flagFlows"`
+	SynAttempts          uint64             `bson:"synAttempts" json:"synAttempts"`
+	SynAcks              uint64             `bson:"synAcks" json:"synAcks"`
+	HandshakeFailures    uint64             `bson:"handshakeFailures" json:"handshakeFailures"`
+	ClientResets         uint64             `bson:"clientResets" json:"clientResets"`
+	ServerResets         uint64             `bson:"serverResets" json:"serverResets"`
+	WindowFlows          uint64             `bson:"windowFlows" json:"windowFlows"`
+	ZeroWindowFlows      uint64             `bson:"zeroWindowFlows" json:"zeroWindowFlows"`
+	RetransmissionFlows  uint64             `bson:"retransmissionFlows" json:"retransmissionFlows"`
+	RetransmissionBase   uint64             `bson:"retransmissionBase" json:"retransmissionBase"`
+	RetransmittedPackets uint64             `bson:"retransmittedPackets" json:"retransmittedPackets"`
+	RTTSamples           uint64             `bson:"rttSamples" json:"rttSamples"`
+	RTTTotalMs           uint64             `bson:"rttTotalMs" json:"rttTotalMs"`
+	RTTMinMs             uint64             `bson:"rttMinMs" json:"rttMinMs"`
+	RTTMaxMs             uint64             `bson:"rttMaxMs" json:"rttMaxMs"`
+	CreatedAt            primitive.DateTime `bson:"createdAt" json:"createdAt"`
+	UpdatedAt            primitive.DateTime `bson:"updatedAt" json:"updatedAt"`
+}
+
+type tcpQualityKey struct {
+	routerIP    string
+	bucket      time.Time
+	servicePort uint16
+}
+
+type tcpQualityCounters struct {
+	routerID             primitive.ObjectID
+	flows                uint64
+	packets              uint64
+	bytes                uint64
+	flagFlows            uint64
+	synAttempts          uint64
+	synAcks              uint64
+	handshakeFailures    uint64
+	clientResets         uint64
+	serverResets         uint64
+	windowFlows          uint64
+	zeroWindowFlows      uint64
+	retransmissionFlows  uint64
+	retransmissionBase   uint64
+	retransmittedPackets uint64
+	rttSamples           uint64
+	rttTotalMs           uint64
+	rttMinMs             uint64
+	rttMaxMs             uint64
+}
+
+func (c *tcpQualityCounters) addRTT(rttMs uint64) {
+	if c.rttSamples == 0 || rttMs < c.rttMinMs {
+		c.rttMinMs = rttMs
+	}
+	if rttMs > c.rttMaxMs {
+		c.rttMaxMs = rttMs
+	}
+	c.rttSamples++
+	c.rttTotalMs += rttMs
+}
+
+// handshakeKey identifica a conexão nos dois sentidos pelo lado do cliente.
+type handshakeKey struct {
+	routerIP   string
+	clientIP   string
+	clientPort uint16
+	serverIP   string
+	serverPort uint16
+}
+
+// handshakeHalf é o primeiro lado visto do handshake: o SYN do cliente ou o
+// SYN-ACK do servidor, com o início do flow em epoch ms.
+type handshakeHalf struct {
+	fromClient bool
+	startMs    uint64
+	seen       time.Time
+}
+
+// TCPQualityMetricProcessor deriva a qualidade de conexões TCP dos flows:
+// tentativas de conexão e handshakes que não completam, RSTs, janela zero,
+// retransmissões e RTT. O RTT vem do campo configurado em TCP_QUALITY_RTT_FIELDS
+// (ms) ou é estimado entre o SYN e o SYN-ACK vistos pelo roteador, o que mede
+// o trecho roteador-servidor. Retransmissões dependem do exportador e são
+// lidas dos campos em TCP_QUALITY_RETRANSMISSION_FIELDS (pacotes, somados).
+// Registros sFlow são pacotes amostrados, cujas flags não descrevem a sessão:
+// entram no volume, na janela e no RTT, mas não em handshakes e RSTs.
+type TCPQualityMetricProcessor struct {
+	collection           *mongo.Collection
+	maxServices          int
+	rttFields            []string
+	retransmissionFields []string
+	mu                   sync.Mutex
+	pending              map[tcpQualityKey]*tcpQualityCounters
+	services             map[bucketKey]map[uint16]bool
+	handshakes           map[handshakeKey]handshakeHalf
+}
+
+// NewTCPQualityMetricProcessor lê TCP_QUALITY_MAX_SERVICES (padrão 1000), o
+// número de portas de serviço por roteador em cada bucket; as demais são
+// somadas na porta 0.
+func NewTCPQualityMetricProcessor() *TCPQualityMetricProcessor {
+	p := &TCPQualityMetricProcessor{
+		maxServices:          utils.EnvPositiveInt("TCP_QUALITY_MAX_SERVICES", 1000),
+		rttFields:            envList("TCP_QUALITY_RTT_FIELDS"),
+		retransmissionFields: envList("TCP_QUALITY_RETRANSMISSION_FIELDS"),
+		pending:              make(map[tcpQualityKey]*tcpQualityCounters),
+		services:             make(map[bucketKey]map[uint16]bool),
+		handshakes:           make(map[handshakeKey]handshakeHalf),
+	}
+
+	ctx := netflow.GetMetricContext()
+	if ctx == nil || ctx.DB == nil {
+		log.Printf("[TCPQuality] Aviso: MetricContext não inicializado")
+		return p
+	}
+	p.collection = ctx.DB.Collection("tcp_quality_metrics")
+
+	go p.flushLoop()
+	return p
+}
+
+func (p *TCPQualityMetricProcessor) Name() string {
+	return "tcp_quality_analyzer"
+}
+
+func (p *TCPQualityMetricProcessor) Process(device interfaces.NetworkDevice, decoded *netflow.DecodedIPFIXMessage) error {
+	if len(decoded.FlowRecords) == 0 {
+		return nil
+	}
+
+	received := decoded.Received
+	if received.IsZero() {
+		received = time.Now()
+	}
+	bucket := received.Truncate(tcpQualityBucket)
+	routerID := deviceObjectID(device)
+	packetSamples := decoded.Protocol == netflow.ProtocolSFlow
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, record := range decoded.FlowRecords {
+		if record.ProtocolIdentifier != 6 {
+			continue
+		}
+
+		port := servicePort(record.SourceTransportPort, record.DestinationTransportPort)
+		fromClient := record.DestinationTransportPort == port
+		counters := p.counters(tcpQualityKey{routerIP: decoded.SrcIP, bucket: bucket, servicePort: port})
+		if !routerID.IsZero() {
+			counters.routerID = routerID
+		}
+		counters.flows++
+		counters.packets += record.PacketDeltaCount
+		counters.bytes += record.OctetDeltaCount
+
+		flagsValue, hasFlags := record.RawFields["tcpControlBits"]
+		flags := rawNumber(flagsValue)
+		if hasFlags && !packetSamples {
+			counters.flagFlows++
+			syn, ack := flags&tcpFlagSYN != 0, flags&tcpFlagACK != 0
+			switch {
+			case fromClient && syn:
+				counters.synAttempts++
+				// O cliente nunca confirmou: sem SYN-ACK ou com RST no lugar.
+				if !ack {
+					counters.handshakeFailures++
+				}
+			case !fromClient && syn && ack:
+				counters.synAcks++
+			}
+			if flags&tcpFlagRST != 0 {
+				if fromClient {
+					counters.clientResets++
+				} else {
+					counters.serverResets++
+				}
+			}
+			if syn && len(p.rttFields) == 0 {
+				p.matchHandshake(decoded.SrcIP, record, fromClient, ack, received, counters)
+			}
+		}
+
+		// RSTs costumam levar janela zero sem indicar receptor sobrecarregado.
+		if window, ok := record.RawFields["tcpWindowSize"]; ok && flags&tcpFlagRST == 0 {
+			counters.windowFlows++
+			if rawNumber(window) == 0 {
+				counters.zeroWindowFlows++
+			}
+		}
+
+		if retransmitted, ok := sumFields(record.RawFields, p.retransmissionFields); ok {
+			counters.retransmissionFlows++
+			counters.retransmissionBase += record.PacketDeltaCount
+			counters.retransmittedPackets += retransmitted
+		}
+
+		for _, field := range p.rttFields {
+			if value, ok := record.RawFields[field]; ok {
+				if rtt := rawNumber(value); rtt > 0 {
+					counters.addRTT(rtt)
+				}
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+// counters limita as portas por roteador e bucket; deve ser chamado com p.mu travado.
+func (p *TCPQualityMetricProcessor) counters(key tcpQualityKey) *tcpQualityCounters {
+	if counters, ok := p.pending[key]; ok {
+		return counters
+	}
+
+	serviceKey := bucketKey{routerIP: key.routerIP, start: key.bucket}
+	ports := p.services[serviceKey]
+	if ports == nil {
+		ports = make(map[uint16]bool)
+		p.services[serviceKey] = ports
+	}
+	if !ports[key.servicePort] {
+		if len(ports) >= p.maxServices {
+			key.servicePort = 0
+			if counters, ok := p.pending[key]; ok {
+				return counters
+			}
+		}
+		ports[key.servicePort] = true
+	}
+
+	counters := &tcpQualityCounters{}
+	p.pending[key] = counters
+	return counters
+}
+
+// matchHandshake junta o SYN do cliente com o SYN-ACK do servidor, em
+// qualquer ordem de chegada, e registra a diferença entre os inícios dos
+// flows como RTT. Deve ser chamado com p.mu travado.
+func (p *TCPQualityMetricProcessor) matchHandshake(routerIP string, record netflow.FlowRecord, fromClient, ack bool, received time.Time, counters *tcpQualityCounters) {
+	if record.FlowStartMilliseconds == 0 {
+		return
+	}
+
+	src, dst := flowAddresses(record)
+	key := handshakeKey{routerIP: routerIP}
+	if fromClient {
+		key.clientIP, key.clientPort = src, record.SourceTransportPort
+		key.serverIP, key.serverPort = dst, record.DestinationTransportPort
+	} else {
+		if !ack {
+			return
+		}
+		key.clientIP, key.clientPort = dst, record.DestinationTransportPort
+		key.serverIP, key.serverPort = src, record.SourceTransportPort
+	}
+
+	other, ok := p.handshakes[key]
+	if !ok || other.fromClient == fromClient {
+		if len(p.handshakes) < maxPendingHandshake {
+			p.handshakes[key] = handshakeHalf{fromClient: fromClient, startMs: record.FlowStartMilliseconds, seen: received}
+		}
+		return
+	}
+	delete(p.handshakes, key)
+
+	synMs, synAckMs := other.startMs, record.FlowStartMilliseconds
+	if fromClient {
+		synMs, synAckMs = record.FlowStartMilliseconds, other.startMs
+	}
+	if synAckMs >= synMs && synAckMs-synMs <= uint64(maxHandshakeRTT.Milliseconds()) {
+		counters.addRTT(synAckMs - synMs)
+	}
+}
+
+func (p *TCPQualityMetricProcessor) flushLoop() {
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		now := time.Now()
+		current := now.Truncate(tcpQualityBucket)
+
+		p.mu.Lock()
+		pending := p.pending
+		p.pending = make(map[tcpQualityKey]*tcpQualityCounters)
+		for serviceKey := range p.services {
+			if serviceKey.start.Before(current) {
+				delete(p.services, serviceKey)
+			}
+		}
+		for key, half := range p.handshakes {
+			if now.Sub(half.seen) > handshakeWait {
+				delete(p.handshakes, key)
+			}
+		}
+		p.mu.Unlock()
+
+		for key, counters := range pending {
+			if err := p.save(key, counters); err != nil {
+				log.Printf("[TCPQuality] Erro ao salvar porta %d de %s: %v", key.servicePort, key.routerIP, err)
+			}
+		}
+	}
+}
+
+func (p *TCPQualityMetricProcessor) save(key tcpQualityKey, counters *tcpQualityCounters) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := primitive.NewDateTimeFromTime(time.Now())
+	filter := bson.M{
+		"routerIp":    key.routerIP,
+		"timestamp":   primitive.NewDateTimeFromTime(key.bucket),
+		"servicePort": key.servicePort,
+	}
+	update := bson.M{
+		"$inc": bson.M{
+			"flows":                counters.flows,
+			"packets":              counters.packets,
+			"bytes":                counters.bytes,
+			"flagFlows":            counters.flagFlows,
+			"synAttempts":          counters.synAttempts,
+			"synAcks":              counters.synAcks,
+			"handshakeFailures":    counters.handshakeFailures,
+			"clientResets":         counters.clientResets,
+			"serverResets":         counters.serverResets,
+			"windowFlows":          counters.windowFlows,
+			"zeroWindowFlows":      counters.zeroWindowFlows,
+			"retransmissionFlows":  counters.retransmissionFlows,
+			"retransmissionBase":   counters.retransmissionBase,
+			"retransmittedPackets": counters.retransmittedPackets,
+			"rttSamples":           counters.rttSamples,
+			"rttTotalMs":           counters.rttTotalMs,
+		},
+		"$set": bson.M{
+			"updatedAt": now,
+		},
+		"$setOnInsert": bson.M{
+			"createdAt": now,
+		},
+	}
+	if !counters.routerID.IsZero() {
+		update["$set"].(bson.M)["routerId"] = counters.routerID
+	}
+	if counters.rttSamples > 0 {
+		update["$min"] = bson.M{"rttMinMs": counters.rttMinMs}
+		update["$max"] = bson.M{"rttMaxMs": counters.rttMaxMs}
+	}
+
+	_, err := p.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	return err
+}
+
+func sumFields(fields map[string]interface{}, names []string) (uint64, bool) {
+	var total uint64
+	found := false
+	for _, name := range names {
+		if value, ok := fields[name]; ok {
+			total += rawNumber(value)
+			found = true
+		}
+	}
+	return total, found
+}
+
+func envList(name string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(name), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
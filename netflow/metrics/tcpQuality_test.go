@@ -0,0 +1,101 @@
+package metrics
+
+import (
+	"net_monitor/netflow"
+	"testing"
+	"time"
+)
+
+const (
+	testRouterIP = "198.51.100.1"
+	testServerIP = "192.0.2.10"
+)
+
+func tcpRecord(srcIP string, srcPort uint16, dstIP string, dstPort uint16, flags uint64, startMs uint64) netflow.FlowRecord {
+	return netflow.FlowRecord{
+		SourceIPv4Address:        srcIP,
+		SourceTransportPort:      srcPort,
+		DestinationIPv4Address:   dstIP,
+		DestinationTransportPort: dstPort,
+		ProtocolIdentifier:       6,
+		PacketDeltaCount:         10,
+		OctetDeltaCount:          1000,
+		FlowStartMilliseconds:    startMs,
+		RawFields:                map[string]interface{}{"tcpControlBits": flags},
+	}
+}
+
+// Uma sessão completa (SYN-ACK 30 ms depois do SYN) e uma recusada com RST.
+func tcpSessionRecords() []netflow.FlowRecord {
+	established := tcpRecord("10.0.0.1", 50000, testServerIP, 443, 0x1b, 1000)
+	established.RawFields["tcpWindowSize"] = uint64(0)
+	return []netflow.FlowRecord{
+		established,
+		tcpRecord(testServerIP, 443, "10.0.0.1", 50000, 0x1b, 1030),
+		tcpRecord("10.0.0.2", 50001, testServerIP, 443, 0x02, 2000),
+		tcpRecord(testServerIP, 443, "10.0.0.2", 50001, 0x14, 2001),
+	}
+}
+
+func processTCPQuality(t *testing.T, protocol string) *tcpQualityCounters {
+	t.Helper()
+	t.Setenv("TCP_QUALITY_RTT_FIELDS", "")
+	p := NewTCPQualityMetricProcessor()
+
+	received := time.Date(2026, 1, 10, 12, 1, 0, 0, time.UTC)
+	decoded := &netflow.DecodedIPFIXMessage{
+		Protocol:    protocol,
+		SrcIP:       testRouterIP,
+		Received:    received,
+		FlowRecords: tcpSessionRecords(),
+	}
+	if err := p.Process(nil, decoded); err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+
+	counters, ok := p.pending[tcpQualityKey{routerIP: testRouterIP, bucket: received.Truncate(tcpQualityBucket), servicePort: 443}]
+	if !ok {
+		t.Fatalf("sem contadores para a porta 443: %v", p.pending)
+	}
+	return counters
+}
+
+func TestTCPQualityIPFIXSessions(t *testing.T) {
+	counters := processTCPQuality(t, netflow.ProtocolIPFIX)
+
+	checks := map[string][2]uint64{
+		"flows":             {counters.flows, 4},
+		"flagFlows":         {counters.flagFlows, 4},
+		"synAttempts":       {counters.synAttempts, 2},
+		"synAcks":           {counters.synAcks, 1},
+		"handshakeFailures": {counters.handshakeFailures, 1},
+		"clientResets":      {counters.clientResets, 0},
+		"serverResets":      {counters.serverResets, 1},
+		"windowFlows":       {counters.windowFlows, 1},
+		"zeroWindowFlows":   {counters.zeroWindowFlows, 1},
+		"rttSamples":        {counters.rttSamples, 1},
+		"rttTotalMs":        {counters.rttTotalMs, 30},
+	}
+	for name, check := range checks {
+		if check[0] != check[1] {
+			t.Errorf("%s = %d, esperado %d", name, check[0], check[1])
+		}
+	}
+}
+
+func TestTCPQualitySFlowSamples(t *testing.T) {
+	counters := processTCPQuality(t, netflow.ProtocolSFlow)
+
+	if counters.flows != 4 || counters.packets != 40 || counters.bytes != 4000 {
+		t.Errorf("volume: %d flows, %d pacotes, %d bytes", counters.flows, counters.packets, counters.bytes)
+	}
+	if counters.flagFlows != 0 || counters.synAttempts != 0 || counters.synAcks != 0 || counters.handshakeFailures != 0 {
+		t.Errorf("handshakes contados em amostras sFlow: %+v", *counters)
+	}
+	if counters.clientResets != 0 || counters.serverResets != 0 || counters.rttSamples != 0 {
+		t.Errorf("RSTs/RTT contados em amostras sFlow: %+v", *counters)
+	}
+	if counters.zeroWindowFlows != 1 {
+		t.Errorf("zeroWindowFlows = %d, esperado 1", counters.zeroWindowFlows)
+	}
+}
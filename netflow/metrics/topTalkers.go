@@ -0,0 +1,301 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net_monitor/interfaces"
+	"net_monitor/netflow"
+	utils "net_monitor/utils"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	TopTalkerSourceIP     = "srcIp"
+	TopTalkerDestIP       = "dstIp"
+	TopTalkerPort         = "port"
+	TopTalkerProtocol     = "protocol"
+	TopTalkerConversation = "conversation"
+	TopTalkerIngressIf    = "ingressInterface"
+	TopTalkerEgressIf     = "egressInterface"
+	TopTalkerSourceAS     = "srcAs"
+	TopTalkerDestAS       = "dstAs"
+	TopTalkerSourceCC     = "srcCountry"
+	TopTalkerDestCC       = "dstCountry"
+	TopTalkerCustomer     = "customer"
+
+	topTalkersBucket = time.Minute
+)
+
+var TopTalkerDimensions = []string{
+	TopTalkerSourceIP,
+	TopTalkerDestIP,
+	TopTalkerPort,
+	TopTalkerProtocol,
+	TopTalkerConversation,
+	TopTalkerIngressIf,
+	TopTalkerEgressIf,
+	TopTalkerSourceAS,
+	TopTalkerDestAS,
+	TopTalkerSourceCC,
+	TopTalkerDestCC,
+	TopTalkerCustomer,
+}
+
+// TopTalkersMetric é o top-N de um roteador em um bucket de 1 minuto. Cada
+// dimensão guarda apenas as entradas de maior volume.
+type TopTalkersMetric struct {
+	ID           primitive.ObjectID            `bson:"_id,omitempty" json:"id,omitempty"`
+	RouterID     primitive.ObjectID            `bson:"routerId,omitempty" json:"routerId,omitempty"`
+	RouterIP     string                        `bson:"routerIp" json:"routerIp"`
+	Timestamp    primitive.DateTime            `bson:"timestamp" json:"timestamp"`
+	TotalBytes   uint64                        `bson:"totalBytes" json:"totalBytes"`
+	TotalPackets uint64                        `bson:"totalPackets" json:"totalPackets"`
+	TotalFlows   uint64                        `bson:"totalFlows" json:"totalFlows"`
+	Dimensions   map[string][]HeavyHitterEntry `bson:"dimensions" json:"dimensions"`
+	CreatedAt    primitive.DateTime            `bson:"createdAt" json:"createdAt"`
+}
+
+type topTalkersBucketState struct {
+	routerID     primitive.ObjectID
+	routerIP     string
+	start        time.Time
+	totalBytes   uint64
+	totalPackets uint64
+	totalFlows   uint64
+	sketches     map[string]*SpaceSaving
+}
+
+type bucketKey struct {
+	routerIP string
+	start    time.Time
+}
+
+type TopTalkersMetricProcessor struct {
+	collection *mongo.Collection
+	capacity   int
+	keep       int
+	mu         sync.Mutex
+	buckets    map[bucketKey]*topTalkersBucketState
+}
+
+func NewTopTalkersMetricProcessor() *TopTalkersMetricProcessor {
+	p := &TopTalkersMetricProcessor{
+		capacity: utils.EnvPositiveInt("TOPTALKERS_CAPACITY", 1000),
+		keep:     utils.EnvPositiveInt("TOPTALKERS_KEEP", 100),
+		buckets:  make(map[bucketKey]*topTalkersBucketState),
+	}
+
+	ctx := netflow.GetMetricContext()
+	if ctx == nil || ctx.DB == nil {
+		log.Printf("[TopTalkers] Aviso: MetricContext não inicializado")
+		return p
+	}
+	p.collection = ctx.DB.Collection("top_talkers")
+
+	go p.flushLoop()
+	return p
+}
+
+func (p *TopTalkersMetricProcessor) Name() string {
+	return "top_talkers_analyzer"
+}
+
+func (p *TopTalkersMetricProcessor) Process(device interfaces.NetworkDevice, decoded *netflow.DecodedIPFIXMessage) error {
+	if len(decoded.FlowRecords) == 0 {
+		return nil
+	}
+
+	received := decoded.Received
+	if received.IsZero() {
+		received = time.Now()
+	}
+	key := bucketKey{routerIP: decoded.SrcIP, start: received.Truncate(topTalkersBucket)}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	bucket, ok := p.buckets[key]
+	if !ok {
+		bucket = &topTalkersBucketState{
+			routerIP: decoded.SrcIP,
+			start:    key.start,
+			sketches: make(map[string]*SpaceSaving, len(TopTalkerDimensions)),
+		}
+		for _, dimension := range TopTalkerDimensions {
+			bucket.sketches[dimension] = NewSpaceSaving(p.capacity)
+		}
+		p.buckets[key] = bucket
+	}
+	if device != nil {
+		bucket.routerID = deviceObjectID(device)
+	}
+
+	for _, record := range decoded.FlowRecords {
+		bytes, packets := record.OctetDeltaCount, record.PacketDeltaCount
+		bucket.totalBytes += bytes
+		bucket.totalPackets += packets
+		bucket.totalFlows++
+
+		src, dst := flowAddresses(record)
+		if src != "" {
+			bucket.sketches[TopTalkerSourceIP].Add(src, bytes, packets)
+		}
+		if dst != "" {
+			bucket.sketches[TopTalkerDestIP].Add(dst, bytes, packets)
+		}
+		if src != "" && dst != "" {
+			bucket.sketches[TopTalkerConversation].Add(conversationKey(src, dst), bytes, packets)
+		}
+
+		protocol := protocolName(record.ProtocolIdentifier)
+		bucket.sketches[TopTalkerProtocol].Add(protocol, bytes, packets)
+		if port := servicePort(record.SourceTransportPort, record.DestinationTransportPort); port != 0 {
+			bucket.sketches[TopTalkerPort].Add(fmt.Sprintf("%s/%d", protocol, port), bytes, packets)
+		}
+
+		if record.IngressInterface != 0 {
+			bucket.sketches[TopTalkerIngressIf].Add(strconv.FormatUint(uint64(record.IngressInterface), 10), bytes, packets)
+		}
+		if record.EgressInterface != 0 {
+			bucket.sketches[TopTalkerEgressIf].Add(strconv.FormatUint(uint64(record.EgressInterface), 10), bytes, packets)
+		}
+
+		// Dimensões preenchidas pelo enriquecimento (ASN, país e cliente).
+		if record.SourceAS != 0 {
+			bucket.sketches[TopTalkerSourceAS].Add(asKey(record.SourceAS, record.SourceASName), bytes, packets)
+		}
+		if record.DestinationAS != 0 {
+			bucket.sketches[TopTalkerDestAS].Add(asKey(record.DestinationAS, record.DestinationASName), bytes, packets)
+		}
+		if record.SourceCountry != "" {
+			bucket.sketches[TopTalkerSourceCC].Add(record.SourceCountry, bytes, packets)
+		}
+		if record.DestinationCountry != "" {
+			bucket.sketches[TopTalkerDestCC].Add(record.DestinationCountry, bytes, packets)
+		}
+		if record.SourceCustomer != "" {
+			bucket.sketches[TopTalkerCustomer].Add(record.SourceCustomer, bytes, packets)
+		}
+		if record.DestinationCustomer != "" && record.DestinationCustomer != record.SourceCustomer {
+			bucket.sketches[TopTalkerCustomer].Add(record.DestinationCustomer, bytes, packets)
+		}
+	}
+
+	return nil
+}
+
+// flushLoop grava os buckets de minutos já encerrados. Flows atrasados de um
+// minuto já gravado viram um segundo documento, somado na consulta.
+func (p *TopTalkersMetricProcessor) flushLoop() {
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		current := time.Now().Truncate(topTalkersBucket)
+
+		p.mu.Lock()
+		var ready []*topTalkersBucketState
+		for key, bucket := range p.buckets {
+			if key.start.Before(current) {
+				ready = append(ready, bucket)
+				delete(p.buckets, key)
+			}
+		}
+		p.mu.Unlock()
+
+		for _, bucket := range ready {
+			if err := p.save(bucket); err != nil {
+				log.Printf("[TopTalkers] Erro ao salvar bucket de %s: %v", bucket.routerIP, err)
+			}
+		}
+	}
+}
+
+func (p *TopTalkersMetricProcessor) save(bucket *topTalkersBucketState) error {
+	if p.collection == nil {
+		return nil
+	}
+
+	metric := TopTalkersMetric{
+		RouterID:     bucket.routerID,
+		RouterIP:     bucket.routerIP,
+		Timestamp:    primitive.NewDateTimeFromTime(bucket.start),
+		TotalBytes:   bucket.totalBytes,
+		TotalPackets: bucket.totalPackets,
+		TotalFlows:   bucket.totalFlows,
+		Dimensions:   make(map[string][]HeavyHitterEntry, len(bucket.sketches)),
+		CreatedAt:    primitive.NewDateTimeFromTime(time.Now()),
+	}
+	for dimension, sketch := range bucket.sketches {
+		metric.Dimensions[dimension] = sketch.Top(p.keep)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := p.collection.InsertOne(ctx, metric)
+	return err
+}
+
+func flowAddresses(record netflow.FlowRecord) (string, string) {
+	if record.SourceIPv4Address != "" || record.DestinationIPv4Address != "" {
+		return record.SourceIPv4Address, record.DestinationIPv4Address
+	}
+	return record.SourceIPv6Address, record.DestinationIPv6Address
+}
+
+// Os dois sentidos de uma conversa caem na mesma chave.
+func conversationKey(a, b string) string {
+	if a > b {
+		a, b = b, a
+	}
+	return a + " <-> " + b
+}
+
+// servicePort escolhe a menor porta não nula, que normalmente é a do serviço.
+func servicePort(src, dst uint16) uint16 {
+	switch {
+	case src == 0:
+		return dst
+	case dst == 0:
+		return src
+	case src < dst:
+		return src
+	default:
+		return dst
+	}
+}
+
+func asKey(number uint32, name string) string {
+	if name == "" {
+		return fmt.Sprintf("AS%d", number)
+	}
+	return fmt.Sprintf("AS%d %s", number, name)
+}
+
+func protocolName(protocol uint8) string {
+	switch protocol {
+	case 1:
+		return "icmp"
+	case 6:
+		return "tcp"
+	case 17:
+		return "udp"
+	case 47:
+		return "gre"
+	case 50:
+		return "esp"
+	case 58:
+		return "icmpv6"
+	case 132:
+		return "sctp"
+	default:
+		return strconv.Itoa(int(protocol))
+	}
+}
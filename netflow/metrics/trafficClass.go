@@ -0,0 +1,303 @@
+package metrics
+
+import (
+	"context"
+	"log"
+	"net/netip"
+	"net_monitor/interfaces"
+	models "net_monitor/models"
+	"net_monitor/netflow"
+	utils "net_monitor/utils"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const trafficClassBucket = 5 * time.Minute
+
+// TrafficClassMetric é o volume de uma classe de aplicação em um roteador
+// durante 5 minutos.
+type TrafficClassMetric struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	RouterID  primitive.ObjectID `bson:"routerId,omitempty" json:"routerId,omitempty"`
+	RouterIP  string             `bson:"routerIp" json:"routerIp"`
+	Timestamp primitive.DateTime `bson:"timestamp" json:"timestamp"`
+	Class     string             `bson:"class" json:"class"`
+	Bytes     uint64             `bson:"bytes" json:"bytes"`
+	Packets   uint64             `bson:"packets" json:"packets"`
+	Flows     uint64             `bson:"flows" json:"flows"`
+	CreatedAt primitive.DateTime `bson:"createdAt" json:"createdAt"`
+	UpdatedAt primitive.DateTime `bson:"updatedAt" json:"updatedAt"`
+}
+
+type classRule struct {
+	class     string
+	protocols []uint8
+	ports     []models.PortRange
+	prefixes  []netip.Prefix
+	asns      []uint32
+	dscp      []uint8
+}
+
+func (r classRule) matches(record netflow.FlowRecord, src, dst netip.Addr) bool {
+	if len(r.protocols) > 0 && !containsValue(r.protocols, record.ProtocolIdentifier) {
+		return false
+	}
+	if len(r.dscp) > 0 && !containsValue(r.dscp, record.IPClassOfService>>2) {
+		return false
+	}
+	if len(r.ports) > 0 && !inPortRanges(r.ports, record.SourceTransportPort) && !inPortRanges(r.ports, record.DestinationTransportPort) {
+		return false
+	}
+	if len(r.asns) > 0 && !containsValue(r.asns, record.SourceAS) && !containsValue(r.asns, record.DestinationAS) {
+		return false
+	}
+	if len(r.prefixes) > 0 && !inPrefixes(r.prefixes, src) && !inPrefixes(r.prefixes, dst) {
+		return false
+	}
+	return true
+}
+
+type trafficClassKey struct {
+	routerIP string
+	bucket   time.Time
+	class    string
+}
+
+type trafficClassCounters struct {
+	routerID primitive.ObjectID
+	bytes    uint64
+	packets  uint64
+	flows    uint64
+}
+
+// TrafficClassMetricProcessor classifica cada flow pelas regras de
+// traffic_class_rules e acumula bytes por classe, roteador e 5 minutos.
+type TrafficClassMetricProcessor struct {
+	rulesCollection *mongo.Collection
+	collection      *mongo.Collection
+	rules           []classRule
+	pending         map[trafficClassKey]*trafficClassCounters
+	mu              sync.Mutex
+	reloadMu        sync.Mutex
+}
+
+func NewTrafficClassMetricProcessor() *TrafficClassMetricProcessor {
+	p := &TrafficClassMetricProcessor{
+		pending: make(map[trafficClassKey]*trafficClassCounters),
+	}
+
+	ctx := netflow.GetMetricContext()
+	if ctx == nil || ctx.DB == nil {
+		log.Printf("[TrafficClass] Aviso: MetricContext não inicializado")
+		return p
+	}
+	p.rulesCollection = ctx.DB.Collection("traffic_class_rules")
+	p.collection = ctx.DB.Collection("traffic_class_metrics")
+
+	p.Reload()
+	go p.reloadLoop(time.Duration(utils.EnvPositiveInt("TRAFFIC_CLASS_RELOAD_SECONDS", 60)) * time.Second)
+	go p.flushLoop()
+	return p
+}
+
+func (p *TrafficClassMetricProcessor) Name() string {
+	return "traffic_class_analyzer"
+}
+
+// Reload recarrega as regras ativas. Regras com prefixo inválido perdem só o
+// prefixo; a validação do cadastro já impede que isso aconteça.
+func (p *TrafficClassMetricProcessor) Reload() {
+	if p.rulesCollection == nil {
+		return
+	}
+	p.reloadMu.Lock()
+	defer p.reloadMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "priority", Value: 1}, {Key: "name", Value: 1}})
+	cursor, err := p.rulesCollection.Find(ctx, bson.M{"active": true}, opts)
+	if err != nil {
+		log.Printf("[TrafficClass] Erro carregando regras: %v", err)
+		return
+	}
+	var stored []models.TrafficClassRule
+	if err := cursor.All(ctx, &stored); err != nil {
+		log.Printf("[TrafficClass] Erro decodificando regras: %v", err)
+		return
+	}
+
+	p.mu.Lock()
+	p.rules = compileClassRules(stored)
+	p.mu.Unlock()
+}
+
+func (p *TrafficClassMetricProcessor) reloadLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		p.Reload()
+	}
+}
+
+func (p *TrafficClassMetricProcessor) Process(device interfaces.NetworkDevice, decoded *netflow.DecodedIPFIXMessage) error {
+	if len(decoded.FlowRecords) == 0 {
+		return nil
+	}
+
+	received := decoded.Received
+	if received.IsZero() {
+		received = time.Now()
+	}
+	bucket := received.Truncate(trafficClassBucket)
+	routerID := deviceObjectID(device)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, record := range decoded.FlowRecords {
+		key := trafficClassKey{routerIP: decoded.SrcIP, bucket: bucket, class: classify(p.rules, record)}
+		counters, ok := p.pending[key]
+		if !ok {
+			counters = &trafficClassCounters{}
+			p.pending[key] = counters
+		}
+		if !routerID.IsZero() {
+			counters.routerID = routerID
+		}
+		counters.bytes += record.OctetDeltaCount
+		counters.packets += record.PacketDeltaCount
+		counters.flows++
+	}
+
+	return nil
+}
+
+func (p *TrafficClassMetricProcessor) flushLoop() {
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		p.mu.Lock()
+		pending := p.pending
+		p.pending = make(map[trafficClassKey]*trafficClassCounters)
+		p.mu.Unlock()
+
+		for key, counters := range pending {
+			if err := p.save(key, counters); err != nil {
+				log.Printf("[TrafficClass] Erro ao salvar classe %s de %s: %v", key.class, key.routerIP, err)
+			}
+		}
+	}
+}
+
+func (p *TrafficClassMetricProcessor) save(key trafficClassKey, counters *trafficClassCounters) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := primitive.NewDateTimeFromTime(time.Now())
+	filter := bson.M{
+		"routerIp":  key.routerIP,
+		"timestamp": primitive.NewDateTimeFromTime(key.bucket),
+		"class":     key.class,
+	}
+	update := bson.M{
+		"$inc": bson.M{
+			"bytes":   counters.bytes,
+			"packets": counters.packets,
+			"flows":   counters.flows,
+		},
+		"$set": bson.M{
+			"updatedAt": now,
+		},
+		"$setOnInsert": bson.M{
+			"createdAt": now,
+		},
+	}
+	if !counters.routerID.IsZero() {
+		update["$set"].(bson.M)["routerId"] = counters.routerID
+	}
+
+	_, err := p.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	return err
+}
+
+func compileClassRules(stored []models.TrafficClassRule) []classRule {
+	sort.SliceStable(stored, func(i, j int) bool { return stored[i].Priority < stored[j].Priority })
+
+	rules := make([]classRule, 0, len(stored))
+	for _, rule := range stored {
+		compiled := classRule{
+			class:     rule.Class,
+			protocols: rule.Protocols,
+			ports:     rule.Ports,
+			asns:      rule.ASNs,
+			dscp:      rule.DSCP,
+		}
+		for _, value := range rule.Prefixes {
+			if prefix, err := parseAddressOrPrefix(value); err == nil {
+				compiled.prefixes = append(compiled.prefixes, prefix.Masked())
+			}
+		}
+		rules = append(rules, compiled)
+	}
+	return rules
+}
+
+func classify(rules []classRule, record netflow.FlowRecord) string {
+	var src, dst netip.Addr
+	srcIP, dstIP := flowAddresses(record)
+	if addr, err := netip.ParseAddr(srcIP); err == nil {
+		src = addr.Unmap()
+	}
+	if addr, err := netip.ParseAddr(dstIP); err == nil {
+		dst = addr.Unmap()
+	}
+
+	for _, rule := range rules {
+		if rule.matches(record, src, dst) {
+			return rule.class
+		}
+	}
+	return models.TrafficClassOther
+}
+
+func containsValue[T comparable](values []T, value T) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
+
+func inPortRanges(ranges []models.PortRange, port uint16) bool {
+	if port == 0 {
+		return false
+	}
+	for _, portRange := range ranges {
+		if port >= portRange.Start && port <= portRange.End {
+			return true
+		}
+	}
+	return false
+}
+
+func inPrefixes(prefixes []netip.Prefix, addr netip.Addr) bool {
+	if !addr.IsValid() {
+		return false
+	}
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,243 @@
+package netflow
+
+import (
+	"errors"
+	"log"
+	utils "net_monitor/utils"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+const (
+	natsFetchBatch = 64
+	natsAckWait    = 30 * time.Second
+)
+
+// NATSQueue usa um stream JetStream com retenção work queue por fila. O
+// cliente reconecta sozinho; o stream é criado em segundo plano quando o
+// servidor responder. O stream é limitado por idade (NATS_STREAM_MAX_AGE_HOURS)
+// e tamanho (NATS_STREAM_MAX_MB); acima disso as mensagens mais antigas saem.
+type NATSQueue struct {
+	name    string
+	subject string
+	conn    *nats.Conn
+	js      nats.JetStreamContext
+
+	maxAge   time.Duration
+	maxBytes int64
+
+	mu        sync.RWMutex
+	ready     bool
+	consuming bool
+
+	deliveries chan Delivery
+	done       chan struct{}
+	once       sync.Once
+}
+
+func NewNATSQueue(url, name string) (*NATSQueue, error) {
+	q := &NATSQueue{
+		name:       name,
+		subject:    "netflow." + name,
+		maxAge:     time.Duration(utils.EnvPositiveInt("NATS_STREAM_MAX_AGE_HOURS", 24)) * time.Hour,
+		maxBytes:   int64(utils.EnvPositiveInt("NATS_STREAM_MAX_MB", 1024)) << 20,
+		deliveries: make(chan Delivery),
+		done:       make(chan struct{}),
+	}
+
+	conn, err := nats.Connect(url,
+		nats.Name("net_monitor-"+name),
+		nats.RetryOnFailedConnect(true),
+		nats.MaxReconnects(-1),
+		nats.ReconnectWait(2*time.Second),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			log.Printf("NATS desconectado (fila %s): %v", name, err)
+		}),
+		nats.ReconnectHandler(func(_ *nats.Conn) {
+			log.Printf("NATS reconectado (fila %s)", name)
+		}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	js, err := conn.JetStream(nats.PublishAsyncMaxPending(4096))
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	q.conn = conn
+	q.js = js
+
+	go q.setup()
+	return q, nil
+}
+
+func (q *NATSQueue) setup() {
+	for {
+		err := q.ensureStream()
+		if err == nil {
+			break
+		}
+		log.Printf("JetStream indisponível para %s, nova tentativa: %v", q.name, err)
+		select {
+		case <-q.done:
+			return
+		case <-time.After(2 * time.Second):
+		}
+	}
+
+	q.mu.Lock()
+	q.ready = true
+	consuming := q.consuming
+	q.mu.Unlock()
+	log.Printf("NATS JetStream pronto - stream %s", q.name)
+
+	if consuming {
+		go q.fetchLoop()
+	}
+}
+
+// ensureStream cria o stream ou ajusta os limites de um já existente.
+func (q *NATSQueue) ensureStream() error {
+	info, err := q.js.StreamInfo(q.name)
+	if errors.Is(err, nats.ErrStreamNotFound) {
+		_, err = q.js.AddStream(&nats.StreamConfig{
+			Name:      q.name,
+			Subjects:  []string{q.subject},
+			Retention: nats.WorkQueuePolicy,
+			Storage:   nats.FileStorage,
+			Discard:   nats.DiscardOld,
+			MaxAge:    q.maxAge,
+			MaxBytes:  q.maxBytes,
+		})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	config := info.Config
+	if config.MaxAge == q.maxAge && config.MaxBytes == q.maxBytes && config.Discard == nats.DiscardOld {
+		return nil
+	}
+	config.Discard = nats.DiscardOld
+	config.MaxAge = q.maxAge
+	config.MaxBytes = q.maxBytes
+	_, err = q.js.UpdateStream(&config)
+	return err
+}
+
+func (q *NATSQueue) Publish(contentType string, body []byte) error {
+	q.mu.RLock()
+	ready := q.ready
+	q.mu.RUnlock()
+	if !ready || !q.conn.IsConnected() {
+		return ErrQueueUnavailable
+	}
+
+	msg := nats.NewMsg(q.subject)
+	msg.Header.Set("Content-Type", contentType)
+	msg.Data = body
+	_, err := q.js.PublishMsgAsync(msg)
+	return err
+}
+
+func (q *NATSQueue) Consume() (<-chan Delivery, error) {
+	q.mu.Lock()
+	alreadyConsuming := q.consuming
+	q.consuming = true
+	ready := q.ready
+	q.mu.Unlock()
+
+	if !alreadyConsuming && ready {
+		go q.fetchLoop()
+	}
+	return q.deliveries, nil
+}
+
+// fetchLoop usa um consumidor pull durável compartilhado por todas as
+// instâncias, de modo que cada mensagem vai para um único worker.
+func (q *NATSQueue) fetchLoop() {
+	var sub *nats.Subscription
+	for sub == nil {
+		var err error
+		sub, err = q.js.PullSubscribe(q.subject, q.name+"_workers", nats.AckWait(natsAckWait), nats.ManualAck())
+		if err != nil {
+			log.Printf("Erro assinando %s no JetStream: %v", q.subject, err)
+			select {
+			case <-q.done:
+				return
+			case <-time.After(2 * time.Second):
+			}
+		}
+	}
+
+	for {
+		select {
+		case <-q.done:
+			return
+		default:
+		}
+
+		messages, err := sub.Fetch(natsFetchBatch, nats.MaxWait(5*time.Second))
+		if err != nil && !errors.Is(err, nats.ErrTimeout) {
+			if q.conn.IsClosed() {
+				return
+			}
+			time.Sleep(time.Second)
+			continue
+		}
+
+		for _, message := range messages {
+			m := message
+			delivery := Delivery{
+				ContentType: m.Header.Get("Content-Type"),
+				Body:        m.Data,
+				ack:         func() error { return m.Ack() },
+				nack: func(requeue bool) error {
+					if requeue {
+						return m.Nak()
+					}
+					return m.Term()
+				},
+			}
+			select {
+			case q.deliveries <- delivery:
+			case <-q.done:
+				return
+			}
+		}
+	}
+}
+
+// Depth devolve as mensagens retidas no stream; na work queue elas saem
+// do stream com o ack.
+func (q *NATSQueue) Depth() (int, error) {
+	q.mu.RLock()
+	ready := q.ready
+	q.mu.RUnlock()
+	if !ready || !q.conn.IsConnected() {
+		return 0, ErrQueueUnavailable
+	}
+
+	info, err := q.js.StreamInfo(q.name)
+	if err != nil {
+		return 0, err
+	}
+	return int(info.State.Msgs), nil
+}
+
+func (q *NATSQueue) Close() {
+	q.once.Do(func() {
+		close(q.done)
+		if q.js != nil {
+			select {
+			case <-q.js.PublishAsyncComplete():
+			case <-time.After(5 * time.Second):
+			}
+		}
+		q.conn.Close()
+	})
+}
@@ -0,0 +1,92 @@
+package netflow
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	netflowV5HeaderLength = 24
+	netflowV5RecordLength = 48
+)
+
+// DecodeNetflowV5 converte o formato fixo do NetFlow v5 nos mesmos FlowRecords
+// gerados para IPFIX, usando os nomes de campo IANA equivalentes.
+func DecodeNetflowV5(b []byte) (*DecodedIPFIXMessage, error) {
+	if len(b) < netflowV5HeaderLength {
+		return nil, fmt.Errorf("buffer menor que header NetFlow v5 (%d bytes)", netflowV5HeaderLength)
+	}
+
+	count := int(binary.BigEndian.Uint16(b[2:4]))
+	sysUptime := binary.BigEndian.Uint32(b[4:8])
+	unixSecs := binary.BigEndian.Uint32(b[8:12])
+	unixNsecs := binary.BigEndian.Uint32(b[12:16])
+	engineType := b[20]
+	engineID := b[21]
+	samplingInterval := binary.BigEndian.Uint16(b[22:24]) & 0x3FFF
+
+	expected := netflowV5HeaderLength + count*netflowV5RecordLength
+	if len(b) < expected {
+		return nil, fmt.Errorf("NetFlow v5 truncado: %d registros exigem %d bytes, recebidos %d", count, expected, len(b))
+	}
+
+	decoded := &DecodedIPFIXMessage{
+		Header: IPFIXHeader{
+			Version:           5,
+			Length:            uint16(expected),
+			ExportTime:        unixSecs,
+			SequenceNumber:    binary.BigEndian.Uint32(b[16:20]),
+			ObservationDomain: uint32(engineType)<<8 | uint32(engineID),
+			SysUptime:         sysUptime,
+		},
+		Templates:   []Template{},
+		FlowRecords: make([]FlowRecord, 0, count),
+	}
+
+	exportMs := uint64(unixSecs)*1000 + uint64(unixNsecs)/1000000
+
+	for i := 0; i < count; i++ {
+		r := b[netflowV5HeaderLength+i*netflowV5RecordLength : netflowV5HeaderLength+(i+1)*netflowV5RecordLength]
+
+		record := FlowRecord{
+			SourceIPv4Address:        decodeIPv4(r[0:4]),
+			DestinationIPv4Address:   decodeIPv4(r[4:8]),
+			IngressInterface:         uint32(binary.BigEndian.Uint16(r[12:14])),
+			EgressInterface:          uint32(binary.BigEndian.Uint16(r[14:16])),
+			PacketDeltaCount:         uint64(binary.BigEndian.Uint32(r[16:20])),
+			OctetDeltaCount:          uint64(binary.BigEndian.Uint32(r[20:24])),
+			FlowStartMilliseconds:    sysUptimeToEpochMs(exportMs, sysUptime, binary.BigEndian.Uint32(r[24:28])),
+			FlowEndMilliseconds:      sysUptimeToEpochMs(exportMs, sysUptime, binary.BigEndian.Uint32(r[28:32])),
+			SourceTransportPort:      binary.BigEndian.Uint16(r[32:34]),
+			DestinationTransportPort: binary.BigEndian.Uint16(r[34:36]),
+			ProtocolIdentifier:       r[38],
+			IPClassOfService:         r[39],
+			IPVersion:                4,
+			RawFields: map[string]interface{}{
+				getFieldName(15): decodeIPv4(r[8:12]),
+				getFieldName(6):  uint64(r[37]),
+				getFieldName(16): uint64(binary.BigEndian.Uint16(r[40:42])),
+				getFieldName(17): uint64(binary.BigEndian.Uint16(r[42:44])),
+				getFieldName(9):  uint64(r[44]),
+				getFieldName(13): uint64(r[45]),
+			},
+		}
+		if samplingInterval > 0 {
+			record.RawFields[getFieldName(34)] = uint64(samplingInterval)
+		}
+
+		decoded.FlowRecords = append(decoded.FlowRecords, record)
+	}
+
+	return decoded, nil
+}
+
+// sysUptimeToEpochMs converte um timestamp relativo ao sysUptime do exportador
+// (ms desde o boot) em epoch ms, usando o horário de exportação como referência.
+func sysUptimeToEpochMs(exportMs uint64, sysUptime uint32, value uint32) uint64 {
+	delta := uint64(sysUptime - value)
+	if delta > exportMs {
+		return 0
+	}
+	return exportMs - delta
+}
@@ -0,0 +1,203 @@
+package netflow
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+const netflowV9HeaderLength = 20
+
+// ParseNetflowV9 lê o header v9 e separa os FlowSets no mesmo formato usado
+// pelo IPFIX. O campo count do v9 conta registros, não bytes, por isso o
+// pacote inteiro é percorrido.
+func ParseNetflowV9(b []byte) (*IPFIXMessage, error) {
+	if len(b) < netflowV9HeaderLength {
+		return nil, errors.New("buffer menor que header NetFlow v9 (20 bytes)")
+	}
+
+	header := IPFIXHeader{
+		Version:           binary.BigEndian.Uint16(b[0:2]),
+		Length:            uint16(len(b)),
+		SysUptime:         binary.BigEndian.Uint32(b[4:8]),
+		ExportTime:        binary.BigEndian.Uint32(b[8:12]),
+		SequenceNumber:    binary.BigEndian.Uint32(b[12:16]),
+		ObservationDomain: binary.BigEndian.Uint32(b[16:20]),
+	}
+
+	msg := &IPFIXMessage{Header: header, FlowSets: []FlowSet{}}
+	offset := netflowV9HeaderLength
+	for offset+4 <= len(b) {
+		flowSetID := binary.BigEndian.Uint16(b[offset : offset+2])
+		flowSetLen := int(binary.BigEndian.Uint16(b[offset+2 : offset+4]))
+		if flowSetLen < 4 || offset+flowSetLen > len(b) {
+			break
+		}
+		msg.FlowSets = append(msg.FlowSets, FlowSet{
+			FlowSetID: flowSetID,
+			Length:    uint16(flowSetLen),
+			Payload:   append([]byte{}, b[offset+4:offset+flowSetLen]...),
+		})
+		offset += flowSetLen
+	}
+
+	return msg, nil
+}
+
+func DecodeNetflowV9(msg *IPFIXMessage, cache *TemplateCache, key TemplateKey) *DecodedIPFIXMessage {
+	decoded := &DecodedIPFIXMessage{
+		Header:      msg.Header,
+		Templates:   []Template{},
+		FlowRecords: []FlowRecord{},
+	}
+
+	for _, fs := range msg.FlowSets {
+		switch {
+		case fs.FlowSetID == 0 || fs.FlowSetID == 1:
+			var templates []Template
+			if fs.FlowSetID == 0 {
+				templates = parseV9TemplateFlowSet(fs.Payload)
+			} else {
+				templates = parseV9OptionsTemplateFlowSet(fs.Payload)
+			}
+			for _, tmpl := range templates {
+				cache.AddTemplate(key, &tmpl)
+				decoded.Templates = append(decoded.Templates, tmpl)
+
+				// Os timestamps usam o header do pacote original, não o atual.
+				for _, pending := range cache.TakePendingFlowSets(key, tmpl.TemplateID) {
+					records := parseDataFlowSet(pending.Payload, &tmpl)
+					applyV9RecordTimestamps(records, pending.Header)
+					appendDataRecords(decoded, cache, key, &tmpl, records)
+				}
+			}
+		case fs.FlowSetID >= 256:
+			template := cache.GetTemplate(key, fs.FlowSetID)
+			if template == nil {
+				cache.BufferFlowSet(key, fs.FlowSetID, fs.Payload, msg.Header)
+				decoded.templatesMissing++
+				continue
+			}
+			records := parseDataFlowSet(fs.Payload, template)
+			applyV9RecordTimestamps(records, msg.Header)
+			appendDataRecords(decoded, cache, key, template, records)
+		}
+	}
+
+	return decoded
+}
+
+func applyV9RecordTimestamps(records []FlowRecord, header IPFIXHeader) {
+	exportMs := uint64(header.ExportTime) * 1000
+	for i := range records {
+		applyV9Timestamps(&records[i], exportMs, header.SysUptime)
+	}
+}
+
+func parseV9TemplateFlowSet(payload []byte) []Template {
+	templates := []Template{}
+	offset := 0
+
+	for offset+4 <= len(payload) {
+		templateID := binary.BigEndian.Uint16(payload[offset : offset+2])
+		fieldCount := binary.BigEndian.Uint16(payload[offset+2 : offset+4])
+		if templateID < 256 {
+			break
+		}
+		offset += 4
+
+		template := Template{TemplateID: templateID, FieldCount: fieldCount, Fields: []TemplateField{}}
+		for i := 0; i < int(fieldCount) && offset+4 <= len(payload); i++ {
+			template.Fields = append(template.Fields, parseV9Field(payload[offset:offset+4]))
+			offset += 4
+		}
+
+		templates = append(templates, template)
+	}
+
+	return templates
+}
+
+// Options template v9: templateId, tamanho em bytes dos campos de escopo e
+// tamanho em bytes dos campos de opção, seguidos dos pares (tipo, tamanho).
+func parseV9OptionsTemplateFlowSet(payload []byte) []Template {
+	templates := []Template{}
+	offset := 0
+
+	for offset+6 <= len(payload) {
+		templateID := binary.BigEndian.Uint16(payload[offset : offset+2])
+		scopeLength := int(binary.BigEndian.Uint16(payload[offset+2 : offset+4]))
+		optionLength := int(binary.BigEndian.Uint16(payload[offset+4 : offset+6]))
+		if templateID < 256 {
+			break
+		}
+		offset += 6
+
+		scopeCount := scopeLength / 4
+		fieldCount := scopeCount + optionLength/4
+		if offset+fieldCount*4 > len(payload) {
+			break
+		}
+
+		template := Template{
+			TemplateID:      templateID,
+			FieldCount:      uint16(fieldCount),
+			ScopeFieldCount: uint16(scopeCount),
+			Fields:          []TemplateField{},
+		}
+		for i := 0; i < fieldCount; i++ {
+			field := parseV9Field(payload[offset : offset+4])
+			if i < scopeCount {
+				field.FieldName = v9ScopeFieldName(field.FieldID)
+				field.DataType = ""
+			}
+			template.Fields = append(template.Fields, field)
+			offset += 4
+		}
+
+		templates = append(templates, template)
+
+		// Cada options template é alinhado em 4 bytes.
+		if rem := offset % 4; rem != 0 {
+			offset += 4 - rem
+		}
+	}
+
+	return templates
+}
+
+func parseV9Field(b []byte) TemplateField {
+	fieldID := binary.BigEndian.Uint16(b[0:2])
+	field := TemplateField{
+		FieldID:     fieldID,
+		FieldLength: binary.BigEndian.Uint16(b[2:4]),
+	}
+	field.FieldName, field.DataType = defaultIERegistry.Describe(0, fieldID)
+	return field
+}
+
+// Os tipos de escopo do v9 (1-5) não coincidem com os elementos IANA de mesmo número.
+func v9ScopeFieldName(scopeType uint16) string {
+	switch scopeType {
+	case 1:
+		return "scopeSystem"
+	case 2:
+		return "scopeInterface"
+	case 3:
+		return "scopeLineCard"
+	case 4:
+		return "scopeCache"
+	case 5:
+		return "scopeTemplate"
+	default:
+		return getFieldName(scopeType)
+	}
+}
+
+func applyV9Timestamps(record *FlowRecord, exportMs uint64, sysUptime uint32) {
+	if first, ok := record.RawFields[getFieldName(22)].(uint64); ok && record.FlowStartMilliseconds == 0 {
+		record.FlowStartMilliseconds = sysUptimeToEpochMs(exportMs, sysUptime, uint32(first))
+	}
+	if last, ok := record.RawFields[getFieldName(21)].(uint64); ok && record.FlowEndMilliseconds == 0 {
+		record.FlowEndMilliseconds = sysUptimeToEpochMs(exportMs, sysUptime, uint32(last))
+	}
+}
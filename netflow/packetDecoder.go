@@ -0,0 +1,88 @@
+package netflow
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+const (
+	NetflowV5 uint16 = 5
+	NetflowV9 uint16 = 9
+	IPFIXV10  uint16 = 10
+)
+
+var ErrUnsupportedVersion = errors.New("versão de exportação não suportada")
+
+// DecodePacket identifica a versão pelos dois primeiros bytes e delega ao
+// decoder correspondente. Todos produzem um DecodedIPFIXMessage. O endereço
+// do exportador compõe a chave do cache de templates.
+func DecodePacket(b []byte, exporterIP string, exporterPort int, cache *TemplateCache) (*DecodedIPFIXMessage, error) {
+	if len(b) < 2 {
+		return nil, errors.New("pacote vazio")
+	}
+
+	var decoded *DecodedIPFIXMessage
+	switch version := binary.BigEndian.Uint16(b[0:2]); version {
+	case NetflowV5:
+		var err error
+		if decoded, err = DecodeNetflowV5(b); err != nil {
+			return nil, err
+		}
+		decoded.Protocol = ProtocolNetflowV5
+		applySampling(decoded, nil)
+	case NetflowV9:
+		msg, err := ParseNetflowV9(b)
+		if err != nil {
+			return nil, err
+		}
+		key := TemplateKey{ExporterIP: exporterIP, ExporterPort: exporterPort, ObservationDomain: msg.Header.ObservationDomain}
+		decoded = DecodeNetflowV9(msg, cache, key)
+		decoded.Protocol = ProtocolNetflowV9
+		applySampling(decoded, cache.GetExporterOptions(key))
+	case IPFIXV10:
+		msg, err := ParseIPFIX(b)
+		if err != nil {
+			return nil, err
+		}
+		key := TemplateKey{ExporterIP: exporterIP, ExporterPort: exporterPort, ObservationDomain: msg.Header.ObservationDomain}
+		decoded = DecodeIPFIX(msg, cache, key)
+		decoded.Protocol = ProtocolIPFIX
+		applySampling(decoded, cache.GetExporterOptions(key))
+	default:
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, version)
+	}
+
+	return decoded, nil
+}
+
+// applySampling multiplica bytes e pacotes pelo intervalo de amostragem
+// reportado no próprio registro ou, na falta dele, pelo options template do
+// exportador. Registros que já vêm escalados (sFlow) mantêm SamplingInterval.
+func applySampling(decoded *DecodedIPFIXMessage, options *ExporterOptions) {
+	for i := range decoded.FlowRecords {
+		record := &decoded.FlowRecords[i]
+		if record.SamplingInterval > 0 {
+			continue
+		}
+
+		interval := samplingIntervalFromFields(record.RawFields)
+		if interval == 0 && options != nil {
+			if samplerID, ok := RawUint(record.RawFields["samplerId"]); ok {
+				interval = options.SamplerIntervals[samplerID]
+			} else if selectorID, ok := RawUint(record.RawFields["selectorId"]); ok {
+				interval = options.SamplerIntervals[selectorID]
+			}
+			if interval == 0 {
+				interval = options.SamplingInterval
+			}
+		}
+		if interval <= 1 {
+			continue
+		}
+
+		record.SamplingInterval = interval
+		record.OctetDeltaCount *= interval
+		record.PacketDeltaCount *= interval
+	}
+}
@@ -0,0 +1,366 @@
+package netflow
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	maxTrackedExporters  = 10000
+	maxRecentDeadLetters = 20
+	// Saltos de sequência maiores que isso são tratados como reinício do
+	// exportador, não como perda.
+	maxSequenceJump = 1 << 24
+	// Pacotes seguidos abaixo do esperado indicam que o exportador reiniciou
+	// a contagem, não que chegaram fora de ordem.
+	maxBehindInARow = 3
+)
+
+type ExporterStats struct {
+	ExporterIP       string    `json:"exporterIp"`
+	Protocol         string    `json:"protocol,omitempty"`
+	PacketsReceived  uint64    `json:"packetsReceived"`
+	BytesReceived    uint64    `json:"bytesReceived"`
+	ParseErrors      uint64    `json:"parseErrors"`
+	TemplatesMissing uint64    `json:"templatesMissing"`
+	RecordsDecoded   uint64    `json:"recordsDecoded"`
+	SequenceGaps     uint64    `json:"sequenceGaps"`
+	EstimatedLoss    uint64    `json:"estimatedLoss"`
+	LossUnit         string    `json:"lossUnit,omitempty"`
+	LastSeen         time.Time `json:"lastSeen"`
+}
+
+type ProcessorStats struct {
+	Name         string    `json:"name"`
+	Processed    uint64    `json:"processed"`
+	Errors       uint64    `json:"errors"`
+	Retries      uint64    `json:"retries"`
+	DeadLettered uint64    `json:"deadLettered"`
+	AvgLatencyMs float64   `json:"avgLatencyMs"`
+	MaxLatencyMs float64   `json:"maxLatencyMs"`
+	LastError    string    `json:"lastError,omitempty"`
+	LastErrorAt  time.Time `json:"lastErrorAt,omitempty"`
+
+	totalLatency time.Duration
+	maxLatency   time.Duration
+}
+
+type ListenerStats struct {
+	ReadErrors    uint64 `json:"readErrors"`
+	PublishErrors uint64 `json:"publishErrors"`
+}
+
+type DecoderStats struct {
+	UnmarshalErrors uint64 `json:"unmarshalErrors"`
+	PublishErrors   uint64 `json:"publishErrors"`
+}
+
+type QueueStatus struct {
+	Name  string `json:"name"`
+	Depth int    `json:"depth"`
+	Error string `json:"error,omitempty"`
+}
+
+type DeadLetterSummary struct {
+	Source   string    `json:"source"`
+	Reason   string    `json:"reason"`
+	Size     int       `json:"size"`
+	FailedAt time.Time `json:"failedAt"`
+}
+
+type PipelineStatus struct {
+	StartedAt         time.Time           `json:"startedAt"`
+	UptimeSeconds     int64               `json:"uptimeSeconds"`
+	Listener          ListenerStats       `json:"listener"`
+	Decoder           DecoderStats        `json:"decoder"`
+	DeadLetters       uint64              `json:"deadLetters"`
+	RecentDeadLetters []DeadLetterSummary `json:"recentDeadLetters"`
+	Queues            []QueueStatus       `json:"queues"`
+	Exporters         []ExporterStats     `json:"exporters"`
+	Processors        []ProcessorStats    `json:"processors"`
+}
+
+// QueueDepth é implementado pelas filas que sabem informar o backlog.
+type QueueDepth interface {
+	Depth() (int, error)
+}
+
+type sequenceKey struct {
+	exporterIP        string
+	exporterPort      int
+	observationDomain uint32
+	protocol          string
+}
+
+// sequenceState guarda o próximo número esperado. Como os decoders rodam em
+// paralelo, um pacote pode chegar depois do seguinte; a perda registrada é
+// devolvida quando o atrasado aparece.
+type sequenceState struct {
+	next       uint32
+	valid      bool
+	recentLoss uint64
+	behind     int
+}
+
+type pipelineStats struct {
+	mu                sync.Mutex
+	startedAt         time.Time
+	listener          ListenerStats
+	decoder           DecoderStats
+	deadLetters       uint64
+	recentDeadLetters []DeadLetterSummary
+	exporters         map[string]*ExporterStats
+	processors        map[string]*ProcessorStats
+	sequences         map[sequenceKey]*sequenceState
+	queues            map[string]Queue
+}
+
+var stats = &pipelineStats{
+	startedAt:  time.Now(),
+	exporters:  make(map[string]*ExporterStats),
+	processors: make(map[string]*ProcessorStats),
+	sequences:  make(map[sequenceKey]*sequenceState),
+	queues:     make(map[string]Queue),
+}
+
+// RegisterStatusQueue inclui a fila no status com o tamanho do backlog.
+func RegisterStatusQueue(name string, queue Queue) {
+	stats.mu.Lock()
+	defer stats.mu.Unlock()
+	stats.queues[name] = queue
+}
+
+// exporter precisa ser chamado com o lock.
+func (s *pipelineStats) exporter(ip string) *ExporterStats {
+	exporter, ok := s.exporters[ip]
+	if !ok {
+		if len(s.exporters) >= maxTrackedExporters {
+			return &ExporterStats{}
+		}
+		exporter = &ExporterStats{ExporterIP: ip}
+		s.exporters[ip] = exporter
+	}
+	return exporter
+}
+
+func (s *pipelineStats) packetReceived(ip string, size int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	exporter := s.exporter(ip)
+	exporter.PacketsReceived++
+	exporter.BytesReceived += uint64(size)
+	exporter.LastSeen = time.Now()
+}
+
+func (s *pipelineStats) listenerReadError() {
+	s.mu.Lock()
+	s.listener.ReadErrors++
+	s.mu.Unlock()
+}
+
+// listenerPublishError conta as mensagens perdidas, não as publicações: um
+// lote binário que falha descarta todas as mensagens dele.
+func (s *pipelineStats) listenerPublishError(messages int) {
+	s.mu.Lock()
+	s.listener.PublishErrors += uint64(messages)
+	s.mu.Unlock()
+}
+
+func (s *pipelineStats) decoderUnmarshalError() {
+	s.mu.Lock()
+	s.decoder.UnmarshalErrors++
+	s.mu.Unlock()
+}
+
+func (s *pipelineStats) decoderPublishError() {
+	s.mu.Lock()
+	s.decoder.PublishErrors++
+	s.mu.Unlock()
+}
+
+func (s *pipelineStats) parseError(ip string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.exporter(ip).ParseErrors++
+}
+
+// packetDecoded contabiliza registros, templates ausentes e a sequência.
+// Cada protocolo numera uma coisa: IPFIX conta registros de dados, v5 conta
+// flows e v9/sFlow contam pacotes.
+func (s *pipelineStats) packetDecoded(ip string, port int, decoded *DecodedIPFIXMessage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	exporter := s.exporter(ip)
+	exporter.Protocol = decoded.Protocol
+	exporter.RecordsDecoded += uint64(len(decoded.FlowRecords) + len(decoded.OptionRecords))
+	exporter.TemplatesMissing += uint64(decoded.templatesMissing)
+
+	var increment uint32
+	known := true
+	switch decoded.Protocol {
+	case ProtocolIPFIX:
+		exporter.LossUnit = "records"
+		increment = uint32(decoded.dataRecords)
+		// Sets sem template têm registros que não sabemos contar.
+		known = decoded.templatesMissing == 0
+	case ProtocolNetflowV5:
+		exporter.LossUnit = "flows"
+		increment = uint32(len(decoded.FlowRecords))
+	case ProtocolNetflowV9, ProtocolSFlow:
+		exporter.LossUnit = "packets"
+		increment = 1
+	default:
+		return
+	}
+
+	key := sequenceKey{
+		exporterIP:        ip,
+		exporterPort:      port,
+		observationDomain: decoded.Header.ObservationDomain,
+		protocol:          decoded.Protocol,
+	}
+	state, ok := s.sequences[key]
+	if !ok {
+		if len(s.sequences) >= maxTrackedExporters {
+			return
+		}
+		state = &sequenceState{}
+		s.sequences[key] = state
+	}
+
+	sequence := decoded.Header.SequenceNumber
+	if !state.valid {
+		state.next = sequence + increment
+		state.valid = known
+		return
+	}
+
+	diff := sequence - state.next
+	if diff > 1<<31 {
+		state.behind++
+		if state.behind < maxBehindInARow {
+			// Chegou atrasado: o que foi contado como perda não era perda.
+			credit := min(uint64(increment), state.recentLoss, exporter.EstimatedLoss)
+			exporter.EstimatedLoss -= credit
+			state.recentLoss -= credit
+			return
+		}
+		// Reinício: o exportador voltou a numerar do começo.
+		diff = maxSequenceJump
+	}
+	state.behind = 0
+
+	switch {
+	case diff == 0:
+		state.next = sequence + increment
+	case diff < maxSequenceJump:
+		exporter.SequenceGaps++
+		exporter.EstimatedLoss += uint64(diff)
+		state.recentLoss += uint64(diff)
+		state.next = sequence + increment
+	default:
+		state.next = sequence + increment
+		state.recentLoss = 0
+	}
+	state.valid = known
+}
+
+func (s *pipelineStats) processorRun(name string, latency time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	processor := s.processor(name)
+	processor.Processed++
+	processor.totalLatency += latency
+	if latency > processor.maxLatency {
+		processor.maxLatency = latency
+	}
+	if err != nil {
+		processor.Errors++
+		processor.LastError = err.Error()
+		processor.LastErrorAt = time.Now()
+	}
+}
+
+func (s *pipelineStats) processorRetry(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.processor(name).Retries++
+}
+
+func (s *pipelineStats) processorDeadLettered(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.processor(name).DeadLettered++
+}
+
+func (s *pipelineStats) processor(name string) *ProcessorStats {
+	processor, ok := s.processors[name]
+	if !ok {
+		processor = &ProcessorStats{Name: name}
+		s.processors[name] = processor
+	}
+	return processor
+}
+
+func (s *pipelineStats) deadLettered(summary DeadLetterSummary) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deadLetters++
+	s.recentDeadLetters = append(s.recentDeadLetters, summary)
+	if len(s.recentDeadLetters) > maxRecentDeadLetters {
+		s.recentDeadLetters = s.recentDeadLetters[1:]
+	}
+}
+
+// GetPipelineStatus devolve uma cópia dos contadores desde o início do processo.
+func GetPipelineStatus() PipelineStatus {
+	stats.mu.Lock()
+	status := PipelineStatus{
+		StartedAt:         stats.startedAt,
+		UptimeSeconds:     int64(time.Since(stats.startedAt).Seconds()),
+		Listener:          stats.listener,
+		Decoder:           stats.decoder,
+		DeadLetters:       stats.deadLetters,
+		RecentDeadLetters: append([]DeadLetterSummary{}, stats.recentDeadLetters...),
+		Exporters:         make([]ExporterStats, 0, len(stats.exporters)),
+		Processors:        make([]ProcessorStats, 0, len(stats.processors)),
+	}
+	for _, exporter := range stats.exporters {
+		status.Exporters = append(status.Exporters, *exporter)
+	}
+	for _, processor := range stats.processors {
+		copied := *processor
+		if copied.Processed > 0 {
+			copied.AvgLatencyMs = float64(copied.totalLatency.Microseconds()) / float64(copied.Processed) / 1000
+		}
+		copied.MaxLatencyMs = float64(copied.maxLatency.Microseconds()) / 1000
+		status.Processors = append(status.Processors, copied)
+	}
+	queues := make(map[string]Queue, len(stats.queues))
+	for name, queue := range stats.queues {
+		queues[name] = queue
+	}
+	stats.mu.Unlock()
+
+	// A consulta ao broker fica fora do lock.
+	status.Queues = make([]QueueStatus, 0, len(queues))
+	for name, queue := range queues {
+		queueStatus := QueueStatus{Name: name, Depth: -1}
+		if depth, ok := queue.(QueueDepth); ok {
+			if value, err := depth.Depth(); err != nil {
+				queueStatus.Error = err.Error()
+			} else {
+				queueStatus.Depth = value
+			}
+		}
+		status.Queues = append(status.Queues, queueStatus)
+	}
+
+	sort.Slice(status.Exporters, func(i, j int) bool { return status.Exporters[i].ExporterIP < status.Exporters[j].ExporterIP })
+	sort.Slice(status.Processors, func(i, j int) bool { return status.Processors[i].Name < status.Processors[j].Name })
+	sort.Slice(status.Queues, func(i, j int) bool { return status.Queues[i].Name < status.Queues[j].Name })
+	return status
+}
@@ -1,104 +1,237 @@
 package netflow
 
 import (
-	"encoding/json"
 	"log"
+	"sync"
+	"time"
 
 	"github.com/streadway/amqp"
 )
 
+const (
+	rabbitReconnectMin = time.Second
+	rabbitReconnectMax = 30 * time.Second
+)
+
+// RabbitMQ mantém a conexão viva em segundo plano: se o broker estiver fora
+// no boot ou a conexão cair, reconecta com backoff e, se o canal fechar com a
+// conexão ainda aberta, só reabre o canal. Enquanto desconectado, Publish
+// devolve ErrQueueUnavailable.
 type RabbitMQ struct {
-	Conn    *amqp.Connection
-	Channel *amqp.Channel
-	Queue   amqp.Queue
+	url       string
+	queueName string
+
+	mu        sync.RWMutex
+	conn      *amqp.Connection
+	channel   *amqp.Channel
+	consuming bool
+
+	deliveries chan Delivery
+	done       chan struct{}
+	once       sync.Once
 }
 
-func NewRabbitMQ(amqpURL, queueName string) (*RabbitMQ, error) {
-	conn, err := amqp.Dial(amqpURL)
-	if err != nil {
-		return nil, err
+func NewRabbitMQ(amqpURL, queueName string) *RabbitMQ {
+	r := &RabbitMQ{
+		url:        amqpURL,
+		queueName:  queueName,
+		deliveries: make(chan Delivery),
+		done:       make(chan struct{}),
+	}
+	go r.run()
+	return r
+}
+
+func (r *RabbitMQ) run() {
+	backoff := rabbitReconnectMin
+	for {
+		conn, err := amqp.Dial(r.url)
+		if err != nil {
+			log.Printf("RabbitMQ indisponível para %s, nova tentativa em %s: %v", r.queueName, backoff, err)
+			if !r.wait(backoff) {
+				return
+			}
+			backoff = min(backoff*2, rabbitReconnectMax)
+			continue
+		}
+		backoff = rabbitReconnectMin
+		log.Printf("RabbitMQ conectado - fila %s", r.queueName)
+
+		connClosed := conn.NotifyClose(make(chan *amqp.Error, 1))
+		r.serveConnection(conn, connClosed)
+
+		r.mu.Lock()
+		r.conn = nil
+		r.mu.Unlock()
+		conn.Close()
+
+		select {
+		case <-r.done:
+			return
+		default:
+		}
+	}
+}
+
+// serveConnection abre (e reabre) canais enquanto a conexão estiver viva.
+func (r *RabbitMQ) serveConnection(conn *amqp.Connection, connClosed chan *amqp.Error) {
+	for {
+		channel, err := r.openChannel(conn)
+		if err != nil {
+			log.Printf("Erro abrindo canal RabbitMQ para %s: %v", r.queueName, err)
+			return
+		}
+		channelClosed := channel.NotifyClose(make(chan *amqp.Error, 1))
+
+		r.mu.Lock()
+		r.conn = conn
+		r.channel = channel
+		consuming := r.consuming
+		r.mu.Unlock()
+
+		if consuming {
+			if err := r.startConsumer(channel); err != nil {
+				log.Printf("Erro iniciando consumo da fila %s: %v", r.queueName, err)
+			}
+		}
+
+		select {
+		case <-r.done:
+			r.clearChannel()
+			return
+		case err := <-connClosed:
+			r.clearChannel()
+			log.Printf("Conexão RabbitMQ da fila %s perdida: %v", r.queueName, err)
+			return
+		case err := <-channelClosed:
+			r.clearChannel()
+			log.Printf("Canal RabbitMQ da fila %s fechado, reabrindo: %v", r.queueName, err)
+			if !r.wait(rabbitReconnectMin) {
+				return
+			}
+		}
 	}
+}
+
+func (r *RabbitMQ) openChannel(conn *amqp.Connection) (*amqp.Channel, error) {
 	ch, err := conn.Channel()
 	if err != nil {
-		conn.Close()
 		return nil, err
 	}
-	q, err := ch.QueueDeclare(
-		queueName,
-		true,
-		false,
-		false,
-		false,
-		nil,
-	)
-	if err != nil {
+	if _, err := ch.QueueDeclare(r.queueName, true, false, false, false, nil); err != nil {
 		ch.Close()
-		conn.Close()
 		return nil, err
 	}
 	if err := ch.Qos(1, 0, false); err != nil {
 		log.Printf("Warning: failed setting QoS: %v", err)
 	}
-	return &RabbitMQ{
-		Conn:    conn,
-		Channel: ch,
-		Queue:   q,
-	}, nil
+	return ch, nil
 }
 
-func (r *RabbitMQ) PublishPacket(msg PacketMessage) error {
-	body, err := json.Marshal(msg)
-	if err != nil {
-		return err
+func (r *RabbitMQ) clearChannel() {
+	r.mu.Lock()
+	r.channel = nil
+	r.mu.Unlock()
+}
+
+func (r *RabbitMQ) wait(d time.Duration) bool {
+	select {
+	case <-r.done:
+		return false
+	case <-time.After(d):
+		return true
 	}
-	return r.Channel.Publish(
-		"",
-		r.Queue.Name,
-		false,
-		false,
-		amqp.Publishing{
-			DeliveryMode: amqp.Persistent,
-			ContentType:  "application/json",
-			Body:         body,
-		},
-	)
 }
 
-func (r *RabbitMQ) PublishDecodedIPFIX(msg DecodedIPFIXMessage) error {
-	body, err := json.Marshal(msg)
-	if err != nil {
-		return err
+func (r *RabbitMQ) Publish(contentType string, body []byte) error {
+	r.mu.RLock()
+	channel := r.channel
+	r.mu.RUnlock()
+	if channel == nil {
+		return ErrQueueUnavailable
 	}
-	return r.Channel.Publish(
+
+	return channel.Publish(
 		"",
-		r.Queue.Name,
+		r.queueName,
 		false,
 		false,
 		amqp.Publishing{
 			DeliveryMode: amqp.Persistent,
-			ContentType:  "application/json",
+			ContentType:  contentType,
 			Body:         body,
 		},
 	)
 }
 
-func (r *RabbitMQ) Consume() (<-chan amqp.Delivery, error) {
-	return r.Channel.Consume(
-		r.Queue.Name,
-		"",
-		false,
-		false,
-		false,
-		false,
-		nil,
-	)
+// Depth conta só as mensagens prontas; as entregues e ainda sem ack ficam de fora.
+func (r *RabbitMQ) Depth() (int, error) {
+	r.mu.RLock()
+	channel := r.channel
+	r.mu.RUnlock()
+	if channel == nil {
+		return 0, ErrQueueUnavailable
+	}
+
+	queue, err := channel.QueueInspect(r.queueName)
+	if err != nil {
+		return 0, err
+	}
+	return queue.Messages, nil
 }
 
-func (r *RabbitMQ) Close() {
-	if r.Channel != nil {
-		r.Channel.Close()
+// Consume pode ser chamado antes da conexão existir; o consumo começa (e é
+// refeito) a cada canal aberto.
+func (r *RabbitMQ) Consume() (<-chan Delivery, error) {
+	r.mu.Lock()
+	alreadyConsuming := r.consuming
+	r.consuming = true
+	channel := r.channel
+	r.mu.Unlock()
+
+	if !alreadyConsuming && channel != nil {
+		if err := r.startConsumer(channel); err != nil {
+			return nil, err
+		}
 	}
-	if r.Conn != nil {
-		r.Conn.Close()
+	return r.deliveries, nil
+}
+
+func (r *RabbitMQ) startConsumer(channel *amqp.Channel) error {
+	messages, err := channel.Consume(r.queueName, "", false, false, false, false, nil)
+	if err != nil {
+		return err
 	}
+
+	go func() {
+		for message := range messages {
+			d := message
+			delivery := Delivery{
+				ContentType: d.ContentType,
+				Body:        d.Body,
+				ack:         func() error { return d.Ack(false) },
+				nack:        func(requeue bool) error { return d.Nack(false, requeue) },
+			}
+			select {
+			case r.deliveries <- delivery:
+			case <-r.done:
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+func (r *RabbitMQ) Close() {
+	r.once.Do(func() {
+		close(r.done)
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if r.channel != nil {
+			r.channel.Close()
+		}
+		if r.conn != nil {
+			r.conn.Close()
+		}
+	})
 }
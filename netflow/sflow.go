@@ -0,0 +1,556 @@
+package netflow
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+const (
+	SFlowVersion5 = 5
+
+	sflowFlowSample            = 1
+	sflowCounterSample         = 2
+	sflowExpandedFlowSample    = 3
+	sflowExpandedCounterSample = 4
+
+	sflowRecordRawPacketHeader = 1
+	sflowRecordSampledIPv4     = 3
+	sflowRecordSampledIPv6     = 4
+	sflowRecordExtendedSwitch  = 1001
+	sflowRecordExtendedRouter  = 1002
+	sflowRecordExtendedGateway = 1003
+
+	sflowCounterGenericInterface = 1
+
+	sflowHeaderProtocolEthernet = 1
+	sflowHeaderProtocolIPv4     = 11
+	sflowHeaderProtocolIPv6     = 12
+)
+
+type InterfaceCounters struct {
+	SourceID     uint32 `json:"sourceId"`
+	IfIndex      uint32 `json:"ifIndex"`
+	IfType       uint32 `json:"ifType"`
+	IfSpeed      uint64 `json:"ifSpeed"`
+	IfDirection  uint32 `json:"ifDirection"`
+	IfAdminUp    bool   `json:"ifAdminUp"`
+	IfOperUp     bool   `json:"ifOperUp"`
+	InOctets     uint64 `json:"inOctets"`
+	InUcastPkts  uint32 `json:"inUcastPkts"`
+	InMcastPkts  uint32 `json:"inMulticastPkts"`
+	InBcastPkts  uint32 `json:"inBroadcastPkts"`
+	InDiscards   uint32 `json:"inDiscards"`
+	InErrors     uint32 `json:"inErrors"`
+	OutOctets    uint64 `json:"outOctets"`
+	OutUcastPkts uint32 `json:"outUcastPkts"`
+	OutMcastPkts uint32 `json:"outMulticastPkts"`
+	OutBcastPkts uint32 `json:"outBroadcastPkts"`
+	OutDiscards  uint32 `json:"outDiscards"`
+	OutErrors    uint32 `json:"outErrors"`
+	SampleSeqNum uint32 `json:"sampleSequenceNumber"`
+}
+
+type sflowReader struct {
+	b   []byte
+	off int
+	err error
+}
+
+func (r *sflowReader) u32() uint32 {
+	if r.err != nil || r.off+4 > len(r.b) {
+		r.err = errors.New("datagrama sFlow truncado")
+		return 0
+	}
+	v := binary.BigEndian.Uint32(r.b[r.off : r.off+4])
+	r.off += 4
+	return v
+}
+
+func (r *sflowReader) u64() uint64 {
+	return uint64(r.u32())<<32 | uint64(r.u32())
+}
+
+func (r *sflowReader) bytes(n int) []byte {
+	if r.err != nil || n < 0 || r.off+n > len(r.b) {
+		r.err = errors.New("datagrama sFlow truncado")
+		return nil
+	}
+	v := r.b[r.off : r.off+n]
+	r.off += n
+	return v
+}
+
+// opaque lê um bloco XDR de tamanho `length`, alinhado em 4 bytes.
+func (r *sflowReader) opaque(length int) *sflowReader {
+	data := r.bytes(length)
+	if pad := (4 - length%4) % 4; pad > 0 {
+		r.bytes(pad)
+	}
+	return &sflowReader{b: data, err: r.err}
+}
+
+// address lê um endereço sFlow (tipo 1 = IPv4, 2 = IPv6).
+func (r *sflowReader) address() (net.IP, int) {
+	switch r.u32() {
+	case 1:
+		return net.IP(r.bytes(4)), 4
+	case 2:
+		return net.IP(r.bytes(16)), 16
+	default:
+		if r.err == nil {
+			r.err = errors.New("tipo de endereço sFlow inválido")
+		}
+		return nil, 0
+	}
+}
+
+// DecodeSFlow decodifica um datagrama sFlow v5. Amostras de fluxo viram
+// FlowRecords já multiplicados pela taxa de amostragem e amostras de contador
+// viram InterfaceCounters.
+func DecodeSFlow(b []byte, received time.Time) (*DecodedIPFIXMessage, error) {
+	r := &sflowReader{b: b}
+
+	if version := r.u32(); version != SFlowVersion5 {
+		if r.err != nil {
+			return nil, r.err
+		}
+		return nil, fmt.Errorf("%w: sFlow %d", ErrUnsupportedVersion, version)
+	}
+
+	var agent net.IP
+	switch addressType := r.u32(); addressType {
+	case 1:
+		agent = net.IP(r.bytes(4))
+	case 2:
+		agent = net.IP(r.bytes(16))
+	default:
+		return nil, fmt.Errorf("tipo de endereço de agente sFlow inválido: %d", addressType)
+	}
+
+	subAgentID := r.u32()
+	sequence := r.u32()
+	uptime := r.u32()
+	numSamples := r.u32()
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	decoded := &DecodedIPFIXMessage{
+		Protocol: ProtocolSFlow,
+		Received: received,
+		Header: IPFIXHeader{
+			Version:           SFlowVersion5,
+			Length:            uint16(len(b)),
+			ExportTime:        uint32(received.Unix()),
+			SequenceNumber:    sequence,
+			ObservationDomain: subAgentID,
+			SysUptime:         uptime,
+		},
+		Templates:   []Template{},
+		FlowRecords: []FlowRecord{},
+	}
+	if agent != nil && !agent.IsUnspecified() {
+		decoded.SrcIP = agent.String()
+	}
+
+	receivedMs := uint64(received.UnixMilli())
+
+	for i := uint32(0); i < numSamples && r.err == nil; i++ {
+		format := r.u32()
+		sample := r.opaque(int(r.u32()))
+		if r.err != nil {
+			break
+		}
+
+		// Formatos de enterprise diferente de 0 são ignorados.
+		if format>>12 != 0 {
+			continue
+		}
+
+		switch format & 0xFFF {
+		case sflowFlowSample, sflowExpandedFlowSample:
+			if record, ok := decodeSFlowFlowSample(sample, format&0xFFF == sflowExpandedFlowSample, receivedMs); ok {
+				decoded.FlowRecords = append(decoded.FlowRecords, record)
+			}
+		case sflowCounterSample, sflowExpandedCounterSample:
+			decoded.InterfaceCounters = append(decoded.InterfaceCounters, decodeSFlowCounterSample(sample, format&0xFFF == sflowExpandedCounterSample)...)
+		}
+	}
+
+	return decoded, r.err
+}
+
+// decodeSFlowFlowSample gera um único FlowRecord por amostra: exportadores
+// podem mandar o cabeçalho bruto e o sampled IP do mesmo pacote, e contar os
+// dois dobraria bytes e pacotes. O cabeçalho tem preferência; os registros
+// estendidos são mesclados no resultado.
+func decodeSFlowFlowSample(r *sflowReader, expanded bool, receivedMs uint64) (FlowRecord, bool) {
+	r.u32() // sequence number
+	if expanded {
+		r.u32()
+		r.u32()
+	} else {
+		r.u32()
+	}
+	samplingRate := uint64(r.u32())
+	r.u32() // sample pool
+	r.u32() // drops
+
+	var input, output uint32
+	if expanded {
+		r.u32()
+		input = r.u32()
+		r.u32()
+		output = r.u32()
+	} else {
+		input = r.u32() & 0x3FFFFFFF
+		output = r.u32() & 0x3FFFFFFF
+	}
+
+	numRecords := r.u32()
+	if samplingRate == 0 {
+		samplingRate = 1
+	}
+
+	newRecord := func() FlowRecord {
+		return FlowRecord{
+			IngressInterface:      input,
+			EgressInterface:       output,
+			FlowStartMilliseconds: receivedMs,
+			FlowEndMilliseconds:   receivedMs,
+			SamplingInterval:      samplingRate,
+			RawFields:             map[string]interface{}{getFieldName(34): samplingRate},
+		}
+	}
+
+	var packet *FlowRecord
+	var octets uint64
+	fromHeader := false
+	extended := newRecord()
+
+	for i := uint32(0); i < numRecords && r.err == nil; i++ {
+		format := r.u32()
+		data := r.opaque(int(r.u32()))
+		if r.err != nil || format>>12 != 0 {
+			continue
+		}
+
+		switch format & 0xFFF {
+		case sflowRecordRawPacketHeader:
+			if fromHeader {
+				continue
+			}
+			record := newRecord()
+			if length, ok := decodeSFlowRawHeader(data, &record); ok {
+				packet, octets, fromHeader = &record, length, true
+			}
+		case sflowRecordSampledIPv4, sflowRecordSampledIPv6:
+			if packet != nil {
+				continue
+			}
+			addrLen := 4
+			if format&0xFFF == sflowRecordSampledIPv6 {
+				addrLen = 16
+			}
+			record := newRecord()
+			if length, ok := decodeSFlowSampledIP(data, &record, addrLen); ok {
+				packet, octets = &record, length
+			}
+		case sflowRecordExtendedSwitch:
+			decodeSFlowExtendedSwitch(data, &extended)
+		case sflowRecordExtendedRouter:
+			decodeSFlowExtendedRouter(data, &extended)
+		case sflowRecordExtendedGateway:
+			decodeSFlowExtendedGateway(data, &extended)
+		}
+	}
+
+	if packet == nil {
+		return FlowRecord{}, false
+	}
+
+	for name, value := range extended.RawFields {
+		if _, exists := packet.RawFields[name]; !exists {
+			packet.RawFields[name] = value
+		}
+	}
+	if extended.SourceAS != 0 {
+		packet.SourceAS = extended.SourceAS
+	}
+	if extended.DestinationAS != 0 {
+		packet.DestinationAS = extended.DestinationAS
+	}
+
+	packet.PacketDeltaCount = samplingRate
+	packet.OctetDeltaCount = octets * samplingRate
+	return *packet, true
+}
+
+func decodeSFlowExtendedSwitch(r *sflowReader, record *FlowRecord) {
+	vlan := uint64(r.u32())
+	if r.err == nil && vlan > 0 {
+		record.RawFields[getFieldName(58)] = vlan
+	}
+}
+
+func decodeSFlowExtendedRouter(r *sflowReader, record *FlowRecord) {
+	nextHop, addrLen := r.address()
+	srcMask := uint64(r.u32())
+	dstMask := uint64(r.u32())
+	if r.err != nil {
+		return
+	}
+
+	if addrLen == 4 {
+		record.RawFields[getFieldName(15)] = nextHop.String()
+		record.RawFields[getFieldName(9)] = srcMask
+		record.RawFields[getFieldName(13)] = dstMask
+	} else {
+		record.RawFields[getFieldName(62)] = nextHop.String()
+		record.RawFields[getFieldName(29)] = srcMask
+		record.RawFields[getFieldName(30)] = dstMask
+	}
+}
+
+// decodeSFlowExtendedGateway usa o último AS do caminho como destino; sem
+// caminho, o destino é o próprio AS do roteador.
+func decodeSFlowExtendedGateway(r *sflowReader, record *FlowRecord) {
+	r.address() // next hop BGP
+	routerAS := r.u32()
+	sourceAS := r.u32()
+	r.u32() // src peer AS
+
+	destinationAS := routerAS
+	segments := r.u32()
+	for i := uint32(0); i < segments && r.err == nil; i++ {
+		r.u32() // tipo do segmento
+		count := r.u32()
+		for j := uint32(0); j < count && r.err == nil; j++ {
+			destinationAS = r.u32()
+		}
+	}
+	if r.err != nil {
+		return
+	}
+
+	record.SourceAS = sourceAS
+	record.DestinationAS = destinationAS
+}
+
+func decodeSFlowRawHeader(r *sflowReader, record *FlowRecord) (uint64, bool) {
+	protocol := r.u32()
+	frameLength := uint64(r.u32())
+	stripped := uint64(r.u32())
+	header := r.opaque(int(r.u32()))
+	if r.err != nil {
+		return 0, false
+	}
+
+	octets := frameLength - stripped
+	if stripped > frameLength {
+		octets = frameLength
+	}
+
+	var ipLength uint64
+	var ok bool
+	switch protocol {
+	case sflowHeaderProtocolEthernet:
+		ipLength, ok = decodeEthernetHeader(header.b, record)
+	case sflowHeaderProtocolIPv4, sflowHeaderProtocolIPv6:
+		ipLength, ok = decodeIPHeader(header.b, record)
+	}
+	if !ok {
+		return 0, false
+	}
+	if ipLength > 0 {
+		octets = ipLength
+	}
+	return octets, true
+}
+
+func decodeSFlowSampledIP(r *sflowReader, record *FlowRecord, addrLen int) (uint64, bool) {
+	length := uint64(r.u32())
+	record.ProtocolIdentifier = uint8(r.u32())
+	src := net.IP(r.bytes(addrLen))
+	dst := net.IP(r.bytes(addrLen))
+	record.SourceTransportPort = uint16(r.u32())
+	record.DestinationTransportPort = uint16(r.u32())
+	record.RawFields[getFieldName(6)] = uint64(r.u32())
+	record.IPClassOfService = uint8(r.u32())
+	if r.err != nil {
+		return 0, false
+	}
+
+	if addrLen == 4 {
+		record.IPVersion = 4
+		record.SourceIPv4Address = src.String()
+		record.DestinationIPv4Address = dst.String()
+	} else {
+		record.IPVersion = 6
+		record.SourceIPv6Address = src.String()
+		record.DestinationIPv6Address = dst.String()
+	}
+	return length, true
+}
+
+func decodeEthernetHeader(b []byte, record *FlowRecord) (uint64, bool) {
+	if len(b) < 14 {
+		return 0, false
+	}
+	record.RawFields[getFieldName(80)] = decodeMacAddress(b[0:6])
+	record.RawFields[getFieldName(56)] = decodeMacAddress(b[6:12])
+
+	etherType := binary.BigEndian.Uint16(b[12:14])
+	offset := 14
+	for (etherType == 0x8100 || etherType == 0x88A8) && offset+4 <= len(b) {
+		if _, exists := record.RawFields[getFieldName(58)]; !exists {
+			record.RawFields[getFieldName(58)] = uint64(binary.BigEndian.Uint16(b[offset:offset+2]) & 0x0FFF)
+		}
+		etherType = binary.BigEndian.Uint16(b[offset+2 : offset+4])
+		offset += 4
+	}
+
+	if etherType != 0x0800 && etherType != 0x86DD {
+		return 0, false
+	}
+	return decodeIPHeader(b[offset:], record)
+}
+
+// decodeIPHeader preenche endereços, protocolo e portas a partir do cabeçalho
+// IP amostrado e devolve o tamanho total do pacote IP.
+func decodeIPHeader(b []byte, record *FlowRecord) (uint64, bool) {
+	if len(b) < 1 {
+		return 0, false
+	}
+
+	var transport []byte
+	var totalLength uint64
+
+	switch b[0] >> 4 {
+	case 4:
+		ihl := int(b[0]&0x0F) * 4
+		if len(b) < 20 || ihl < 20 {
+			return 0, false
+		}
+		record.IPVersion = 4
+		record.IPClassOfService = b[1]
+		totalLength = uint64(binary.BigEndian.Uint16(b[2:4]))
+		record.RawFields[getFieldName(192)] = b[8]
+		record.ProtocolIdentifier = b[9]
+		record.SourceIPv4Address = decodeIPv4(b[12:16])
+		record.DestinationIPv4Address = decodeIPv4(b[16:20])
+
+		// Fragmentos não iniciais não carregam cabeçalho de transporte.
+		if binary.BigEndian.Uint16(b[6:8])&0x1FFF == 0 && len(b) > ihl {
+			transport = b[ihl:]
+		}
+	case 6:
+		if len(b) < 40 {
+			return 0, false
+		}
+		record.IPVersion = 6
+		record.IPClassOfService = uint8(binary.BigEndian.Uint16(b[0:2]) >> 4)
+		totalLength = uint64(binary.BigEndian.Uint16(b[4:6])) + 40
+		record.RawFields[getFieldName(192)] = b[7]
+		record.SourceIPv6Address = decodeIPv6(b[8:24])
+		record.DestinationIPv6Address = decodeIPv6(b[24:40])
+
+		nextHeader := b[6]
+		offset := 40
+		for offset+8 <= len(b) && (nextHeader == 0 || nextHeader == 43 || nextHeader == 60 || nextHeader == 44) {
+			headerLength := (int(b[offset+1]) + 1) * 8
+			if nextHeader == 44 {
+				headerLength = 8
+			}
+			nextHeader = b[offset]
+			offset += headerLength
+		}
+		record.ProtocolIdentifier = nextHeader
+		if offset < len(b) {
+			transport = b[offset:]
+		}
+	default:
+		return 0, false
+	}
+
+	switch record.ProtocolIdentifier {
+	case 6:
+		if len(transport) >= 14 {
+			record.SourceTransportPort = binary.BigEndian.Uint16(transport[0:2])
+			record.DestinationTransportPort = binary.BigEndian.Uint16(transport[2:4])
+			record.RawFields[getFieldName(6)] = uint64(transport[13])
+			if len(transport) >= 16 {
+				record.RawFields[getFieldName(186)] = uint64(binary.BigEndian.Uint16(transport[14:16]))
+			}
+		}
+	case 17:
+		if len(transport) >= 4 {
+			record.SourceTransportPort = binary.BigEndian.Uint16(transport[0:2])
+			record.DestinationTransportPort = binary.BigEndian.Uint16(transport[2:4])
+		}
+	case 1:
+		if len(transport) >= 2 {
+			record.RawFields[getFieldName(176)] = transport[0]
+			record.RawFields[getFieldName(177)] = transport[1]
+		}
+	case 58:
+		if len(transport) >= 2 {
+			record.RawFields[getFieldName(178)] = transport[0]
+			record.RawFields[getFieldName(179)] = transport[1]
+		}
+	}
+
+	return totalLength, true
+}
+
+func decodeSFlowCounterSample(r *sflowReader, expanded bool) []InterfaceCounters {
+	sequence := r.u32()
+	var sourceID uint32
+	if expanded {
+		sourceID = r.u32()<<24 | r.u32()&0x00FFFFFF
+	} else {
+		sourceID = r.u32()
+	}
+	numRecords := r.u32()
+
+	counters := []InterfaceCounters{}
+	for i := uint32(0); i < numRecords && r.err == nil; i++ {
+		format := r.u32()
+		data := r.opaque(int(r.u32()))
+		if r.err != nil || format != sflowCounterGenericInterface {
+			continue
+		}
+
+		c := InterfaceCounters{SourceID: sourceID, SampleSeqNum: sequence}
+		c.IfIndex = data.u32()
+		c.IfType = data.u32()
+		c.IfSpeed = data.u64()
+		c.IfDirection = data.u32()
+		status := data.u32()
+		c.IfAdminUp = status&0x1 != 0
+		c.IfOperUp = status&0x2 != 0
+		c.InOctets = data.u64()
+		c.InUcastPkts = data.u32()
+		c.InMcastPkts = data.u32()
+		c.InBcastPkts = data.u32()
+		c.InDiscards = data.u32()
+		c.InErrors = data.u32()
+		data.u32() // ifInUnknownProtos
+		c.OutOctets = data.u64()
+		c.OutUcastPkts = data.u32()
+		c.OutMcastPkts = data.u32()
+		c.OutBcastPkts = data.u32()
+		c.OutDiscards = data.u32()
+		c.OutErrors = data.u32()
+		if data.err != nil {
+			continue
+		}
+
+		counters = append(counters, c)
+	}
+
+	return counters
+}
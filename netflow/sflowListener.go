@@ -0,0 +1,73 @@
+package netflow
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"time"
+)
+
+// O sFlow não depende de templates, então o datagrama é decodificado no
+// próprio listener e publicado direto na fila de mensagens decodificadas.
+func StartSFlowListener(decodedQueue Queue, listenAddr string) error {
+	udpAddr, err := net.ResolveUDPAddr("udp", listenAddr)
+	if err != nil {
+		return err
+	}
+	conn, err := net.ListenUDP("udp", udpAddr)
+	if err != nil {
+		return err
+	}
+	log.Printf("sFlow listener iniciado em %s", listenAddr)
+	publisher := NewDecodedPublisher(decodedQueue)
+	go func() {
+		defer conn.Close()
+		buf := make([]byte, 65535)
+		for {
+			n, addr, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				log.Printf("Erro lendo UDP sFlow: %v", err)
+				stats.listenerReadError()
+				continue
+			}
+
+			// Os contadores ficam no endereço de origem do datagrama, como
+			// no listener IPFIX, mesmo quando o agente informa outro IP.
+			srcIP := addr.IP.String()
+			stats.packetReceived(srcIP, n)
+			decoded, err := DecodeSFlow(buf[:n], time.Now())
+			if err != nil {
+				log.Printf("Erro decodificando sFlow de %s: %v", srcIP, err)
+				stats.parseError(srcIP)
+				continue
+			}
+			stats.packetDecoded(srcIP, addr.Port, decoded)
+			if decoded.SrcIP == "" {
+				decoded.SrcIP = srcIP
+			}
+			decoded.SrcPort = addr.Port
+
+			if len(decoded.FlowRecords) == 0 && len(decoded.InterfaceCounters) == 0 {
+				continue
+			}
+
+			if err := publisher.Add(*decoded); err != nil {
+				log.Printf("Erro publicando sFlow decodificado na fila: %v", err)
+			}
+		}
+	}()
+	return nil
+}
+
+func GetSFlowListenAddr() string {
+	ip := os.Getenv("SFLOW_LISTEN")
+	port := os.Getenv("SFLOW_PORT")
+	if ip == "" {
+		ip = "0.0.0.0"
+	}
+	if port == "" {
+		port = "6343"
+	}
+	return fmt.Sprintf("%s:%s", ip, port)
+}
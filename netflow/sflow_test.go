@@ -0,0 +1,87 @@
+package netflow
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+func xdrRecord(format uint32, words ...uint32) []byte {
+	b := binary.BigEndian.AppendUint32(nil, format)
+	b = binary.BigEndian.AppendUint32(b, uint32(len(words)*4))
+	for _, w := range words {
+		b = binary.BigEndian.AppendUint32(b, w)
+	}
+	return b
+}
+
+// Cabeçalho IPv4/TCP de 40 bytes com total length 1000.
+func sampledIPv4Header() []byte {
+	ip := make([]byte, 40)
+	ip[0] = 0x45
+	binary.BigEndian.PutUint16(ip[2:4], 1000)
+	ip[8], ip[9] = 64, 6
+	copy(ip[12:16], []byte{10, 0, 0, 1})
+	copy(ip[16:20], []byte{192, 0, 2, 10})
+	binary.BigEndian.PutUint16(ip[20:22], 50000)
+	binary.BigEndian.PutUint16(ip[22:24], 443)
+	ip[33] = 0x10
+	return ip
+}
+
+func buildSFlowDatagram(records ...[]byte) []byte {
+	var body []byte
+	for _, r := range records {
+		body = append(body, r...)
+	}
+	sample := []byte{}
+	for _, w := range []uint32{1, 0, 100, 0, 0, 3, 7, uint32(len(records))} {
+		sample = binary.BigEndian.AppendUint32(sample, w)
+	}
+	sample = append(sample, body...)
+
+	b := []byte{}
+	for _, w := range []uint32{SFlowVersion5, 1, 0xC0000201, 0, 1, 1000, 1, sflowFlowSample, uint32(len(sample))} {
+		b = binary.BigEndian.AppendUint32(b, w)
+	}
+	return append(b, sample...)
+}
+
+func TestSFlowHeaderAndSampledIPCountOnce(t *testing.T) {
+	header := sampledIPv4Header()
+	rawHeader := binary.BigEndian.AppendUint32(nil, sflowRecordRawPacketHeader)
+	rawHeader = binary.BigEndian.AppendUint32(rawHeader, uint32(16+len(header)))
+	for _, w := range []uint32{sflowHeaderProtocolIPv4, 1000, 0, uint32(len(header))} {
+		rawHeader = binary.BigEndian.AppendUint32(rawHeader, w)
+	}
+	rawHeader = append(rawHeader, header...)
+
+	sampledIP := xdrRecord(sflowRecordSampledIPv4, 1000, 6, 0x0a000001, 0xc000020a, 50000, 443, 0x10, 0)
+	extendedSwitch := xdrRecord(sflowRecordExtendedSwitch, 100, 0, 200, 0)
+	extendedGateway := xdrRecord(sflowRecordExtendedGateway, 1, 0x0a0000fe, 64512, 64513, 64513, 1, 2, 2, 3356, 15169, 0, 100)
+
+	decoded, err := DecodeSFlow(buildSFlowDatagram(sampledIP, rawHeader, extendedSwitch, extendedGateway), time.Now())
+	if err != nil {
+		t.Fatalf("DecodeSFlow: %v", err)
+	}
+	if len(decoded.FlowRecords) != 1 {
+		t.Fatalf("esperado 1 registro por amostra, veio %d", len(decoded.FlowRecords))
+	}
+
+	record := decoded.FlowRecords[0]
+	if record.PacketDeltaCount != 100 || record.OctetDeltaCount != 100000 {
+		t.Errorf("contadores: %d pacotes, %d bytes", record.PacketDeltaCount, record.OctetDeltaCount)
+	}
+	if record.SourceIPv4Address != "10.0.0.1" || record.DestinationTransportPort != 443 {
+		t.Errorf("registro não veio do cabeçalho: %s -> :%d", record.SourceIPv4Address, record.DestinationTransportPort)
+	}
+	if _, ok := record.RawFields["ipTTL"]; !ok {
+		t.Error("cabeçalho bruto deveria ter preferência sobre o sampled IP")
+	}
+	if vlan, _ := record.RawFields["vlanId"].(uint64); vlan != 100 {
+		t.Errorf("vlanId = %#v", record.RawFields["vlanId"])
+	}
+	if record.SourceAS != 64513 || record.DestinationAS != 15169 {
+		t.Errorf("AS %d -> %d", record.SourceAS, record.DestinationAS)
+	}
+}
@@ -0,0 +1,319 @@
+package netflow
+
+import (
+	"log"
+	"os"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const defaultTemplateTTL = 30 * time.Minute
+
+const (
+	maxPendingFlowSets = 32
+	pendingFlowSetTTL  = time.Minute
+)
+
+// TemplateKey identifica um exportador. O observation domain sozinho não é
+// único entre roteadores, então o IP e a porta de origem fazem parte da chave.
+type TemplateKey struct {
+	ExporterIP        string
+	ExporterPort      int
+	ObservationDomain uint32
+}
+
+type cachedTemplate struct {
+	template  *Template
+	updatedAt time.Time
+}
+
+// pendingFlowSet é um data set que chegou antes do template correspondente.
+type pendingFlowSet struct {
+	Payload  []byte
+	Header   IPFIXHeader
+	received time.Time
+}
+
+type pendingKey struct {
+	key        TemplateKey
+	templateID uint16
+}
+
+// ExporterOptions guarda os dados de options templates mais recentes de um
+// exportador: taxa de amostragem, nomes de interface e os registros brutos.
+type ExporterOptions struct {
+	SamplingInterval uint64                  `json:"samplingInterval,omitempty"`
+	SamplerIntervals map[uint64]uint64       `json:"samplerIntervals,omitempty"`
+	InterfaceNames   map[uint32]string       `json:"interfaceNames,omitempty"`
+	Records          map[uint16][]FlowRecord `json:"records,omitempty"`
+	UpdatedAt        time.Time               `json:"updatedAt"`
+}
+
+type TemplateCache struct {
+	mu        sync.RWMutex
+	templates map[TemplateKey]map[uint16]*cachedTemplate
+	options   map[TemplateKey]*ExporterOptions
+	pending   map[pendingKey][]pendingFlowSet
+	ttl       time.Duration
+	store     TemplateStore
+}
+
+func NewTemplateCache(ttl time.Duration, store TemplateStore) *TemplateCache {
+	return &TemplateCache{
+		templates: make(map[TemplateKey]map[uint16]*cachedTemplate),
+		options:   make(map[TemplateKey]*ExporterOptions),
+		pending:   make(map[pendingKey][]pendingFlowSet),
+		ttl:       ttl,
+		store:     store,
+	}
+}
+
+// Load recarrega os templates persistidos. O relógio do TTL recomeça no load,
+// já que o exportador continua reenviando os templates normalmente.
+func (tc *TemplateCache) Load() (int, error) {
+	if tc.store == nil {
+		return 0, nil
+	}
+	stored, err := tc.store.LoadAll()
+	if err != nil {
+		return 0, err
+	}
+
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	count := 0
+	now := time.Now()
+	for key, templates := range stored {
+		if tc.templates[key] == nil {
+			tc.templates[key] = make(map[uint16]*cachedTemplate)
+		}
+		for i := range templates {
+			tc.templates[key][templates[i].TemplateID] = &cachedTemplate{template: &templates[i], updatedAt: now}
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (tc *TemplateCache) AddTemplate(key TemplateKey, template *Template) {
+	tc.mu.Lock()
+	if tc.templates[key] == nil {
+		tc.templates[key] = make(map[uint16]*cachedTemplate)
+	}
+	previous := tc.templates[key][template.TemplateID]
+	tc.templates[key][template.TemplateID] = &cachedTemplate{template: template, updatedAt: time.Now()}
+	tc.mu.Unlock()
+
+	// Exportadores reenviam o mesmo template a cada poucos segundos; só
+	// persiste quando é novo ou mudou.
+	if tc.store != nil && (previous == nil || !reflect.DeepEqual(previous.template, template)) {
+		if err := tc.store.Save(key, template); err != nil {
+			log.Printf("Erro persistindo template %d de %s:%d: %v", template.TemplateID, key.ExporterIP, key.ExporterPort, err)
+		}
+	}
+}
+
+func (tc *TemplateCache) GetTemplate(key TemplateKey, templateID uint16) *Template {
+	tc.mu.RLock()
+	cached, ok := tc.templates[key][templateID]
+	tc.mu.RUnlock()
+	if !ok {
+		return nil
+	}
+	if tc.ttl > 0 && time.Since(cached.updatedAt) > tc.ttl {
+		tc.expireTemplate(key, templateID, cached)
+		return nil
+	}
+	return cached.template
+}
+
+// expireTemplate remove o template vencido só se ele ainda for a mesma
+// entrada: um AddTemplate concorrente pode ter acabado de renová-lo. O lock
+// fica com o store.Delete para que o Save da renovação venha depois dele.
+func (tc *TemplateCache) expireTemplate(key TemplateKey, templateID uint16, expired *cachedTemplate) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	if tc.templates[key][templateID] != expired {
+		return
+	}
+	delete(tc.templates[key], templateID)
+
+	if tc.store != nil {
+		if err := tc.store.Delete(key, templateID); err != nil {
+			log.Printf("Erro removendo template %d de %s:%d: %v", templateID, key.ExporterIP, key.ExporterPort, err)
+		}
+	}
+}
+
+func (tc *TemplateCache) WithdrawTemplate(key TemplateKey, templateID uint16) {
+	tc.mu.Lock()
+	delete(tc.templates[key], templateID)
+	tc.mu.Unlock()
+
+	if tc.store != nil {
+		if err := tc.store.Delete(key, templateID); err != nil {
+			log.Printf("Erro removendo template %d de %s:%d: %v", templateID, key.ExporterIP, key.ExporterPort, err)
+		}
+	}
+}
+
+// WithdrawAllTemplates remove todos os templates de dados (ou de opções) do domínio.
+func (tc *TemplateCache) WithdrawAllTemplates(key TemplateKey, optionsTemplates bool) {
+	tc.mu.RLock()
+	var withdrawn []uint16
+	for templateID, cached := range tc.templates[key] {
+		if (cached.template.ScopeFieldCount > 0) == optionsTemplates {
+			withdrawn = append(withdrawn, templateID)
+		}
+	}
+	tc.mu.RUnlock()
+
+	for _, templateID := range withdrawn {
+		tc.WithdrawTemplate(key, templateID)
+	}
+}
+
+// BufferFlowSet guarda um data set sem template para ser decodificado quando
+// o template chegar. O buffer é limitado por quantidade e idade.
+func (tc *TemplateCache) BufferFlowSet(key TemplateKey, templateID uint16, payload []byte, header IPFIXHeader) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	pk := pendingKey{key: key, templateID: templateID}
+	sets := pruneExpiredFlowSets(tc.pending[pk])
+	if len(sets) >= maxPendingFlowSets {
+		sets = sets[1:]
+	}
+	tc.pending[pk] = append(sets, pendingFlowSet{Payload: payload, Header: header, received: time.Now()})
+}
+
+func (tc *TemplateCache) TakePendingFlowSets(key TemplateKey, templateID uint16) []pendingFlowSet {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	pk := pendingKey{key: key, templateID: templateID}
+	sets := pruneExpiredFlowSets(tc.pending[pk])
+	delete(tc.pending, pk)
+	return sets
+}
+
+func pruneExpiredFlowSets(sets []pendingFlowSet) []pendingFlowSet {
+	for len(sets) > 0 && time.Since(sets[0].received) > pendingFlowSetTTL {
+		sets = sets[1:]
+	}
+	return sets
+}
+
+func (tc *TemplateCache) StoreOptionRecords(key TemplateKey, templateID uint16, records []FlowRecord) {
+	if len(records) == 0 {
+		return
+	}
+
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	options := tc.options[key]
+	if options == nil {
+		options = &ExporterOptions{
+			SamplerIntervals: make(map[uint64]uint64),
+			InterfaceNames:   make(map[uint32]string),
+			Records:          make(map[uint16][]FlowRecord),
+		}
+		tc.options[key] = options
+	}
+	for _, record := range records {
+		interval := samplingIntervalFromFields(record.RawFields)
+		samplerID, hasSampler := RawUint(record.RawFields["samplerId"])
+		if !hasSampler {
+			samplerID, hasSampler = RawUint(record.RawFields["selectorId"])
+		}
+		if interval > 0 {
+			if hasSampler {
+				options.SamplerIntervals[samplerID] = interval
+			} else {
+				options.SamplingInterval = interval
+			}
+		}
+
+		if name, ok := record.RawFields["interfaceName"].(string); ok {
+			ifIndex, hasIndex := RawUint(record.RawFields["ingressInterface"])
+			if !hasIndex {
+				ifIndex, hasIndex = RawUint(record.RawFields["scopeInterface"])
+			}
+			if !hasIndex && record.IngressInterface != 0 {
+				ifIndex, hasIndex = uint64(record.IngressInterface), true
+			}
+			if hasIndex {
+				options.InterfaceNames[uint32(ifIndex)] = name
+			}
+		}
+	}
+
+	options.Records[templateID] = records
+	options.UpdatedAt = time.Now()
+}
+
+// GetExporterOptions devolve uma cópia para que o chamador possa ler sem lock.
+func (tc *TemplateCache) GetExporterOptions(key TemplateKey) *ExporterOptions {
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
+
+	options, ok := tc.options[key]
+	if !ok {
+		return nil
+	}
+	copied := &ExporterOptions{
+		SamplingInterval: options.SamplingInterval,
+		SamplerIntervals: make(map[uint64]uint64, len(options.SamplerIntervals)),
+		InterfaceNames:   make(map[uint32]string, len(options.InterfaceNames)),
+		Records:          make(map[uint16][]FlowRecord, len(options.Records)),
+		UpdatedAt:        options.UpdatedAt,
+	}
+	for id, interval := range options.SamplerIntervals {
+		copied.SamplerIntervals[id] = interval
+	}
+	for ifIndex, name := range options.InterfaceNames {
+		copied.InterfaceNames[ifIndex] = name
+	}
+	for templateID, records := range options.Records {
+		copied.Records[templateID] = records
+	}
+	return copied
+}
+
+func GetTemplateTTL() time.Duration {
+	value := os.Getenv("IPFIX_TEMPLATE_TTL")
+	if value == "" {
+		return defaultTemplateTTL
+	}
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 0 {
+		return defaultTemplateTTL
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// samplingIntervalFromFields aceita as formas de reportar amostragem do
+// NetFlow v9 e do IPFIX: intervalo direto, intervalo/espaço de pacotes e
+// população/tamanho da amostra.
+func samplingIntervalFromFields(fields map[string]interface{}) uint64 {
+	for _, name := range []string{"samplingInterval", "samplerRandomInterval"} {
+		if value, ok := RawUint(fields[name]); ok && value > 0 {
+			return value
+		}
+	}
+	if packetInterval, ok := RawUint(fields["samplingPacketInterval"]); ok && packetInterval > 0 {
+		space, _ := RawUint(fields["samplingPacketSpace"])
+		return (packetInterval + space) / packetInterval
+	}
+	if size, ok := RawUint(fields["samplingSize"]); ok && size > 0 {
+		if population, ok := RawUint(fields["samplingPopulation"]); ok {
+			return population / size
+		}
+	}
+	return 0
+}
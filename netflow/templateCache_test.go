@@ -0,0 +1,39 @@
+package netflow
+
+import (
+	"testing"
+	"time"
+)
+
+type countingStore struct {
+	saves, deletes int
+}
+
+func (s *countingStore) Save(TemplateKey, *Template) error { s.saves++; return nil }
+
+func (s *countingStore) Delete(TemplateKey, uint16) error { s.deletes++; return nil }
+
+func (s *countingStore) LoadAll() (map[TemplateKey][]Template, error) { return nil, nil }
+
+// Um template renovado entre a leitura e a expiração não pode ser removido.
+func TestTemplateExpiryKeepsRefreshedTemplate(t *testing.T) {
+	store := &countingStore{}
+	cache := NewTemplateCache(time.Minute, store)
+	key := TemplateKey{ExporterIP: "192.0.2.1", ExporterPort: 2055}
+	template := &Template{TemplateID: 256, FieldCount: 1}
+
+	cache.AddTemplate(key, template)
+	stale := cache.templates[key][256]
+	stale.updatedAt = time.Now().Add(-2 * time.Minute)
+	cache.AddTemplate(key, template)
+
+	cache.expireTemplate(key, 256, stale)
+	if cache.GetTemplate(key, 256) == nil || store.deletes != 0 {
+		t.Fatalf("template renovado removido (%d deletes)", store.deletes)
+	}
+
+	cache.templates[key][256].updatedAt = time.Now().Add(-2 * time.Minute)
+	if cache.GetTemplate(key, 256) != nil || store.deletes != 1 {
+		t.Fatalf("template vencido mantido (%d deletes)", store.deletes)
+	}
+}
@@ -0,0 +1,92 @@
+package netflow
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TemplateStore persiste os templates recebidos para que sobrevivam a um
+// restart sem esperar o próximo refresh do exportador.
+type TemplateStore interface {
+	Save(key TemplateKey, template *Template) error
+	Delete(key TemplateKey, templateID uint16) error
+	LoadAll() (map[TemplateKey][]Template, error)
+}
+
+type storedTemplate struct {
+	ExporterIP        string    `bson:"exporterIp"`
+	ExporterPort      int       `bson:"exporterPort"`
+	ObservationDomain uint32    `bson:"observationDomain"`
+	TemplateID        uint16    `bson:"templateId"`
+	Template          Template  `bson:"template"`
+	UpdatedAt         time.Time `bson:"updatedAt"`
+}
+
+type MongoTemplateStore struct {
+	collection *mongo.Collection
+}
+
+func NewMongoTemplateStore(collection *mongo.Collection) *MongoTemplateStore {
+	return &MongoTemplateStore{collection: collection}
+}
+
+func templateFilter(key TemplateKey, templateID uint16) bson.M {
+	return bson.M{
+		"exporterIp":        key.ExporterIP,
+		"exporterPort":      key.ExporterPort,
+		"observationDomain": key.ObservationDomain,
+		"templateId":        templateID,
+	}
+}
+
+func (s *MongoTemplateStore) Save(key TemplateKey, template *Template) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	doc := storedTemplate{
+		ExporterIP:        key.ExporterIP,
+		ExporterPort:      key.ExporterPort,
+		ObservationDomain: key.ObservationDomain,
+		TemplateID:        template.TemplateID,
+		Template:          *template,
+		UpdatedAt:         time.Now(),
+	}
+
+	_, err := s.collection.ReplaceOne(ctx, templateFilter(key, template.TemplateID), doc, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (s *MongoTemplateStore) Delete(key TemplateKey, templateID uint16) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := s.collection.DeleteOne(ctx, templateFilter(key, templateID))
+	return err
+}
+
+func (s *MongoTemplateStore) LoadAll() (map[TemplateKey][]Template, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := s.collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []storedTemplate
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	result := make(map[TemplateKey][]Template)
+	for _, doc := range docs {
+		key := TemplateKey{ExporterIP: doc.ExporterIP, ExporterPort: doc.ExporterPort, ObservationDomain: doc.ObservationDomain}
+		result[key] = append(result[key], doc.Template)
+	}
+	return result, nil
+}
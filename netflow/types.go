@@ -15,6 +15,7 @@ type IPFIXHeader struct {
 	ExportTime        uint32 `json:"exportTime"`
 	SequenceNumber    uint32 `json:"sequenceNumber"`
 	ObservationDomain uint32 `json:"observationDomain"`
+	SysUptime         uint32 `json:"sysUptime,omitempty"`
 }
 
 type IPFIXMessage struct {
@@ -29,16 +30,18 @@ type FlowSet struct {
 }
 
 type Template struct {
-	TemplateID uint16          `json:"templateId"`
-	FieldCount uint16          `json:"fieldCount"`
-	Fields     []TemplateField `json:"fields"`
+	TemplateID      uint16          `json:"templateId"`
+	FieldCount      uint16          `json:"fieldCount"`
+	ScopeFieldCount uint16          `json:"scopeFieldCount,omitempty"`
+	Fields          []TemplateField `json:"fields"`
 }
 
 type TemplateField struct {
-	FieldID       uint16 `json:"fieldId"`
-	FieldLength   uint16 `json:"fieldLength"`
-	EnterpriseNum uint32 `json:"enterpriseNum,omitempty"`
-	FieldName     string `json:"fieldName"`
+	FieldID       uint16     `json:"fieldId"`
+	FieldLength   uint16     `json:"fieldLength"`
+	EnterpriseNum uint32     `json:"enterpriseNum,omitempty"`
+	FieldName     string     `json:"fieldName"`
+	DataType      IEDataType `json:"dataType,omitempty"`
 }
 
 type FlowRecord struct {
@@ -59,14 +62,66 @@ type FlowRecord struct {
 	IPClassOfService         uint8                  `json:"ipClassOfService,omitempty"`
 	FlowDirection            uint8                  `json:"flowDirection,omitempty"`
 	IPVersion                uint8                  `json:"ipVersion,omitempty"`
+	SamplingInterval         uint64                 `json:"samplingInterval,omitempty"`
+	SourceAS                 uint32                 `json:"sourceAs,omitempty"`
+	DestinationAS            uint32                 `json:"destinationAs,omitempty"`
+	SourceASName             string                 `json:"sourceAsName,omitempty"`
+	DestinationASName        string                 `json:"destinationAsName,omitempty"`
+	SourceCountry            string                 `json:"sourceCountry,omitempty"`
+	DestinationCountry       string                 `json:"destinationCountry,omitempty"`
+	SourceCustomer           string                 `json:"sourceCustomer,omitempty"`
+	DestinationCustomer      string                 `json:"destinationCustomer,omitempty"`
 	RawFields                map[string]interface{} `json:"rawFields,omitempty"`
 }
 
+// RawUint converte um valor de RawFields em inteiro sem sinal. O tipo depende
+// do caminho: inteiros nativos pelo decoder e pelo lote binário, float64
+// depois de passar pela fila em JSON.
+func RawUint(value interface{}) (uint64, bool) {
+	switch v := value.(type) {
+	case uint64:
+		return v, true
+	case uint32:
+		return uint64(v), true
+	case uint16:
+		return uint64(v), true
+	case uint8:
+		return uint64(v), true
+	case int64:
+		if v >= 0 {
+			return uint64(v), true
+		}
+	case int:
+		if v >= 0 {
+			return uint64(v), true
+		}
+	case float64:
+		if v >= 0 {
+			return uint64(v), true
+		}
+	}
+	return 0, false
+}
+
+const (
+	ProtocolNetflowV5 = "netflow_v5"
+	ProtocolNetflowV9 = "netflow_v9"
+	ProtocolIPFIX     = "ipfix"
+	ProtocolSFlow     = "sflow"
+)
+
 type DecodedIPFIXMessage struct {
-	SrcIP       string       `json:"srcIp"`
-	SrcPort     int          `json:"srcPort"`
-	Received    time.Time    `json:"received"`
-	Header      IPFIXHeader  `json:"header"`
-	Templates   []Template   `json:"templates"`
-	FlowRecords []FlowRecord `json:"flowRecords"`
+	Protocol          string              `json:"protocol,omitempty"`
+	SrcIP             string              `json:"srcIp"`
+	SrcPort           int                 `json:"srcPort"`
+	Received          time.Time           `json:"received"`
+	Header            IPFIXHeader         `json:"header"`
+	Templates         []Template          `json:"templates"`
+	FlowRecords       []FlowRecord        `json:"flowRecords"`
+	OptionRecords     []FlowRecord        `json:"optionRecords,omitempty"`
+	InterfaceCounters []InterfaceCounters `json:"interfaceCounters,omitempty"`
+
+	// Preenchidos pelo decoder para as métricas do pipeline; não vão para a fila.
+	dataRecords      int
+	templatesMissing int
 }
@@ -1,16 +1,18 @@
 package netflow
 
 import (
-	"encoding/json"
 	"log"
-	models "net_monitor/models"
-	repository "net_monitor/repository"
+	"net_monitor/interfaces"
+	"time"
+)
 
-	"go.mongodb.org/mongo-driver/bson"
+const (
+	processorAttempts   = 3
+	processorRetryDelay = 500 * time.Millisecond
 )
 
-func StartMetricWorkers(decodedRabbit *RabbitMQ, routerRepo repository.MongoRepository[models.Roteador], workerCount int) error {
-	deliveries, err := decodedRabbit.Consume()
+func StartMetricWorkers(decodedQueue Queue, workerCount int) error {
+	deliveries, err := decodedQueue.Consume()
 	if err != nil {
 		return err
 	}
@@ -30,46 +32,61 @@ func StartMetricWorkers(decodedRabbit *RabbitMQ, routerRepo repository.MongoRepo
 			//log.Printf("Metric worker %d iniciado com %d processadores", workerId, len(processors))
 
 			for d := range deliveries {
-				var dm DecodedIPFIXMessage
-				if err := json.Unmarshal(d.Body, &dm); err != nil {
+				messages, err := UnmarshalDecoded(d.ContentType, d.Body)
+				if err != nil {
 					//log.Printf("metric worker %d erro unmarshal msg: %v", workerId, err)
-					d.Nack(false, false)
+					deadLetter("metrics", err.Error(), d.ContentType, d.Body)
+					d.Ack()
 					continue
 				}
 
-				var roteador *models.Roteador
-				routers, err := routerRepo.GetByFilter(bson.M{"ipAddress": dm.SrcIP})
-				if err != nil {
-					//log.Printf("metric worker %d erro buscando roteador: %v", workerId, err)
-				}
+				for i := range messages {
+					dm := &messages[i]
 
-				if len(routers) > 0 {
-					roteador = &routers[0]
-					// log.Printf("metric worker %d roteador encontrado: %s (%s) - %d flows",
-					// 	workerId, roteador.Name, roteador.IPAddress, len(dm.FlowRecords))
-				} else {
-					roteador = nil
-					// log.Printf("metric worker %d nenhum roteador encontrado para IP %s - %d flows",
-					// 	workerId, dm.SrcIP, len(dm.FlowRecords))
-				}
+					if enricher := GetFlowEnricher(); enricher != nil {
+						enricher.Enrich(dm)
+					}
 
-				hasError := false
-				for _, processor := range processors {
-					if err := processor.Process(roteador, &dm); err != nil {
-						// log.Printf("metric worker %d erro no processador '%s': %v",
-						// 	workerId, processor.Name(), err)
-						hasError = true
+					var device interfaces.NetworkDevice
+					if resolver := GetExporterResolver(); resolver != nil {
+						device = resolver.Resolve(dm.SrcIP, dm.Header.ObservationDomain)
 					}
-				}
 
-				if hasError {
-					d.Nack(false, true)
-				} else {
-					d.Ack(false)
+					for _, processor := range processors {
+						if err := runProcessor(processor, device, dm); err != nil {
+							log.Printf("metric worker %d: processador '%s' falhou após %d tentativas: %v",
+								workerId, processor.Name(), processorAttempts, err)
+							stats.processorDeadLettered(processor.Name())
+							deadLetterDecoded(processor.Name()+": "+err.Error(), *dm)
+						}
+					}
 				}
+
+				// As falhas já foram para a dead letter; devolver o lote à
+				// fila reprocessaria os outros processadores.
+				d.Ack()
 			}
 		}(i)
 	}
 
 	return nil
 }
+
+// runProcessor tenta algumas vezes antes de desistir, para absorver falhas
+// passageiras do banco sem reenfileirar o lote.
+func runProcessor(processor MetricProcessor, device interfaces.NetworkDevice, dm *DecodedIPFIXMessage) error {
+	var err error
+	for attempt := 1; attempt <= processorAttempts; attempt++ {
+		if attempt > 1 {
+			stats.processorRetry(processor.Name())
+			time.Sleep(processorRetryDelay * time.Duration(attempt-1))
+		}
+		start := time.Now()
+		err = processor.Process(device, dm)
+		stats.processorRun(processor.Name(), time.Since(start), err)
+		if err == nil {
+			return nil
+		}
+	}
+	return err
+}
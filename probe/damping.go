@@ -0,0 +1,64 @@
+package probe
+
+import "sync"
+
+type State string
+
+const (
+	StateUnknown State = "unknown"
+	StateUp      State = "up"
+	StateDown    State = "down"
+)
+
+// FlapDamper só troca o estado após downThreshold falhas ou upThreshold
+// sucessos consecutivos, evitando alertas por perdas isoladas.
+type FlapDamper struct {
+	downThreshold int
+	upThreshold   int
+	state         State
+	failures      int
+	successes     int
+	mu            sync.Mutex
+}
+
+func NewFlapDamper(downThreshold, upThreshold int) *FlapDamper {
+	if downThreshold < 1 {
+		downThreshold = 1
+	}
+	if upThreshold < 1 {
+		upThreshold = 1
+	}
+	return &FlapDamper{
+		downThreshold: downThreshold,
+		upThreshold:   upThreshold,
+		state:         StateUnknown,
+	}
+}
+
+func (d *FlapDamper) Observe(success bool) (State, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	previous := d.state
+	if success {
+		d.successes++
+		d.failures = 0
+		if d.state != StateUp && (d.successes >= d.upThreshold || d.state == StateUnknown) {
+			d.state = StateUp
+		}
+	} else {
+		d.failures++
+		d.successes = 0
+		if d.state != StateDown && d.failures >= d.downThreshold {
+			d.state = StateDown
+		}
+	}
+
+	return d.state, d.state != previous
+}
+
+func (d *FlapDamper) State() State {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.state
+}
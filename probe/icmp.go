@@ -0,0 +1,141 @@
+package probe
+
+import (
+	"fmt"
+	"math/rand"
+	"net"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+const (
+	protocolICMP     = 1
+	protocolICMPv6   = 58
+	icmpPacketGap    = 200 * time.Millisecond
+	icmpPayloadBytes = 32
+)
+
+type ICMPProber struct{}
+
+func NewICMPProber() *ICMPProber {
+	return &ICMPProber{}
+}
+
+func (p *ICMPProber) Name() string {
+	return "icmp"
+}
+
+func (p *ICMPProber) Probe(address string, count int, timeout time.Duration) ([]time.Duration, error) {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		addr, err := net.ResolveIPAddr("ip", address)
+		if err != nil {
+			return nil, fmt.Errorf("endereço inválido %s: %v", address, err)
+		}
+		ip = addr.IP
+	}
+
+	conn, privileged, err := listenICMP(ip)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var dst net.Addr = &net.UDPAddr{IP: ip}
+	if privileged {
+		dst = &net.IPAddr{IP: ip}
+	}
+
+	echoType, replyType, proto := icmp.Type(ipv4.ICMPTypeEcho), icmp.Type(ipv4.ICMPTypeEchoReply), protocolICMP
+	if ip.To4() == nil {
+		echoType, replyType, proto = ipv6.ICMPTypeEchoRequest, ipv6.ICMPTypeEchoReply, protocolICMPv6
+	}
+
+	// Em sockets não privilegiados o kernel reescreve o ID, então o match é feito só pela sequência.
+	id := rand.Intn(0xffff)
+	payload := make([]byte, icmpPayloadBytes)
+	rtts := make([]time.Duration, 0, count)
+	buffer := make([]byte, 1500)
+
+	for seq := 1; seq <= count; seq++ {
+		message := icmp.Message{
+			Type: echoType,
+			Body: &icmp.Echo{ID: id, Seq: seq, Data: payload},
+		}
+		packet, err := message.Marshal(nil)
+		if err != nil {
+			return rtts, err
+		}
+
+		sentAt := time.Now()
+		if _, err := conn.WriteTo(packet, dst); err != nil {
+			return rtts, fmt.Errorf("erro ao enviar ICMP para %s: %v", address, err)
+		}
+
+		if rtt, ok := waitEchoReply(conn, buffer, proto, replyType, id, seq, privileged, sentAt, timeout); ok {
+			rtts = append(rtts, rtt)
+		}
+
+		if seq < count {
+			time.Sleep(icmpPacketGap)
+		}
+	}
+
+	return rtts, nil
+}
+
+func listenICMP(ip net.IP) (*icmp.PacketConn, bool, error) {
+	network, privilegedNetwork, listenAddr := "udp4", "ip4:icmp", "0.0.0.0"
+	if ip.To4() == nil {
+		network, privilegedNetwork, listenAddr = "udp6", "ip6:ipv6-icmp", "::"
+	}
+
+	conn, err := icmp.ListenPacket(network, listenAddr)
+	if err == nil {
+		return conn, false, nil
+	}
+
+	conn, privilegedErr := icmp.ListenPacket(privilegedNetwork, listenAddr)
+	if privilegedErr != nil {
+		return nil, false, fmt.Errorf("não foi possível abrir socket ICMP (%v / %v)", err, privilegedErr)
+	}
+	return conn, true, nil
+}
+
+func waitEchoReply(
+	conn *icmp.PacketConn,
+	buffer []byte,
+	proto int,
+	replyType icmp.Type,
+	id, seq int,
+	privileged bool,
+	sentAt time.Time,
+	timeout time.Duration,
+) (time.Duration, bool) {
+	deadline := sentAt.Add(timeout)
+	if err := conn.SetReadDeadline(deadline); err != nil {
+		return 0, false
+	}
+
+	for {
+		n, _, err := conn.ReadFrom(buffer)
+		if err != nil {
+			return 0, false
+		}
+
+		reply, err := icmp.ParseMessage(proto, buffer[:n])
+		if err != nil || reply.Type != replyType {
+			continue
+		}
+
+		echo, ok := reply.Body.(*icmp.Echo)
+		if !ok || echo.Seq != seq || (privileged && echo.ID != id) {
+			continue
+		}
+
+		return time.Since(sentAt), true
+	}
+}
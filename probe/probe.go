@@ -0,0 +1,64 @@
+package probe
+
+import (
+	"math"
+	"time"
+)
+
+type Prober interface {
+	Probe(address string, count int, timeout time.Duration) ([]time.Duration, error)
+	Name() string
+}
+
+type Stats struct {
+	Sent        int     `json:"sent"`
+	Received    int     `json:"received"`
+	LossPercent float64 `json:"lossPercent"`
+	RttMinMs    float64 `json:"rttMinMs"`
+	RttAvgMs    float64 `json:"rttAvgMs"`
+	RttMaxMs    float64 `json:"rttMaxMs"`
+	JitterMs    float64 `json:"jitterMs"`
+}
+
+// Jitter é a média da diferença absoluta entre RTTs consecutivos (RFC 3550 sem suavização).
+func ComputeStats(sent int, rtts []time.Duration) Stats {
+	stats := Stats{Sent: sent, Received: len(rtts)}
+	if sent > 0 {
+		stats.LossPercent = round2(float64(sent-len(rtts)) / float64(sent) * 100)
+	}
+	if len(rtts) == 0 {
+		return stats
+	}
+
+	min, max, sum := rtts[0], rtts[0], time.Duration(0)
+	var jitterSum float64
+	for i, rtt := range rtts {
+		if rtt < min {
+			min = rtt
+		}
+		if rtt > max {
+			max = rtt
+		}
+		sum += rtt
+		if i > 0 {
+			jitterSum += math.Abs(toMs(rtt) - toMs(rtts[i-1]))
+		}
+	}
+
+	stats.RttMinMs = round2(toMs(min))
+	stats.RttMaxMs = round2(toMs(max))
+	stats.RttAvgMs = round2(toMs(sum) / float64(len(rtts)))
+	if len(rtts) > 1 {
+		stats.JitterMs = round2(jitterSum / float64(len(rtts)-1))
+	}
+
+	return stats
+}
+
+func toMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+func round2(value float64) float64 {
+	return math.Round(value*100) / 100
+}
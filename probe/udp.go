@@ -0,0 +1,66 @@
+package probe
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const DefaultUDPProbePort = 33434
+
+// UDPProber considera o destino alcançável quando recebe qualquer resposta
+// UDP ou um ICMP port unreachable (ECONNREFUSED no socket conectado).
+type UDPProber struct {
+	Port int
+}
+
+func NewUDPProber(port int) *UDPProber {
+	if port <= 0 {
+		port = DefaultUDPProbePort
+	}
+	return &UDPProber{Port: port}
+}
+
+func (p *UDPProber) Name() string {
+	return "udp"
+}
+
+func (p *UDPProber) Probe(address string, count int, timeout time.Duration) ([]time.Duration, error) {
+	conn, err := net.Dial("udp", net.JoinHostPort(address, strconv.Itoa(p.Port)))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir socket UDP para %s: %v", address, err)
+	}
+	defer conn.Close()
+
+	rtts := make([]time.Duration, 0, count)
+	buffer := make([]byte, 512)
+
+	for seq := 1; seq <= count; seq++ {
+		sentAt := time.Now()
+		if _, err := conn.Write([]byte("net_monitor probe " + strconv.Itoa(seq))); err != nil && !isConnRefused(err) {
+			return rtts, fmt.Errorf("erro ao enviar UDP para %s: %v", address, err)
+		}
+
+		if err := conn.SetReadDeadline(sentAt.Add(timeout)); err != nil {
+			return rtts, err
+		}
+
+		_, err := conn.Read(buffer)
+		if err == nil || isConnRefused(err) {
+			rtts = append(rtts, time.Since(sentAt))
+		}
+
+		if seq < count {
+			time.Sleep(icmpPacketGap)
+		}
+	}
+
+	return rtts, nil
+}
+
+func isConnRefused(err error) bool {
+	return errors.Is(err, syscall.ECONNREFUSED)
+}
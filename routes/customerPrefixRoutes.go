@@ -0,0 +1,28 @@
+package routes
+
+import (
+	"net_monitor/controllers"
+	"net_monitor/middlewares"
+	"net_monitor/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupCustomerPrefixRoutes(
+	router *gin.Engine,
+	customerPrefixController *controllers.CustomerPrefixController,
+	authService services.AuthService,
+) {
+	api := router.Group("/api")
+	{
+		customerPrefixes := api.Group("/customerPrefixes")
+		customerPrefixes.Use(middlewares.AuthMiddleware(authService))
+		{
+			customerPrefixes.GET("", customerPrefixController.GetAllCustomerPrefixes)
+			customerPrefixes.GET("/:id", customerPrefixController.GetCustomerPrefix)
+			customerPrefixes.POST("", customerPrefixController.CreateCustomerPrefix)
+			customerPrefixes.PUT("/:id", customerPrefixController.UpdateCustomerPrefix)
+			customerPrefixes.DELETE("/:id", customerPrefixController.DeleteCustomerPrefix)
+		}
+	}
+}
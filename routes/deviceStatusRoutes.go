@@ -0,0 +1,40 @@
+package routes
+
+import (
+	"net_monitor/controllers"
+	"net_monitor/middlewares"
+	"net_monitor/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupDeviceStatusRoutes(
+	router *gin.Engine,
+	deviceStatusController *controllers.DeviceStatusController,
+	authService services.AuthService,
+) {
+	api := router.Group("/api")
+	{
+		deviceStatus := api.Group("/deviceStatus")
+		deviceStatus.Use(middlewares.AuthMiddleware(authService))
+		{
+			deviceStatus.GET("", deviceStatusController.GetAllDeviceStatuses)
+			deviceStatus.GET("/:deviceId", deviceStatusController.GetDeviceStatus)
+			deviceStatus.GET("/:deviceId/history", deviceStatusController.GetDeviceStatusHistory)
+		}
+
+		sla := api.Group("/sla")
+		sla.Use(middlewares.AuthMiddleware(authService))
+		{
+			sla.GET("/report", deviceStatusController.GetSLAReport)
+		}
+
+		maintenanceWindows := api.Group("/maintenanceWindows")
+		maintenanceWindows.Use(middlewares.AuthMiddleware(authService))
+		{
+			maintenanceWindows.GET("", deviceStatusController.GetMaintenanceWindows)
+			maintenanceWindows.POST("", deviceStatusController.CreateMaintenanceWindow)
+			maintenanceWindows.DELETE("/:id", deviceStatusController.DeleteMaintenanceWindow)
+		}
+	}
+}
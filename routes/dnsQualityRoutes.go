@@ -0,0 +1,27 @@
+package routes
+
+import (
+	"net_monitor/controllers"
+	"net_monitor/middlewares"
+	"net_monitor/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupDNSQualityRoutes(
+	router *gin.Engine,
+	dnsQualityController *controllers.DNSQualityController,
+	authService services.AuthService,
+) {
+	api := router.Group("/api/metrics")
+	{
+		dns := api.Group("/dns")
+		dns.Use(middlewares.AuthMiddleware(authService))
+		{
+			dns.GET("/servers", dnsQualityController.GetServers)
+			dns.GET("/servers/:ip/latency", dnsQualityController.GetLatency)
+			dns.GET("/servers/:ip/trend", dnsQualityController.GetTrend)
+			dns.GET("/compare", dnsQualityController.Compare)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package routes
+
+import (
+	"net_monitor/controllers"
+	"net_monitor/middlewares"
+	"net_monitor/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupFlowExporterRoutes(
+	router *gin.Engine,
+	flowExporterController *controllers.FlowExporterController,
+	authService services.AuthService,
+) {
+	api := router.Group("/api")
+	{
+		flowExporters := api.Group("/flowExporters")
+		flowExporters.Use(middlewares.AuthMiddleware(authService))
+		{
+			flowExporters.GET("", flowExporterController.GetAllFlowExporters)
+			flowExporters.GET("/unknown", flowExporterController.GetUnknownFlowExporters)
+			flowExporters.GET("/:id", flowExporterController.GetFlowExporter)
+			flowExporters.POST("", flowExporterController.CreateFlowExporter)
+			flowExporters.PUT("/:id", flowExporterController.UpdateFlowExporter)
+			flowExporters.DELETE("/:id", flowExporterController.DeleteFlowExporter)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"net_monitor/controllers"
+	"net_monitor/middlewares"
+	"net_monitor/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupFlowSearchRoutes(
+	router *gin.Engine,
+	flowSearchController *controllers.FlowSearchController,
+	authService services.AuthService,
+) {
+	api := router.Group("/api")
+	{
+		flows := api.Group("/flows")
+		flows.Use(middlewares.AuthMiddleware(authService))
+		{
+			flows.GET("/search", flowSearchController.SearchFlows)
+		}
+	}
+}
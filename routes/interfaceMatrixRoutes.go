@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"net_monitor/controllers"
+	"net_monitor/middlewares"
+	"net_monitor/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupInterfaceMatrixRoutes(
+	router *gin.Engine,
+	interfaceMatrixController *controllers.InterfaceMatrixController,
+	authService services.AuthService,
+) {
+	api := router.Group("/api/metrics")
+	{
+		interfaceMatrix := api.Group("/interfaceMatrix")
+		interfaceMatrix.Use(middlewares.AuthMiddleware(authService))
+		{
+			interfaceMatrix.GET("/:routerId", interfaceMatrixController.GetMatrix)
+			interfaceMatrix.GET("/:routerId/rates", interfaceMatrixController.GetRates)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"net_monitor/controllers"
+	"net_monitor/middlewares"
+	"net_monitor/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupMetricAggregationRoutes(
+	router *gin.Engine,
+	metricAggregationController *controllers.MetricAggregationController,
+	authService services.AuthService,
+) {
+	api := router.Group("/api/metrics")
+	{
+		aggregate := api.Group("/aggregate")
+		aggregate.Use(middlewares.AuthMiddleware(authService))
+		{
+			aggregate.GET("/:source", metricAggregationController.Aggregate)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"net_monitor/controllers"
+	"net_monitor/middlewares"
+	"net_monitor/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupNATLogRoutes(
+	router *gin.Engine,
+	natLogController *controllers.NATLogController,
+	authService services.AuthService,
+) {
+	api := router.Group("/api")
+	{
+		natLogs := api.Group("/natLogs")
+		natLogs.Use(middlewares.AuthMiddleware(authService))
+		natLogs.Use(middlewares.AuthorizedUsersMiddleware("NAT_LOG_AUTHORIZED_USERS"))
+		{
+			natLogs.GET("/lookup", natLogController.Lookup)
+			natLogs.GET("/audit", natLogController.GetAudit)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"net_monitor/controllers"
+	"net_monitor/middlewares"
+	"net_monitor/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupNetflowStatusRoutes(
+	router *gin.Engine,
+	netflowStatusController *controllers.NetflowStatusController,
+	authService services.AuthService,
+) {
+	api := router.Group("/api")
+	{
+		netflow := api.Group("/netflow")
+		netflow.Use(middlewares.AuthMiddleware(authService))
+		{
+			netflow.GET("/status", netflowStatusController.GetStatus)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package routes
+
+import (
+	"net_monitor/controllers"
+	"net_monitor/middlewares"
+	"net_monitor/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupProbeRoutes(
+	router *gin.Engine,
+	probeController *controllers.ProbeController,
+	authService services.AuthService,
+) {
+	api := router.Group("/api")
+	{
+		probes := api.Group("/probes")
+		probes.Use(middlewares.AuthMiddleware(authService))
+		{
+			probes.GET("", probeController.GetAllProbeConfigs)
+			probes.GET("/:deviceId", probeController.GetProbeConfig)
+			probes.PUT("/:deviceId", probeController.SaveProbeConfig)
+			probes.DELETE("/:deviceId", probeController.DeleteProbeConfig)
+			probes.GET("/:deviceId/results", probeController.GetProbeResults)
+			probes.GET("/:deviceId/availability", probeController.GetProbeAvailability)
+			probes.GET("/:deviceId/status", probeController.GetProbeStatus)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package routes
+
+import (
+	"net_monitor/controllers"
+	"net_monitor/middlewares"
+	"net_monitor/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupSubscriberRoutes(
+	router *gin.Engine,
+	subscriberController *controllers.SubscriberController,
+	authService services.AuthService,
+) {
+	api := router.Group("/api")
+	{
+		subscribers := api.Group("/subscribers")
+		subscribers.Use(middlewares.AuthMiddleware(authService))
+		{
+			subscribers.GET("", subscriberController.GetAllSubscribers)
+			subscribers.GET("/topConsumers", subscriberController.GetTopConsumers)
+			subscribers.GET("/:id", subscriberController.GetSubscriber)
+			subscribers.POST("", subscriberController.CreateSubscriber)
+			subscribers.PUT("/:id", subscriberController.UpdateSubscriber)
+			subscribers.DELETE("/:id", subscriberController.DeleteSubscriber)
+			subscribers.GET("/:id/usage", subscriberController.GetSubscriberUsage)
+		}
+	}
+}
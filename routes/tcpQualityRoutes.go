@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"net_monitor/controllers"
+	"net_monitor/middlewares"
+	"net_monitor/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupTCPQualityRoutes(
+	router *gin.Engine,
+	tcpQualityController *controllers.TCPQualityController,
+	authService services.AuthService,
+) {
+	api := router.Group("/api/metrics")
+	{
+		tcpQuality := api.Group("/tcpQuality")
+		tcpQuality.Use(middlewares.AuthMiddleware(authService))
+		{
+			tcpQuality.GET("/services", tcpQualityController.GetServices)
+			tcpQuality.GET("/series", tcpQualityController.GetSeries)
+		}
+	}
+}
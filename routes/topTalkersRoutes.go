@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"net_monitor/controllers"
+	"net_monitor/middlewares"
+	"net_monitor/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupTopTalkersRoutes(
+	router *gin.Engine,
+	topTalkersController *controllers.TopTalkersController,
+	authService services.AuthService,
+) {
+	api := router.Group("/api/metrics")
+	{
+		topTalkers := api.Group("/topTalkers")
+		topTalkers.Use(middlewares.AuthMiddleware(authService))
+		{
+			topTalkers.GET("/:dimension", topTalkersController.GetTopTalkers)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"net_monitor/controllers"
+	"net_monitor/middlewares"
+	"net_monitor/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupTrafficClassMetricRoutes(
+	router *gin.Engine,
+	trafficClassController *controllers.TrafficClassMetricController,
+	authService services.AuthService,
+) {
+	api := router.Group("/api/metrics")
+	{
+		trafficClass := api.Group("/trafficClass")
+		trafficClass.Use(middlewares.AuthMiddleware(authService))
+		{
+			trafficClass.GET("/daily", trafficClassController.GetTrafficClassDaily)
+			trafficClass.GET("/daily/:routerId", trafficClassController.GetTrafficClassDailyByRouter)
+			trafficClass.GET("/day/:date", trafficClassController.GetTrafficClassByDay)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package routes
+
+import (
+	"net_monitor/controllers"
+	"net_monitor/middlewares"
+	"net_monitor/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupTrafficClassRuleRoutes(
+	router *gin.Engine,
+	trafficClassRuleController *controllers.TrafficClassRuleController,
+	authService services.AuthService,
+) {
+	api := router.Group("/api")
+	{
+		trafficClassRules := api.Group("/trafficClassRules")
+		trafficClassRules.Use(middlewares.AuthMiddleware(authService))
+		{
+			trafficClassRules.GET("", trafficClassRuleController.GetAllTrafficClassRules)
+			trafficClassRules.GET("/:id", trafficClassRuleController.GetTrafficClassRule)
+			trafficClassRules.POST("", trafficClassRuleController.CreateTrafficClassRule)
+			trafficClassRules.PUT("/:id", trafficClassRuleController.UpdateTrafficClassRule)
+			trafficClassRules.DELETE("/:id", trafficClassRuleController.DeleteTrafficClassRule)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"net_monitor/interfaces"
+	"net_monitor/snmp"
+)
+
+type BgpSessionChange struct {
+	PeerIP        string
+	RemoteAS      int
+	PreviousState int
+	CurrentState  int
+}
+
+func DiffBgpPeers(previous, current []snmp.BgpPeer) []BgpSessionChange {
+	previousByIP := make(map[string]snmp.BgpPeer, len(previous))
+	for _, peer := range previous {
+		previousByIP[peer.PeerIP] = peer
+	}
+
+	var changes []BgpSessionChange
+	for _, peer := range current {
+		previousPeer, existed := previousByIP[peer.PeerIP]
+		if !existed || previousPeer.State == peer.State {
+			continue
+		}
+		changes = append(changes, BgpSessionChange{
+			PeerIP:        peer.PeerIP,
+			RemoteAS:      peer.RemoteAS,
+			PreviousState: previousPeer.State,
+			CurrentState:  peer.State,
+		})
+	}
+
+	return changes
+}
+
+func (s *SNMPService) publishBgpSessionChanges(collection *DeviceCollection, previousValue, currentValue interface{}) {
+	previous, ok := previousValue.([]snmp.BgpPeer)
+	if !ok {
+		return
+	}
+	current, ok := currentValue.([]snmp.BgpPeer)
+	if !ok {
+		return
+	}
+
+	for _, change := range DiffBgpPeers(previous, current) {
+		event := interfaces.TrapEvent{
+			DeviceID:   collection.DeviceID,
+			DeviceName: collection.Device.GetName(),
+			DeviceIP:   collection.Device.GetIPAddress(),
+			DeviceType: string(collection.DeviceType),
+			Vendor:     collection.Device.GetIntegration(),
+			EventType:  "bgp_session_state_change",
+			Message: fmt.Sprintf("Sessão BGP com %s (AS%d) mudou de %s para %s",
+				change.PeerIP, change.RemoteAS,
+				snmp.BgpStateName(change.PreviousState), snmp.BgpStateName(change.CurrentState)),
+			Data: map[string]interface{}{
+				"peer_ip":        change.PeerIP,
+				"remote_as":      change.RemoteAS,
+				"previous_state": snmp.BgpStateName(change.PreviousState),
+				"state":          snmp.BgpStateName(change.CurrentState),
+				"established":    change.CurrentState == snmp.BgpStateEstablished,
+			},
+			Timestamp: time.Now(),
+		}
+
+		jsonData, err := json.Marshal(event)
+		if err != nil {
+			log.Printf("Erro ao serializar evento BGP: %v", err)
+			continue
+		}
+
+		s.hub.Broadcast(jsonData)
+		log.Printf("[BGP EVENT] %s: %s", collection.Device.GetName(), event.Message)
+	}
+}
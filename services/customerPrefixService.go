@@ -0,0 +1,129 @@
+package services
+
+import (
+	"net/netip"
+	models "net_monitor/models"
+	enrichment "net_monitor/netflow/enrichment"
+	repository "net_monitor/repository"
+	utils "net_monitor/utils"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type CustomerPrefixService interface {
+	GetAll() ([]models.CustomerPrefix, error)
+	GetById(id string) (*models.CustomerPrefix, error)
+	Create(customerPrefix *models.CustomerPrefix) (error, *utils.APIError)
+	Update(id string, customerPrefix *models.CustomerPrefix) (error, *utils.APIError)
+	Delete(id string) error
+	SetChangeListener(listener func())
+}
+
+type customerPrefixServiceImpl struct {
+	repo     *repository.MongoRepository[models.CustomerPrefix]
+	onChange func()
+}
+
+func NewCustomerPrefixService(repo *repository.MongoRepository[models.CustomerPrefix]) CustomerPrefixService {
+	return &customerPrefixServiceImpl{repo: repo}
+}
+
+// SetChangeListener é chamado após qualquer alteração, para o enriquecimento
+// de flows recarregar a lista sem esperar o próximo ciclo.
+func (s *customerPrefixServiceImpl) SetChangeListener(listener func()) {
+	s.onChange = listener
+}
+
+func (s *customerPrefixServiceImpl) GetAll() ([]models.CustomerPrefix, error) {
+	return s.repo.GetAll()
+}
+
+func (s *customerPrefixServiceImpl) GetById(id string) (*models.CustomerPrefix, error) {
+	return s.repo.GetById(id)
+}
+
+func (s *customerPrefixServiceImpl) Create(customerPrefix *models.CustomerPrefix) (error, *utils.APIError) {
+	if apiErr := s.validate(customerPrefix, primitive.NilObjectID); apiErr != nil {
+		return nil, apiErr
+	}
+	if err := s.repo.Create(customerPrefix); err != nil {
+		return err, nil
+	}
+	s.notifyChange()
+	return nil, nil
+}
+
+func (s *customerPrefixServiceImpl) Update(id string, customerPrefix *models.CustomerPrefix) (error, *utils.APIError) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err, nil
+	}
+	existent, err := s.repo.GetById(id)
+	if err != nil {
+		return err, nil
+	}
+	if existent == nil {
+		return nil, &utils.APIError{
+			Code:    "CUSTOMER_PREFIX_NOT_FOUND",
+			Message: "Customer prefix not found",
+		}
+	}
+	if apiErr := s.validate(customerPrefix, objectID); apiErr != nil {
+		return nil, apiErr
+	}
+	customerPrefix.ID = objectID
+	customerPrefix.Created_At = existent.Created_At
+	if err := s.repo.Update(id, customerPrefix); err != nil {
+		return err, nil
+	}
+	s.notifyChange()
+	return nil, nil
+}
+
+func (s *customerPrefixServiceImpl) Delete(id string) error {
+	if err := s.repo.Delete(id); err != nil {
+		return err
+	}
+	s.notifyChange()
+	return nil
+}
+
+// validate normaliza o prefixo (10.0.0.1/24 vira 10.0.0.0/24, ::ffff:10.0.0.0/104
+// vira 10.0.0.0/8) e rejeita duplicados.
+func (s *customerPrefixServiceImpl) validate(customerPrefix *models.CustomerPrefix, ignoreID primitive.ObjectID) *utils.APIError {
+	prefix, err := netip.ParsePrefix(customerPrefix.Prefix)
+	if err != nil {
+		return &utils.APIError{
+			Code:    "INVALID_PREFIX",
+			Message: "Prefix must be in CIDR notation",
+		}
+	}
+	prefix, ok := enrichment.NormalizePrefix(prefix)
+	if !ok {
+		return &utils.APIError{
+			Code:    "INVALID_PREFIX",
+			Message: "IPv4-mapped prefixes must be /96 or longer",
+		}
+	}
+	customerPrefix.Prefix = prefix.String()
+
+	filter := bson.M{"prefix": customerPrefix.Prefix}
+	if !ignoreID.IsZero() {
+		filter["_id"] = bson.M{"$ne": ignoreID}
+	}
+	existent, err := s.repo.GetByFilter(filter)
+	if err == nil && len(existent) > 0 {
+		return &utils.APIError{
+			Code:    "DUPLICATED_PREFIX",
+			Message: "A customer prefix with that network already exists",
+		}
+	}
+	return nil
+}
+
+func (s *customerPrefixServiceImpl) notifyChange() {
+	if s.onChange != nil {
+		go s.onChange()
+	}
+}
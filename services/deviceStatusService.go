@@ -0,0 +1,381 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"net_monitor/interfaces"
+	models "net_monitor/models"
+	repository "net_monitor/repository"
+	utils "net_monitor/utils"
+	"net_monitor/websocket"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	snmpPollFailureThreshold = 3
+
+	// Intervalo mínimo entre atualizações do lastSeen da transição atual.
+	statusHeartbeatInterval = time.Minute
+)
+
+type DeviceStatusService interface {
+	ReportSNMPPoll(device interfaces.NetworkDevice, deviceType DeviceType, success bool)
+	ReportProbe(device interfaces.NetworkDevice, deviceType DeviceType, status models.ProbeStatus)
+	ReportTrap(device interfaces.NetworkDevice, deviceType DeviceType, event *interfaces.TrapEvent)
+	GetStatus(deviceID string) DeviceStatusSnapshot
+	GetAllStatuses() []DeviceStatusSnapshot
+	GetTransitions(deviceID string, from, to time.Time) ([]models.DeviceStatusTransition, error)
+	GetMaintenanceWindows() ([]models.MaintenanceWindow, error)
+	CreateMaintenanceWindow(window *models.MaintenanceWindow) (error, *utils.APIError)
+	DeleteMaintenanceWindow(id string) error
+	GetSLAReport(month time.Time, site string, deviceID string, location *time.Location) (*SLAReport, error)
+}
+
+type DeviceStatusSnapshot struct {
+	DeviceID      string                         `json:"deviceId"`
+	DeviceName    string                         `json:"deviceName"`
+	DeviceType    string                         `json:"deviceType"`
+	Site          string                         `json:"site,omitempty"`
+	Status        models.DeviceOperationalStatus `json:"status"`
+	Reason        string                         `json:"reason"`
+	Since         *time.Time                     `json:"since,omitempty"`
+	SNMPFailures  int                            `json:"snmpFailures"`
+	ProbeStatus   models.ProbeStatus             `json:"probeStatus"`
+	LinksDown     []string                       `json:"linksDown"`
+	LastEvaluated *time.Time                     `json:"lastEvaluated,omitempty"`
+}
+
+type deviceStatusState struct {
+	device        interfaces.NetworkDevice
+	deviceType    DeviceType
+	status        models.DeviceOperationalStatus
+	reason        string
+	since         *time.Time
+	snmpReported  bool
+	snmpFailures  int
+	probeStatus   models.ProbeStatus
+	linksDown     map[string]bool
+	lastEvaluated *time.Time
+	transitionID  primitive.ObjectID
+	lastSeenSaved time.Time
+}
+
+type deviceStatusServiceImpl struct {
+	hub             *websocket.Hub
+	deviceService   DeviceService
+	transitionRepo  *repository.MongoRepository[models.DeviceStatusTransition]
+	maintenanceRepo *repository.MongoRepository[models.MaintenanceWindow]
+	staleAfter      time.Duration
+	states          map[string]*deviceStatusState
+	mu              sync.Mutex
+}
+
+// NewDeviceStatusService lê SLA_STALE_MINUTES (padrão 15): sem avaliação do
+// dispositivo por esse tempo, o SLA passa a contar o período como unknown.
+// Deve ser maior que o maior intervalo de coleta ou de probe.
+func NewDeviceStatusService(
+	hub *websocket.Hub,
+	deviceService DeviceService,
+	transitionRepo *repository.MongoRepository[models.DeviceStatusTransition],
+	maintenanceRepo *repository.MongoRepository[models.MaintenanceWindow],
+) DeviceStatusService {
+	return &deviceStatusServiceImpl{
+		hub:             hub,
+		deviceService:   deviceService,
+		transitionRepo:  transitionRepo,
+		maintenanceRepo: maintenanceRepo,
+		staleAfter:      time.Duration(utils.EnvPositiveInt("SLA_STALE_MINUTES", 15)) * time.Minute,
+		states:          make(map[string]*deviceStatusState),
+	}
+}
+
+func (s *deviceStatusServiceImpl) ReportSNMPPoll(device interfaces.NetworkDevice, deviceType DeviceType, success bool) {
+	s.update(device, deviceType, func(state *deviceStatusState) {
+		state.snmpReported = true
+		if success {
+			state.snmpFailures = 0
+		} else {
+			state.snmpFailures++
+		}
+	})
+}
+
+func (s *deviceStatusServiceImpl) ReportProbe(device interfaces.NetworkDevice, deviceType DeviceType, status models.ProbeStatus) {
+	s.update(device, deviceType, func(state *deviceStatusState) {
+		state.probeStatus = status
+	})
+}
+
+func (s *deviceStatusServiceImpl) ReportTrap(device interfaces.NetworkDevice, deviceType DeviceType, event *interfaces.TrapEvent) {
+	switch event.EventType {
+	case "link_down", "link_up", "cold_start", "warm_start":
+	default:
+		return
+	}
+
+	s.update(device, deviceType, func(state *deviceStatusState) {
+		switch event.EventType {
+		case "link_down":
+			state.linksDown[trapInterfaceKey(event)] = true
+		case "link_up":
+			delete(state.linksDown, trapInterfaceKey(event))
+		case "cold_start", "warm_start":
+			state.linksDown = make(map[string]bool)
+		}
+	})
+}
+
+func (s *deviceStatusServiceImpl) GetStatus(deviceID string) DeviceStatusSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, exists := s.states[deviceID]
+	if !exists {
+		return DeviceStatusSnapshot{
+			DeviceID:    deviceID,
+			Status:      models.DeviceStatusUnknown,
+			ProbeStatus: models.ProbeStatusUnknown,
+			LinksDown:   []string{},
+		}
+	}
+	return state.snapshot()
+}
+
+func (s *deviceStatusServiceImpl) GetAllStatuses() []DeviceStatusSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshots := make([]DeviceStatusSnapshot, 0, len(s.states))
+	for _, state := range s.states {
+		snapshots = append(snapshots, state.snapshot())
+	}
+	return snapshots
+}
+
+func (s *deviceStatusServiceImpl) GetTransitions(deviceID string, from, to time.Time) ([]models.DeviceStatusTransition, error) {
+	deviceObjectID, err := primitive.ObjectIDFromHex(deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"deviceId": deviceObjectID,
+		"timestamp": bson.M{
+			"$gte": primitive.NewDateTimeFromTime(from),
+			"$lt":  primitive.NewDateTimeFromTime(to),
+		},
+	}
+	cursor, err := s.transitionRepo.Collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "timestamp", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	transitions := []models.DeviceStatusTransition{}
+	if err := cursor.All(ctx, &transitions); err != nil {
+		return nil, err
+	}
+	return transitions, nil
+}
+
+func (s *deviceStatusServiceImpl) GetMaintenanceWindows() ([]models.MaintenanceWindow, error) {
+	return s.maintenanceRepo.GetAll()
+}
+
+func (s *deviceStatusServiceImpl) CreateMaintenanceWindow(window *models.MaintenanceWindow) (error, *utils.APIError) {
+	if !window.Start.Time().Before(window.End.Time()) {
+		return nil, &utils.APIError{
+			Code:    "INVALID_MAINTENANCE_WINDOW",
+			Message: "Maintenance window start must be before its end",
+		}
+	}
+	if window.DeviceID != nil {
+		if _, _, err := s.deviceService.GetByID(window.DeviceID.Hex()); err != nil {
+			return nil, &utils.APIError{
+				Code:    "DEVICE_NOT_FOUND",
+				Message: "Device not found",
+			}
+		}
+	}
+	return s.maintenanceRepo.Create(window), nil
+}
+
+func (s *deviceStatusServiceImpl) DeleteMaintenanceWindow(id string) error {
+	return s.maintenanceRepo.Delete(id)
+}
+
+func (s *deviceStatusServiceImpl) update(device interfaces.NetworkDevice, deviceType DeviceType, apply func(state *deviceStatusState)) {
+	s.mu.Lock()
+	state, exists := s.states[device.GetID()]
+	if !exists {
+		state = &deviceStatusState{
+			status:      models.DeviceStatusUnknown,
+			probeStatus: models.ProbeStatusUnknown,
+			linksDown:   make(map[string]bool),
+		}
+		s.states[device.GetID()] = state
+	}
+	state.device = device
+	state.deviceType = deviceType
+
+	apply(state)
+
+	now := time.Now()
+	state.lastEvaluated = &now
+	status, reason := state.evaluate()
+	previous := state.status
+	state.reason = reason
+	if status == previous {
+		transitionID := state.transitionID
+		heartbeat := !transitionID.IsZero() && now.Sub(state.lastSeenSaved) >= statusHeartbeatInterval
+		if heartbeat {
+			state.lastSeenSaved = now
+		}
+		s.mu.Unlock()
+		if heartbeat {
+			s.touchTransition(transitionID, now)
+		}
+		return
+	}
+	state.status = status
+	state.since = &now
+	transitionID := primitive.NewObjectID()
+	state.transitionID = transitionID
+	state.lastSeenSaved = now
+	s.mu.Unlock()
+
+	s.recordTransition(transitionID, device, deviceType, previous, status, reason, now)
+}
+
+func (s *deviceStatusServiceImpl) touchTransition(transitionID primitive.ObjectID, now time.Time) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	update := bson.M{"$set": bson.M{"lastSeen": primitive.NewDateTimeFromTime(now)}}
+	if _, err := s.transitionRepo.Collection.UpdateByID(ctx, transitionID, update); err != nil {
+		log.Printf("Erro ao atualizar lastSeen da transição %s: %v", transitionID.Hex(), err)
+	}
+}
+
+// evaluate combina os sinais disponíveis: ICMP e SNMP definem se o equipamento
+// está alcançável e traps de link indicam degradação parcial.
+func (state *deviceStatusState) evaluate() (models.DeviceOperationalStatus, string) {
+	probeDown := state.probeStatus == models.ProbeStatusDown
+	probeUp := state.probeStatus == models.ProbeStatusUp
+	snmpDown := state.snmpReported && state.snmpFailures >= snmpPollFailureThreshold
+	snmpUp := state.snmpReported && state.snmpFailures < snmpPollFailureThreshold
+
+	switch {
+	case probeDown && snmpUp:
+		return models.DeviceStatusDegraded, "ICMP sem resposta, SNMP respondendo"
+	case probeDown:
+		return models.DeviceStatusDown, "ICMP sem resposta"
+	case snmpDown && probeUp:
+		return models.DeviceStatusDegraded, fmt.Sprintf("SNMP sem resposta após %d coletas", state.snmpFailures)
+	case snmpDown:
+		return models.DeviceStatusDown, fmt.Sprintf("SNMP sem resposta após %d coletas", state.snmpFailures)
+	case len(state.linksDown) > 0 && (probeUp || snmpUp):
+		return models.DeviceStatusDegraded, fmt.Sprintf("%d interface(s) em link down", len(state.linksDown))
+	case probeUp || snmpUp:
+		return models.DeviceStatusUp, "Dispositivo respondendo"
+	default:
+		return models.DeviceStatusUnknown, "Sem dados de monitoramento"
+	}
+}
+
+func (state *deviceStatusState) snapshot() DeviceStatusSnapshot {
+	linksDown := make([]string, 0, len(state.linksDown))
+	for key := range state.linksDown {
+		linksDown = append(linksDown, key)
+	}
+
+	return DeviceStatusSnapshot{
+		DeviceID:      state.device.GetID(),
+		DeviceName:    state.device.GetName(),
+		DeviceType:    string(state.deviceType),
+		Site:          state.device.GetSite(),
+		Status:        state.status,
+		Reason:        state.reason,
+		Since:         state.since,
+		SNMPFailures:  state.snmpFailures,
+		ProbeStatus:   state.probeStatus,
+		LinksDown:     linksDown,
+		LastEvaluated: state.lastEvaluated,
+	}
+}
+
+func (s *deviceStatusServiceImpl) recordTransition(
+	transitionID primitive.ObjectID,
+	device interfaces.NetworkDevice,
+	deviceType DeviceType,
+	previous, current models.DeviceOperationalStatus,
+	reason string,
+	timestamp time.Time,
+) {
+	deviceObjectID, err := primitive.ObjectIDFromHex(device.GetID())
+	if err != nil {
+		return
+	}
+
+	transition := models.DeviceStatusTransition{
+		ID:             transitionID,
+		DeviceID:       deviceObjectID,
+		DeviceName:     device.GetName(),
+		DeviceType:     string(deviceType),
+		Site:           device.GetSite(),
+		PreviousStatus: previous,
+		Status:         current,
+		Reason:         reason,
+		Timestamp:      primitive.NewDateTimeFromTime(timestamp),
+		LastSeen:       primitive.NewDateTimeFromTime(timestamp),
+	}
+	if err := s.transitionRepo.Create(&transition); err != nil {
+		log.Printf("Erro ao salvar transição de status de %s: %v", device.GetName(), err)
+	}
+
+	event := interfaces.TrapEvent{
+		DeviceID:   device.GetID(),
+		DeviceName: device.GetName(),
+		DeviceIP:   device.GetIPAddress(),
+		DeviceType: string(deviceType),
+		Vendor:     device.GetIntegration(),
+		EventType:  "device_status_change",
+		Message:    fmt.Sprintf("Status de %s mudou de %s para %s: %s", device.GetName(), previous, current, reason),
+		Data: map[string]interface{}{
+			"previous_status": string(previous),
+			"status":          string(current),
+			"reason":          reason,
+		},
+		Timestamp: timestamp,
+	}
+
+	jsonData, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Erro ao serializar evento de status: %v", err)
+		return
+	}
+
+	s.hub.Broadcast(jsonData)
+	log.Printf("[STATUS EVENT] %s", event.Message)
+}
+
+func trapInterfaceKey(event *interfaces.TrapEvent) string {
+	if index, ok := event.Data["interface_index"]; ok {
+		return fmt.Sprint(index)
+	}
+	if name, ok := event.Data["interface_name"]; ok {
+		return fmt.Sprint(name)
+	}
+	return "unknown"
+}
@@ -0,0 +1,403 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net_monitor/netflow/metrics"
+	"net_monitor/repository"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DNSQualityService lê as métricas de DNS derivadas dos flows: servidores
+// vistos com participação e nota de saúde, histograma de latência, evolução
+// de timeouts e a comparação dos resolvedores usados em cada roteador.
+type DNSQualityService interface {
+	GetServers(routerId string, from, to time.Time) ([]DNSServerSummary, error)
+	GetLatency(serverIP string, routerId string, from, to time.Time) (*DNSLatencyHistogram, error)
+	GetTrend(serverIP string, routerId string, from, to time.Time, bucket string, location *time.Location) ([]DNSTrendPoint, error)
+	CompareByRouter(routerId string, servers []string, from, to time.Time) ([]DNSRouterComparison, error)
+}
+
+type DNSServerSummary struct {
+	IPAddress         string     `json:"ipAddress"`
+	Hostname          string     `json:"hostname,omitempty"`
+	FirstSeen         *time.Time `json:"firstSeen,omitempty"`
+	LastSeen          *time.Time `json:"lastSeen,omitempty"`
+	Queries           uint64     `json:"queries"`
+	Responses         uint64     `json:"responses"`
+	Timeouts          uint64     `json:"timeouts"`
+	Bytes             uint64     `json:"bytes"`
+	TrafficShare      float64    `json:"trafficShare"`
+	SuccessRate       float64    `json:"successRate"`
+	TimeoutPercentage float64    `json:"timeoutPercentage"`
+	LatencySamples    uint64     `json:"latencySamples"`
+	AvgResponseTime   float64    `json:"avgResponseTime"`
+	MinResponseTime   uint64     `json:"minResponseTime"`
+	MaxResponseTime   uint64     `json:"maxResponseTime"`
+	HealthScore       float64    `json:"healthScore"`
+}
+
+type DNSLatencyBucket struct {
+	Label      string  `json:"label"`
+	Count      uint64  `json:"count"`
+	Percentage float64 `json:"percentage"`
+}
+
+type DNSLatencyHistogram struct {
+	ServerIP        string             `json:"serverIp"`
+	Samples         uint64             `json:"samples"`
+	AvgResponseTime float64            `json:"avgResponseTime"`
+	MinResponseTime uint64             `json:"minResponseTime"`
+	MaxResponseTime uint64             `json:"maxResponseTime"`
+	Buckets         []DNSLatencyBucket `json:"buckets"`
+}
+
+type DNSTrendPoint struct {
+	Timestamp         time.Time `json:"timestamp"`
+	Queries           uint64    `json:"queries"`
+	Responses         uint64    `json:"responses"`
+	Timeouts          uint64    `json:"timeouts"`
+	TimeoutPercentage float64   `json:"timeoutPercentage"`
+	SuccessRate       float64   `json:"successRate"`
+	AvgResponseTime   float64   `json:"avgResponseTime"`
+}
+
+type DNSRouterComparison struct {
+	RouterID string             `json:"routerId,omitempty"`
+	RouterIP string             `json:"routerIp"`
+	Servers  []DNSServerSummary `json:"servers"`
+}
+
+type dnsQualityRow struct {
+	ID struct {
+		ServerIP string             `bson:"serverIp"`
+		RouterID primitive.ObjectID `bson:"routerId"`
+		RouterIP string             `bson:"routerIp"`
+	} `bson:"_id"`
+	Queries             uint64 `bson:"queries"`
+	Responses           uint64 `bson:"responses"`
+	Timeouts            uint64 `bson:"timeouts"`
+	Bytes               uint64 `bson:"bytes"`
+	TotalResponseTime   uint64 `bson:"totalResponseTime"`
+	ResponsesUnder50ms  uint64 `bson:"responsesUnder50ms"`
+	Responses50to100ms  uint64 `bson:"responses50to100ms"`
+	Responses100to500ms uint64 `bson:"responses100to500ms"`
+	ResponsesOver500ms  uint64 `bson:"responsesOver500ms"`
+	MinResponseTime     uint64 `bson:"minResponseTime"`
+	MaxResponseTime     uint64 `bson:"maxResponseTime"`
+}
+
+func (row dnsQualityRow) latencySamples() uint64 {
+	return row.ResponsesUnder50ms + row.Responses50to100ms + row.Responses100to500ms + row.ResponsesOver500ms
+}
+
+type dnsQualityServiceImpl struct {
+	metricsRepo        *repository.MongoRepository[metrics.DNSQualityMetric]
+	serversRepo        *repository.MongoRepository[metrics.DNSServer]
+	aggregationService MetricAggregationService
+}
+
+func NewDNSQualityService(
+	metricsRepo *repository.MongoRepository[metrics.DNSQualityMetric],
+	serversRepo *repository.MongoRepository[metrics.DNSServer],
+	aggregationService MetricAggregationService,
+) DNSQualityService {
+	return &dnsQualityServiceImpl{metricsRepo: metricsRepo, serversRepo: serversRepo, aggregationService: aggregationService}
+}
+
+func (s *dnsQualityServiceImpl) GetServers(routerId string, from, to time.Time) ([]DNSServerSummary, error) {
+	match, err := dnsQualityMatch(routerId, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.aggregate(match, bson.M{"serverIp": "$dnsServerIp"})
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := dnsSummaries(rows)
+	s.fillServerDetails(summaries)
+	return summaries, nil
+}
+
+func (s *dnsQualityServiceImpl) GetLatency(serverIP string, routerId string, from, to time.Time) (*DNSLatencyHistogram, error) {
+	match, err := dnsQualityMatch(routerId, from, to)
+	if err != nil {
+		return nil, err
+	}
+	match["dnsServerIp"] = serverIP
+
+	rows, err := s.aggregate(match, bson.M{"serverIp": "$dnsServerIp"})
+	if err != nil {
+		return nil, err
+	}
+
+	histogram := &DNSLatencyHistogram{ServerIP: serverIP}
+	var row dnsQualityRow
+	if len(rows) > 0 {
+		row = rows[0]
+		histogram.Samples = row.latencySamples()
+		histogram.MinResponseTime = row.MinResponseTime
+		histogram.MaxResponseTime = row.MaxResponseTime
+		if histogram.Samples > 0 {
+			histogram.AvgResponseTime = roundTwoDecimals(float64(row.TotalResponseTime) / float64(histogram.Samples))
+		}
+	}
+
+	counts := []struct {
+		label string
+		count uint64
+	}{
+		{"<50ms", row.ResponsesUnder50ms},
+		{"50-100ms", row.Responses50to100ms},
+		{"100-500ms", row.Responses100to500ms},
+		{">500ms", row.ResponsesOver500ms},
+	}
+	for _, bucket := range counts {
+		histogram.Buckets = append(histogram.Buckets, DNSLatencyBucket{
+			Label:      bucket.label,
+			Count:      bucket.count,
+			Percentage: percentage(bucket.count, histogram.Samples),
+		})
+	}
+	return histogram, nil
+}
+
+func (s *dnsQualityServiceImpl) GetTrend(serverIP string, routerId string, from, to time.Time, bucket string, location *time.Location) ([]DNSTrendPoint, error) {
+	points, err := s.aggregationService.Aggregate(AggregationQuery{
+		Source:   "dns_quality",
+		From:     from,
+		To:       to,
+		Bucket:   bucket,
+		Location: location,
+		RouterID: routerId,
+		Filters:  map[string]string{"dnsServerIp": serverIP},
+		Fields: []AggregationField{
+			{Name: "queries", Field: "totalQueries", Operator: "sum"},
+			{Name: "responses", Field: "totalResponses", Operator: "sum"},
+			{Name: "timeouts", Field: "timeoutCount", Operator: "sum"},
+			{Name: "responseTime", Field: "totalResponseTime", Operator: "sum"},
+			{Name: "under50ms", Field: "responsesUnder50ms", Operator: "sum"},
+			{Name: "under100ms", Field: "responses50to100ms", Operator: "sum"},
+			{Name: "under500ms", Field: "responses100to500ms", Operator: "sum"},
+			{Name: "over500ms", Field: "responsesOver500ms", Operator: "sum"},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	trend := make([]DNSTrendPoint, 0, len(points))
+	for _, point := range points {
+		values := point.Values
+		queries, responses, timeouts := uint64(values["queries"]), uint64(values["responses"]), uint64(values["timeouts"])
+		samples := values["under50ms"] + values["under100ms"] + values["under500ms"] + values["over500ms"]
+
+		trendPoint := DNSTrendPoint{
+			Timestamp:         point.Bucket,
+			Queries:           queries,
+			Responses:         responses,
+			Timeouts:          timeouts,
+			TimeoutPercentage: percentage(timeouts, queries),
+			SuccessRate:       dnsSuccessRate(responses, queries),
+		}
+		if samples > 0 {
+			trendPoint.AvgResponseTime = roundTwoDecimals(values["responseTime"] / samples)
+		}
+		trend = append(trend, trendPoint)
+	}
+	return trend, nil
+}
+
+func (s *dnsQualityServiceImpl) CompareByRouter(routerId string, servers []string, from, to time.Time) ([]DNSRouterComparison, error) {
+	match, err := dnsQualityMatch(routerId, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if len(servers) > 0 {
+		match["dnsServerIp"] = bson.M{"$in": servers}
+	}
+
+	rows, err := s.aggregate(match, bson.M{"serverIp": "$dnsServerIp", "routerId": "$routerId", "routerIp": "$routerIp"})
+	if err != nil {
+		return nil, err
+	}
+
+	byRouter := make(map[string][]dnsQualityRow)
+	for _, row := range rows {
+		byRouter[row.ID.RouterIP] = append(byRouter[row.ID.RouterIP], row)
+	}
+
+	comparisons := make([]DNSRouterComparison, 0, len(byRouter))
+	for routerIP, routerRows := range byRouter {
+		comparison := DNSRouterComparison{RouterIP: routerIP, Servers: dnsSummaries(routerRows)}
+		for _, row := range routerRows {
+			if !row.ID.RouterID.IsZero() {
+				comparison.RouterID = row.ID.RouterID.Hex()
+				break
+			}
+		}
+		// Na comparação o melhor resolvedor vem primeiro.
+		sort.SliceStable(comparison.Servers, func(i, j int) bool {
+			return comparison.Servers[i].HealthScore > comparison.Servers[j].HealthScore
+		})
+		s.fillServerDetails(comparison.Servers)
+		comparisons = append(comparisons, comparison)
+	}
+	sort.Slice(comparisons, func(i, j int) bool { return comparisons[i].RouterIP < comparisons[j].RouterIP })
+	return comparisons, nil
+}
+
+func (s *dnsQualityServiceImpl) aggregate(match bson.M, groupID bson.M) ([]dnsQualityRow, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	pipeline := []bson.M{
+		{"$match": match},
+		{
+			"$group": bson.M{
+				"_id":                 groupID,
+				"queries":             bson.M{"$sum": "$totalQueries"},
+				"responses":           bson.M{"$sum": "$totalResponses"},
+				"timeouts":            bson.M{"$sum": "$timeoutCount"},
+				"bytes":               bson.M{"$sum": "$totalBytes"},
+				"totalResponseTime":   bson.M{"$sum": "$totalResponseTime"},
+				"responsesUnder50ms":  bson.M{"$sum": "$responsesUnder50ms"},
+				"responses50to100ms":  bson.M{"$sum": "$responses50to100ms"},
+				"responses100to500ms": bson.M{"$sum": "$responses100to500ms"},
+				"responsesOver500ms":  bson.M{"$sum": "$responsesOver500ms"},
+				"minResponseTime":     bson.M{"$min": "$minResponseTime"},
+				"maxResponseTime":     bson.M{"$max": "$maxResponseTime"},
+			},
+		},
+	}
+
+	cursor, err := s.metricsRepo.Collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao agregar métricas de DNS: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var rows []dnsQualityRow
+	if err = cursor.All(ctx, &rows); err != nil {
+		return nil, fmt.Errorf("erro ao decodificar resultados: %w", err)
+	}
+	return rows, nil
+}
+
+// fillServerDetails completa hostname e datas com o cadastro em dns_servers.
+func (s *dnsQualityServiceImpl) fillServerDetails(summaries []DNSServerSummary) {
+	if len(summaries) == 0 {
+		return
+	}
+	ips := make([]string, 0, len(summaries))
+	for _, summary := range summaries {
+		ips = append(ips, summary.IPAddress)
+	}
+
+	servers, err := s.serversRepo.GetByFilter(bson.M{"ipAddress": bson.M{"$in": ips}})
+	if err != nil {
+		return
+	}
+	byIP := make(map[string]metrics.DNSServer, len(servers))
+	for _, server := range servers {
+		byIP[server.IPAddress] = server
+	}
+
+	for i := range summaries {
+		server, ok := byIP[summaries[i].IPAddress]
+		if !ok {
+			continue
+		}
+		firstSeen, lastSeen := server.FirstSeen.Time(), server.LastSeen.Time()
+		summaries[i].Hostname = server.Hostname
+		summaries[i].FirstSeen = &firstSeen
+		summaries[i].LastSeen = &lastSeen
+	}
+}
+
+// dnsSummaries calcula a participação de cada servidor nas consultas do
+// conjunto e devolve do mais usado para o menos usado.
+func dnsSummaries(rows []dnsQualityRow) []DNSServerSummary {
+	var totalQueries uint64
+	for _, row := range rows {
+		totalQueries += row.Queries
+	}
+
+	summaries := make([]DNSServerSummary, 0, len(rows))
+	for _, row := range rows {
+		samples := row.latencySamples()
+		summary := DNSServerSummary{
+			IPAddress:         row.ID.ServerIP,
+			Queries:           row.Queries,
+			Responses:         row.Responses,
+			Timeouts:          row.Timeouts,
+			Bytes:             row.Bytes,
+			TrafficShare:      percentage(row.Queries, totalQueries),
+			SuccessRate:       dnsSuccessRate(row.Responses, row.Queries),
+			TimeoutPercentage: percentage(row.Timeouts, row.Queries),
+			LatencySamples:    samples,
+			MinResponseTime:   row.MinResponseTime,
+			MaxResponseTime:   row.MaxResponseTime,
+			HealthScore:       dnsHealthScore(row),
+		}
+		if samples > 0 {
+			summary.AvgResponseTime = roundTwoDecimals(float64(row.TotalResponseTime) / float64(samples))
+		}
+		summaries = append(summaries, summary)
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].Queries != summaries[j].Queries {
+			return summaries[i].Queries > summaries[j].Queries
+		}
+		return summaries[i].IPAddress < summaries[j].IPAddress
+	})
+	return summaries
+}
+
+// Respostas de um lote podem chegar no seguinte, então a taxa é limitada a 100%.
+func dnsSuccessRate(responses, queries uint64) float64 {
+	if queries == 0 {
+		return 0
+	}
+	if responses > queries {
+		return 100
+	}
+	return percentage(responses, queries)
+}
+
+// dnsHealthScore vai de 0 a 100: metade pela taxa de sucesso e metade pela
+// latência, em que respostas abaixo de 50ms valem 1, até 100ms 0,75, até
+// 500ms 0,35 e acima disso nada. Sem amostras de latência vale só o sucesso.
+func dnsHealthScore(row dnsQualityRow) float64 {
+	success := dnsSuccessRate(row.Responses, row.Queries)
+	samples := row.latencySamples()
+	if samples == 0 {
+		return success
+	}
+	latency := (float64(row.ResponsesUnder50ms) + 0.75*float64(row.Responses50to100ms) + 0.35*float64(row.Responses100to500ms)) / float64(samples) * 100
+	return roundTwoDecimals((success + latency) / 2)
+}
+
+func dnsQualityMatch(routerId string, from, to time.Time) (bson.M, error) {
+	match := bson.M{
+		"timestamp": bson.M{
+			"$gte": primitive.NewDateTimeFromTime(from),
+			"$lt":  primitive.NewDateTimeFromTime(to),
+		},
+	}
+	if routerId != "" {
+		objectID, err := primitive.ObjectIDFromHex(routerId)
+		if err != nil {
+			return nil, fmt.Errorf("routerId inválido: %w", err)
+		}
+		match["routerId"] = objectID
+	}
+	return match, nil
+}
@@ -0,0 +1,296 @@
+package services
+
+import (
+	"log"
+	"net/netip"
+	"net_monitor/interfaces"
+	models "net_monitor/models"
+	repository "net_monitor/repository"
+	utils "net_monitor/utils"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const maxUnknownExporters = 1000
+
+// ExporterRegistryService resolve o dispositivo de cada exportador de flows.
+// O cadastro explícito (IP + observation domain, ou só IP) tem prioridade; na
+// falta dele vale o IP de gerência de roteadores, OLTs e switches, nessa
+// ordem. Exportadores sem dispositivo ficam listados para adoção.
+type ExporterRegistryService interface {
+	GetAll() ([]models.FlowExporter, error)
+	GetById(id string) (*models.FlowExporter, error)
+	Create(exporter *models.FlowExporter) (error, *utils.APIError)
+	Update(id string, exporter *models.FlowExporter) (error, *utils.APIError)
+	Delete(id string) error
+	GetUnknown() []UnknownExporter
+	Resolve(exporterIP string, observationDomain uint32) interfaces.NetworkDevice
+	Start()
+	Reload()
+}
+
+type UnknownExporter struct {
+	ExporterIP        string    `json:"exporterIp"`
+	ObservationDomain uint32    `json:"observationDomain"`
+	Messages          uint64    `json:"messages"`
+	FirstSeen         time.Time `json:"firstSeen"`
+	LastSeen          time.Time `json:"lastSeen"`
+}
+
+type exporterKey struct {
+	ip                string
+	observationDomain uint32
+}
+
+type exporterTable struct {
+	byDomain     map[exporterKey]interfaces.NetworkDevice
+	byIP         map[string]interfaces.NetworkDevice
+	byManagement map[string]interfaces.NetworkDevice
+}
+
+type exporterRegistryServiceImpl struct {
+	repo           *repository.MongoRepository[models.FlowExporter]
+	deviceService  DeviceService
+	reloadInterval time.Duration
+
+	table    atomic.Pointer[exporterTable]
+	reloadMu sync.Mutex
+
+	unknownMu sync.Mutex
+	unknown   map[exporterKey]*UnknownExporter
+}
+
+// NewExporterRegistryService lê EXPORTER_REGISTRY_RELOAD_SECONDS (padrão 60);
+// alterações no cadastro recarregam na hora.
+func NewExporterRegistryService(repo *repository.MongoRepository[models.FlowExporter], deviceService DeviceService) ExporterRegistryService {
+	interval := time.Minute
+	if seconds, err := strconv.Atoi(os.Getenv("EXPORTER_REGISTRY_RELOAD_SECONDS")); err == nil && seconds > 0 {
+		interval = time.Duration(seconds) * time.Second
+	}
+
+	s := &exporterRegistryServiceImpl{
+		repo:           repo,
+		deviceService:  deviceService,
+		reloadInterval: interval,
+		unknown:        make(map[exporterKey]*UnknownExporter),
+	}
+	s.table.Store(&exporterTable{})
+	return s
+}
+
+func (s *exporterRegistryServiceImpl) Start() {
+	s.Reload()
+
+	go func() {
+		ticker := time.NewTicker(s.reloadInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			s.Reload()
+		}
+	}()
+}
+
+// Reload monta a tabela nova por inteiro e troca o ponteiro, então os
+// workers nunca veem uma tabela pela metade. Em caso de erro a anterior vale.
+func (s *exporterRegistryServiceImpl) Reload() {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	devices, err := s.deviceService.GetAllDevices()
+	if err != nil {
+		log.Printf("[ExporterRegistry] Erro carregando dispositivos: %v", err)
+		return
+	}
+	exporters, err := s.repo.GetAll()
+	if err != nil {
+		log.Printf("[ExporterRegistry] Erro carregando exportadores: %v", err)
+		return
+	}
+
+	table := &exporterTable{
+		byDomain:     make(map[exporterKey]interfaces.NetworkDevice),
+		byIP:         make(map[string]interfaces.NetworkDevice),
+		byManagement: make(map[string]interfaces.NetworkDevice),
+	}
+	byID := make(map[string]interfaces.NetworkDevice, len(devices))
+	for _, cached := range devices {
+		byID[string(cached.DeviceType)+":"+cached.Device.GetID()] = cached.Device
+		if ip := cached.Device.GetIPAddress(); ip != "" {
+			if _, exists := table.byManagement[ip]; !exists {
+				table.byManagement[ip] = cached.Device
+			}
+		}
+	}
+	for _, exporter := range exporters {
+		device, ok := byID[exporter.DeviceType+":"+exporter.DeviceID]
+		if !ok {
+			log.Printf("[ExporterRegistry] Exportador %s aponta para dispositivo inexistente %s/%s",
+				exporter.ExporterIP, exporter.DeviceType, exporter.DeviceID)
+			continue
+		}
+		if exporter.ObservationDomain != nil {
+			table.byDomain[exporterKey{ip: exporter.ExporterIP, observationDomain: *exporter.ObservationDomain}] = device
+		} else {
+			table.byIP[exporter.ExporterIP] = device
+		}
+	}
+	s.table.Store(table)
+
+	s.unknownMu.Lock()
+	for key := range s.unknown {
+		if table.lookup(key) != nil {
+			delete(s.unknown, key)
+		}
+	}
+	s.unknownMu.Unlock()
+}
+
+func (t *exporterTable) lookup(key exporterKey) interfaces.NetworkDevice {
+	if device, ok := t.byDomain[key]; ok {
+		return device
+	}
+	if device, ok := t.byIP[key.ip]; ok {
+		return device
+	}
+	return t.byManagement[key.ip]
+}
+
+func (s *exporterRegistryServiceImpl) Resolve(exporterIP string, observationDomain uint32) interfaces.NetworkDevice {
+	key := exporterKey{ip: exporterIP, observationDomain: observationDomain}
+	if device := s.table.Load().lookup(key); device != nil {
+		return device
+	}
+
+	now := time.Now()
+	s.unknownMu.Lock()
+	defer s.unknownMu.Unlock()
+	unknown, ok := s.unknown[key]
+	if !ok {
+		if len(s.unknown) >= maxUnknownExporters {
+			return nil
+		}
+		unknown = &UnknownExporter{ExporterIP: exporterIP, ObservationDomain: observationDomain, FirstSeen: now}
+		s.unknown[key] = unknown
+		log.Printf("[ExporterRegistry] Exportador desconhecido %s (domain %d)", exporterIP, observationDomain)
+	}
+	unknown.Messages++
+	unknown.LastSeen = now
+	return nil
+}
+
+func (s *exporterRegistryServiceImpl) GetUnknown() []UnknownExporter {
+	s.unknownMu.Lock()
+	unknown := make([]UnknownExporter, 0, len(s.unknown))
+	for _, exporter := range s.unknown {
+		unknown = append(unknown, *exporter)
+	}
+	s.unknownMu.Unlock()
+
+	sort.Slice(unknown, func(i, j int) bool { return unknown[i].LastSeen.After(unknown[j].LastSeen) })
+	return unknown
+}
+
+func (s *exporterRegistryServiceImpl) GetAll() ([]models.FlowExporter, error) {
+	return s.repo.GetAll()
+}
+
+func (s *exporterRegistryServiceImpl) GetById(id string) (*models.FlowExporter, error) {
+	return s.repo.GetById(id)
+}
+
+func (s *exporterRegistryServiceImpl) Create(exporter *models.FlowExporter) (error, *utils.APIError) {
+	if apiErr := s.validate(exporter, primitive.NilObjectID); apiErr != nil {
+		return nil, apiErr
+	}
+	if err := s.repo.Create(exporter); err != nil {
+		return err, nil
+	}
+	go s.Reload()
+	return nil, nil
+}
+
+func (s *exporterRegistryServiceImpl) Update(id string, exporter *models.FlowExporter) (error, *utils.APIError) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err, nil
+	}
+	existent, err := s.repo.GetById(id)
+	if err != nil {
+		return err, nil
+	}
+	if existent == nil {
+		return nil, &utils.APIError{
+			Code:    "FLOW_EXPORTER_NOT_FOUND",
+			Message: "Flow exporter not found",
+		}
+	}
+	if apiErr := s.validate(exporter, objectID); apiErr != nil {
+		return nil, apiErr
+	}
+	exporter.ID = objectID
+	exporter.Created_At = existent.Created_At
+	if err := s.repo.Update(id, exporter); err != nil {
+		return err, nil
+	}
+	go s.Reload()
+	return nil, nil
+}
+
+func (s *exporterRegistryServiceImpl) Delete(id string) error {
+	if err := s.repo.Delete(id); err != nil {
+		return err
+	}
+	go s.Reload()
+	return nil
+}
+
+// validate normaliza o IP, confere se o dispositivo existe e rejeita
+// exportadores duplicados.
+func (s *exporterRegistryServiceImpl) validate(exporter *models.FlowExporter, ignoreID primitive.ObjectID) *utils.APIError {
+	addr, err := netip.ParseAddr(exporter.ExporterIP)
+	if err != nil {
+		return &utils.APIError{
+			Code:    "INVALID_EXPORTER_IP",
+			Message: "Exporter IP must be a valid IPv4 or IPv6 address",
+		}
+	}
+	exporter.ExporterIP = addr.Unmap().String()
+
+	switch DeviceType(exporter.DeviceType) {
+	case DeviceTypeRouter, DeviceTypeOLT, DeviceTypeSwitch:
+	default:
+		return &utils.APIError{
+			Code:    "INVALID_DEVICE_TYPE",
+			Message: "Device type must be router, olt or switch",
+		}
+	}
+	if _, deviceType, err := s.deviceService.GetByID(exporter.DeviceID); err != nil || string(deviceType) != exporter.DeviceType {
+		return &utils.APIError{
+			Code:    "DEVICE_NOT_FOUND",
+			Message: "Device not found for the given type",
+		}
+	}
+
+	filter := bson.M{"exporterIp": exporter.ExporterIP, "observationDomain": bson.M{"$exists": false}}
+	if exporter.ObservationDomain != nil {
+		filter["observationDomain"] = *exporter.ObservationDomain
+	}
+	if !ignoreID.IsZero() {
+		filter["_id"] = bson.M{"$ne": ignoreID}
+	}
+	existent, err := s.repo.GetByFilter(filter)
+	if err == nil && len(existent) > 0 {
+		return &utils.APIError{
+			Code:    "DUPLICATED_FLOW_EXPORTER",
+			Message: "A flow exporter with that IP and observation domain already exists",
+		}
+	}
+	return nil
+}
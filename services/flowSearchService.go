@@ -0,0 +1,277 @@
+package services
+
+import (
+	"context"
+	"net/netip"
+	models "net_monitor/models"
+	repository "net_monitor/repository"
+	utils "net_monitor/utils"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	FlowSearchDefaultPageSize = 100
+	FlowSearchMaxPageSize     = 1000
+)
+
+// FlowSearchService busca nos flows brutos. Toda consulta tem orçamento:
+// período máximo (RAW_FLOW_MAX_QUERY_HOURS), paginação limitada
+// (RAW_FLOW_MAX_OFFSET), contagem até RAW_FLOW_MAX_COUNT documentos e tempo
+// de execução no servidor (RAW_FLOW_QUERY_TIMEOUT_MS).
+type FlowSearchService interface {
+	Search(request FlowSearchRequest) (*FlowSearchResult, error, *utils.APIError)
+}
+
+// Os filtros de IP aceitam endereço ou CIDR. IP casa com origem ou destino,
+// Port com qualquer das portas e Interface com entrada ou saída.
+type FlowSearchRequest struct {
+	From      time.Time
+	To        time.Time
+	SrcIP     string
+	DstIP     string
+	IP        string
+	SrcPort   *uint16
+	DstPort   *uint16
+	Port      *uint16
+	Protocol  *uint8
+	RouterID  string
+	RouterIP  string
+	Interface *uint32
+	Page      int
+	PageSize  int
+}
+
+type FlowSearchResult struct {
+	From         time.Time        `json:"from"`
+	To           time.Time        `json:"to"`
+	Page         int              `json:"page"`
+	PageSize     int              `json:"pageSize"`
+	Total        int64            `json:"total"`
+	TotalCapped  bool             `json:"totalCapped"`
+	TotalBytes   uint64           `json:"totalBytes"`
+	TotalPackets uint64           `json:"totalPackets"`
+	Flows        []models.RawFlow `json:"flows"`
+}
+
+type flowSearchServiceImpl struct {
+	repo         *repository.MongoRepository[models.RawFlow]
+	maxRange     time.Duration
+	maxOffset    int
+	maxCount     int
+	queryTimeout time.Duration
+}
+
+func NewFlowSearchService(repo *repository.MongoRepository[models.RawFlow]) FlowSearchService {
+	return &flowSearchServiceImpl{
+		repo:         repo,
+		maxRange:     time.Duration(utils.EnvPositiveInt("RAW_FLOW_MAX_QUERY_HOURS", 24)) * time.Hour,
+		maxOffset:    utils.EnvPositiveInt("RAW_FLOW_MAX_OFFSET", 10000),
+		maxCount:     utils.EnvPositiveInt("RAW_FLOW_MAX_COUNT", 100000),
+		queryTimeout: time.Duration(utils.EnvPositiveInt("RAW_FLOW_QUERY_TIMEOUT_MS", 5000)) * time.Millisecond,
+	}
+}
+
+func (s *flowSearchServiceImpl) Search(request FlowSearchRequest) (*FlowSearchResult, error, *utils.APIError) {
+	if request.To.Sub(request.From) > s.maxRange {
+		return nil, nil, &utils.APIError{
+			Code:    "QUERY_RANGE_TOO_LARGE",
+			Message: "Time range exceeds the maximum of " + s.maxRange.String(),
+		}
+	}
+	if request.Page < 1 {
+		request.Page = 1
+	}
+	if request.PageSize < 1 {
+		request.PageSize = FlowSearchDefaultPageSize
+	}
+	request.PageSize = min(request.PageSize, FlowSearchMaxPageSize)
+	offset := (request.Page - 1) * request.PageSize
+	if offset+request.PageSize > s.maxOffset {
+		return nil, nil, &utils.APIError{
+			Code:    "QUERY_OFFSET_TOO_LARGE",
+			Message: "Narrow the filters instead of paging this far",
+		}
+	}
+
+	filter, apiErr := buildFlowSearchFilter(request)
+	if apiErr != nil {
+		return nil, nil, apiErr
+	}
+
+	// O tempo é limitado no servidor (maxTimeMS) e, com folga, no cliente.
+	ctx, cancel := context.WithTimeout(context.Background(), 2*s.queryTimeout)
+	defer cancel()
+
+	result := &FlowSearchResult{
+		From:     request.From,
+		To:       request.To,
+		Page:     request.Page,
+		PageSize: request.PageSize,
+		Flows:    []models.RawFlow{},
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "timestamp", Value: -1}}).
+		SetSkip(int64(offset)).
+		SetLimit(int64(request.PageSize)).
+		SetMaxTime(s.queryTimeout)
+	cursor, err := s.repo.Collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return s.failed(err)
+	}
+	if err := cursor.All(ctx, &result.Flows); err != nil {
+		return s.failed(err)
+	}
+
+	// Os totais param em maxCount documentos; acima disso são parciais.
+	pipeline := []bson.M{
+		{"$match": filter},
+		{"$limit": s.maxCount},
+		{"$group": bson.M{
+			"_id":     nil,
+			"count":   bson.M{"$sum": 1},
+			"bytes":   bson.M{"$sum": bson.M{"$multiply": bson.A{"$bytes", "$sampleRate"}}},
+			"packets": bson.M{"$sum": bson.M{"$multiply": bson.A{"$packets", "$sampleRate"}}},
+		}},
+	}
+	aggregateCursor, err := s.repo.Collection.Aggregate(ctx, pipeline, options.Aggregate().SetMaxTime(s.queryTimeout))
+	if err != nil {
+		return s.failed(err)
+	}
+	var totals []struct {
+		Count   int64 `bson:"count"`
+		Bytes   int64 `bson:"bytes"`
+		Packets int64 `bson:"packets"`
+	}
+	if err := aggregateCursor.All(ctx, &totals); err != nil {
+		return s.failed(err)
+	}
+	if len(totals) > 0 {
+		result.Total = totals[0].Count
+		result.TotalCapped = totals[0].Count >= int64(s.maxCount)
+		result.TotalBytes = uint64(totals[0].Bytes)
+		result.TotalPackets = uint64(totals[0].Packets)
+	}
+
+	return result, nil, nil
+}
+
+func (s *flowSearchServiceImpl) failed(err error) (*FlowSearchResult, error, *utils.APIError) {
+	if mongo.IsTimeout(err) {
+		return nil, nil, &utils.APIError{
+			Code:    "QUERY_BUDGET_EXCEEDED",
+			Message: "Query took too long, narrow the time range or add filters",
+		}
+	}
+	return nil, err, nil
+}
+
+func buildFlowSearchFilter(request FlowSearchRequest) (bson.M, *utils.APIError) {
+	conditions := bson.A{
+		bson.M{"timestamp": bson.M{
+			"$gte": primitive.NewDateTimeFromTime(request.From),
+			"$lt":  primitive.NewDateTimeFromTime(request.To),
+		}},
+	}
+
+	for _, ipFilter := range []struct {
+		value  string
+		fields []string
+	}{
+		{request.SrcIP, []string{"srcIpKey"}},
+		{request.DstIP, []string{"dstIpKey"}},
+		{request.IP, []string{"srcIpKey", "dstIpKey"}},
+	} {
+		if ipFilter.value == "" {
+			continue
+		}
+		first, last, ok := ipKeyRange(ipFilter.value)
+		if !ok {
+			return nil, &utils.APIError{
+				Code:    "INVALID_IP_FILTER",
+				Message: "IP filters must be an address or a CIDR prefix",
+			}
+		}
+		keyRange := bson.M{"$gte": first, "$lte": last}
+		conditions = append(conditions, eitherField(ipFilter.fields, keyRange))
+	}
+
+	if request.SrcPort != nil {
+		conditions = append(conditions, bson.M{"srcPort": *request.SrcPort})
+	}
+	if request.DstPort != nil {
+		conditions = append(conditions, bson.M{"dstPort": *request.DstPort})
+	}
+	if request.Port != nil {
+		conditions = append(conditions, eitherField([]string{"srcPort", "dstPort"}, *request.Port))
+	}
+	if request.Protocol != nil {
+		conditions = append(conditions, bson.M{"protocol": *request.Protocol})
+	}
+	if request.Interface != nil {
+		conditions = append(conditions, eitherField([]string{"ingressInterface", "egressInterface"}, *request.Interface))
+	}
+	if request.RouterIP != "" {
+		conditions = append(conditions, bson.M{"routerIp": request.RouterIP})
+	}
+	if request.RouterID != "" {
+		objectID, err := primitive.ObjectIDFromHex(request.RouterID)
+		if err != nil {
+			return nil, &utils.APIError{
+				Code:    "INVALID_ROUTER_ID",
+				Message: "Router ID is not a valid id",
+			}
+		}
+		conditions = append(conditions, bson.M{"routerId": objectID})
+	}
+
+	return bson.M{"$and": conditions}, nil
+}
+
+func eitherField(fields []string, value interface{}) bson.M {
+	if len(fields) == 1 {
+		return bson.M{fields[0]: value}
+	}
+	alternatives := bson.A{}
+	for _, field := range fields {
+		alternatives = append(alternatives, bson.M{field: value})
+	}
+	return bson.M{"$or": alternatives}
+}
+
+// ipKeyRange converte endereço ou CIDR na faixa de chaves de 16 bytes
+// gravadas em RawFlow (IPv4 mapeado em IPv6).
+func ipKeyRange(value string) ([]byte, []byte, bool) {
+	var prefix netip.Prefix
+	if strings.Contains(value, "/") {
+		parsed, err := netip.ParsePrefix(value)
+		if err != nil {
+			return nil, nil, false
+		}
+		prefix = parsed
+	} else {
+		addr, err := netip.ParseAddr(value)
+		if err != nil {
+			return nil, nil, false
+		}
+		prefix = netip.PrefixFrom(addr, addr.BitLen())
+	}
+
+	addr := prefix.Masked().Addr()
+	bits := prefix.Bits()
+	if addr.Is4() {
+		bits += 96
+	}
+	first := addr.As16()
+	last := first
+	for i := bits; i < 128; i++ {
+		last[i/8] |= 1 << (7 - i%8)
+	}
+	return first[:], last[:], true
+}
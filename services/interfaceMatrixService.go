@@ -0,0 +1,299 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net_monitor/interfaces"
+	"net_monitor/netflow/metrics"
+	"net_monitor/repository"
+	"net_monitor/snmp"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// InterfaceMatrixService consulta o tráfego por interface derivado dos flows,
+// útil para dispositivos cujos contadores não são coletados por SNMP.
+type InterfaceMatrixService interface {
+	GetMatrix(routerId string, from, to time.Time, limit int) (*InterfaceMatrixResult, error)
+	GetRates(routerId string, from, to time.Time, ifIndex *uint32) (*InterfaceRatesResult, error)
+}
+
+// Bps e Pps dos resumos são médias no período consultado.
+type InterfaceTrafficSummary struct {
+	IfIndex     uint32  `bson:"_id" json:"ifIndex"`
+	Name        string  `bson:"name" json:"name,omitempty"`
+	Description string  `bson:"description" json:"description,omitempty"`
+	InBytes     uint64  `bson:"inBytes" json:"inBytes"`
+	OutBytes    uint64  `bson:"outBytes" json:"outBytes"`
+	InPackets   uint64  `bson:"inPackets" json:"inPackets"`
+	OutPackets  uint64  `bson:"outPackets" json:"outPackets"`
+	InBps       float64 `bson:"-" json:"inBps"`
+	OutBps      float64 `bson:"-" json:"outBps"`
+}
+
+type InterfaceMatrixResult struct {
+	RouterID   string                         `json:"routerId"`
+	From       time.Time                      `json:"from"`
+	To         time.Time                      `json:"to"`
+	Interfaces []InterfaceTrafficSummary      `json:"interfaces"`
+	Pairs      []metrics.InterfacePairTraffic `json:"pairs"`
+}
+
+type InterfaceRatesPoint struct {
+	Timestamp  time.Time               `json:"timestamp"`
+	Interfaces []metrics.InterfaceRate `json:"interfaces"`
+}
+
+type InterfaceRatesResult struct {
+	RouterID string                `json:"routerId"`
+	From     time.Time             `json:"from"`
+	To       time.Time             `json:"to"`
+	Points   []InterfaceRatesPoint `json:"points"`
+}
+
+type interfaceMatrixServiceImpl struct {
+	repo                  *repository.MongoRepository[metrics.InterfaceMatrixMetric]
+	deviceService         DeviceService
+	interfaceTableService InterfaceTableService
+}
+
+func NewInterfaceMatrixService(
+	repo *repository.MongoRepository[metrics.InterfaceMatrixMetric],
+	deviceService DeviceService,
+	interfaceTableService InterfaceTableService,
+) InterfaceMatrixService {
+	return &interfaceMatrixServiceImpl{
+		repo:                  repo,
+		deviceService:         deviceService,
+		interfaceTableService: interfaceTableService,
+	}
+}
+
+func (s *interfaceMatrixServiceImpl) GetMatrix(routerId string, from, to time.Time, limit int) (*InterfaceMatrixResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	match, err := interfaceMatrixMatch(routerId, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	// Os nomes mais recentes prevalecem, por isso a ordenação antes dos grupos.
+	pipeline := []bson.M{
+		{"$match": match},
+		{"$sort": bson.M{"timestamp": 1}},
+		{
+			"$facet": bson.M{
+				"interfaces": []bson.M{
+					{"$unwind": "$interfaces"},
+					{
+						"$group": bson.M{
+							"_id":         "$interfaces.ifIndex",
+							"name":        bson.M{"$last": "$interfaces.name"},
+							"description": bson.M{"$last": "$interfaces.description"},
+							"inBytes":     bson.M{"$sum": "$interfaces.inBytes"},
+							"outBytes":    bson.M{"$sum": "$interfaces.outBytes"},
+							"inPackets":   bson.M{"$sum": "$interfaces.inPackets"},
+							"outPackets":  bson.M{"$sum": "$interfaces.outPackets"},
+						},
+					},
+					{"$sort": bson.M{"_id": 1}},
+				},
+				"pairs": []bson.M{
+					{"$unwind": "$pairs"},
+					{
+						"$group": bson.M{
+							"_id":         bson.M{"ingress": "$pairs.ingress", "egress": "$pairs.egress"},
+							"ingressName": bson.M{"$last": "$pairs.ingressName"},
+							"egressName":  bson.M{"$last": "$pairs.egressName"},
+							"bytes":       bson.M{"$sum": "$pairs.bytes"},
+							"packets":     bson.M{"$sum": "$pairs.packets"},
+							"flows":       bson.M{"$sum": "$pairs.flows"},
+						},
+					},
+					{"$sort": bson.D{{Key: "bytes", Value: -1}, {Key: "_id.ingress", Value: 1}, {Key: "_id.egress", Value: 1}}},
+					{"$limit": limit},
+					{
+						"$project": bson.M{
+							"_id":         0,
+							"ingress":     "$_id.ingress",
+							"egress":      "$_id.egress",
+							"ingressName": 1,
+							"egressName":  1,
+							"bytes":       1,
+							"packets":     1,
+							"flows":       1,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	cursor, err := s.repo.Collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao agregar matriz de interfaces: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var facets []struct {
+		Interfaces []InterfaceTrafficSummary      `bson:"interfaces"`
+		Pairs      []metrics.InterfacePairTraffic `bson:"pairs"`
+	}
+	if err = cursor.All(ctx, &facets); err != nil {
+		return nil, fmt.Errorf("erro ao decodificar resultados: %w", err)
+	}
+
+	result := &InterfaceMatrixResult{
+		RouterID:   routerId,
+		From:       from,
+		To:         to,
+		Interfaces: []InterfaceTrafficSummary{},
+		Pairs:      []metrics.InterfacePairTraffic{},
+	}
+	if len(facets) == 0 {
+		return result, nil
+	}
+
+	seconds := to.Sub(from).Seconds()
+	names := s.nameResolver(routerId)
+	for _, summary := range facets[0].Interfaces {
+		if summary.Name == "" {
+			info := names(summary.IfIndex)
+			summary.Name, summary.Description = info.Name, info.Description
+		}
+		summary.InBps = roundTwoDecimals(float64(summary.InBytes) * 8 / seconds)
+		summary.OutBps = roundTwoDecimals(float64(summary.OutBytes) * 8 / seconds)
+		result.Interfaces = append(result.Interfaces, summary)
+	}
+	for _, pair := range facets[0].Pairs {
+		if pair.IngressName == "" {
+			pair.IngressName = names(pair.Ingress).Name
+		}
+		if pair.EgressName == "" {
+			pair.EgressName = names(pair.Egress).Name
+		}
+		result.Pairs = append(result.Pairs, pair)
+	}
+
+	return result, nil
+}
+
+func (s *interfaceMatrixServiceImpl) GetRates(routerId string, from, to time.Time, ifIndex *uint32) (*InterfaceRatesResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	match, err := interfaceMatrixMatch(routerId, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	pipeline := []bson.M{
+		{"$match": match},
+		{"$project": bson.M{"timestamp": 1, "interfaces": 1}},
+		{"$unwind": "$interfaces"},
+	}
+	if ifIndex != nil {
+		pipeline = append(pipeline, bson.M{"$match": bson.M{"interfaces.ifIndex": *ifIndex}})
+	}
+	// Flows atrasados geram um segundo documento para o mesmo minuto.
+	pipeline = append(pipeline,
+		bson.M{"$group": bson.M{
+			"_id":         bson.M{"timestamp": "$timestamp", "ifIndex": "$interfaces.ifIndex"},
+			"name":        bson.M{"$max": "$interfaces.name"},
+			"description": bson.M{"$max": "$interfaces.description"},
+			"inBytes":     bson.M{"$sum": "$interfaces.inBytes"},
+			"outBytes":    bson.M{"$sum": "$interfaces.outBytes"},
+			"inPackets":   bson.M{"$sum": "$interfaces.inPackets"},
+			"outPackets":  bson.M{"$sum": "$interfaces.outPackets"},
+		}},
+		bson.M{"$sort": bson.D{{Key: "_id.timestamp", Value: 1}, {Key: "_id.ifIndex", Value: 1}}},
+	)
+
+	cursor, err := s.repo.Collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao agregar taxas de interfaces: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		ID struct {
+			Timestamp primitive.DateTime `bson:"timestamp"`
+			IfIndex   uint32             `bson:"ifIndex"`
+		} `bson:"_id"`
+		Name        string `bson:"name"`
+		Description string `bson:"description"`
+		InBytes     uint64 `bson:"inBytes"`
+		OutBytes    uint64 `bson:"outBytes"`
+		InPackets   uint64 `bson:"inPackets"`
+		OutPackets  uint64 `bson:"outPackets"`
+	}
+	if err = cursor.All(ctx, &rows); err != nil {
+		return nil, fmt.Errorf("erro ao decodificar resultados: %w", err)
+	}
+
+	byMinute := make(map[time.Time][]metrics.InterfaceFlowTraffic)
+	for _, row := range rows {
+		timestamp := row.ID.Timestamp.Time()
+		byMinute[timestamp] = append(byMinute[timestamp], metrics.InterfaceFlowTraffic{
+			IfIndex:     row.ID.IfIndex,
+			Name:        row.Name,
+			Description: row.Description,
+			InBytes:     row.InBytes,
+			OutBytes:    row.OutBytes,
+			InPackets:   row.InPackets,
+			OutPackets:  row.OutPackets,
+		})
+	}
+
+	result := &InterfaceRatesResult{
+		RouterID: routerId,
+		From:     from,
+		To:       to,
+		Points:   make([]InterfaceRatesPoint, 0, len(byMinute)),
+	}
+	for timestamp, traffic := range byMinute {
+		result.Points = append(result.Points, InterfaceRatesPoint{
+			Timestamp:  timestamp,
+			Interfaces: metrics.InterfaceRates(traffic, time.Minute),
+		})
+	}
+	sort.Slice(result.Points, func(i, j int) bool {
+		return result.Points[i].Timestamp.Before(result.Points[j].Timestamp)
+	})
+
+	return result, nil
+}
+
+// nameResolver completa nomes que não estavam no cache do SNMP quando o
+// minuto foi gravado.
+func (s *interfaceMatrixServiceImpl) nameResolver(routerId string) func(ifIndex uint32) snmp.InterfaceInfo {
+	var device interfaces.NetworkDevice
+	if s.deviceService != nil {
+		device, _, _ = s.deviceService.GetByID(routerId)
+	}
+	return func(ifIndex uint32) snmp.InterfaceInfo {
+		if device == nil || ifIndex == 0 || s.interfaceTableService == nil {
+			return snmp.InterfaceInfo{}
+		}
+		info, _ := s.interfaceTableService.Lookup(device, ifIndex)
+		return info
+	}
+}
+
+func interfaceMatrixMatch(routerId string, from, to time.Time) (bson.M, error) {
+	objectID, err := primitive.ObjectIDFromHex(routerId)
+	if err != nil {
+		return nil, fmt.Errorf("routerId inválido: %w", err)
+	}
+	return bson.M{
+		"routerId": objectID,
+		"timestamp": bson.M{
+			"$gte": primitive.NewDateTimeFromTime(from),
+			"$lt":  primitive.NewDateTimeFromTime(to),
+		},
+	}, nil
+}
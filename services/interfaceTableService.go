@@ -0,0 +1,141 @@
+package services
+
+import (
+	"log"
+	"net_monitor/interfaces"
+	"net_monitor/snmp"
+	utils "net_monitor/utils"
+	"sync"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+// InterfaceTableService mantém em memória a tabela de interfaces (IF-MIB) de
+// cada dispositivo, usada para dar nome aos ifIndex que chegam nos flows. As
+// consultas nunca esperam o SNMP: tabelas ausentes ou vencidas são buscadas
+// em segundo plano e a resposta usa o que já estiver em cache.
+type InterfaceTableService interface {
+	Lookup(device interfaces.NetworkDevice, ifIndex uint32) (snmp.InterfaceInfo, bool)
+	GetTable(device interfaces.NetworkDevice) map[uint32]snmp.InterfaceInfo
+}
+
+type interfaceTableEntry struct {
+	table     map[uint32]snmp.InterfaceInfo
+	fetchedAt time.Time
+	failedAt  time.Time
+	fetching  bool
+}
+
+type interfaceTableServiceImpl struct {
+	refreshInterval time.Duration
+	retryInterval   time.Duration
+	mu              sync.Mutex
+	entries         map[string]*interfaceTableEntry
+}
+
+// NewInterfaceTableService lê IF_TABLE_REFRESH_MINUTES (padrão 30). Falhas
+// de SNMP e ifIndex desconhecidos são tentados de novo após um minuto.
+func NewInterfaceTableService() InterfaceTableService {
+	return &interfaceTableServiceImpl{
+		refreshInterval: time.Duration(utils.EnvPositiveInt("IF_TABLE_REFRESH_MINUTES", 30)) * time.Minute,
+		retryInterval:   time.Minute,
+		entries:         make(map[string]*interfaceTableEntry),
+	}
+}
+
+func (s *interfaceTableServiceImpl) Lookup(device interfaces.NetworkDevice, ifIndex uint32) (snmp.InterfaceInfo, bool) {
+	if device == nil {
+		return snmp.InterfaceInfo{}, false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := s.entry(device)
+	info, ok := entry.table[ifIndex]
+	// Interface criada depois da última leitura da tabela.
+	if !ok && entry.fetchedAt.Before(time.Now().Add(-s.retryInterval)) {
+		s.refresh(device, entry)
+	}
+	return info, ok
+}
+
+func (s *interfaceTableServiceImpl) GetTable(device interfaces.NetworkDevice) map[uint32]snmp.InterfaceInfo {
+	if device == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// O mapa nunca é alterado depois de publicado, então pode ser compartilhado.
+	return s.entry(device).table
+}
+
+// entry devolve a entrada do dispositivo, disparando a atualização quando a
+// tabela venceu. Deve ser chamado com s.mu travado.
+func (s *interfaceTableServiceImpl) entry(device interfaces.NetworkDevice) *interfaceTableEntry {
+	key := device.GetID()
+	if key == "" {
+		key = device.GetIPAddress()
+	}
+
+	entry, ok := s.entries[key]
+	if !ok {
+		entry = &interfaceTableEntry{}
+		s.entries[key] = entry
+	}
+	if time.Since(entry.fetchedAt) > s.refreshInterval {
+		s.refresh(device, entry)
+	}
+	return entry
+}
+
+// refresh deve ser chamado com s.mu travado.
+func (s *interfaceTableServiceImpl) refresh(device interfaces.NetworkDevice, entry *interfaceTableEntry) {
+	if entry.fetching || time.Since(entry.failedAt) < s.retryInterval {
+		return
+	}
+	if device.GetSnmpCommunity() == "" || !device.IsActive() {
+		return
+	}
+	entry.fetching = true
+
+	go func() {
+		table, err := fetchInterfaceTable(device)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		entry.fetching = false
+		if err != nil {
+			entry.failedAt = time.Now()
+			log.Printf("Erro ao ler tabela de interfaces de %s (%s): %v", device.GetName(), device.GetIPAddress(), err)
+			return
+		}
+		entry.table = table
+		entry.fetchedAt = time.Now()
+	}()
+}
+
+func fetchInterfaceTable(device interfaces.NetworkDevice) (map[uint32]snmp.InterfaceInfo, error) {
+	snmpPort, err := utils.ParseInt(device.GetSnmpPort())
+	if err != nil {
+		return nil, err
+	}
+
+	params := &gosnmp.GoSNMP{
+		Target:    device.GetIPAddress(),
+		Port:      uint16(snmpPort),
+		Community: device.GetSnmpCommunity(),
+		Version:   gosnmp.Version2c,
+		Timeout:   2 * time.Second,
+		Retries:   1,
+	}
+	if err := params.Connect(); err != nil {
+		return nil, err
+	}
+	defer params.Conn.Close()
+
+	return snmp.GetInterfaceTable(params)
+}
@@ -1,22 +1,17 @@
 package services
 
 import (
-	"context"
 	"fmt"
-	"net_monitor/netflow/metrics"
-	"net_monitor/repository"
 	"time"
-
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// IPVersionMetricService monta a divisão IPv4/IPv6 sobre a agregação genérica.
+// routerId vazio considera todos os roteadores; as datas e horas seguem o fuso
+// recebido.
 type IPVersionMetricService interface {
-	GetIPVersionFlowsPercent() ([]IPVersionDailyData, error)
-	GetIPVersionFlowsPercentByRouter(routerId string) ([]IPVersionDailyData, error)
-	GetIPVersionBytes() ([]IPVersionBytesDailyData, error)
-	GetIPVersionBytesByRouter(routerId string) ([]IPVersionBytesDailyData, error)
-	GetIPVersionFlowsPercentByDay(date string) ([]IPVersionHourlyData, error)
+	GetIPVersionFlowsPercent(routerId string, from, to time.Time, location *time.Location) ([]IPVersionDailyData, error)
+	GetIPVersionBytes(routerId string, from, to time.Time, location *time.Location) ([]IPVersionBytesDailyData, error)
+	GetIPVersionFlowsPercentByDay(date string, routerId string, location *time.Location) ([]IPVersionHourlyData, error)
 }
 
 type IPVersionDailyData struct {
@@ -43,539 +38,114 @@ type IPVersionHourlyData struct {
 	TotalFlows     uint64  `json:"totalFlows"`
 }
 
-type ipVersionMetricServiceImpl struct {
-	repo *repository.MongoRepository[metrics.IPVersionMetric]
-}
-
-func NewIPVersionMetricService(repo *repository.MongoRepository[metrics.IPVersionMetric]) IPVersionMetricService {
-	return &ipVersionMetricServiceImpl{repo: repo}
-}
-
-func (s *ipVersionMetricServiceImpl) GetIPVersionFlowsPercent() ([]IPVersionDailyData, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	now := time.Now()
-	brazilLocation := time.FixedZone("BRT", -3*60*60)
-	nowBrazil := now.In(brazilLocation)
-	startDate := nowBrazil.AddDate(0, 0, -30).Truncate(24 * time.Hour)
-	startDateUTC := startDate.UTC()
-
-	pipeline := []bson.M{
-		{
-			"$match": bson.M{
-				"timestamp": bson.M{
-					"$gte": primitive.NewDateTimeFromTime(startDateUTC),
-				},
-			},
-		},
-		{
-			"$addFields": bson.M{
-				"brazilDate": bson.M{
-					"$dateToString": bson.M{
-						"format":   "%Y-%m-%d",
-						"date":     "$timestamp",
-						"timezone": "-03:00",
-					},
-				},
-			},
-		},
-		{
-			"$group": bson.M{
-				"_id": "$brazilDate",
-				"totalIPv4Flows": bson.M{
-					"$sum": "$ipv4FlowCount",
-				},
-				"totalIPv6Flows": bson.M{
-					"$sum": "$ipv6FlowCount",
-				},
-			},
-		},
-		{
-			"$addFields": bson.M{
-				"totalFlows": bson.M{
-					"$add": []interface{}{"$totalIPv4Flows", "$totalIPv6Flows"},
-				},
-			},
-		},
-		{
-			"$addFields": bson.M{
-				"ipv4Percentage": bson.M{
-					"$cond": bson.M{
-						"if":   bson.M{"$eq": []interface{}{"$totalFlows", 0}},
-						"then": 0,
-						"else": bson.M{
-							"$multiply": []interface{}{
-								bson.M{"$divide": []interface{}{"$totalIPv4Flows", "$totalFlows"}},
-								100,
-							},
-						},
-					},
-				},
-				"ipv6Percentage": bson.M{
-					"$cond": bson.M{
-						"if":   bson.M{"$eq": []interface{}{"$totalFlows", 0}},
-						"then": 0,
-						"else": bson.M{
-							"$multiply": []interface{}{
-								bson.M{"$divide": []interface{}{"$totalIPv6Flows", "$totalFlows"}},
-								100,
-							},
-						},
-					},
-				},
-			},
-		},
-		{
-			"$sort": bson.M{"_id": 1},
-		},
-		{
-			"$project": bson.M{
-				"_id":            0,
-				"date":           "$_id",
-				"ipv4Percentage": 1,
-				"ipv6Percentage": 1,
-				"totalFlows":     1,
-			},
-		},
+var (
+	ipVersionFlowFields = []AggregationField{
+		{Name: "ipv4Flows", Field: "ipv4FlowCount", Operator: "sum"},
+		{Name: "ipv6Flows", Field: "ipv6FlowCount", Operator: "sum"},
 	}
-
-	cursor, err := s.repo.Collection.Aggregate(ctx, pipeline)
-	if err != nil {
-		return nil, fmt.Errorf("erro ao agregar métricas de IPv4/IPv6: %w", err)
-	}
-	defer cursor.Close(ctx)
-
-	var results []IPVersionDailyData
-	if err = cursor.All(ctx, &results); err != nil {
-		return nil, fmt.Errorf("erro ao decodificar resultados: %w", err)
+	ipVersionByteFields = []AggregationField{
+		{Name: "ipv4Bytes", Field: "ipv4Bytes", Operator: "sum"},
+		{Name: "ipv6Bytes", Field: "ipv6Bytes", Operator: "sum"},
 	}
+)
 
-	return results, nil
+type ipVersionMetricServiceImpl struct {
+	aggregation MetricAggregationService
 }
 
-func (s *ipVersionMetricServiceImpl) GetIPVersionFlowsPercentByRouter(routerId string) ([]IPVersionDailyData, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	objectID, err := primitive.ObjectIDFromHex(routerId)
-	if err != nil {
-		return nil, fmt.Errorf("routerId inválido: %w", err)
-	}
-
-	now := time.Now()
-	brazilLocation := time.FixedZone("BRT", -3*60*60)
-	nowBrazil := now.In(brazilLocation)
-	startDate := nowBrazil.AddDate(0, 0, -30).Truncate(24 * time.Hour)
-	startDateUTC := startDate.UTC()
-
-	pipeline := []bson.M{
-		{
-			"$match": bson.M{
-				"routerId": objectID,
-				"timestamp": bson.M{
-					"$gte": primitive.NewDateTimeFromTime(startDateUTC),
-				},
-			},
-		},
-		{
-			"$addFields": bson.M{
-				"brazilDate": bson.M{
-					"$dateToString": bson.M{
-						"format":   "%Y-%m-%d",
-						"date":     "$timestamp",
-						"timezone": "-03:00",
-					},
-				},
-			},
-		},
-		{
-			"$group": bson.M{
-				"_id": "$brazilDate",
-				"totalIPv4Flows": bson.M{
-					"$sum": "$ipv4FlowCount",
-				},
-				"totalIPv6Flows": bson.M{
-					"$sum": "$ipv6FlowCount",
-				},
-			},
-		},
-		{
-			"$addFields": bson.M{
-				"totalFlows": bson.M{
-					"$add": []interface{}{"$totalIPv4Flows", "$totalIPv6Flows"},
-				},
-			},
-		},
-		{
-			"$addFields": bson.M{
-				"ipv4Percentage": bson.M{
-					"$cond": bson.M{
-						"if":   bson.M{"$eq": []interface{}{"$totalFlows", 0}},
-						"then": 0,
-						"else": bson.M{
-							"$multiply": []interface{}{
-								bson.M{"$divide": []interface{}{"$totalIPv4Flows", "$totalFlows"}},
-								100,
-							},
-						},
-					},
-				},
-				"ipv6Percentage": bson.M{
-					"$cond": bson.M{
-						"if":   bson.M{"$eq": []interface{}{"$totalFlows", 0}},
-						"then": 0,
-						"else": bson.M{
-							"$multiply": []interface{}{
-								bson.M{"$divide": []interface{}{"$totalIPv6Flows", "$totalFlows"}},
-								100,
-							},
-						},
-					},
-				},
-			},
-		},
-		{
-			"$sort": bson.M{"_id": 1},
-		},
-		{
-			"$project": bson.M{
-				"_id":            0,
-				"date":           "$_id",
-				"ipv4Percentage": 1,
-				"ipv6Percentage": 1,
-				"totalFlows":     1,
-			},
-		},
-	}
-
-	cursor, err := s.repo.Collection.Aggregate(ctx, pipeline)
-	if err != nil {
-		return nil, fmt.Errorf("erro ao agregar métricas de IPv4/IPv6 por roteador: %w", err)
-	}
-	defer cursor.Close(ctx)
-
-	var results []IPVersionDailyData
-	if err = cursor.All(ctx, &results); err != nil {
-		return nil, fmt.Errorf("erro ao decodificar resultados: %w", err)
-	}
-
-	return results, nil
+func NewIPVersionMetricService(aggregation MetricAggregationService) IPVersionMetricService {
+	return &ipVersionMetricServiceImpl{aggregation: aggregation}
 }
 
-func (s *ipVersionMetricServiceImpl) GetIPVersionBytes() ([]IPVersionBytesDailyData, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	now := time.Now()
-	brazilLocation := time.FixedZone("BRT", -3*60*60)
-	nowBrazil := now.In(brazilLocation)
-	startDate := nowBrazil.AddDate(0, 0, -30).Truncate(24 * time.Hour)
-	startDateUTC := startDate.UTC()
-
-	pipeline := []bson.M{
-		{
-			"$match": bson.M{
-				"timestamp": bson.M{
-					"$gte": primitive.NewDateTimeFromTime(startDateUTC),
-				},
-			},
-		},
-		{
-			"$addFields": bson.M{
-				"brazilDate": bson.M{
-					"$dateToString": bson.M{
-						"format":   "%Y-%m-%d",
-						"date":     "$timestamp",
-						"timezone": "-03:00",
-					},
-				},
-			},
-		},
-		{
-			"$group": bson.M{
-				"_id": "$brazilDate",
-				"ipv4Bytes": bson.M{
-					"$sum": "$ipv4Bytes",
-				},
-				"ipv6Bytes": bson.M{
-					"$sum": "$ipv6Bytes",
-				},
-			},
-		},
-		{
-			"$addFields": bson.M{
-				"totalBytes": bson.M{
-					"$add": []interface{}{"$ipv4Bytes", "$ipv6Bytes"},
-				},
-				"ipv4MB": bson.M{
-					"$divide": []interface{}{"$ipv4Bytes", 1048576},
-				},
-				"ipv6MB": bson.M{
-					"$divide": []interface{}{"$ipv6Bytes", 1048576},
-				},
-			},
-		},
-		{
-			"$addFields": bson.M{
-				"totalMB": bson.M{
-					"$add": []interface{}{"$ipv4MB", "$ipv6MB"},
-				},
-			},
-		},
-		{
-			"$sort": bson.M{"_id": 1},
-		},
-		{
-			"$project": bson.M{
-				"_id":        0,
-				"date":       "$_id",
-				"ipv4Bytes":  1,
-				"ipv6Bytes":  1,
-				"ipv4MB":     1,
-				"ipv6MB":     1,
-				"totalBytes": 1,
-				"totalMB":    1,
-			},
-		},
-	}
-
-	cursor, err := s.repo.Collection.Aggregate(ctx, pipeline)
+func (s *ipVersionMetricServiceImpl) GetIPVersionFlowsPercent(routerId string, from, to time.Time, location *time.Location) ([]IPVersionDailyData, error) {
+	points, err := s.aggregate(routerId, from, to, "day", location, ipVersionFlowFields)
 	if err != nil {
-		return nil, fmt.Errorf("erro ao agregar bytes de IPv4/IPv6: %w", err)
+		return nil, fmt.Errorf("erro ao agregar métricas de IPv4/IPv6: %w", err)
 	}
-	defer cursor.Close(ctx)
 
-	var results []IPVersionBytesDailyData
-	if err = cursor.All(ctx, &results); err != nil {
-		return nil, fmt.Errorf("erro ao decodificar resultados: %w", err)
+	results := make([]IPVersionDailyData, 0, len(points))
+	for _, point := range points {
+		ipv4, ipv6 := point.Values["ipv4Flows"], point.Values["ipv6Flows"]
+		ipv4Percentage, ipv6Percentage := versionShares(ipv4, ipv6)
+		results = append(results, IPVersionDailyData{
+			Date:           point.Bucket.Format("2006-01-02"),
+			IPv4Percentage: ipv4Percentage,
+			IPv6Percentage: ipv6Percentage,
+			TotalFlows:     uint64(ipv4 + ipv6),
+		})
 	}
-
 	return results, nil
 }
 
-func (s *ipVersionMetricServiceImpl) GetIPVersionBytesByRouter(routerId string) ([]IPVersionBytesDailyData, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	objectID, err := primitive.ObjectIDFromHex(routerId)
+func (s *ipVersionMetricServiceImpl) GetIPVersionBytes(routerId string, from, to time.Time, location *time.Location) ([]IPVersionBytesDailyData, error) {
+	points, err := s.aggregate(routerId, from, to, "day", location, ipVersionByteFields)
 	if err != nil {
-		return nil, fmt.Errorf("routerId inválido: %w", err)
-	}
-
-	now := time.Now()
-	brazilLocation := time.FixedZone("BRT", -3*60*60)
-	nowBrazil := now.In(brazilLocation)
-	startDate := nowBrazil.AddDate(0, 0, -30).Truncate(24 * time.Hour)
-	startDateUTC := startDate.UTC()
-
-	pipeline := []bson.M{
-		{
-			"$match": bson.M{
-				"routerId": objectID,
-				"timestamp": bson.M{
-					"$gte": primitive.NewDateTimeFromTime(startDateUTC),
-				},
-			},
-		},
-		{
-			"$addFields": bson.M{
-				"brazilDate": bson.M{
-					"$dateToString": bson.M{
-						"format":   "%Y-%m-%d",
-						"date":     "$timestamp",
-						"timezone": "-03:00",
-					},
-				},
-			},
-		},
-		{
-			"$group": bson.M{
-				"_id": "$brazilDate",
-				"ipv4Bytes": bson.M{
-					"$sum": "$ipv4Bytes",
-				},
-				"ipv6Bytes": bson.M{
-					"$sum": "$ipv6Bytes",
-				},
-			},
-		},
-		{
-			"$addFields": bson.M{
-				"totalBytes": bson.M{
-					"$add": []interface{}{"$ipv4Bytes", "$ipv6Bytes"},
-				},
-				"ipv4MB": bson.M{
-					"$divide": []interface{}{"$ipv4Bytes", 1048576},
-				},
-				"ipv6MB": bson.M{
-					"$divide": []interface{}{"$ipv6Bytes", 1048576},
-				},
-			},
-		},
-		{
-			"$addFields": bson.M{
-				"totalMB": bson.M{
-					"$add": []interface{}{"$ipv4MB", "$ipv6MB"},
-				},
-			},
-		},
-		{
-			"$sort": bson.M{"_id": 1},
-		},
-		{
-			"$project": bson.M{
-				"_id":        0,
-				"date":       "$_id",
-				"ipv4Bytes":  1,
-				"ipv6Bytes":  1,
-				"ipv4MB":     1,
-				"ipv6MB":     1,
-				"totalBytes": 1,
-				"totalMB":    1,
-			},
-		},
-	}
-
-	cursor, err := s.repo.Collection.Aggregate(ctx, pipeline)
-	if err != nil {
-		return nil, fmt.Errorf("erro ao agregar bytes de IPv4/IPv6 por roteador: %w", err)
+		return nil, fmt.Errorf("erro ao agregar bytes de IPv4/IPv6: %w", err)
 	}
-	defer cursor.Close(ctx)
 
-	var results []IPVersionBytesDailyData
-	if err = cursor.All(ctx, &results); err != nil {
-		return nil, fmt.Errorf("erro ao decodificar resultados: %w", err)
+	results := make([]IPVersionBytesDailyData, 0, len(points))
+	for _, point := range points {
+		ipv4, ipv6 := point.Values["ipv4Bytes"], point.Values["ipv6Bytes"]
+		results = append(results, IPVersionBytesDailyData{
+			Date:       point.Bucket.Format("2006-01-02"),
+			IPv4Bytes:  uint64(ipv4),
+			IPv6Bytes:  uint64(ipv6),
+			IPv4MB:     ipv4 / 1048576,
+			IPv6MB:     ipv6 / 1048576,
+			TotalBytes: uint64(ipv4 + ipv6),
+			TotalMB:    (ipv4 + ipv6) / 1048576,
+		})
 	}
-
 	return results, nil
 }
 
-func (s *ipVersionMetricServiceImpl) GetIPVersionFlowsPercentByDay(date string) ([]IPVersionHourlyData, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	targetDate, err := time.Parse("2006-01-02", date)
+func (s *ipVersionMetricServiceImpl) GetIPVersionFlowsPercentByDay(date string, routerId string, location *time.Location) ([]IPVersionHourlyData, error) {
+	targetDate, err := time.ParseInLocation("2006-01-02", date, location)
 	if err != nil {
 		return nil, fmt.Errorf("formato de data inválido: %w", err)
 	}
-
-	brazilLocation := time.FixedZone("BRT", -3*60*60)
-
-	startOfDay := time.Date(targetDate.Year(), targetDate.Month(), targetDate.Day(),
-		0, 0, 0, 0, brazilLocation).UTC()
-
-	endOfDay := time.Date(targetDate.Year(), targetDate.Month(), targetDate.Day(),
-		23, 59, 59, 999999999, brazilLocation).UTC()
-
-	pipeline := []bson.M{
-		{
-			"$match": bson.M{
-				"timestamp": bson.M{
-					"$gte": primitive.NewDateTimeFromTime(startOfDay),
-					"$lte": primitive.NewDateTimeFromTime(endOfDay),
-				},
-			},
-		},
-		{
-			"$addFields": bson.M{
-				"brazilHour": bson.M{
-					"$hour": bson.M{
-						"date":     "$timestamp",
-						"timezone": "-03:00",
-					},
-				},
-			},
-		},
-		{
-			"$group": bson.M{
-				"_id": "$brazilHour",
-				"totalIPv4Flows": bson.M{
-					"$sum": "$ipv4FlowCount",
-				},
-				"totalIPv6Flows": bson.M{
-					"$sum": "$ipv6FlowCount",
-				},
-			},
-		},
-		{
-			"$addFields": bson.M{
-				"totalFlows": bson.M{
-					"$add": []interface{}{"$totalIPv4Flows", "$totalIPv6Flows"},
-				},
-			},
-		},
-		{
-			"$addFields": bson.M{
-				"ipv4Percentage": bson.M{
-					"$cond": bson.M{
-						"if":   bson.M{"$eq": []interface{}{"$totalFlows", 0}},
-						"then": 0,
-						"else": bson.M{
-							"$multiply": []interface{}{
-								bson.M{"$divide": []interface{}{"$totalIPv4Flows", "$totalFlows"}},
-								100,
-							},
-						},
-					},
-				},
-				"ipv6Percentage": bson.M{
-					"$cond": bson.M{
-						"if":   bson.M{"$eq": []interface{}{"$totalFlows", 0}},
-						"then": 0,
-						"else": bson.M{
-							"$multiply": []interface{}{
-								bson.M{"$divide": []interface{}{"$totalIPv6Flows", "$totalFlows"}},
-								100,
-							},
-						},
-					},
-				},
-			},
-		},
-		{
-			"$sort": bson.M{"_id": 1},
-		},
-		{
-			"$project": bson.M{
-				"_id":            0,
-				"hour":           "$_id",
-				"ipv4Percentage": 1,
-				"ipv6Percentage": 1,
-				"totalFlows":     1,
-			},
-		},
-	}
-
-	cursor, err := s.repo.Collection.Aggregate(ctx, pipeline)
+	points, err := s.aggregate(routerId, targetDate, targetDate.AddDate(0, 0, 1), "hour", location, ipVersionFlowFields)
 	if err != nil {
 		return nil, fmt.Errorf("erro ao agregar métricas horárias: %w", err)
 	}
-	defer cursor.Close(ctx)
 
-	var results []IPVersionHourlyData
-	if err = cursor.All(ctx, &results); err != nil {
-		return nil, fmt.Errorf("erro ao decodificar resultados: %w", err)
+	// A hora repetida no fim do horário de verão cai no mesmo índice.
+	var ipv4, ipv6 [24]float64
+	for _, point := range points {
+		hour := point.Bucket.Hour()
+		ipv4[hour] += point.Values["ipv4Flows"]
+		ipv6[hour] += point.Values["ipv6Flows"]
 	}
 
-	hourMap := make(map[int]IPVersionHourlyData)
-	for _, r := range results {
-		hourMap[r.Hour] = r
-	}
-
-	finalResults := make([]IPVersionHourlyData, 24)
-	for i := 0; i < 24; i++ {
-		if data, exists := hourMap[i]; exists {
-			finalResults[i] = data
-		} else {
-			finalResults[i] = IPVersionHourlyData{
-				Hour:           i,
-				IPv4Percentage: 0,
-				IPv6Percentage: 0,
-				TotalFlows:     0,
-			}
+	results := make([]IPVersionHourlyData, 24)
+	for hour := range results {
+		ipv4Percentage, ipv6Percentage := versionShares(ipv4[hour], ipv6[hour])
+		results[hour] = IPVersionHourlyData{
+			Hour:           hour,
+			IPv4Percentage: ipv4Percentage,
+			IPv6Percentage: ipv6Percentage,
+			TotalFlows:     uint64(ipv4[hour] + ipv6[hour]),
 		}
 	}
+	return results, nil
+}
 
-	return finalResults, nil
+func (s *ipVersionMetricServiceImpl) aggregate(routerId string, from, to time.Time, bucket string, location *time.Location, fields []AggregationField) ([]AggregationPoint, error) {
+	return s.aggregation.Aggregate(AggregationQuery{
+		Source:   "ip_version",
+		From:     from,
+		To:       to,
+		Bucket:   bucket,
+		Location: location,
+		RouterID: routerId,
+		Fields:   fields,
+	})
+}
+
+func versionShares(ipv4, ipv6 float64) (float64, float64) {
+	total := ipv4 + ipv6
+	if total == 0 {
+		return 0, 0
+	}
+	return ipv4 / total * 100, ipv6 / total * 100
 }
@@ -0,0 +1,358 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net_monitor/models"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	AggregationBuckets = []string{"minute", "hour", "day", "week", "month"}
+	AggregationGroups  = []string{"", "router", "site"}
+)
+
+var percentileOperator = regexp.MustCompile(`^p([1-9][0-9]?)$`)
+
+// siteCollections são as coleções de dispositivos que exportam flows; o
+// routerId das métricas aponta para uma delas.
+var siteCollections = []string{"roteador", "transmissorFibra", "switchRede"}
+
+// aggregationSources lista as coleções de métricas de flow, os campos
+// numéricos que podem ser agregados em cada uma e os campos de texto pelos
+// quais também é possível agrupar.
+var aggregationSources = map[string]aggregationSource{
+	"ip_version": {
+		collection: "ip_version_metrics",
+		filters:    []string{"routerIp"},
+		fields:     []string{"ipv4FlowCount", "ipv6FlowCount", "ipv4Bytes", "ipv6Bytes", "ipv4Packets", "ipv6Packets", "ipv4Percentage", "ipv6Percentage"},
+	},
+	"packet_loss": {
+		collection: "packet_loss_metrics",
+		filters:    []string{"routerIp"},
+		fields:     []string{"droppedPackets", "droppedOctets", "totalPacketsReceived", "totalOctetsReceived", "packetLossPercentage"},
+	},
+	"dns_quality": {
+		collection: "dns_quality_metrics",
+		filters:    []string{"dnsServerIp", "routerIp"},
+		fields: []string{
+			"totalQueries", "totalResponses", "avgResponseTime", "minResponseTime", "maxResponseTime",
+			"totalResponseTime", "queryBytes", "responseBytes", "totalBytes", "timeoutCount", "timeoutPercentage",
+			"successRate", "responsesUnder50ms", "responses50to100ms", "responses100to500ms", "responsesOver500ms",
+		},
+	},
+	"tcp_quality": {
+		collection: "tcp_quality_metrics",
+		filters:    []string{"routerIp"},
+		fields: []string{
+			"flows", "packets", "bytes", "flagFlows", "synAttempts", "synAcks", "handshakeFailures",
+			"clientResets", "serverResets", "windowFlows", "zeroWindowFlows", "retransmissionBase",
+			"retransmittedPackets", "rttSamples", "rttTotalMs", "rttMinMs", "rttMaxMs",
+		},
+	},
+	"traffic_class": {
+		collection: "traffic_class_metrics",
+		filters:    []string{"routerIp", "class"},
+		groups:     []string{"class"},
+		fields:     []string{"bytes", "packets", "flows"},
+	},
+}
+
+type aggregationSource struct {
+	collection string
+	filters    []string
+	groups     []string
+	fields     []string
+}
+
+// AggregationField é um campo da coleção com o operador aplicado em cada
+// bucket: sum, avg, min, max ou pNN (percentil NN).
+type AggregationField struct {
+	Name       string
+	Field      string
+	Operator   string
+	Percentile float64
+}
+
+// AggregationQuery descreve a agregação; From é inclusivo e To exclusivo.
+// GroupBy aceita "", "router", "site" (o site vem do cadastro do roteador,
+// OLT ou switch) ou um dos campos de agrupamento da fonte.
+// Filters compara por igualdade os campos de texto permitidos na coleção.
+type AggregationQuery struct {
+	Source   string
+	From     time.Time
+	To       time.Time
+	Bucket   string
+	Location *time.Location
+	GroupBy  string
+	RouterID string
+	Site     string
+	Filters  map[string]string
+	Fields   []AggregationField
+}
+
+type AggregationPoint struct {
+	Bucket time.Time          `json:"bucket"`
+	Group  string             `json:"group,omitempty"`
+	Values map[string]float64 `json:"values"`
+}
+
+// MetricAggregationService agrega as coleções de métricas de flow em buckets
+// de tempo alinhados ao fuso pedido. O fuso vem da requisição, do usuário ou
+// de METRICS_TIMEZONE (padrão America/Sao_Paulo), nessa ordem. Requer MongoDB
+// 7.0 ou superior ($dateTrunc e $percentile).
+type MetricAggregationService interface {
+	Aggregate(query AggregationQuery) ([]AggregationPoint, error)
+	Location(timezone string, user *models.User) (*time.Location, error)
+}
+
+type metricAggregationServiceImpl struct {
+	database        *mongo.Database
+	defaultLocation *time.Location
+}
+
+func NewMetricAggregationService(database *mongo.Database) MetricAggregationService {
+	location, err := time.LoadLocation(envString("METRICS_TIMEZONE", "America/Sao_Paulo"))
+	if err != nil {
+		log.Printf("METRICS_TIMEZONE inválido, usando America/Sao_Paulo: %v", err)
+		location, _ = time.LoadLocation("America/Sao_Paulo")
+	}
+	return &metricAggregationServiceImpl{database: database, defaultLocation: location}
+}
+
+func (s *metricAggregationServiceImpl) Location(timezone string, user *models.User) (*time.Location, error) {
+	if timezone != "" {
+		location, err := time.LoadLocation(timezone)
+		if err != nil {
+			return nil, fmt.Errorf("fuso horário inválido: %s", timezone)
+		}
+		return location, nil
+	}
+	if user != nil && user.Timezone != "" {
+		if location, err := time.LoadLocation(user.Timezone); err == nil {
+			return location, nil
+		}
+	}
+	return s.defaultLocation, nil
+}
+
+// AggregationGroupAllowed diz se groupBy é um dos agrupamentos comuns ou um
+// campo de agrupamento da fonte.
+func AggregationGroupAllowed(source string, groupBy string) bool {
+	if slices.Contains(AggregationGroups, groupBy) {
+		return true
+	}
+	definition, ok := aggregationSources[source]
+	return ok && slices.Contains(definition.groups, groupBy)
+}
+
+// ParseAggregationFields lê uma lista "campo:operador,..." validando os campos
+// contra a coleção; sem operador o campo é somado.
+func ParseAggregationFields(source string, spec string) ([]AggregationField, error) {
+	definition, ok := aggregationSources[source]
+	if !ok {
+		return nil, fmt.Errorf("fonte de métricas desconhecida: %s", source)
+	}
+
+	var fields []AggregationField
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, operator, _ := strings.Cut(item, ":")
+		if operator == "" {
+			operator = "sum"
+		}
+		if !slices.Contains(definition.fields, name) {
+			return nil, fmt.Errorf("campo inválido para %s: %s", source, name)
+		}
+
+		field := AggregationField{Name: name + "_" + operator, Field: name, Operator: operator}
+		switch operator {
+		case "sum", "avg", "min", "max":
+		default:
+			match := percentileOperator.FindStringSubmatch(operator)
+			if match == nil {
+				return nil, fmt.Errorf("operador inválido: %s", operator)
+			}
+			field.Percentile, _ = strconv.ParseFloat(match[1], 64)
+		}
+		fields = append(fields, field)
+	}
+
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("informe ao menos um campo")
+	}
+	return fields, nil
+}
+
+func (s *metricAggregationServiceImpl) Aggregate(query AggregationQuery) ([]AggregationPoint, error) {
+	definition, ok := aggregationSources[query.Source]
+	if !ok {
+		return nil, fmt.Errorf("fonte de métricas desconhecida: %s", query.Source)
+	}
+	if !slices.Contains(AggregationBuckets, query.Bucket) {
+		return nil, fmt.Errorf("bucket inválido: %s", query.Bucket)
+	}
+	if !AggregationGroupAllowed(query.Source, query.GroupBy) {
+		return nil, fmt.Errorf("agrupamento inválido: %s", query.GroupBy)
+	}
+	location := query.Location
+	if location == nil {
+		location = s.defaultLocation
+	}
+
+	match := bson.M{
+		"timestamp": bson.M{
+			"$gte": primitive.NewDateTimeFromTime(query.From),
+			"$lt":  primitive.NewDateTimeFromTime(query.To),
+		},
+	}
+	if query.RouterID != "" {
+		objectID, err := primitive.ObjectIDFromHex(query.RouterID)
+		if err != nil {
+			return nil, fmt.Errorf("routerId inválido: %w", err)
+		}
+		match["routerId"] = objectID
+	}
+	for field, value := range query.Filters {
+		if !slices.Contains(definition.filters, field) {
+			return nil, fmt.Errorf("filtro inválido para %s: %s", query.Source, field)
+		}
+		match[field] = value
+	}
+
+	pipeline := []bson.M{{"$match": match}}
+	if query.GroupBy == "site" || query.Site != "" {
+		sites := make([]interface{}, 0, len(siteCollections)+1)
+		for i, collection := range siteCollections {
+			as := fmt.Sprintf("device%d", i)
+			pipeline = append(pipeline, bson.M{"$lookup": bson.M{
+				"from":         collection,
+				"localField":   "routerId",
+				"foreignField": "_id",
+				"pipeline":     []bson.M{{"$project": bson.M{"site": 1}}},
+				"as":           as,
+			}})
+			sites = append(sites, bson.M{"$arrayElemAt": []interface{}{"$" + as + ".site", 0}})
+		}
+		sites = append(sites, "")
+		pipeline = append(pipeline, bson.M{"$addFields": bson.M{"site": bson.M{"$ifNull": sites}}})
+		if query.Site != "" {
+			pipeline = append(pipeline, bson.M{"$match": bson.M{"site": query.Site}})
+		}
+	}
+
+	groupID := bson.M{
+		"bucket": bson.M{
+			"$dateTrunc": bson.M{
+				"date":        "$timestamp",
+				"unit":        query.Bucket,
+				"timezone":    location.String(),
+				"startOfWeek": "monday",
+			},
+		},
+	}
+	switch query.GroupBy {
+	case "":
+	case "router":
+		groupID["group"] = "$routerId"
+	default:
+		groupID["group"] = "$" + query.GroupBy
+	}
+
+	group := bson.M{"_id": groupID}
+	for i, field := range query.Fields {
+		accumulator := bson.M{"$" + field.Operator: "$" + field.Field}
+		if field.Percentile > 0 {
+			accumulator = bson.M{"$percentile": bson.M{
+				"input":  "$" + field.Field,
+				"p":      []float64{field.Percentile / 100},
+				"method": "approximate",
+			}}
+		}
+		group[fmt.Sprintf("v%d", i)] = accumulator
+	}
+	pipeline = append(pipeline, bson.M{"$group": group})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	opts := options.Aggregate().SetAllowDiskUse(true)
+	cursor, err := s.database.Collection(definition.collection).Aggregate(ctx, pipeline, opts)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao agregar %s: %w", query.Source, err)
+	}
+	defer cursor.Close(ctx)
+
+	var rows []bson.M
+	if err = cursor.All(ctx, &rows); err != nil {
+		return nil, fmt.Errorf("erro ao decodificar resultados: %w", err)
+	}
+
+	points := make([]AggregationPoint, 0, len(rows))
+	for _, row := range rows {
+		id, _ := row["_id"].(bson.M)
+		point := AggregationPoint{Values: make(map[string]float64, len(query.Fields))}
+		if bucket, ok := id["bucket"].(primitive.DateTime); ok {
+			point.Bucket = bucket.Time().In(location)
+		}
+		switch group := id["group"].(type) {
+		case primitive.ObjectID:
+			point.Group = group.Hex()
+		case string:
+			point.Group = group
+		}
+		for i, field := range query.Fields {
+			value := row[fmt.Sprintf("v%d", i)]
+			// $percentile devolve um valor por percentil pedido.
+			if values, ok := value.(bson.A); ok && len(values) > 0 {
+				value = values[0]
+			}
+			point.Values[field.Name] = numberValue(value)
+		}
+		points = append(points, point)
+	}
+
+	sort.Slice(points, func(i, j int) bool {
+		if !points[i].Bucket.Equal(points[j].Bucket) {
+			return points[i].Bucket.Before(points[j].Bucket)
+		}
+		return points[i].Group < points[j].Group
+	})
+	return points, nil
+}
+
+func numberValue(value interface{}) float64 {
+	switch number := value.(type) {
+	case int32:
+		return float64(number)
+	case int64:
+		return float64(number)
+	case float64:
+		return number
+	default:
+		return 0
+	}
+}
+
+func envString(name string, defaultValue string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return defaultValue
+}
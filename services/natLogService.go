@@ -0,0 +1,276 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/netip"
+	models "net_monitor/models"
+	enrichment "net_monitor/netflow/enrichment"
+	repository "net_monitor/repository"
+	utils "net_monitor/utils"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	maxNATLogMatches = 100
+
+	// O índice de endereços dos assinantes é recarregado no máximo uma vez
+	// por natSubscriberCacheTTL.
+	natSubscriberCacheTTL = time.Minute
+)
+
+type NATLogService interface {
+	Lookup(request NATLogLookupRequest, user *models.User) ([]NATLogMatch, error)
+	GetAudit(from, to time.Time, limit int) ([]models.NATLogAudit, error)
+}
+
+type NATLogLookupRequest struct {
+	PublicIP  string
+	Port      uint16
+	At        time.Time
+	Tolerance time.Duration
+	Reason    string
+	ClientIP  string
+}
+
+// NATLogMatch é uma tradução que cobre o IP:porta no instante consultado,
+// com o assinante identificado quando possível.
+type NATLogMatch struct {
+	models.NATLogRecord `bson:",inline"`
+	SubscriberID        *primitive.ObjectID `json:"subscriberId,omitempty"`
+	SubscriberName      string              `json:"subscriberName,omitempty"`
+	SubscriberCode      string              `json:"subscriberCode,omitempty"`
+}
+
+type natLogServiceImpl struct {
+	repo               *repository.MongoRepository[models.NATLogRecord]
+	auditRepo          *repository.MongoRepository[models.NATLogAudit]
+	subscriberRepo     *repository.MongoRepository[models.Subscriber]
+	maxSessionDuration time.Duration
+
+	mu                  sync.Mutex
+	subscribers         *enrichment.PrefixTrie[*models.Subscriber]
+	subscribersLoadedAt time.Time
+}
+
+// NewNATLogService lê NAT_LOG_MAX_SESSION_HOURS (padrão 48), a maior duração
+// esperada de uma sessão ou bloco de portas. Traduções iniciadas antes disso
+// não são consideradas, o que limita a varredura do índice em registros sem fim.
+func NewNATLogService(
+	repo *repository.MongoRepository[models.NATLogRecord],
+	auditRepo *repository.MongoRepository[models.NATLogAudit],
+	subscriberRepo *repository.MongoRepository[models.Subscriber],
+) NATLogService {
+	return &natLogServiceImpl{
+		repo:               repo,
+		auditRepo:          auditRepo,
+		subscriberRepo:     subscriberRepo,
+		maxSessionDuration: time.Duration(utils.EnvPositiveInt("NAT_LOG_MAX_SESSION_HOURS", 48)) * time.Hour,
+	}
+}
+
+// Lookup sempre grava a auditoria, inclusive quando a consulta falha.
+func (s *natLogServiceImpl) Lookup(request NATLogLookupRequest, user *models.User) ([]NATLogMatch, error) {
+	matches, err := s.lookup(request)
+
+	audit := models.NATLogAudit{
+		ClientIP:    request.ClientIP,
+		PublicIP:    request.PublicIP,
+		Port:        request.Port,
+		At:          primitive.NewDateTimeFromTime(request.At),
+		Reason:      request.Reason,
+		ResultCount: len(matches),
+		Timestamp:   primitive.NewDateTimeFromTime(time.Now()),
+	}
+	if user != nil {
+		audit.UserID = user.ID
+		audit.Username = user.Username
+	}
+	if err != nil {
+		audit.Error = err.Error()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, auditErr := s.auditRepo.Collection.InsertOne(ctx, audit); auditErr != nil {
+		// Sem auditoria a consulta não pode ser devolvida.
+		log.Printf("[NATLog] Erro ao gravar auditoria de %s: %v", audit.Username, auditErr)
+		return nil, fmt.Errorf("erro ao registrar auditoria: %w", auditErr)
+	}
+
+	return matches, err
+}
+
+func (s *natLogServiceImpl) lookup(request NATLogLookupRequest) ([]NATLogMatch, error) {
+	addr, err := netip.ParseAddr(request.PublicIP)
+	if err != nil {
+		return nil, fmt.Errorf("IP público inválido: %s", request.PublicIP)
+	}
+	publicIP := addr.Unmap().String()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Mesma ordem de campos do índice (outsideIp, start, portas).
+	filter := bson.M{
+		"outsideIp": publicIP,
+		"start": bson.M{
+			"$gte": primitive.NewDateTimeFromTime(request.At.Add(-request.Tolerance - s.maxSessionDuration)),
+			"$lte": primitive.NewDateTimeFromTime(request.At.Add(request.Tolerance)),
+		},
+		"outsidePortStart": bson.M{"$lte": request.Port},
+		"outsidePortEnd":   bson.M{"$gte": request.Port},
+		"$or": []bson.M{
+			{"end": bson.M{"$gte": primitive.NewDateTimeFromTime(request.At.Add(-request.Tolerance))}},
+			{"end": bson.M{"$exists": false}},
+		},
+	}
+	opts := options.Find().SetSort(bson.M{"start": -1}).SetLimit(maxNATLogMatches)
+
+	cursor, err := s.repo.Collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao consultar log de NAT: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var records []models.NATLogRecord
+	if err = cursor.All(ctx, &records); err != nil {
+		return nil, fmt.Errorf("erro ao decodificar resultados: %w", err)
+	}
+
+	matches := make([]NATLogMatch, 0, len(records))
+	if len(records) > 0 {
+		subscribers, err := s.subscriberIndex()
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range records {
+			match := NATLogMatch{NATLogRecord: record}
+			if subscriber := findSubscriberByAddress(subscribers, record.InsideIP); subscriber != nil {
+				match.setSubscriber(subscriber)
+			}
+			matches = append(matches, match)
+		}
+	}
+
+	// Sem registro dinâmico, usa o mapeamento CGNAT estático dos assinantes.
+	if len(matches) == 0 {
+		subscriber, err := s.findSubscriberByCGNAT(publicIP, request.Port)
+		if err != nil {
+			return nil, err
+		}
+		if subscriber != nil {
+			match := NATLogMatch{NATLogRecord: models.NATLogRecord{
+				OutsideIP:        publicIP,
+				OutsidePortStart: request.Port,
+				OutsidePortEnd:   request.Port,
+				Source:           "static_mapping",
+			}}
+			match.setSubscriber(subscriber)
+			matches = append(matches, match)
+		}
+	}
+
+	return matches, nil
+}
+
+func (s *natLogServiceImpl) GetAudit(from, to time.Time, limit int) ([]models.NATLogAudit, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"timestamp": bson.M{
+			"$gte": primitive.NewDateTimeFromTime(from),
+			"$lt":  primitive.NewDateTimeFromTime(to),
+		},
+	}
+	opts := options.Find().SetSort(bson.M{"timestamp": -1}).SetLimit(int64(limit))
+
+	cursor, err := s.auditRepo.Collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao consultar auditoria: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	results := []models.NATLogAudit{}
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, fmt.Errorf("erro ao decodificar resultados: %w", err)
+	}
+	return results, nil
+}
+
+func (m *NATLogMatch) setSubscriber(subscriber *models.Subscriber) {
+	id := subscriber.ID
+	m.SubscriberID = &id
+	m.SubscriberName = subscriber.Name
+	m.SubscriberCode = subscriber.Code
+}
+
+// subscriberIndex indexa os endereços e prefixos dos assinantes. Assinantes
+// inativos também entram: a consulta pode ser sobre um período em que o
+// contrato ainda estava ativo.
+func (s *natLogServiceImpl) subscriberIndex() (*enrichment.PrefixTrie[*models.Subscriber], error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.subscribers != nil && time.Since(s.subscribersLoadedAt) < natSubscriberCacheTTL {
+		return s.subscribers, nil
+	}
+
+	subscribers, err := s.subscriberRepo.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("erro ao carregar assinantes: %w", err)
+	}
+
+	index := enrichment.NewPrefixTrie[*models.Subscriber]()
+	for i := range subscribers {
+		for _, value := range subscribers[i].Addresses {
+			prefix, err := netip.ParsePrefix(value)
+			if err != nil {
+				addr, addrErr := netip.ParseAddr(value)
+				if addrErr != nil {
+					continue
+				}
+				addr = addr.Unmap()
+				prefix = netip.PrefixFrom(addr, addr.BitLen())
+			}
+			index.Insert(prefix, &subscribers[i])
+		}
+	}
+
+	s.subscribers = index
+	s.subscribersLoadedAt = time.Now()
+	return index, nil
+}
+
+func findSubscriberByAddress(subscribers *enrichment.PrefixTrie[*models.Subscriber], address string) *models.Subscriber {
+	addr, err := netip.ParseAddr(address)
+	if err != nil {
+		return nil
+	}
+	subscriber, _ := subscribers.Lookup(addr)
+	return subscriber
+}
+
+func (s *natLogServiceImpl) findSubscriberByCGNAT(publicIP string, port uint16) (*models.Subscriber, error) {
+	subscribers, err := s.subscriberRepo.GetByFilter(bson.M{
+		"cgnatMappings": bson.M{"$elemMatch": bson.M{
+			"publicIp":  publicIP,
+			"portStart": bson.M{"$lte": port},
+			"portEnd":   bson.M{"$gte": port},
+		}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao consultar mapeamento CGNAT: %w", err)
+	}
+	if len(subscribers) == 0 {
+		return nil, nil
+	}
+	return &subscribers[0], nil
+}
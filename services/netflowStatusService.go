@@ -0,0 +1,40 @@
+package services
+
+import (
+	"net_monitor/netflow"
+)
+
+const (
+	NetflowHealthOK       = "ok"
+	NetflowHealthDegraded = "degraded"
+)
+
+type NetflowStatusService interface {
+	GetStatus() *NetflowStatus
+}
+
+type NetflowStatus struct {
+	Health string `json:"health"`
+	netflow.PipelineStatus
+}
+
+type netflowStatusServiceImpl struct{}
+
+func NewNetflowStatusService() NetflowStatusService {
+	return &netflowStatusServiceImpl{}
+}
+
+// O pipeline fica degradado quando alguma fila não responde ao broker.
+func (s *netflowStatusServiceImpl) GetStatus() *NetflowStatus {
+	status := &NetflowStatus{
+		Health:         NetflowHealthOK,
+		PipelineStatus: netflow.GetPipelineStatus(),
+	}
+	for _, queue := range status.Queues {
+		if queue.Error != "" {
+			status.Health = NetflowHealthDegraded
+			break
+		}
+	}
+	return status
+}
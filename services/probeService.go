@@ -0,0 +1,531 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"net_monitor/interfaces"
+	models "net_monitor/models"
+	"net_monitor/probe"
+	repository "net_monitor/repository"
+	utils "net_monitor/utils"
+	"net_monitor/websocket"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	defaultProbeInterval      = 30
+	defaultProbePacketCount   = 5
+	defaultProbeTimeoutMs     = 1000
+	defaultProbeDownThreshold = 3
+	defaultProbeUpThreshold   = 2
+	minProbeInterval          = 5
+	maxProbePacketCount       = 50
+)
+
+type ProbeService interface {
+	Start()
+	Stop()
+	GetAllConfigs() ([]models.ProbeConfig, error)
+	GetConfig(deviceID string) (*models.ProbeConfig, error)
+	SaveConfig(deviceID string, probeConfig *models.ProbeConfig) (error, *utils.APIError)
+	DeleteConfig(deviceID string) error
+	GetResults(deviceID string, from, to time.Time) ([]models.ProbeResult, error)
+	GetAvailability(deviceID string, from, to time.Time) (*ProbeAvailability, error)
+	GetStatus(deviceID string) ProbeDeviceStatus
+}
+
+type ProbeAvailability struct {
+	DeviceID            string    `json:"deviceId"`
+	From                time.Time `json:"from"`
+	To                  time.Time `json:"to"`
+	TotalProbes         int       `json:"totalProbes"`
+	UpProbes            int       `json:"upProbes"`
+	AvailabilityPercent float64   `json:"availabilityPercent"`
+	AvgRttMs            float64   `json:"avgRttMs"`
+	AvgLossPercent      float64   `json:"avgLossPercent"`
+	AvgJitterMs         float64   `json:"avgJitterMs"`
+}
+
+type ProbeDeviceStatus struct {
+	DeviceID   string             `json:"deviceId"`
+	Status     models.ProbeStatus `json:"status"`
+	LastChange *time.Time         `json:"lastChange,omitempty"`
+	LastProbe  *time.Time         `json:"lastProbe,omitempty"`
+	LastResult *probe.Stats       `json:"lastResult,omitempty"`
+	IsProbing  bool               `json:"isProbing"`
+}
+
+type probeRunner struct {
+	config     models.ProbeConfig
+	device     interfaces.NetworkDevice
+	deviceType DeviceType
+	prober     probe.Prober
+	damper     *probe.FlapDamper
+	stopCh     chan struct{}
+	lastChange *time.Time
+	lastProbe  *time.Time
+	lastStats  *probe.Stats
+	mu         sync.RWMutex
+}
+
+type probeServiceImpl struct {
+	hub           *websocket.Hub
+	deviceService DeviceService
+	statusService DeviceStatusService
+	configRepo    *repository.MongoRepository[models.ProbeConfig]
+	resultRepo    *repository.MongoRepository[models.ProbeResult]
+	runners       map[string]*probeRunner
+	mu            sync.RWMutex
+}
+
+func NewProbeService(
+	hub *websocket.Hub,
+	deviceService DeviceService,
+	statusService DeviceStatusService,
+	configRepo *repository.MongoRepository[models.ProbeConfig],
+	resultRepo *repository.MongoRepository[models.ProbeResult],
+) ProbeService {
+	return &probeServiceImpl{
+		hub:           hub,
+		deviceService: deviceService,
+		statusService: statusService,
+		configRepo:    configRepo,
+		resultRepo:    resultRepo,
+		runners:       make(map[string]*probeRunner),
+	}
+}
+
+func (s *probeServiceImpl) Start() {
+	configs, err := s.configRepo.GetByFilter(bson.M{"active": true})
+	if err != nil {
+		log.Printf("Erro ao carregar configurações de probe: %v", err)
+		return
+	}
+
+	for _, probeConfig := range configs {
+		if err := s.startRunner(probeConfig); err != nil {
+			log.Printf("Erro ao iniciar probe para dispositivo %s: %v", probeConfig.DeviceID.Hex(), err)
+		}
+	}
+
+	log.Printf("%d probe(s) de disponibilidade iniciados", len(configs))
+}
+
+func (s *probeServiceImpl) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for deviceID, runner := range s.runners {
+		close(runner.stopCh)
+		delete(s.runners, deviceID)
+	}
+}
+
+func (s *probeServiceImpl) GetAllConfigs() ([]models.ProbeConfig, error) {
+	return s.configRepo.GetAll()
+}
+
+func (s *probeServiceImpl) GetConfig(deviceID string) (*models.ProbeConfig, error) {
+	deviceObjectID, err := primitive.ObjectIDFromHex(deviceID)
+	if err != nil {
+		return nil, err
+	}
+	configs, err := s.configRepo.GetByFilter(bson.M{"deviceId": deviceObjectID})
+	if err != nil || len(configs) == 0 {
+		return nil, err
+	}
+	return &configs[0], nil
+}
+
+func (s *probeServiceImpl) SaveConfig(deviceID string, probeConfig *models.ProbeConfig) (error, *utils.APIError) {
+	deviceObjectID, errObjectId := primitive.ObjectIDFromHex(deviceID)
+	if errObjectId != nil {
+		return errObjectId, nil
+	}
+
+	_, deviceType, errDevice := s.deviceService.GetByID(deviceID)
+	if errDevice != nil {
+		return nil, &utils.APIError{
+			Code:    "DEVICE_NOT_FOUND",
+			Message: "Device not found",
+		}
+	}
+
+	if apiErr := normalizeProbeConfig(probeConfig); apiErr != nil {
+		return nil, apiErr
+	}
+	probeConfig.DeviceID = deviceObjectID
+	probeConfig.DeviceType = string(deviceType)
+
+	existent, errSearch := s.GetConfig(deviceID)
+	if errSearch != nil {
+		return errSearch, nil
+	}
+
+	if existent == nil {
+		probeConfig.ID = primitive.NilObjectID
+		if err := s.configRepo.Create(probeConfig); err != nil {
+			return err, nil
+		}
+	} else {
+		probeConfig.ID = existent.ID
+		probeConfig.Created_At = existent.Created_At
+		if err := s.configRepo.Update(existent.ID.Hex(), probeConfig); err != nil {
+			return err, nil
+		}
+	}
+
+	s.stopRunner(deviceID)
+	if probeConfig.Active {
+		if err := s.startRunner(*probeConfig); err != nil {
+			return err, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (s *probeServiceImpl) DeleteConfig(deviceID string) error {
+	existent, err := s.GetConfig(deviceID)
+	if err != nil {
+		return err
+	}
+	s.stopRunner(deviceID)
+	if existent == nil {
+		return nil
+	}
+	return s.configRepo.Delete(existent.ID.Hex())
+}
+
+func (s *probeServiceImpl) GetResults(deviceID string, from, to time.Time) ([]models.ProbeResult, error) {
+	deviceObjectID, err := primitive.ObjectIDFromHex(deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"deviceId": deviceObjectID,
+		"timestamp": bson.M{
+			"$gte": primitive.NewDateTimeFromTime(from),
+			"$lte": primitive.NewDateTimeFromTime(to),
+		},
+	}
+	findOptions := options.Find().SetSort(bson.D{{Key: "timestamp", Value: 1}})
+
+	cursor, err := s.resultRepo.Collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	results := []models.ProbeResult{}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (s *probeServiceImpl) GetAvailability(deviceID string, from, to time.Time) (*ProbeAvailability, error) {
+	deviceObjectID, err := primitive.ObjectIDFromHex(deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	pipeline := []bson.M{
+		{
+			"$match": bson.M{
+				"deviceId": deviceObjectID,
+				"timestamp": bson.M{
+					"$gte": primitive.NewDateTimeFromTime(from),
+					"$lte": primitive.NewDateTimeFromTime(to),
+				},
+			},
+		},
+		{
+			"$group": bson.M{
+				"_id":   nil,
+				"total": bson.M{"$sum": 1},
+				"up": bson.M{"$sum": bson.M{
+					"$cond": []interface{}{bson.M{"$eq": []interface{}{"$status", models.ProbeStatusUp}}, 1, 0},
+				}},
+				"avgRtt":    bson.M{"$avg": bson.M{"$cond": []interface{}{bson.M{"$gt": []interface{}{"$received", 0}}, "$rttAvgMs", nil}}},
+				"avgLoss":   bson.M{"$avg": "$lossPercent"},
+				"avgJitter": bson.M{"$avg": "$jitterMs"},
+			},
+		},
+	}
+
+	cursor, err := s.resultRepo.Collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var aggregated []struct {
+		Total     int     `bson:"total"`
+		Up        int     `bson:"up"`
+		AvgRtt    float64 `bson:"avgRtt"`
+		AvgLoss   float64 `bson:"avgLoss"`
+		AvgJitter float64 `bson:"avgJitter"`
+	}
+	if err := cursor.All(ctx, &aggregated); err != nil {
+		return nil, err
+	}
+
+	availability := &ProbeAvailability{DeviceID: deviceID, From: from, To: to}
+	if len(aggregated) == 0 || aggregated[0].Total == 0 {
+		return availability, nil
+	}
+
+	result := aggregated[0]
+	availability.TotalProbes = result.Total
+	availability.UpProbes = result.Up
+	availability.AvailabilityPercent = roundTwoDecimals(float64(result.Up) / float64(result.Total) * 100)
+	availability.AvgRttMs = roundTwoDecimals(result.AvgRtt)
+	availability.AvgLossPercent = roundTwoDecimals(result.AvgLoss)
+	availability.AvgJitterMs = roundTwoDecimals(result.AvgJitter)
+
+	return availability, nil
+}
+
+func (s *probeServiceImpl) GetStatus(deviceID string) ProbeDeviceStatus {
+	s.mu.RLock()
+	runner, exists := s.runners[deviceID]
+	s.mu.RUnlock()
+
+	status := ProbeDeviceStatus{DeviceID: deviceID, Status: models.ProbeStatusUnknown}
+	if !exists {
+		return status
+	}
+
+	runner.mu.RLock()
+	defer runner.mu.RUnlock()
+
+	status.Status = models.ProbeStatus(runner.damper.State())
+	status.LastChange = runner.lastChange
+	status.LastProbe = runner.lastProbe
+	status.LastResult = runner.lastStats
+	status.IsProbing = true
+	return status
+}
+
+func (s *probeServiceImpl) startRunner(probeConfig models.ProbeConfig) error {
+	deviceID := probeConfig.DeviceID.Hex()
+
+	device, deviceType, err := s.deviceService.GetByID(deviceID)
+	if err != nil {
+		return err
+	}
+
+	var prober probe.Prober = probe.NewICMPProber()
+	if probeConfig.Protocol == models.ProbeUDP {
+		prober = probe.NewUDPProber(probeConfig.UDPPort)
+	}
+
+	runner := &probeRunner{
+		config:     probeConfig,
+		device:     device,
+		deviceType: deviceType,
+		prober:     prober,
+		damper:     probe.NewFlapDamper(probeConfig.DownThreshold, probeConfig.UpThreshold),
+		stopCh:     make(chan struct{}),
+	}
+
+	s.mu.Lock()
+	if _, exists := s.runners[deviceID]; exists {
+		s.mu.Unlock()
+		return nil
+	}
+	s.runners[deviceID] = runner
+	s.mu.Unlock()
+
+	go s.runProbeLoop(runner)
+
+	log.Printf("Probe %s iniciado para %s %s a cada %ds (%d pacotes)",
+		prober.Name(), deviceType, device.GetName(), probeConfig.IntervalSeconds, probeConfig.PacketCount)
+	return nil
+}
+
+func (s *probeServiceImpl) stopRunner(deviceID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if runner, exists := s.runners[deviceID]; exists {
+		close(runner.stopCh)
+		delete(s.runners, deviceID)
+		log.Printf("Probe interrompido para dispositivo %s", deviceID)
+	}
+}
+
+func (s *probeServiceImpl) runProbeLoop(runner *probeRunner) {
+	s.performProbe(runner)
+
+	ticker := time.NewTicker(time.Duration(runner.config.IntervalSeconds) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-runner.stopCh:
+			return
+		case <-ticker.C:
+			s.performProbe(runner)
+		}
+	}
+}
+
+func (s *probeServiceImpl) performProbe(runner *probeRunner) {
+	timeout := time.Duration(runner.config.TimeoutMs) * time.Millisecond
+	rtts, probeErr := runner.prober.Probe(runner.device.GetIPAddress(), runner.config.PacketCount, timeout)
+	stats := probe.ComputeStats(runner.config.PacketCount, rtts)
+	now := time.Now()
+
+	state, changed := runner.damper.Observe(stats.Received > 0)
+	if s.statusService != nil {
+		s.statusService.ReportProbe(runner.device, runner.deviceType, models.ProbeStatus(state))
+	}
+
+	runner.mu.Lock()
+	runner.lastProbe = &now
+	runner.lastStats = &stats
+	if changed {
+		runner.lastChange = &now
+	}
+	runner.mu.Unlock()
+
+	result := models.ProbeResult{
+		DeviceID:    runner.config.DeviceID,
+		DeviceIP:    runner.device.GetIPAddress(),
+		Protocol:    models.ProbeProtocol(runner.prober.Name()),
+		Timestamp:   primitive.NewDateTimeFromTime(now),
+		Sent:        stats.Sent,
+		Received:    stats.Received,
+		LossPercent: stats.LossPercent,
+		RttMinMs:    stats.RttMinMs,
+		RttAvgMs:    stats.RttAvgMs,
+		RttMaxMs:    stats.RttMaxMs,
+		JitterMs:    stats.JitterMs,
+		Status:      models.ProbeStatus(state),
+	}
+	if probeErr != nil {
+		result.Error = probeErr.Error()
+		log.Printf("Erro no probe de %s: %v", runner.device.GetName(), probeErr)
+	}
+
+	if err := s.resultRepo.Create(&result); err != nil {
+		log.Printf("Erro ao salvar resultado de probe de %s: %v", runner.device.GetName(), err)
+	}
+
+	message := SNMPMetricMessage{
+		DeviceID:   runner.config.DeviceID.Hex(),
+		DeviceName: runner.device.GetName(),
+		DeviceType: string(runner.deviceType),
+		Vendor:     runner.device.GetIntegration(),
+		Metric:     "reachability",
+		Value:      result,
+		Timestamp:  now,
+		Error:      result.Error,
+	}
+	if jsonData, err := json.Marshal(message); err == nil {
+		s.hub.Broadcast(jsonData)
+	}
+
+	if changed && state != probe.StateUnknown {
+		s.publishReachabilityChange(runner, state, stats, now)
+	}
+}
+
+func (s *probeServiceImpl) publishReachabilityChange(runner *probeRunner, state probe.State, stats probe.Stats, timestamp time.Time) {
+	event := interfaces.TrapEvent{
+		DeviceID:   runner.config.DeviceID.Hex(),
+		DeviceName: runner.device.GetName(),
+		DeviceIP:   runner.device.GetIPAddress(),
+		DeviceType: string(runner.deviceType),
+		Vendor:     runner.device.GetIntegration(),
+		EventType:  "device_" + string(state),
+		Message:    fmt.Sprintf("Dispositivo %s está %s (probe %s)", runner.device.GetName(), state, runner.prober.Name()),
+		Data: map[string]interface{}{
+			"state":        string(state),
+			"protocol":     runner.prober.Name(),
+			"loss_percent": stats.LossPercent,
+			"rtt_avg_ms":   stats.RttAvgMs,
+		},
+		Timestamp: timestamp,
+	}
+
+	jsonData, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Erro ao serializar evento de disponibilidade: %v", err)
+		return
+	}
+
+	s.hub.Broadcast(jsonData)
+	log.Printf("[PROBE EVENT] %s", event.Message)
+}
+
+func normalizeProbeConfig(probeConfig *models.ProbeConfig) *utils.APIError {
+	if probeConfig.Protocol == "" {
+		probeConfig.Protocol = models.ProbeICMP
+	}
+	if probeConfig.Protocol != models.ProbeICMP && probeConfig.Protocol != models.ProbeUDP {
+		return &utils.APIError{
+			Code:    "INVALID_PROBE_PROTOCOL",
+			Message: "Protocol must be icmp or udp",
+		}
+	}
+	if probeConfig.IntervalSeconds == 0 {
+		probeConfig.IntervalSeconds = defaultProbeInterval
+	}
+	if probeConfig.IntervalSeconds < minProbeInterval {
+		return &utils.APIError{
+			Code:    "INVALID_PROBE_INTERVAL",
+			Message: fmt.Sprintf("Interval must be at least %d seconds", minProbeInterval),
+		}
+	}
+	if probeConfig.PacketCount == 0 {
+		probeConfig.PacketCount = defaultProbePacketCount
+	}
+	if probeConfig.PacketCount < 1 || probeConfig.PacketCount > maxProbePacketCount {
+		return &utils.APIError{
+			Code:    "INVALID_PROBE_PACKET_COUNT",
+			Message: fmt.Sprintf("Packet count must be between 1 and %d", maxProbePacketCount),
+		}
+	}
+	if probeConfig.TimeoutMs <= 0 {
+		probeConfig.TimeoutMs = defaultProbeTimeoutMs
+	}
+	if probeConfig.DownThreshold <= 0 {
+		probeConfig.DownThreshold = defaultProbeDownThreshold
+	}
+	if probeConfig.UpThreshold <= 0 {
+		probeConfig.UpThreshold = defaultProbeUpThreshold
+	}
+
+	// Cada ciclo precisa terminar antes do próximo tick.
+	cycle := probeConfig.PacketCount * (probeConfig.TimeoutMs + 200)
+	if cycle >= probeConfig.IntervalSeconds*1000 {
+		return &utils.APIError{
+			Code:    "INVALID_PROBE_TIMING",
+			Message: "Packet count and timeout do not fit in the probe interval",
+		}
+	}
+	return nil
+}
+
+func roundTwoDecimals(value float64) float64 {
+	return math.Round(value*100) / 100
+}
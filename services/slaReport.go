@@ -0,0 +1,382 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	models "net_monitor/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type SLAReport struct {
+	Month   string           `json:"month"`
+	From    time.Time        `json:"from"`
+	To      time.Time        `json:"to"`
+	Site    string           `json:"site,omitempty"`
+	Summary SLAReportSummary `json:"summary"`
+	Devices []SLAReportEntry `json:"devices"`
+}
+
+type SLAReportSummary struct {
+	DeviceCount         int     `json:"deviceCount"`
+	UptimePercent       float64 `json:"uptimePercent"`
+	MonitoredMinutes    float64 `json:"monitoredMinutes"`
+	DowntimeMinutes     float64 `json:"downtimeMinutes"`
+	OutageCount         int     `json:"outageCount"`
+	MTTRMinutes         float64 `json:"mttrMinutes"`
+	MaintenanceMinutes  float64 `json:"maintenanceMinutes"`
+	DegradedMinutes     float64 `json:"degradedMinutes"`
+	UnmonitoredMinutes  float64 `json:"unmonitoredMinutes"`
+	DevicesBelowFullSLA int     `json:"devicesBelowFullSla"`
+}
+
+type SLAReportEntry struct {
+	DeviceID           string  `json:"deviceId"`
+	DeviceName         string  `json:"deviceName"`
+	DeviceType         string  `json:"deviceType"`
+	Site               string  `json:"site,omitempty"`
+	UptimePercent      float64 `json:"uptimePercent"`
+	MonitoredMinutes   float64 `json:"monitoredMinutes"`
+	DowntimeMinutes    float64 `json:"downtimeMinutes"`
+	DegradedMinutes    float64 `json:"degradedMinutes"`
+	MaintenanceMinutes float64 `json:"maintenanceMinutes"`
+	UnmonitoredMinutes float64 `json:"unmonitoredMinutes"`
+	OutageCount        int     `json:"outageCount"`
+	MTTRMinutes        float64 `json:"mttrMinutes"`
+}
+
+type timeInterval struct {
+	start time.Time
+	end   time.Time
+}
+
+type statusSegment struct {
+	timeInterval
+	status models.DeviceOperationalStatus
+}
+
+func SLAReportCSVHeader() []string {
+	return []string{
+		"device_id", "device_name", "device_type", "site",
+		"uptime_percent", "monitored_minutes", "downtime_minutes", "degraded_minutes",
+		"maintenance_minutes", "unmonitored_minutes", "outage_count", "mttr_minutes",
+	}
+}
+
+func (e SLAReportEntry) CSVRecord() []string {
+	return []string{
+		e.DeviceID, e.DeviceName, e.DeviceType, e.Site,
+		fmt.Sprintf("%.3f", e.UptimePercent),
+		fmt.Sprintf("%.2f", e.MonitoredMinutes),
+		fmt.Sprintf("%.2f", e.DowntimeMinutes),
+		fmt.Sprintf("%.2f", e.DegradedMinutes),
+		fmt.Sprintf("%.2f", e.MaintenanceMinutes),
+		fmt.Sprintf("%.2f", e.UnmonitoredMinutes),
+		fmt.Sprintf("%d", e.OutageCount),
+		fmt.Sprintf("%.2f", e.MTTRMinutes),
+	}
+}
+
+// GetSLAReport calcula o SLA do mês de `month` no fuso recebido. Tempo em
+// status unknown, inclusive os intervalos sem coleta, não entra na base de
+// cálculo e janelas de manutenção são descontadas tanto do tempo monitorado
+// quanto das indisponibilidades.
+func (s *deviceStatusServiceImpl) GetSLAReport(month time.Time, site string, deviceID string, location *time.Location) (*SLAReport, error) {
+	monthStart := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, location)
+	monthEnd := monthStart.AddDate(0, 1, 0)
+	periodEnd := monthEnd
+	if now := time.Now(); now.Before(periodEnd) {
+		periodEnd = now
+	}
+
+	report := &SLAReport{
+		Month:   monthStart.Format("2006-01"),
+		From:    monthStart,
+		To:      monthEnd,
+		Site:    site,
+		Devices: []SLAReportEntry{},
+	}
+	if !monthStart.Before(periodEnd) {
+		return report, nil
+	}
+
+	devices, err := s.deviceService.GetAllDevices()
+	if err != nil {
+		return nil, err
+	}
+
+	windows, err := s.findMaintenanceWindows(monthStart, periodEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, cached := range devices {
+		device := cached.Device
+		if deviceID != "" && device.GetID() != deviceID {
+			continue
+		}
+		if site != "" && device.GetSite() != site {
+			continue
+		}
+
+		segments, err := s.buildStatusTimeline(device.GetID(), monthStart, periodEnd)
+		if err != nil {
+			return nil, err
+		}
+
+		maintenance := mergeIntervals(applicableMaintenance(windows, device.GetID(), device.GetSite(), monthStart, periodEnd))
+		entry := computeSLAEntry(segments, maintenance)
+		entry.DeviceID = device.GetID()
+		entry.DeviceName = device.GetName()
+		entry.DeviceType = string(cached.DeviceType)
+		entry.Site = device.GetSite()
+		report.Devices = append(report.Devices, entry)
+	}
+
+	sort.Slice(report.Devices, func(i, j int) bool {
+		return report.Devices[i].DeviceName < report.Devices[j].DeviceName
+	})
+	report.Summary = summarizeSLA(report.Devices)
+
+	return report, nil
+}
+
+func (s *deviceStatusServiceImpl) buildStatusTimeline(deviceID string, from, to time.Time) ([]statusSegment, error) {
+	deviceObjectID, err := primitive.ObjectIDFromHex(deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	current := models.DeviceStatusUnknown
+	var lastSeen time.Time
+	var previous models.DeviceStatusTransition
+	err = s.transitionRepo.Collection.FindOne(ctx,
+		bson.M{"deviceId": deviceObjectID, "timestamp": bson.M{"$lt": primitive.NewDateTimeFromTime(from)}},
+		options.FindOne().SetSort(bson.D{{Key: "timestamp", Value: -1}}),
+	).Decode(&previous)
+	if err == nil {
+		current, lastSeen = previous.Status, transitionLastSeen(previous)
+	} else if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	transitions, err := s.GetTransitions(deviceID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	segments := []statusSegment{}
+	cursor := from
+	for _, transition := range transitions {
+		timestamp := transition.Timestamp.Time()
+		if timestamp.After(cursor) {
+			segments = appendStatusSegment(segments, timeInterval{cursor, timestamp}, current, lastSeen, s.staleAfter)
+		}
+		cursor = timestamp
+		current, lastSeen = transition.Status, transitionLastSeen(transition)
+	}
+	if to.After(cursor) {
+		segments = appendStatusSegment(segments, timeInterval{cursor, to}, current, lastSeen, s.staleAfter)
+	}
+
+	return segments, nil
+}
+
+// appendStatusSegment só atribui o status até lastSeen + staleAfter; sem
+// avaliações depois disso (coletor parado ou sem polls) o restante do trecho
+// conta como unknown. Transições sem lastSeen valem até a seguinte.
+func appendStatusSegment(segments []statusSegment, interval timeInterval, status models.DeviceOperationalStatus, lastSeen time.Time, staleAfter time.Duration) []statusSegment {
+	if !lastSeen.IsZero() && status != models.DeviceStatusUnknown {
+		if confirmed := lastSeen.Add(staleAfter); confirmed.Before(interval.end) {
+			if confirmed.After(interval.start) {
+				segments = append(segments, statusSegment{timeInterval{interval.start, confirmed}, status})
+				interval.start = confirmed
+			}
+			status = models.DeviceStatusUnknown
+		}
+	}
+	return append(segments, statusSegment{interval, status})
+}
+
+func transitionLastSeen(transition models.DeviceStatusTransition) time.Time {
+	if transition.LastSeen == 0 {
+		return time.Time{}
+	}
+	return transition.LastSeen.Time()
+}
+
+func (s *deviceStatusServiceImpl) findMaintenanceWindows(from, to time.Time) ([]models.MaintenanceWindow, error) {
+	return s.maintenanceRepo.GetByFilter(bson.M{
+		"start": bson.M{"$lt": primitive.NewDateTimeFromTime(to)},
+		"end":   bson.M{"$gt": primitive.NewDateTimeFromTime(from)},
+	})
+}
+
+func applicableMaintenance(windows []models.MaintenanceWindow, deviceID, site string, from, to time.Time) []timeInterval {
+	intervals := []timeInterval{}
+	for _, window := range windows {
+		switch {
+		case window.DeviceID != nil:
+			if window.DeviceID.Hex() != deviceID {
+				continue
+			}
+		case window.Site != "":
+			if window.Site != site {
+				continue
+			}
+		}
+
+		interval := timeInterval{window.Start.Time(), window.End.Time()}
+		if interval.start.Before(from) {
+			interval.start = from
+		}
+		if interval.end.After(to) {
+			interval.end = to
+		}
+		if interval.start.Before(interval.end) {
+			intervals = append(intervals, interval)
+		}
+	}
+	return intervals
+}
+
+func mergeIntervals(intervals []timeInterval) []timeInterval {
+	if len(intervals) == 0 {
+		return intervals
+	}
+
+	sort.Slice(intervals, func(i, j int) bool { return intervals[i].start.Before(intervals[j].start) })
+	merged := []timeInterval{intervals[0]}
+	for _, interval := range intervals[1:] {
+		last := &merged[len(merged)-1]
+		if !interval.start.After(last.end) {
+			if interval.end.After(last.end) {
+				last.end = interval.end
+			}
+			continue
+		}
+		merged = append(merged, interval)
+	}
+	return merged
+}
+
+// subtractIntervals devolve as partes de `interval` fora de `excluded` (ordenado e sem sobreposição).
+func subtractIntervals(interval timeInterval, excluded []timeInterval) []timeInterval {
+	remaining := []timeInterval{}
+	cursor := interval.start
+	for _, ex := range excluded {
+		if !ex.end.After(cursor) || !ex.start.Before(interval.end) {
+			continue
+		}
+		if ex.start.After(cursor) {
+			remaining = append(remaining, timeInterval{cursor, ex.start})
+		}
+		cursor = ex.end
+		if !cursor.Before(interval.end) {
+			return remaining
+		}
+	}
+	if cursor.Before(interval.end) {
+		remaining = append(remaining, timeInterval{cursor, interval.end})
+	}
+	return remaining
+}
+
+func computeSLAEntry(segments []statusSegment, maintenance []timeInterval) SLAReportEntry {
+	var monitored, down, degraded, unmonitored time.Duration
+	var outages []timeInterval
+
+	for _, segment := range segments {
+		for _, piece := range subtractIntervals(segment.timeInterval, maintenance) {
+			duration := piece.end.Sub(piece.start)
+			switch segment.status {
+			case models.DeviceStatusUnknown:
+				unmonitored += duration
+				continue
+			case models.DeviceStatusDown:
+				down += duration
+				if last := len(outages) - 1; last >= 0 && outages[last].end.Equal(piece.start) {
+					outages[last].end = piece.end
+				} else {
+					outages = append(outages, piece)
+				}
+			case models.DeviceStatusDegraded:
+				degraded += duration
+			}
+			monitored += duration
+		}
+	}
+
+	var inMaintenance time.Duration
+	for _, interval := range maintenance {
+		inMaintenance += interval.end.Sub(interval.start)
+	}
+
+	entry := SLAReportEntry{
+		MonitoredMinutes:   roundTwoDecimals(monitored.Minutes()),
+		DowntimeMinutes:    roundTwoDecimals(down.Minutes()),
+		DegradedMinutes:    roundTwoDecimals(degraded.Minutes()),
+		MaintenanceMinutes: roundTwoDecimals(inMaintenance.Minutes()),
+		UnmonitoredMinutes: roundTwoDecimals(unmonitored.Minutes()),
+		OutageCount:        len(outages),
+	}
+	if monitored > 0 {
+		entry.UptimePercent = roundThreeDecimals(float64(monitored-down) / float64(monitored) * 100)
+	}
+	if len(outages) > 0 {
+		var outageTotal time.Duration
+		for _, outage := range outages {
+			outageTotal += outage.end.Sub(outage.start)
+		}
+		entry.MTTRMinutes = roundTwoDecimals(outageTotal.Minutes() / float64(len(outages)))
+	}
+
+	return entry
+}
+
+func summarizeSLA(entries []SLAReportEntry) SLAReportSummary {
+	summary := SLAReportSummary{DeviceCount: len(entries)}
+	var outageMinutes float64
+
+	for _, entry := range entries {
+		summary.MonitoredMinutes += entry.MonitoredMinutes
+		summary.DowntimeMinutes += entry.DowntimeMinutes
+		summary.DegradedMinutes += entry.DegradedMinutes
+		summary.MaintenanceMinutes += entry.MaintenanceMinutes
+		summary.UnmonitoredMinutes += entry.UnmonitoredMinutes
+		summary.OutageCount += entry.OutageCount
+		outageMinutes += entry.MTTRMinutes * float64(entry.OutageCount)
+		if entry.MonitoredMinutes > 0 && entry.UptimePercent < 100 {
+			summary.DevicesBelowFullSLA++
+		}
+	}
+
+	if summary.MonitoredMinutes > 0 {
+		summary.UptimePercent = roundThreeDecimals((summary.MonitoredMinutes - summary.DowntimeMinutes) / summary.MonitoredMinutes * 100)
+	}
+	if summary.OutageCount > 0 {
+		summary.MTTRMinutes = roundTwoDecimals(outageMinutes / float64(summary.OutageCount))
+	}
+	summary.MonitoredMinutes = roundTwoDecimals(summary.MonitoredMinutes)
+	summary.DowntimeMinutes = roundTwoDecimals(summary.DowntimeMinutes)
+	summary.DegradedMinutes = roundTwoDecimals(summary.DegradedMinutes)
+	summary.MaintenanceMinutes = roundTwoDecimals(summary.MaintenanceMinutes)
+	summary.UnmonitoredMinutes = roundTwoDecimals(summary.UnmonitoredMinutes)
+
+	return summary
+}
+
+func roundThreeDecimals(value float64) float64 {
+	return math.Round(value*1000) / 1000
+}
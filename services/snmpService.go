@@ -34,6 +34,8 @@ func (r RouterAdapter) GetSnmpPort() string       { return r.Router.SnmpPort }
 func (r RouterAdapter) GetAccessUser() string     { return r.Router.AccessUser }
 func (r RouterAdapter) GetAccessPassword() string { return r.Router.AccessPassword }
 func (r RouterAdapter) IsActive() bool            { return r.Router.Active }
+func (r RouterAdapter) GetSite() string           { return r.Router.Site }
+func (r RouterAdapter) GetDeviceType() string     { return string(DeviceTypeRouter) }
 
 type OLTAdapter struct {
 	OLT models.TransmissorFibra
@@ -48,6 +50,8 @@ func (o OLTAdapter) GetSnmpPort() string       { return o.OLT.SnmpPort }
 func (o OLTAdapter) GetAccessUser() string     { return o.OLT.AccessUser }
 func (o OLTAdapter) GetAccessPassword() string { return o.OLT.AccessPassword }
 func (o OLTAdapter) IsActive() bool            { return o.OLT.Active }
+func (o OLTAdapter) GetSite() string           { return o.OLT.Site }
+func (o OLTAdapter) GetDeviceType() string     { return string(DeviceTypeOLT) }
 
 type SwitchAdapter struct {
 	Switch models.SwitchRede
@@ -62,9 +66,12 @@ func (s SwitchAdapter) GetSnmpPort() string       { return s.Switch.SnmpPort }
 func (s SwitchAdapter) GetAccessUser() string     { return s.Switch.AccessUser }
 func (s SwitchAdapter) GetAccessPassword() string { return s.Switch.AccessPassword }
 func (s SwitchAdapter) IsActive() bool            { return s.Switch.Active }
+func (s SwitchAdapter) GetSite() string           { return s.Switch.Site }
+func (s SwitchAdapter) GetDeviceType() string     { return string(DeviceTypeSwitch) }
 
 type DeviceService interface {
 	GetByID(id string) (interfaces.NetworkDevice, DeviceType, error)
+	GetAllDevices() ([]CachedDevice, error)
 }
 
 type UnifiedDeviceService struct {
@@ -101,11 +108,42 @@ func (u *UnifiedDeviceService) GetByID(id string) (interfaces.NetworkDevice, Dev
 	return nil, "", fmt.Errorf("dispositivo não encontrado: %s", id)
 }
 
+func (u *UnifiedDeviceService) GetAllDevices() ([]CachedDevice, error) {
+	var devices []CachedDevice
+
+	routers, err := u.roteadorService.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	for _, router := range routers {
+		devices = append(devices, CachedDevice{Device: RouterAdapter{Router: router}, DeviceType: DeviceTypeRouter})
+	}
+
+	olts, err := u.transmissorFibraService.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	for _, olt := range olts {
+		devices = append(devices, CachedDevice{Device: OLTAdapter{OLT: olt}, DeviceType: DeviceTypeOLT})
+	}
+
+	switches, err := u.switchRedeService.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	for _, sw := range switches {
+		devices = append(devices, CachedDevice{Device: SwitchAdapter{Switch: sw}, DeviceType: DeviceTypeSwitch})
+	}
+
+	return devices, nil
+}
+
 type SNMPService struct {
 	hub            *websocket.Hub
 	deviceService  DeviceService
 	collectors     map[string]interfaces.SNMPCollector
 	activeChannels map[string]*DeviceCollection
+	statusService  DeviceStatusService
 	mu             sync.RWMutex
 }
 
@@ -117,6 +155,28 @@ type DeviceCollection struct {
 	Metrics    map[string]*MetricCollection
 	IsRunning  bool
 	StopCh     chan struct{}
+
+	// Resultado das coletas desde o último ciclo; o status do dispositivo
+	// recebe um único poll por ciclo, não um por métrica.
+	pollMu        sync.Mutex
+	pollAttempted bool
+	pollSucceeded bool
+}
+
+func (c *DeviceCollection) recordPoll(success bool) {
+	c.pollMu.Lock()
+	defer c.pollMu.Unlock()
+	c.pollAttempted = true
+	c.pollSucceeded = c.pollSucceeded || success
+}
+
+// finishPollCycle devolve se houve coleta no ciclo e se alguma respondeu.
+func (c *DeviceCollection) finishPollCycle() (bool, bool) {
+	c.pollMu.Lock()
+	defer c.pollMu.Unlock()
+	attempted, succeeded := c.pollAttempted, c.pollSucceeded
+	c.pollAttempted, c.pollSucceeded = false, false
+	return attempted, succeeded
 }
 
 type MetricCollection struct {
@@ -148,15 +208,38 @@ func NewSNMPService(hub *websocket.Hub, deviceService DeviceService) *SNMPServic
 	}
 }
 
+func (s *SNMPService) SetStatusService(statusService DeviceStatusService) {
+	s.statusService = statusService
+}
+
+// vendorKey identifica coletores e handlers de trap; os que implementam
+// interfaces.DeviceTypeScoped ficam sob "tipo:fabricante".
+func vendorKey(deviceType DeviceType, vendor string) string {
+	if deviceType == "" {
+		return vendor
+	}
+	return string(deviceType) + ":" + vendor
+}
+
+func registrationKey(vendor string, registered interface{}) string {
+	if scoped, ok := registered.(interfaces.DeviceTypeScoped); ok {
+		return vendorKey(DeviceType(scoped.GetDeviceType()), vendor)
+	}
+	return vendor
+}
+
 func (s *SNMPService) RegisterCollector(collector interfaces.SNMPCollector) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.collectors[collector.GetVendor()] = collector
+	s.collectors[registrationKey(collector.GetVendor(), collector)] = collector
 	s.hub.RegisterCollector(collector)
 }
 
-func (s *SNMPService) getMetricConfigs(vendor string) []config.MetricConfig {
+func (s *SNMPService) getMetricConfigs(deviceType DeviceType, vendor string) []config.MetricConfig {
+	if configs, exists := config.VendorMetricMappings[vendorKey(deviceType, vendor)]; exists {
+		return configs
+	}
 	if configs, exists := config.VendorMetricMappings[vendor]; exists {
 		return configs
 	}
@@ -180,13 +263,16 @@ func (s *SNMPService) StartCollectionWithConfig(deviceID string) error {
 	}
 
 	integration := device.GetIntegration()
-	collector, exists := s.collectors[integration]
+	collector, exists := s.collectors[vendorKey(deviceType, integration)]
+	if !exists {
+		collector, exists = s.collectors[integration]
+	}
 	if !exists {
 		log.Printf("Collector não encontrado para vendor: %s", integration)
 		return fmt.Errorf("collector não encontrado para vendor: %s", integration)
 	}
 
-	configs := s.getMetricConfigs(integration)
+	configs := s.getMetricConfigs(deviceType, integration)
 
 	collection := &DeviceCollection{
 		DeviceID:   deviceID,
@@ -298,6 +384,14 @@ func (s *SNMPService) createGenericCollectFunction(
 func (s *SNMPService) startMetricCollections(collection *DeviceCollection) {
 	var wg sync.WaitGroup
 
+	if s.statusService != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.reportPollCycles(collection)
+		}()
+	}
+
 	for metricName, metric := range collection.Metrics {
 		wg.Add(1)
 		go func(name string, m *MetricCollection) {
@@ -326,8 +420,36 @@ func (s *SNMPService) collectMetricData(collection *DeviceCollection, metricName
 	}
 }
 
+// reportPollCycles usa o menor intervalo entre as métricas como ciclo de poll.
+func (s *SNMPService) reportPollCycles(collection *DeviceCollection) {
+	var interval time.Duration
+	for _, metric := range collection.Metrics {
+		if interval == 0 || (metric.Config.Interval > 0 && metric.Config.Interval < interval) {
+			interval = metric.Config.Interval
+		}
+	}
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-collection.StopCh:
+			return
+		case <-ticker.C:
+			if attempted, succeeded := collection.finishPollCycle(); attempted {
+				s.statusService.ReportSNMPPoll(collection.Device, collection.DeviceType, succeeded)
+			}
+		}
+	}
+}
+
 func (s *SNMPService) performMetricCollection(collection *DeviceCollection, metricName string, metric *MetricCollection) {
 	value, err := metric.CollectFn()
+	collection.recordPoll(err == nil)
 
 	message := SNMPMetricMessage{
 		DeviceID:   collection.DeviceID,
@@ -343,6 +465,7 @@ func (s *SNMPService) performMetricCollection(collection *DeviceCollection, metr
 		message.Error = err.Error()
 		log.Printf("Erro na coleta da métrica %s para %s: %v", metricName, collection.Device.GetName(), err)
 	} else if value != nil {
+		s.publishBgpSessionChanges(collection, metric.LastValue, value)
 		metric.LastValue = value
 		metric.LastUpdate = message.Timestamp
 	}
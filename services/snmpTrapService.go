@@ -16,13 +16,14 @@ import (
 )
 
 type TrapService struct {
-	listener     *gosnmp.TrapListener
-	hub          *websocket.Hub
-	deviceCache  map[string]*CachedDevice
-	trapHandlers map[string]interfaces.TrapHandler
-	rfcHandler   interfaces.TrapHandler
-	port         string
-	mu           sync.RWMutex
+	listener      *gosnmp.TrapListener
+	hub           *websocket.Hub
+	deviceCache   map[string]*CachedDevice
+	trapHandlers  map[string]interfaces.TrapHandler
+	rfcHandler    interfaces.TrapHandler
+	statusService DeviceStatusService
+	port          string
+	mu            sync.RWMutex
 }
 
 type CachedDevice struct {
@@ -47,12 +48,17 @@ func NewTrapService(
 	return ts
 }
 
+func (ts *TrapService) SetStatusService(statusService DeviceStatusService) {
+	ts.statusService = statusService
+}
+
 func (ts *TrapService) RegisterTrapHandler(handler interfaces.TrapHandler) {
 	ts.mu.Lock()
 	defer ts.mu.Unlock()
 
-	ts.trapHandlers[handler.GetVendor()] = handler
-	log.Printf("Trap handler registrado para vendor: %s", handler.GetVendor())
+	key := registrationKey(handler.GetVendor(), handler)
+	ts.trapHandlers[key] = handler
+	log.Printf("Trap handler registrado para vendor: %s", key)
 }
 
 func (ts *TrapService) RegisterDevice(device interfaces.NetworkDevice, deviceType DeviceType) {
@@ -121,6 +127,9 @@ func (ts *TrapService) handleTrap(packet *gosnmp.SnmpPacket, addr *net.UDPAddr)
 	if event != nil {
 		ts.broadcastEvent(event)
 		ts.logEvent(event)
+		if ts.statusService != nil {
+			ts.statusService.ReportTrap(cachedDevice.Device, cachedDevice.DeviceType, event)
+		}
 	}
 }
 
@@ -130,7 +139,10 @@ func (ts *TrapService) parseTrap(packet *gosnmp.SnmpPacket, cachedDevice *Cached
 	vendor := device.GetIntegration()
 
 	ts.mu.RLock()
-	handler, exists := ts.trapHandlers[vendor]
+	handler, exists := ts.trapHandlers[vendorKey(cachedDevice.DeviceType, vendor)]
+	if !exists {
+		handler, exists = ts.trapHandlers[vendor]
+	}
 	ts.mu.RUnlock()
 
 	if exists {
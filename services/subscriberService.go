@@ -0,0 +1,310 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	models "net_monitor/models"
+	"net_monitor/netflow/metrics"
+	repository "net_monitor/repository"
+	utils "net_monitor/utils"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type SubscriberService interface {
+	GetAll() ([]models.Subscriber, error)
+	GetById(id string) (*models.Subscriber, error)
+	Create(subscriber *models.Subscriber) (error, *utils.APIError)
+	Update(id string, subscriber *models.Subscriber) (error, *utils.APIError)
+	Delete(id string) error
+	GetUsage(id string, granularity string, from, to time.Time, location *time.Location) ([]SubscriberUsagePoint, error)
+	GetTopConsumers(from, to time.Time, limit int, direction string) ([]SubscriberUsageTotal, error)
+	SetChangeListener(listener func())
+}
+
+type SubscriberUsagePoint struct {
+	Period          string `bson:"period" json:"period"`
+	UploadBytes     uint64 `bson:"uploadBytes" json:"uploadBytes"`
+	DownloadBytes   uint64 `bson:"downloadBytes" json:"downloadBytes"`
+	UploadPackets   uint64 `bson:"uploadPackets" json:"uploadPackets"`
+	DownloadPackets uint64 `bson:"downloadPackets" json:"downloadPackets"`
+	TotalBytes      uint64 `bson:"totalBytes" json:"totalBytes"`
+}
+
+type SubscriberUsageTotal struct {
+	SubscriberID   primitive.ObjectID `bson:"subscriberId" json:"subscriberId"`
+	SubscriberName string             `bson:"subscriberName" json:"subscriberName"`
+	UploadBytes    uint64             `bson:"uploadBytes" json:"uploadBytes"`
+	DownloadBytes  uint64             `bson:"downloadBytes" json:"downloadBytes"`
+	TotalBytes     uint64             `bson:"totalBytes" json:"totalBytes"`
+}
+
+var usageGranularityFormats = map[string]string{
+	"hour":  "%Y-%m-%dT%H:00",
+	"day":   "%Y-%m-%d",
+	"month": "%Y-%m",
+}
+
+type subscriberServiceImpl struct {
+	repo      *repository.MongoRepository[models.Subscriber]
+	usageRepo *repository.MongoRepository[metrics.SubscriberUsageMetric]
+	onChange  func()
+}
+
+func NewSubscriberService(
+	repo *repository.MongoRepository[models.Subscriber],
+	usageRepo *repository.MongoRepository[metrics.SubscriberUsageMetric],
+) SubscriberService {
+	return &subscriberServiceImpl{repo: repo, usageRepo: usageRepo}
+}
+
+// SetChangeListener permite ao processador de flows recarregar os assinantes
+// logo após uma alteração.
+func (s *subscriberServiceImpl) SetChangeListener(listener func()) {
+	s.onChange = listener
+}
+
+func (s *subscriberServiceImpl) GetAll() ([]models.Subscriber, error) {
+	return s.repo.GetAll()
+}
+
+func (s *subscriberServiceImpl) GetById(id string) (*models.Subscriber, error) {
+	return s.repo.GetById(id)
+}
+
+func (s *subscriberServiceImpl) Create(subscriber *models.Subscriber) (error, *utils.APIError) {
+	if apiErr := normalizeSubscriber(subscriber); apiErr != nil {
+		return nil, apiErr
+	}
+	if err := s.repo.Create(subscriber); err != nil {
+		return err, nil
+	}
+	s.notifyChange()
+	return nil, nil
+}
+
+func (s *subscriberServiceImpl) Update(id string, subscriber *models.Subscriber) (error, *utils.APIError) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err, nil
+	}
+	existent, err := s.repo.GetById(id)
+	if err != nil {
+		return err, nil
+	}
+	if existent == nil {
+		return nil, &utils.APIError{
+			Code:    "SUBSCRIBER_NOT_FOUND",
+			Message: "Subscriber not found",
+		}
+	}
+	if apiErr := normalizeSubscriber(subscriber); apiErr != nil {
+		return nil, apiErr
+	}
+	subscriber.ID = objectID
+	subscriber.Created_At = existent.Created_At
+	if err := s.repo.Update(id, subscriber); err != nil {
+		return err, nil
+	}
+	s.notifyChange()
+	return nil, nil
+}
+
+func (s *subscriberServiceImpl) Delete(id string) error {
+	if err := s.repo.Delete(id); err != nil {
+		return err
+	}
+	s.notifyChange()
+	return nil
+}
+
+// GetUsage agrupa o uso por hora, dia ou mês no fuso recebido.
+func (s *subscriberServiceImpl) GetUsage(id string, granularity string, from, to time.Time, location *time.Location) ([]SubscriberUsagePoint, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("subscriberId inválido: %w", err)
+	}
+	format, ok := usageGranularityFormats[granularity]
+	if !ok {
+		return nil, fmt.Errorf("granularidade inválida: %s", granularity)
+	}
+
+	pipeline := []bson.M{
+		{
+			"$match": bson.M{
+				"subscriberId": objectID,
+				"timestamp": bson.M{
+					"$gte": primitive.NewDateTimeFromTime(from),
+					"$lt":  primitive.NewDateTimeFromTime(to),
+				},
+			},
+		},
+		{
+			"$group": bson.M{
+				"_id": bson.M{
+					"$dateToString": bson.M{
+						"format":   format,
+						"date":     "$timestamp",
+						"timezone": location.String(),
+					},
+				},
+				"uploadBytes":     bson.M{"$sum": "$uploadBytes"},
+				"downloadBytes":   bson.M{"$sum": "$downloadBytes"},
+				"uploadPackets":   bson.M{"$sum": "$uploadPackets"},
+				"downloadPackets": bson.M{"$sum": "$downloadPackets"},
+			},
+		},
+		{
+			"$sort": bson.M{"_id": 1},
+		},
+		{
+			"$project": bson.M{
+				"_id":             0,
+				"period":          "$_id",
+				"uploadBytes":     1,
+				"downloadBytes":   1,
+				"uploadPackets":   1,
+				"downloadPackets": 1,
+				"totalBytes":      bson.M{"$add": []interface{}{"$uploadBytes", "$downloadBytes"}},
+			},
+		},
+	}
+
+	cursor, err := s.usageRepo.Collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao agregar uso do assinante: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	results := []SubscriberUsagePoint{}
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, fmt.Errorf("erro ao decodificar resultados: %w", err)
+	}
+
+	return results, nil
+}
+
+func (s *subscriberServiceImpl) GetTopConsumers(from, to time.Time, limit int, direction string) ([]SubscriberUsageTotal, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	sortField := "totalBytes"
+	switch direction {
+	case "upload":
+		sortField = "uploadBytes"
+	case "download":
+		sortField = "downloadBytes"
+	}
+
+	pipeline := []bson.M{
+		{
+			"$match": bson.M{
+				"timestamp": bson.M{
+					"$gte": primitive.NewDateTimeFromTime(from),
+					"$lt":  primitive.NewDateTimeFromTime(to),
+				},
+			},
+		},
+		{
+			"$group": bson.M{
+				"_id":            "$subscriberId",
+				"subscriberName": bson.M{"$last": "$subscriberName"},
+				"uploadBytes":    bson.M{"$sum": "$uploadBytes"},
+				"downloadBytes":  bson.M{"$sum": "$downloadBytes"},
+			},
+		},
+		{
+			"$addFields": bson.M{
+				"totalBytes": bson.M{"$add": []interface{}{"$uploadBytes", "$downloadBytes"}},
+			},
+		},
+		{
+			"$sort": bson.D{{Key: sortField, Value: -1}},
+		},
+		{
+			"$limit": limit,
+		},
+		{
+			"$project": bson.M{
+				"_id":            0,
+				"subscriberId":   "$_id",
+				"subscriberName": 1,
+				"uploadBytes":    1,
+				"downloadBytes":  1,
+				"totalBytes":     1,
+			},
+		},
+	}
+
+	cursor, err := s.usageRepo.Collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao agregar maiores consumidores: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	results := []SubscriberUsageTotal{}
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, fmt.Errorf("erro ao decodificar resultados: %w", err)
+	}
+
+	return results, nil
+}
+
+// normalizeSubscriber valida endereços (IP ou CIDR) e faixas CGNAT.
+func normalizeSubscriber(subscriber *models.Subscriber) *utils.APIError {
+	if len(subscriber.Addresses) == 0 && len(subscriber.CGNATMappings) == 0 {
+		return &utils.APIError{
+			Code:    "SUBSCRIBER_WITHOUT_ADDRESS",
+			Message: "Subscriber must have at least one address or CGNAT mapping",
+		}
+	}
+
+	for i, address := range subscriber.Addresses {
+		if prefix, err := netip.ParsePrefix(address); err == nil {
+			subscriber.Addresses[i] = prefix.Masked().String()
+			continue
+		}
+		addr, err := netip.ParseAddr(address)
+		if err != nil {
+			return &utils.APIError{
+				Code:    "INVALID_SUBSCRIBER_ADDRESS",
+				Message: fmt.Sprintf("Invalid address: %s", address),
+			}
+		}
+		subscriber.Addresses[i] = addr.Unmap().String()
+	}
+
+	for i, mapping := range subscriber.CGNATMappings {
+		addr, err := netip.ParseAddr(mapping.PublicIP)
+		if err != nil {
+			return &utils.APIError{
+				Code:    "INVALID_CGNAT_MAPPING",
+				Message: fmt.Sprintf("Invalid CGNAT public IP: %s", mapping.PublicIP),
+			}
+		}
+		if mapping.PortStart > mapping.PortEnd {
+			return &utils.APIError{
+				Code:    "INVALID_CGNAT_MAPPING",
+				Message: "CGNAT port range start must not exceed its end",
+			}
+		}
+		subscriber.CGNATMappings[i].PublicIP = addr.Unmap().String()
+	}
+
+	if subscriber.Addresses == nil {
+		subscriber.Addresses = []string{}
+	}
+	return nil
+}
+
+func (s *subscriberServiceImpl) notifyChange() {
+	if s.onChange != nil {
+		go s.onChange()
+	}
+}
@@ -0,0 +1,238 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net_monitor/netflow/metrics"
+	"net_monitor/repository"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var TCPQualityOrderFields = []string{"flows", "handshakeFailureRate", "resetRate", "zeroWindowRate", "retransmissionRate", "avgRttMs"}
+
+// TCPQualityService consulta a qualidade TCP por serviço (porta do servidor).
+// Percentuais usam como base apenas os flows que trouxeram o campo medido.
+type TCPQualityService interface {
+	GetServices(routerId string, from, to time.Time, limit int, orderBy string) ([]TCPQualityEntry, error)
+	GetSeries(routerId string, servicePort *uint16, from, to time.Time) ([]TCPQualityPoint, error)
+}
+
+type TCPQualityEntry struct {
+	ServicePort          uint16  `json:"servicePort"`
+	Flows                uint64  `json:"flows"`
+	Packets              uint64  `json:"packets"`
+	Bytes                uint64  `json:"bytes"`
+	SynAttempts          uint64  `json:"synAttempts"`
+	SynAcks              uint64  `json:"synAcks"`
+	HandshakeFailures    uint64  `json:"handshakeFailures"`
+	HandshakeFailureRate float64 `json:"handshakeFailureRate"`
+	ClientResets         uint64  `json:"clientResets"`
+	ServerResets         uint64  `json:"serverResets"`
+	ResetRate            float64 `json:"resetRate"`
+	ZeroWindowFlows      uint64  `json:"zeroWindowFlows"`
+	ZeroWindowRate       float64 `json:"zeroWindowRate"`
+	RetransmittedPackets uint64  `json:"retransmittedPackets"`
+	RetransmissionRate   float64 `json:"retransmissionRate"`
+	RTTSamples           uint64  `json:"rttSamples"`
+	AvgRTTMs             float64 `json:"avgRttMs"`
+	MinRTTMs             uint64  `json:"minRttMs"`
+	MaxRTTMs             uint64  `json:"maxRttMs"`
+}
+
+type TCPQualityPoint struct {
+	Timestamp time.Time `json:"timestamp"`
+	TCPQualityEntry
+}
+
+// tcpQualityRow espelha o $group; as bases dos percentuais não saem no JSON.
+type tcpQualityRow struct {
+	ID                   interface{} `bson:"_id"`
+	Flows                uint64      `bson:"flows"`
+	Packets              uint64      `bson:"packets"`
+	Bytes                uint64      `bson:"bytes"`
+	FlagFlows            uint64      `bson:"flagFlows"`
+	SynAttempts          uint64      `bson:"synAttempts"`
+	SynAcks              uint64      `bson:"synAcks"`
+	HandshakeFailures    uint64      `bson:"handshakeFailures"`
+	ClientResets         uint64      `bson:"clientResets"`
+	ServerResets         uint64      `bson:"serverResets"`
+	WindowFlows          uint64      `bson:"windowFlows"`
+	ZeroWindowFlows      uint64      `bson:"zeroWindowFlows"`
+	RetransmissionBase   uint64      `bson:"retransmissionBase"`
+	RetransmittedPackets uint64      `bson:"retransmittedPackets"`
+	RTTSamples           uint64      `bson:"rttSamples"`
+	RTTTotalMs           uint64      `bson:"rttTotalMs"`
+	RTTMinMs             uint64      `bson:"rttMinMs"`
+	RTTMaxMs             uint64      `bson:"rttMaxMs"`
+}
+
+type tcpQualityServiceImpl struct {
+	repo *repository.MongoRepository[metrics.TCPQualityMetric]
+}
+
+func NewTCPQualityService(repo *repository.MongoRepository[metrics.TCPQualityMetric]) TCPQualityService {
+	return &tcpQualityServiceImpl{repo: repo}
+}
+
+func (s *tcpQualityServiceImpl) GetServices(routerId string, from, to time.Time, limit int, orderBy string) ([]TCPQualityEntry, error) {
+	match, err := tcpQualityMatch(routerId, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.aggregate(match, "$servicePort")
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]TCPQualityEntry, 0, len(rows))
+	for _, row := range rows {
+		entry := row.entry()
+		if port, ok := row.ID.(int32); ok {
+			entry.ServicePort = uint16(port)
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i].orderValue(orderBy), entries[j].orderValue(orderBy)
+		if a != b {
+			return a > b
+		}
+		return entries[i].Flows > entries[j].Flows
+	})
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries, nil
+}
+
+func (s *tcpQualityServiceImpl) GetSeries(routerId string, servicePort *uint16, from, to time.Time) ([]TCPQualityPoint, error) {
+	match, err := tcpQualityMatch(routerId, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if servicePort != nil {
+		match["servicePort"] = *servicePort
+	}
+
+	rows, err := s.aggregate(match, "$timestamp")
+	if err != nil {
+		return nil, err
+	}
+
+	points := make([]TCPQualityPoint, 0, len(rows))
+	for _, row := range rows {
+		point := TCPQualityPoint{TCPQualityEntry: row.entry()}
+		if timestamp, ok := row.ID.(primitive.DateTime); ok {
+			point.Timestamp = timestamp.Time()
+		}
+		if servicePort != nil {
+			point.ServicePort = *servicePort
+		}
+		points = append(points, point)
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].Timestamp.Before(points[j].Timestamp) })
+	return points, nil
+}
+
+func (s *tcpQualityServiceImpl) aggregate(match bson.M, groupBy string) ([]tcpQualityRow, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	group := bson.M{
+		"_id":      groupBy,
+		"rttMinMs": bson.M{"$min": "$rttMinMs"},
+		"rttMaxMs": bson.M{"$max": "$rttMaxMs"},
+	}
+	for _, field := range []string{
+		"flows", "packets", "bytes", "flagFlows", "synAttempts", "synAcks", "handshakeFailures",
+		"clientResets", "serverResets", "windowFlows", "zeroWindowFlows", "retransmissionBase",
+		"retransmittedPackets", "rttSamples", "rttTotalMs",
+	} {
+		group[field] = bson.M{"$sum": "$" + field}
+	}
+
+	cursor, err := s.repo.Collection.Aggregate(ctx, []bson.M{{"$match": match}, {"$group": group}})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao agregar qualidade TCP: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var rows []tcpQualityRow
+	if err = cursor.All(ctx, &rows); err != nil {
+		return nil, fmt.Errorf("erro ao decodificar resultados: %w", err)
+	}
+	return rows, nil
+}
+
+func (row tcpQualityRow) entry() TCPQualityEntry {
+	entry := TCPQualityEntry{
+		Flows:                row.Flows,
+		Packets:              row.Packets,
+		Bytes:                row.Bytes,
+		SynAttempts:          row.SynAttempts,
+		SynAcks:              row.SynAcks,
+		HandshakeFailures:    row.HandshakeFailures,
+		ClientResets:         row.ClientResets,
+		ServerResets:         row.ServerResets,
+		ZeroWindowFlows:      row.ZeroWindowFlows,
+		RetransmittedPackets: row.RetransmittedPackets,
+		RTTSamples:           row.RTTSamples,
+		MinRTTMs:             row.RTTMinMs,
+		MaxRTTMs:             row.RTTMaxMs,
+	}
+	entry.HandshakeFailureRate = percentage(row.HandshakeFailures, row.SynAttempts)
+	entry.ResetRate = percentage(row.ClientResets+row.ServerResets, row.FlagFlows)
+	entry.ZeroWindowRate = percentage(row.ZeroWindowFlows, row.WindowFlows)
+	entry.RetransmissionRate = percentage(row.RetransmittedPackets, row.RetransmissionBase)
+	if row.RTTSamples > 0 {
+		entry.AvgRTTMs = roundTwoDecimals(float64(row.RTTTotalMs) / float64(row.RTTSamples))
+	}
+	return entry
+}
+
+func (entry TCPQualityEntry) orderValue(orderBy string) float64 {
+	switch orderBy {
+	case "handshakeFailureRate":
+		return entry.HandshakeFailureRate
+	case "resetRate":
+		return entry.ResetRate
+	case "zeroWindowRate":
+		return entry.ZeroWindowRate
+	case "retransmissionRate":
+		return entry.RetransmissionRate
+	case "avgRttMs":
+		return entry.AvgRTTMs
+	default:
+		return float64(entry.Flows)
+	}
+}
+
+func percentage(part, total uint64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return roundTwoDecimals(float64(part) / float64(total) * 100)
+}
+
+func tcpQualityMatch(routerId string, from, to time.Time) (bson.M, error) {
+	match := bson.M{
+		"timestamp": bson.M{
+			"$gte": primitive.NewDateTimeFromTime(from),
+			"$lt":  primitive.NewDateTimeFromTime(to),
+		},
+	}
+	if routerId != "" {
+		objectID, err := primitive.ObjectIDFromHex(routerId)
+		if err != nil {
+			return nil, fmt.Errorf("routerId inválido: %w", err)
+		}
+		match["routerId"] = objectID
+	}
+	return match, nil
+}
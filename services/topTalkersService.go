@@ -0,0 +1,157 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net_monitor/netflow/metrics"
+	"net_monitor/repository"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type TopTalkersService interface {
+	GetTop(dimension string, routerId string, from, to time.Time, limit int, orderBy string) (*TopTalkersResult, error)
+	IsValidDimension(dimension string) bool
+}
+
+type TopTalkerEntry struct {
+	Key        string  `bson:"key" json:"key"`
+	Bytes      uint64  `bson:"bytes" json:"bytes"`
+	Packets    uint64  `bson:"packets" json:"packets"`
+	Flows      uint64  `bson:"flows" json:"flows"`
+	Percentage float64 `bson:"-" json:"percentage"`
+}
+
+type TopTalkersResult struct {
+	Dimension    string           `json:"dimension"`
+	From         time.Time        `json:"from"`
+	To           time.Time        `json:"to"`
+	TotalBytes   uint64           `json:"totalBytes"`
+	TotalPackets uint64           `json:"totalPackets"`
+	Entries      []TopTalkerEntry `json:"entries"`
+}
+
+type topTalkersServiceImpl struct {
+	repo *repository.MongoRepository[metrics.TopTalkersMetric]
+}
+
+func NewTopTalkersService(repo *repository.MongoRepository[metrics.TopTalkersMetric]) TopTalkersService {
+	return &topTalkersServiceImpl{repo: repo}
+}
+
+func (s *topTalkersServiceImpl) IsValidDimension(dimension string) bool {
+	for _, valid := range metrics.TopTalkerDimensions {
+		if dimension == valid {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *topTalkersServiceImpl) GetTop(dimension string, routerId string, from, to time.Time, limit int, orderBy string) (*TopTalkersResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if !s.IsValidDimension(dimension) {
+		return nil, fmt.Errorf("dimensão inválida: %s", dimension)
+	}
+
+	match := bson.M{
+		"timestamp": bson.M{
+			"$gte": primitive.NewDateTimeFromTime(from),
+			"$lt":  primitive.NewDateTimeFromTime(to),
+		},
+	}
+	if routerId != "" {
+		objectID, err := primitive.ObjectIDFromHex(routerId)
+		if err != nil {
+			return nil, fmt.Errorf("routerId inválido: %w", err)
+		}
+		match["routerId"] = objectID
+	}
+
+	sortField := "bytes"
+	if orderBy == "packets" {
+		sortField = "packets"
+	}
+
+	pipeline := []bson.M{
+		{"$match": match},
+		{
+			"$facet": bson.M{
+				"totals": []bson.M{
+					{
+						"$group": bson.M{
+							"_id":          nil,
+							"totalBytes":   bson.M{"$sum": "$totalBytes"},
+							"totalPackets": bson.M{"$sum": "$totalPackets"},
+						},
+					},
+				},
+				"entries": []bson.M{
+					{"$unwind": "$dimensions." + dimension},
+					{
+						"$group": bson.M{
+							"_id":     "$dimensions." + dimension + ".key",
+							"bytes":   bson.M{"$sum": "$dimensions." + dimension + ".bytes"},
+							"packets": bson.M{"$sum": "$dimensions." + dimension + ".packets"},
+							"flows":   bson.M{"$sum": "$dimensions." + dimension + ".flows"},
+						},
+					},
+					{"$sort": bson.D{{Key: sortField, Value: -1}, {Key: "_id", Value: 1}}},
+					{"$limit": limit},
+					{
+						"$project": bson.M{
+							"_id":     0,
+							"key":     "$_id",
+							"bytes":   1,
+							"packets": 1,
+							"flows":   1,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	cursor, err := s.repo.Collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao agregar top talkers: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var facets []struct {
+		Totals []struct {
+			TotalBytes   uint64 `bson:"totalBytes"`
+			TotalPackets uint64 `bson:"totalPackets"`
+		} `bson:"totals"`
+		Entries []TopTalkerEntry `bson:"entries"`
+	}
+	if err = cursor.All(ctx, &facets); err != nil {
+		return nil, fmt.Errorf("erro ao decodificar resultados: %w", err)
+	}
+
+	result := &TopTalkersResult{
+		Dimension: dimension,
+		From:      from,
+		To:        to,
+		Entries:   []TopTalkerEntry{},
+	}
+	if len(facets) == 0 {
+		return result, nil
+	}
+	if len(facets[0].Totals) > 0 {
+		result.TotalBytes = facets[0].Totals[0].TotalBytes
+		result.TotalPackets = facets[0].Totals[0].TotalPackets
+	}
+	for _, entry := range facets[0].Entries {
+		if result.TotalBytes > 0 {
+			entry.Percentage = roundTwoDecimals(float64(entry.Bytes) / float64(result.TotalBytes) * 100)
+		}
+		result.Entries = append(result.Entries, entry)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// TrafficClassMetricService monta a divisão do tráfego por aplicação, por dia
+// ou por hora em um dia, sobre a agregação genérica. As datas e horas seguem o
+// fuso recebido.
+type TrafficClassMetricService interface {
+	GetTrafficClassDaily(routerId string, from, to time.Time, location *time.Location) ([]TrafficClassDailyData, error)
+	GetTrafficClassByDay(date string, routerId string, location *time.Location) ([]TrafficClassHourlyData, error)
+}
+
+type TrafficClassShare struct {
+	Class      string  `json:"class"`
+	Bytes      uint64  `json:"bytes"`
+	Packets    uint64  `json:"packets"`
+	Flows      uint64  `json:"flows"`
+	Percentage float64 `json:"percentage"`
+}
+
+type TrafficClassDailyData struct {
+	Date       string              `json:"date"`
+	TotalBytes uint64              `json:"totalBytes"`
+	Classes    []TrafficClassShare `json:"classes"`
+}
+
+type TrafficClassHourlyData struct {
+	Hour       int                 `json:"hour"`
+	TotalBytes uint64              `json:"totalBytes"`
+	Classes    []TrafficClassShare `json:"classes"`
+}
+
+var trafficClassFields = []AggregationField{
+	{Name: "bytes", Field: "bytes", Operator: "sum"},
+	{Name: "packets", Field: "packets", Operator: "sum"},
+	{Name: "flows", Field: "flows", Operator: "sum"},
+}
+
+type trafficClassMetricServiceImpl struct {
+	aggregation MetricAggregationService
+}
+
+func NewTrafficClassMetricService(aggregation MetricAggregationService) TrafficClassMetricService {
+	return &trafficClassMetricServiceImpl{aggregation: aggregation}
+}
+
+func (s *trafficClassMetricServiceImpl) GetTrafficClassDaily(routerId string, from, to time.Time, location *time.Location) ([]TrafficClassDailyData, error) {
+	points, err := s.aggregate(routerId, from, to, "day", location)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao agregar classes de tráfego: %w", err)
+	}
+
+	byDate := make(map[string][]TrafficClassShare)
+	for _, point := range points {
+		date := point.Bucket.Format("2006-01-02")
+		byDate[date] = append(byDate[date], pointShare(point))
+	}
+
+	results := make([]TrafficClassDailyData, 0, len(byDate))
+	for date, shares := range byDate {
+		total := withPercentages(shares)
+		results = append(results, TrafficClassDailyData{Date: date, TotalBytes: total, Classes: shares})
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Date < results[j].Date })
+
+	return results, nil
+}
+
+func (s *trafficClassMetricServiceImpl) GetTrafficClassByDay(date string, routerId string, location *time.Location) ([]TrafficClassHourlyData, error) {
+	targetDate, err := time.ParseInLocation("2006-01-02", date, location)
+	if err != nil {
+		return nil, fmt.Errorf("formato de data inválido: %w", err)
+	}
+	points, err := s.aggregate(routerId, targetDate, targetDate.AddDate(0, 0, 1), "hour", location)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao agregar classes de tráfego: %w", err)
+	}
+
+	// A hora repetida no fim do horário de verão cai no mesmo índice.
+	byHour := make([]map[string]*TrafficClassShare, 24)
+	for _, point := range points {
+		hour := point.Bucket.Hour()
+		if byHour[hour] == nil {
+			byHour[hour] = make(map[string]*TrafficClassShare)
+		}
+		share, ok := byHour[hour][point.Group]
+		if !ok {
+			share = &TrafficClassShare{Class: point.Group}
+			byHour[hour][point.Group] = share
+		}
+		add := pointShare(point)
+		share.Bytes += add.Bytes
+		share.Packets += add.Packets
+		share.Flows += add.Flows
+	}
+
+	results := make([]TrafficClassHourlyData, 24)
+	for hour := range results {
+		classes := make([]TrafficClassShare, 0, len(byHour[hour]))
+		for _, share := range byHour[hour] {
+			classes = append(classes, *share)
+		}
+		results[hour] = TrafficClassHourlyData{Hour: hour, TotalBytes: withPercentages(classes), Classes: classes}
+	}
+
+	return results, nil
+}
+
+func (s *trafficClassMetricServiceImpl) aggregate(routerId string, from, to time.Time, bucket string, location *time.Location) ([]AggregationPoint, error) {
+	return s.aggregation.Aggregate(AggregationQuery{
+		Source:   "traffic_class",
+		From:     from,
+		To:       to,
+		Bucket:   bucket,
+		Location: location,
+		GroupBy:  "class",
+		RouterID: routerId,
+		Fields:   trafficClassFields,
+	})
+}
+
+func pointShare(point AggregationPoint) TrafficClassShare {
+	return TrafficClassShare{
+		Class:   point.Group,
+		Bytes:   uint64(point.Values["bytes"]),
+		Packets: uint64(point.Values["packets"]),
+		Flows:   uint64(point.Values["flows"]),
+	}
+}
+
+// withPercentages preenche a participação de cada classe, ordena da maior
+// para a menor e devolve o total de bytes.
+func withPercentages(shares []TrafficClassShare) uint64 {
+	var total uint64
+	for _, share := range shares {
+		total += share.Bytes
+	}
+	for i := range shares {
+		if total > 0 {
+			shares[i].Percentage = roundTwoDecimals(float64(shares[i].Bytes) / float64(total) * 100)
+		}
+	}
+	sort.Slice(shares, func(i, j int) bool {
+		if shares[i].Bytes != shares[j].Bytes {
+			return shares[i].Bytes > shares[j].Bytes
+		}
+		return shares[i].Class < shares[j].Class
+	})
+	return total
+}
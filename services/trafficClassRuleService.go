@@ -0,0 +1,187 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"net/netip"
+	models "net_monitor/models"
+	repository "net_monitor/repository"
+	utils "net_monitor/utils"
+	"regexp"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// O nome da classe vira chave nas consultas, então fica restrito.
+var trafficClassNamePattern = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+
+type TrafficClassRuleService interface {
+	GetAll() ([]models.TrafficClassRule, error)
+	GetById(id string) (*models.TrafficClassRule, error)
+	Create(rule *models.TrafficClassRule) (error, *utils.APIError)
+	Update(id string, rule *models.TrafficClassRule) (error, *utils.APIError)
+	Delete(id string) error
+	EnsureDefaultRules()
+	SetChangeListener(listener func())
+}
+
+type trafficClassRuleServiceImpl struct {
+	repo     *repository.MongoRepository[models.TrafficClassRule]
+	onChange func()
+}
+
+func NewTrafficClassRuleService(repo *repository.MongoRepository[models.TrafficClassRule]) TrafficClassRuleService {
+	return &trafficClassRuleServiceImpl{repo: repo}
+}
+
+// SetChangeListener é chamado após qualquer alteração, para o classificador
+// de flows recarregar as regras sem esperar o próximo ciclo.
+func (s *trafficClassRuleServiceImpl) SetChangeListener(listener func()) {
+	s.onChange = listener
+}
+
+// EnsureDefaultRules grava as regras padrão quando a coleção está vazia.
+func (s *trafficClassRuleServiceImpl) EnsureDefaultRules() {
+	existent, err := s.repo.GetAll()
+	if err != nil {
+		log.Printf("Erro ao verificar regras de classificação: %v", err)
+		return
+	}
+	if len(existent) > 0 {
+		return
+	}
+	for _, rule := range models.DefaultTrafficClassRules() {
+		if err := s.repo.Create(&rule); err != nil {
+			log.Printf("Erro ao criar regra de classificação %s: %v", rule.Name, err)
+		}
+	}
+	s.notifyChange()
+}
+
+func (s *trafficClassRuleServiceImpl) GetAll() ([]models.TrafficClassRule, error) {
+	return s.repo.GetAll()
+}
+
+func (s *trafficClassRuleServiceImpl) GetById(id string) (*models.TrafficClassRule, error) {
+	return s.repo.GetById(id)
+}
+
+func (s *trafficClassRuleServiceImpl) Create(rule *models.TrafficClassRule) (error, *utils.APIError) {
+	if apiErr := s.validate(rule, primitive.NilObjectID); apiErr != nil {
+		return nil, apiErr
+	}
+	if err := s.repo.Create(rule); err != nil {
+		return err, nil
+	}
+	s.notifyChange()
+	return nil, nil
+}
+
+func (s *trafficClassRuleServiceImpl) Update(id string, rule *models.TrafficClassRule) (error, *utils.APIError) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err, nil
+	}
+	existent, err := s.repo.GetById(id)
+	if err != nil {
+		return err, nil
+	}
+	if existent == nil {
+		return nil, &utils.APIError{
+			Code:    "TRAFFIC_CLASS_RULE_NOT_FOUND",
+			Message: "Traffic class rule not found",
+		}
+	}
+	if apiErr := s.validate(rule, objectID); apiErr != nil {
+		return nil, apiErr
+	}
+	rule.ID = objectID
+	rule.Created_At = existent.Created_At
+	if err := s.repo.Update(id, rule); err != nil {
+		return err, nil
+	}
+	s.notifyChange()
+	return nil, nil
+}
+
+func (s *trafficClassRuleServiceImpl) Delete(id string) error {
+	if err := s.repo.Delete(id); err != nil {
+		return err
+	}
+	s.notifyChange()
+	return nil
+}
+
+// validate normaliza a classe e os prefixos e exige ao menos um critério,
+// já que uma regra vazia casaria com todo o tráfego.
+func (s *trafficClassRuleServiceImpl) validate(rule *models.TrafficClassRule, ignoreID primitive.ObjectID) *utils.APIError {
+	rule.Class = strings.ToLower(strings.TrimSpace(rule.Class))
+	if !trafficClassNamePattern.MatchString(rule.Class) {
+		return &utils.APIError{
+			Code:    "INVALID_TRAFFIC_CLASS",
+			Message: "Class must have up to 32 lowercase letters, digits, '-' or '_'",
+		}
+	}
+
+	if len(rule.Protocols) == 0 && len(rule.Ports) == 0 && len(rule.Prefixes) == 0 && len(rule.ASNs) == 0 && len(rule.DSCP) == 0 {
+		return &utils.APIError{
+			Code:    "TRAFFIC_CLASS_RULE_WITHOUT_CRITERIA",
+			Message: "Rule must have at least one protocol, port, prefix, ASN or DSCP",
+		}
+	}
+
+	for _, portRange := range rule.Ports {
+		if portRange.Start == 0 || portRange.Start > portRange.End {
+			return &utils.APIError{
+				Code:    "INVALID_PORT_RANGE",
+				Message: fmt.Sprintf("Invalid port range: %d-%d", portRange.Start, portRange.End),
+			}
+		}
+	}
+
+	for _, dscp := range rule.DSCP {
+		if dscp > 63 {
+			return &utils.APIError{
+				Code:    "INVALID_DSCP",
+				Message: fmt.Sprintf("Invalid DSCP: %d", dscp),
+			}
+		}
+	}
+
+	for i, value := range rule.Prefixes {
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			addr, errAddr := netip.ParseAddr(value)
+			if errAddr != nil {
+				return &utils.APIError{
+					Code:    "INVALID_PREFIX",
+					Message: fmt.Sprintf("Invalid prefix: %s", value),
+				}
+			}
+			addr = addr.Unmap()
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		rule.Prefixes[i] = prefix.Masked().String()
+	}
+
+	filter := bson.M{"name": rule.Name}
+	if !ignoreID.IsZero() {
+		filter["_id"] = bson.M{"$ne": ignoreID}
+	}
+	existent, err := s.repo.GetByFilter(filter)
+	if err == nil && len(existent) > 0 {
+		return &utils.APIError{
+			Code:    "DUPLICATED_TRAFFIC_CLASS_RULE",
+			Message: "A traffic class rule with that name already exists",
+		}
+	}
+	return nil
+}
+
+func (s *trafficClassRuleServiceImpl) notifyChange() {
+	if s.onChange != nil {
+		go s.onChange()
+	}
+}
@@ -1,9 +1,11 @@
 package services
 
 import (
+	"fmt"
 	models "net_monitor/models"
 	repository "net_monitor/repository"
 	utils "net_monitor/utils"
+	"time"
 )
 
 type UserService interface {
@@ -27,6 +29,9 @@ func (s *userServiceImpl) GetAll() ([]models.User, error) {
 }
 
 func (s *userServiceImpl) Create(user *models.User) error {
+	if err := validateTimezone(user.Timezone); err != nil {
+		return err
+	}
 	hashedPassword, err := utils.HashPassword(user.Password)
 	if err != nil {
 		return err
@@ -44,6 +49,9 @@ func (s *userServiceImpl) Delete(id string) error {
 }
 
 func (s *userServiceImpl) Update(id string, user *models.User) error {
+	if err := validateTimezone(user.Timezone); err != nil {
+		return err
+	}
 	hashedPassword, err := utils.HashPassword(user.Password)
 	if err != nil {
 		return err
@@ -51,3 +59,14 @@ func (s *userServiceImpl) Update(id string, user *models.User) error {
 	user.Password = hashedPassword
 	return s.repo.Update(id, user)
 }
+
+// O fuso do usuário é um nome IANA, usado nas agregações de métricas.
+func validateTimezone(timezone string) error {
+	if timezone == "" {
+		return nil
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return fmt.Errorf("fuso horário inválido: %s", timezone)
+	}
+	return nil
+}
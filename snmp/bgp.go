@@ -0,0 +1,133 @@
+package snmp
+
+import (
+	"net_monitor/interfaces"
+	"sort"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+const (
+	OID_BGP_PEER_STATE            = "1.3.6.1.2.1.15.3.1.2"
+	OID_BGP_PEER_ADMIN_STATUS     = "1.3.6.1.2.1.15.3.1.3"
+	OID_BGP_PEER_REMOTE_ADDR      = "1.3.6.1.2.1.15.3.1.7"
+	OID_BGP_PEER_REMOTE_AS        = "1.3.6.1.2.1.15.3.1.9"
+	OID_BGP_PEER_IN_UPDATES       = "1.3.6.1.2.1.15.3.1.10"
+	OID_BGP_PEER_OUT_UPDATES      = "1.3.6.1.2.1.15.3.1.11"
+	OID_BGP_PEER_LAST_ERROR       = "1.3.6.1.2.1.15.3.1.14"
+	OID_BGP_PEER_ESTABLISHED_TIME = "1.3.6.1.2.1.15.3.1.16"
+)
+
+const BgpStateEstablished = 6
+
+var bgpStateNames = map[int]string{
+	1: "idle",
+	2: "connect",
+	3: "active",
+	4: "openSent",
+	5: "openConfirm",
+	6: "established",
+}
+
+type BgpPeer struct {
+	PeerIP           string  `json:"peerIp"`
+	RemoteAS         int     `json:"remoteAs"`
+	State            int     `json:"state"`
+	StateName        string  `json:"stateName"`
+	AdminStatus      int     `json:"adminStatus"` // 1=stop, 2=start
+	EstablishedTime  int     `json:"establishedTime"`
+	InUpdates        int     `json:"inUpdates"`
+	OutUpdates       int     `json:"outUpdates"`
+	PrefixesReceived *uint64 `json:"prefixesReceived,omitempty"`
+	PrefixesAccepted *uint64 `json:"prefixesAccepted,omitempty"`
+}
+
+func BgpStateName(state int) string {
+	if name, ok := bgpStateNames[state]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// CollectBgpPeers walks the standard BGP4-MIB bgpPeerTable. Prefix counters
+// are not part of that table and are left for vendor collectors to fill in.
+func CollectBgpPeers(goSnmp *gosnmp.GoSNMP, device interfaces.NetworkDevice) ([]BgpPeer, error) {
+	stateMap, err := GetTreeAsIndexMap(goSnmp, OID_BGP_PEER_STATE, true)
+	if err != nil {
+		return nil, err
+	}
+
+	adminStatusMap, err := GetTreeAsIndexMap(goSnmp, OID_BGP_PEER_ADMIN_STATUS, true)
+	if err != nil {
+		return nil, err
+	}
+
+	remoteAsMap, err := GetTreeAsIndexMap(goSnmp, OID_BGP_PEER_REMOTE_AS, true)
+	if err != nil {
+		return nil, err
+	}
+
+	inUpdatesMap, err := GetTreeAsIndexMap(goSnmp, OID_BGP_PEER_IN_UPDATES, true)
+	if err != nil {
+		return nil, err
+	}
+
+	outUpdatesMap, err := GetTreeAsIndexMap(goSnmp, OID_BGP_PEER_OUT_UPDATES, true)
+	if err != nil {
+		return nil, err
+	}
+
+	establishedTimeMap, err := GetTreeAsIndexMap(goSnmp, OID_BGP_PEER_ESTABLISHED_TIME, true)
+	if err != nil {
+		return nil, err
+	}
+
+	peers := make([]BgpPeer, 0, len(stateMap))
+
+	for peerIP, stateResult := range stateMap {
+		peer := BgpPeer{PeerIP: peerIP}
+
+		if state, err := stateResult.IntValue(); err == nil {
+			peer.State = state
+		}
+		peer.StateName = BgpStateName(peer.State)
+
+		if adminStatusResult, hasAdminStatus := adminStatusMap[peerIP]; hasAdminStatus {
+			if adminStatus, err := adminStatusResult.IntValue(); err == nil {
+				peer.AdminStatus = adminStatus
+			}
+		}
+
+		if remoteAsResult, hasRemoteAs := remoteAsMap[peerIP]; hasRemoteAs {
+			if remoteAs, err := remoteAsResult.IntValue(); err == nil {
+				peer.RemoteAS = remoteAs
+			}
+		}
+
+		if inUpdatesResult, hasInUpdates := inUpdatesMap[peerIP]; hasInUpdates {
+			if inUpdates, err := inUpdatesResult.IntValue(); err == nil {
+				peer.InUpdates = inUpdates
+			}
+		}
+
+		if outUpdatesResult, hasOutUpdates := outUpdatesMap[peerIP]; hasOutUpdates {
+			if outUpdates, err := outUpdatesResult.IntValue(); err == nil {
+				peer.OutUpdates = outUpdates
+			}
+		}
+
+		if establishedTimeResult, hasEstablishedTime := establishedTimeMap[peerIP]; hasEstablishedTime {
+			if establishedTime, err := establishedTimeResult.IntValue(); err == nil {
+				peer.EstablishedTime = establishedTime
+			}
+		}
+
+		peers = append(peers, peer)
+	}
+
+	sort.Slice(peers, func(i, j int) bool {
+		return peers[i].PeerIP < peers[j].PeerIP
+	})
+
+	return peers, nil
+}
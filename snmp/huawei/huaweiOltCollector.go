@@ -0,0 +1,129 @@
+package huawei
+
+import (
+	"fmt"
+	"net_monitor/interfaces"
+	huaweisnmpcollectors "net_monitor/snmp/huawei/huaweiSnmpCollectors"
+	Utils "net_monitor/utils"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+type HuaweiCollector struct{}
+
+func NewHuaweiCollector() *HuaweiCollector {
+	return &HuaweiCollector{}
+}
+
+func (h *HuaweiCollector) GetVendor() string {
+	return "huawei"
+}
+
+func (h *HuaweiCollector) GetDeviceType() string {
+	return "olt"
+}
+
+func (h *HuaweiCollector) Collect(device interfaces.NetworkDevice) (map[string]interface{}, error) {
+	snmpParams, err := h.createSNMPParams(device)
+	if err != nil {
+		return nil, err
+	}
+	defer snmpParams.Conn.Close()
+
+	data := make(map[string]interface{})
+
+	if uptime, err := huaweisnmpcollectors.CollectHuaweiUptime(snmpParams, device); err == nil {
+		data["system_uptime"] = uptime
+	}
+
+	// CPU, memória e temperatura vêm da placa de controle: a tabela de placas
+	// é percorrida uma vez só.
+	if boards, err := huaweisnmpcollectors.CollectHuaweiBoards(snmpParams, device); err == nil {
+		data["boards"] = boards
+		if board, err := huaweisnmpcollectors.HuaweiControlBoard(boards, device); err == nil {
+			data["cpu_usage_percent"] = board.CpuUsage
+			data["memory_usage_percent"] = board.MemoryUsage
+			data["temperature"] = board.Temperature
+		}
+	}
+
+	if ponInterfaces, err := huaweisnmpcollectors.CollectHuaweiPonInterfaces(snmpParams, device); err == nil {
+		data["ponInterfaces"] = ponInterfaces
+	}
+
+	if onuInfo, err := huaweisnmpcollectors.CollectHuaweiOnuInfo(snmpParams, device); err == nil {
+		data["onuInfo"] = onuInfo
+	}
+
+	return data, nil
+}
+
+func (h *HuaweiCollector) CollectMetric(device interfaces.NetworkDevice, metricName string) (interface{}, error) {
+	snmpParams, err := h.createSNMPParams(device)
+	if err != nil {
+		return nil, err
+	}
+	defer snmpParams.Conn.Close()
+
+	switch metricName {
+	case "uptime":
+		return huaweisnmpcollectors.CollectHuaweiUptime(snmpParams, device)
+	case "boards":
+		return huaweisnmpcollectors.CollectHuaweiBoards(snmpParams, device)
+	case "cpu_usage":
+		return huaweisnmpcollectors.CollectHuaweiCpuUtilizationPercent(snmpParams, device)
+	case "memory_usage_percent":
+		return huaweisnmpcollectors.CollectHuaweiMemoryUsagePercent(snmpParams, device)
+	case "temperature":
+		return huaweisnmpcollectors.CollectHuaweiTemperature(snmpParams, device)
+	case "ponInterfaces":
+		return huaweisnmpcollectors.CollectHuaweiPonInterfaces(snmpParams, device)
+	case "onuInfo":
+		return huaweisnmpcollectors.CollectHuaweiOnuInfo(snmpParams, device)
+	default:
+		return nil, fmt.Errorf("Metric '%s' not supported by Huawei collector", metricName)
+	}
+}
+
+func (h *HuaweiCollector) GetSupportedMetrics() []string {
+	return []string{
+		"uptime", "boards", "cpu_usage", "memory_usage_percent",
+		"temperature", "ponInterfaces", "onuInfo",
+	}
+}
+
+func (h *HuaweiCollector) GetMetricMapping() map[string]string {
+	return map[string]string{
+		"uptime":               "system_uptime",
+		"boards":               "boards",
+		"cpu_usage":            "cpu_usage_percent",
+		"memory_usage_percent": "memory_usage_percent",
+		"temperature":          "temperature",
+		"ponInterfaces":        "ponInterfaces",
+		"onuInfo":              "onuInfo",
+	}
+}
+
+func (h *HuaweiCollector) createSNMPParams(device interfaces.NetworkDevice) (*gosnmp.GoSNMP, error) {
+	snmpPort, err := Utils.ParseInt(device.GetSnmpPort())
+	if err != nil {
+		return nil, err
+	}
+
+	params := &gosnmp.GoSNMP{
+		Target:    device.GetIPAddress(),
+		Port:      uint16(snmpPort),
+		Community: device.GetSnmpCommunity(),
+		Version:   gosnmp.Version2c,
+		Timeout:   5 * time.Second,
+		Retries:   1,
+	}
+
+	err = params.Connect()
+	if err != nil {
+		return nil, err
+	}
+
+	return params, nil
+}
@@ -0,0 +1,130 @@
+package huaweisnmpcollectors
+
+import (
+	"fmt"
+	"net_monitor/interfaces"
+	"net_monitor/snmp"
+	"strings"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+const huaweiInvalidValue = 2147483647
+
+type HuaweiBoard struct {
+	Index       string  `json:"index"`
+	Name        string  `json:"name"`
+	OperStatus  int     `json:"operStatus"`
+	CpuUsage    int     `json:"cpuUsage"`
+	MemoryUsage int     `json:"memoryUsage"`
+	Temperature float64 `json:"temperature"`
+}
+
+func CollectHuaweiBoards(goSnmp *gosnmp.GoSNMP, device interfaces.NetworkDevice) ([]HuaweiBoard, error) {
+	baseBoardCpuOid := "1.3.6.1.4.1.2011.2.6.7.1.1.2.1.5"          // hwMusaBoardCpuRate
+	baseBoardMemoryOid := "1.3.6.1.4.1.2011.2.6.7.1.1.2.1.6"       // hwMusaBoardRamUseRate
+	baseBoardNameOid := "1.3.6.1.4.1.2011.2.6.7.1.1.2.1.7"         // hwMusaBoardSlotDesc
+	baseBoardOperStatusOid := "1.3.6.1.4.1.2011.2.6.7.1.1.2.1.8"   // hwMusaBoardOperStatus
+	baseBoardTemperatureOid := "1.3.6.1.4.1.2011.2.6.7.1.1.2.1.10" // hwMusaBoardTemperature
+
+	namesMap, err := snmp.GetTreeAsIndexMap(goSnmp, baseBoardNameOid, true)
+	if err != nil {
+		return nil, err
+	}
+
+	cpuMap, err := snmp.GetTreeAsIndexMap(goSnmp, baseBoardCpuOid, true)
+	if err != nil {
+		return nil, err
+	}
+
+	memoryMap, err := snmp.GetTreeAsIndexMap(goSnmp, baseBoardMemoryOid, true)
+	if err != nil {
+		return nil, err
+	}
+
+	operStatusMap, err := snmp.GetTreeAsIndexMap(goSnmp, baseBoardOperStatusOid, true)
+	if err != nil {
+		return nil, err
+	}
+
+	temperatureMap, err := snmp.GetTreeAsIndexMap(goSnmp, baseBoardTemperatureOid, true)
+	if err != nil {
+		return nil, err
+	}
+
+	boards := make([]HuaweiBoard, 0)
+
+	for index, nameResult := range namesMap {
+		name := strings.TrimSpace(nameResult.StringValue())
+		if name == "" {
+			continue
+		}
+
+		board := HuaweiBoard{
+			Index: index,
+			Name:  name,
+		}
+
+		if operStatusResult, hasOperStatus := operStatusMap[index]; hasOperStatus {
+			if operStatus, err := operStatusResult.IntValue(); err == nil {
+				board.OperStatus = operStatus
+			}
+		}
+
+		if cpuResult, hasCpu := cpuMap[index]; hasCpu {
+			if cpu, err := cpuResult.IntValue(); err == nil && cpu != huaweiInvalidValue {
+				board.CpuUsage = cpu
+			}
+		}
+
+		if memoryResult, hasMemory := memoryMap[index]; hasMemory {
+			if memory, err := memoryResult.IntValue(); err == nil && memory != huaweiInvalidValue {
+				board.MemoryUsage = memory
+			}
+		}
+
+		if temperatureResult, hasTemperature := temperatureMap[index]; hasTemperature {
+			if temperature, err := temperatureResult.IntValue(); err == nil && temperature != huaweiInvalidValue {
+				board.Temperature = float64(temperature)
+			}
+		}
+
+		boards = append(boards, board)
+	}
+
+	return boards, nil
+}
+
+func getHuaweiControlBoard(goSnmp *gosnmp.GoSNMP, device interfaces.NetworkDevice) (*HuaweiBoard, error) {
+	boards, err := CollectHuaweiBoards(goSnmp, device)
+	if err != nil {
+		return nil, err
+	}
+	return HuaweiControlBoard(boards, device)
+}
+
+// HuaweiControlBoard escolhe a placa de controle (SCU/MPL/MCU) entre as placas
+// já coletadas, ou a primeira quando nenhuma é reconhecida.
+func HuaweiControlBoard(boards []HuaweiBoard, device interfaces.NetworkDevice) (*HuaweiBoard, error) {
+	if len(boards) == 0 {
+		return nil, fmt.Errorf("No boards found for %v:%v", device.GetName(), device.GetIPAddress())
+	}
+
+	for i := range boards {
+		if isHuaweiControlBoard(boards[i].Name) {
+			return &boards[i], nil
+		}
+	}
+
+	return &boards[0], nil
+}
+
+func isHuaweiControlBoard(name string) bool {
+	upperName := strings.ToUpper(name)
+	for _, prefix := range []string{"SCU", "MPL", "MCU"} {
+		if strings.Contains(upperName, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,16 @@
+package huaweisnmpcollectors
+
+import (
+	"net_monitor/interfaces"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+func CollectHuaweiCpuUtilizationPercent(goSnmp *gosnmp.GoSNMP, device interfaces.NetworkDevice) (int, error) {
+	board, err := getHuaweiControlBoard(goSnmp, device)
+	if err != nil {
+		return 0, err
+	}
+
+	return board.CpuUsage, nil
+}
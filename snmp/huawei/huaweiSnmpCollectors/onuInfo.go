@@ -0,0 +1,166 @@
+package huaweisnmpcollectors
+
+import (
+	"fmt"
+	"net_monitor/interfaces"
+	"net_monitor/snmp"
+	"strings"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+var huaweiOnuDownCauses = map[int]string{
+	1:  "LOS",
+	2:  "LOSi",
+	3:  "LOFi",
+	4:  "SFi",
+	5:  "LOAi",
+	6:  "LOAMi",
+	7:  "deactive ONT fails",
+	8:  "deactive ONT success",
+	9:  "reset ONT",
+	10: "re-register ONT",
+	11: "pop up fail",
+	13: "dying-gasp",
+	15: "LOKI",
+	18: "deactived ONT due to the ring",
+	30: "shut down ONT optical module",
+	31: "reset ONT by ONT command",
+	32: "reset ONT by ONT reset button",
+	33: "reset ONT by ONT software",
+	34: "deactived ONT due to broadcast attack",
+	35: "operator check fail",
+	37: "a rogue ONT detected by itself",
+}
+
+func CollectHuaweiOnuInfo(goSnmp *gosnmp.GoSNMP, device interfaces.NetworkDevice) ([]snmp.OnuInfo, error) {
+	baseSerialNumberOid := "1.3.6.1.4.1.2011.6.128.1.1.2.43.1.3"   // hwGponDeviceOntSn
+	baseOnuRunStatusOid := "1.3.6.1.4.1.2011.6.128.1.1.2.46.1.15"  // hwGponDeviceOntControlRunStatus
+	baseLastDownCauseOid := "1.3.6.1.4.1.2011.6.128.1.1.2.46.1.24" // hwGponDeviceOntControlLastDownCause
+	baseTemperatureOid := "1.3.6.1.4.1.2011.6.128.1.1.2.51.1.1"    // hwGponOntOpticalDdmTemperature
+	baseBiasCurrentOid := "1.3.6.1.4.1.2011.6.128.1.1.2.51.1.2"    // hwGponOntOpticalDdmBiasCurrent
+	baseTxPowerOid := "1.3.6.1.4.1.2011.6.128.1.1.2.51.1.3"        // hwGponOntOpticalDdmTxPower
+	baseRXPowerOid := "1.3.6.1.4.1.2011.6.128.1.1.2.51.1.4"        // hwGponOntOpticalDdmRxPower
+	baseVoltageOid := "1.3.6.1.4.1.2011.6.128.1.1.2.51.1.5"        // hwGponOntOpticalDdmVoltage
+
+	onus := make([]snmp.OnuInfo, 0)
+
+	serialNumberMap, err := snmp.GetTreeAsIndexMap(goSnmp, baseSerialNumberOid, true)
+	if err != nil {
+		return nil, err
+	}
+
+	runStatusMap, err := snmp.GetTreeAsIndexMap(goSnmp, baseOnuRunStatusOid, true)
+	if err != nil {
+		return nil, err
+	}
+
+	lastDownCauseMap, err := snmp.GetTreeAsIndexMap(goSnmp, baseLastDownCauseOid, true)
+	if err != nil {
+		return nil, err
+	}
+
+	temperatureMap, err := snmp.GetTreeAsIndexMap(goSnmp, baseTemperatureOid, true)
+	if err != nil {
+		return nil, err
+	}
+
+	biasCurrentMap, err := snmp.GetTreeAsIndexMap(goSnmp, baseBiasCurrentOid, true)
+	if err != nil {
+		return nil, err
+	}
+
+	txPowerMap, err := snmp.GetTreeAsIndexMap(goSnmp, baseTxPowerOid, true)
+	if err != nil {
+		return nil, err
+	}
+
+	rxPowerMap, err := snmp.GetTreeAsIndexMap(goSnmp, baseRXPowerOid, true)
+	if err != nil {
+		return nil, err
+	}
+
+	voltageMap, err := snmp.GetTreeAsIndexMap(goSnmp, baseVoltageOid, true)
+	if err != nil {
+		return nil, err
+	}
+
+	for index, snResult := range serialNumberMap {
+		onu := snmp.OnuInfo{
+			Index:        index,
+			SerialNumber: FormatHuaweiOnuSerialNumber(snResult.Value),
+		}
+
+		if runStatusResult, hasRunStatus := runStatusMap[index]; hasRunStatus {
+			if runStatus, err := runStatusResult.IntValue(); err == nil {
+				onu.OnlineStatus = runStatus
+			}
+		}
+
+		if lastDownCauseResult, hasLastDownCause := lastDownCauseMap[index]; hasLastDownCause {
+			if lastDownCause, err := lastDownCauseResult.IntValue(); err == nil {
+				onu.LastDownCause = HuaweiOnuDownCause(lastDownCause)
+			}
+		}
+
+		if temperatureResult, hasTemperature := temperatureMap[index]; hasTemperature {
+			if temperature, err := temperatureResult.IntValue(); err == nil && temperature != huaweiInvalidValue {
+				onu.Temperature = float64(temperature)
+			}
+		}
+
+		if biasCurrentResult, hasBiasCurrent := biasCurrentMap[index]; hasBiasCurrent {
+			if biasCurrent, err := biasCurrentResult.IntValue(); err == nil && biasCurrent != huaweiInvalidValue {
+				onu.BiasCurrent = float64(biasCurrent)
+			}
+		}
+
+		if txPowerResult, hasTxPower := txPowerMap[index]; hasTxPower {
+			if txPower, err := txPowerResult.IntValue(); err == nil && txPower != huaweiInvalidValue {
+				onu.TXPower = float64(txPower) / 100.0
+			}
+		}
+
+		if rxPowerResult, hasRxPower := rxPowerMap[index]; hasRxPower {
+			if rxPower, err := rxPowerResult.IntValue(); err == nil && rxPower != huaweiInvalidValue {
+				onu.RXPower = float64(rxPower) / 100.0
+			}
+		}
+
+		if voltageResult, hasVoltage := voltageMap[index]; hasVoltage {
+			if voltage, err := voltageResult.IntValue(); err == nil && voltage != huaweiInvalidValue {
+				onu.Voltage = float64(voltage) / 1000
+			}
+		}
+
+		onus = append(onus, onu)
+	}
+
+	return onus, nil
+}
+
+// FormatHuaweiOnuSerialNumber renders the 8-byte hwGponDeviceOntSn as the
+// vendor id followed by the hex serial, e.g. "HWTC1A2B3C4D".
+func FormatHuaweiOnuSerialNumber(value interface{}) string {
+	switch v := value.(type) {
+	case []byte:
+		if len(v) == 8 {
+			return fmt.Sprintf("%s%X", string(v[:4]), v[4:])
+		}
+		return strings.ToUpper(fmt.Sprintf("%X", v))
+	case string:
+		return strings.ToUpper(v)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+func HuaweiOnuDownCause(cause int) string {
+	if description, ok := huaweiOnuDownCauses[cause]; ok {
+		return description
+	}
+	if cause <= 0 || cause == huaweiInvalidValue {
+		return ""
+	}
+	return fmt.Sprintf("unknown (%d)", cause)
+}
@@ -0,0 +1,92 @@
+package huaweisnmpcollectors
+
+import (
+	"net_monitor/interfaces"
+	"net_monitor/snmp"
+	Utils "net_monitor/utils"
+	"strings"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+func CollectHuaweiPonInterfaces(goSnmp *gosnmp.GoSNMP, device interfaces.NetworkDevice) ([]snmp.PonInterface, error) {
+	baseOidName := "1.3.6.1.2.1.31.1.1.1.1"                       // ifName
+	baseOidAdminStatus := "1.3.6.1.2.1.2.2.1.7"                   // ifAdminStatus
+	baseOidOpticalBias := "1.3.6.1.4.1.2011.6.128.1.1.2.23.1.2"   // hwGponOltOpticsDdmInfoBiasCurrent
+	baseOidOpticalVcc := "1.3.6.1.4.1.2011.6.128.1.1.2.23.1.5"    // hwGponOltOpticsDdmInfoSupplyVoltage
+	baseOidOntRunStatus := "1.3.6.1.4.1.2011.6.128.1.1.2.46.1.15" // hwGponDeviceOntControlRunStatus
+
+	namesMap, err := snmp.GetTreeAsIndexMap(goSnmp, baseOidName, true)
+	if err != nil {
+		return nil, err
+	}
+
+	adminStatusMap, err := snmp.GetTreeAsIndexMap(goSnmp, baseOidAdminStatus, true)
+	if err != nil {
+		return nil, err
+	}
+
+	opticalBiasMap, err := snmp.GetTreeAsIndexMap(goSnmp, baseOidOpticalBias, true)
+	if err != nil {
+		opticalBiasMap = make(map[string]snmp.WalkResult)
+	}
+
+	opticalVccMap, err := snmp.GetTreeAsIndexMap(goSnmp, baseOidOpticalVcc, true)
+	if err != nil {
+		opticalVccMap = make(map[string]snmp.WalkResult)
+	}
+
+	ontRunStatusMap, err := snmp.GetTreeAsIndexMap(goSnmp, baseOidOntRunStatus, true)
+	if err != nil {
+		return nil, err
+	}
+
+	onlineOnusByPort := make(map[string]int)
+	for index, runStatusResult := range ontRunStatusMap {
+		runStatus, err := runStatusResult.IntValue()
+		if err != nil || runStatus != 1 {
+			continue
+		}
+		ponIndex, _, found := strings.Cut(index, ".")
+		if !found {
+			continue
+		}
+		onlineOnusByPort[ponIndex]++
+	}
+
+	ponInterfaces := make([]snmp.PonInterface, 0)
+
+	for index, nameResult := range namesMap {
+		name := nameResult.StringValue()
+		if !strings.Contains(strings.ToUpper(name), "PON") {
+			continue
+		}
+
+		ponInterface := snmp.PonInterface{
+			Name:           name,
+			OnlineOnuCount: onlineOnusByPort[index],
+		}
+
+		if adminStatusResult, hasAdminStatus := adminStatusMap[index]; hasAdminStatus {
+			if adminStatus, err := adminStatusResult.IntValue(); err == nil {
+				ponInterface.ConfigStatus = adminStatus
+			}
+		}
+
+		if opticalBiasResult, hasOpticalBias := opticalBiasMap[index]; hasOpticalBias {
+			if opticalBias, err := opticalBiasResult.IntValue(); err == nil && opticalBias != huaweiInvalidValue {
+				ponInterface.OpticalBias = Utils.ChangeFloatPrecision(float64(opticalBias)/1000, 3)
+			}
+		}
+
+		if opticalVccResult, hasOpticalVcc := opticalVccMap[index]; hasOpticalVcc {
+			if opticalVcc, err := opticalVccResult.IntValue(); err == nil && opticalVcc != huaweiInvalidValue {
+				ponInterface.OpticalVcc = Utils.ChangeFloatPrecision(float64(opticalVcc)/100, 2)
+			}
+		}
+
+		ponInterfaces = append(ponInterfaces, ponInterface)
+	}
+
+	return ponInterfaces, nil
+}
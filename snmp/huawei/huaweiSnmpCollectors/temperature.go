@@ -0,0 +1,16 @@
+package huaweisnmpcollectors
+
+import (
+	"net_monitor/interfaces"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+func CollectHuaweiTemperature(goSnmp *gosnmp.GoSNMP, device interfaces.NetworkDevice) (float64, error) {
+	board, err := getHuaweiControlBoard(goSnmp, device)
+	if err != nil {
+		return 0.0, err
+	}
+
+	return board.Temperature, nil
+}
@@ -0,0 +1,68 @@
+package snmp
+
+import (
+	"strconv"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+const (
+	oidIfDescr     = "1.3.6.1.2.1.2.2.1.2"
+	oidIfName      = "1.3.6.1.2.1.31.1.1.1.1"
+	oidIfAlias     = "1.3.6.1.2.1.31.1.1.1.18"
+	oidIfHighSpeed = "1.3.6.1.2.1.31.1.1.1.15"
+)
+
+// InterfaceInfo é a linha da IF-MIB usada para dar nome aos ifIndex dos flows.
+type InterfaceInfo struct {
+	IfIndex     uint32 `json:"ifIndex" bson:"ifIndex"`
+	Name        string `json:"name" bson:"name"`
+	Description string `json:"description" bson:"description"`
+	Alias       string `json:"alias,omitempty" bson:"alias,omitempty"`
+	SpeedMbps   uint64 `json:"speedMbps,omitempty" bson:"speedMbps,omitempty"`
+}
+
+// GetInterfaceTable percorre ifDescr e, quando o equipamento suporta a
+// ifXTable, ifName, ifAlias e ifHighSpeed. Sem ifName, usa ifDescr como nome.
+func GetInterfaceTable(goSnmp *gosnmp.GoSNMP) (map[uint32]InterfaceInfo, error) {
+	descrs, err := GetTreeAsIndexMap(goSnmp, oidIfDescr, true)
+	if err != nil {
+		return nil, err
+	}
+
+	table := make(map[uint32]InterfaceInfo, len(descrs))
+	for index, result := range descrs {
+		ifIndex, err := strconv.ParseUint(index, 10, 32)
+		if err != nil {
+			continue
+		}
+		descr := result.StringValue()
+		table[uint32(ifIndex)] = InterfaceInfo{
+			IfIndex:     uint32(ifIndex),
+			Name:        descr,
+			Description: descr,
+		}
+	}
+
+	names, _ := GetTreeAsIndexMap(goSnmp, oidIfName, true)
+	aliases, _ := GetTreeAsIndexMap(goSnmp, oidIfAlias, true)
+	speeds, _ := GetTreeAsIndexMap(goSnmp, oidIfHighSpeed, true)
+
+	for ifIndex, info := range table {
+		index := strconv.FormatUint(uint64(ifIndex), 10)
+		if name, ok := names[index]; ok && name.StringValue() != "" {
+			info.Name = name.StringValue()
+		}
+		if alias, ok := aliases[index]; ok {
+			info.Alias = alias.StringValue()
+		}
+		if speed, ok := speeds[index]; ok {
+			if value, err := speed.IntValue(); err == nil && value > 0 {
+				info.SpeedMbps = uint64(value)
+			}
+		}
+		table[ifIndex] = info
+	}
+
+	return table, nil
+}
@@ -0,0 +1,128 @@
+package juniper
+
+import (
+	"fmt"
+	"net_monitor/interfaces"
+	junipersnmpcollectors "net_monitor/snmp/juniper/juniperSnmpCollectors"
+	Utils "net_monitor/utils"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+type JuniperCollector struct{}
+
+func NewJuniperCollector() *JuniperCollector {
+	return &JuniperCollector{}
+}
+
+func (j *JuniperCollector) GetVendor() string {
+	return "juniper"
+}
+
+func (j *JuniperCollector) Collect(device interfaces.NetworkDevice) (map[string]interface{}, error) {
+	snmpParams, err := j.createSNMPParams(device)
+	if err != nil {
+		return nil, err
+	}
+	defer snmpParams.Conn.Close()
+
+	data := make(map[string]interface{})
+
+	if uptime, err := junipersnmpcollectors.CollectJuniperUptime(snmpParams, device); err == nil {
+		data["system_uptime"] = uptime
+	}
+
+	// A tabela de REs é percorrida uma vez; CPU, memória e temperatura vêm do master.
+	if routingEngines, err := junipersnmpcollectors.CollectJuniperRoutingEngines(snmpParams, device); err == nil {
+		data["routing_engines"] = routingEngines
+		if master, err := junipersnmpcollectors.JuniperMasterRoutingEngine(routingEngines, device); err == nil {
+			data["routing_engine_cpu"] = master.CpuUsage
+			data["routing_engine_memory"] = Utils.ChangeFloatPrecision(master.UsedMemoryMB, 1)
+			data["total_memory_mb"] = master.MemoryMB
+			data["temperature"] = master.Temperature
+		}
+	}
+
+	if interfaceStats, err := junipersnmpcollectors.CollectJuniperInterfaceStats(snmpParams, device); err == nil {
+		data["interface_stats"] = interfaceStats
+	}
+
+	if bgpPeers, err := junipersnmpcollectors.CollectJuniperBgpPeers(snmpParams, device); err == nil {
+		data["bgpPeers"] = bgpPeers
+	}
+
+	return data, nil
+}
+
+func (j *JuniperCollector) CollectMetric(device interfaces.NetworkDevice, metricName string) (interface{}, error) {
+	snmpParams, err := j.createSNMPParams(device)
+	if err != nil {
+		return nil, err
+	}
+	defer snmpParams.Conn.Close()
+
+	switch metricName {
+	case "uptime":
+		return junipersnmpcollectors.CollectJuniperUptime(snmpParams, device)
+	case "routing_engines":
+		return junipersnmpcollectors.CollectJuniperRoutingEngines(snmpParams, device)
+	case "cpu_usage":
+		return junipersnmpcollectors.CollectJuniperRoutingEngineCpu(snmpParams, device)
+	case "memory_usage":
+		return junipersnmpcollectors.CollectJuniperRoutingEngineMemory(snmpParams, device)
+	case "total_memory":
+		return junipersnmpcollectors.CollectJuniperRoutingEngineTotalMemory(snmpParams, device)
+	case "temperature":
+		return junipersnmpcollectors.CollectJuniperTemperature(snmpParams, device)
+	case "interface_stats":
+		return junipersnmpcollectors.CollectJuniperInterfaceStats(snmpParams, device)
+	case "bgpPeers":
+		return junipersnmpcollectors.CollectJuniperBgpPeers(snmpParams, device)
+	default:
+		return nil, fmt.Errorf("Metric '%s' not supported by Juniper collector", metricName)
+	}
+}
+
+func (j *JuniperCollector) GetSupportedMetrics() []string {
+	return []string{
+		"uptime", "routing_engines", "cpu_usage", "memory_usage",
+		"total_memory", "temperature", "interface_stats", "bgpPeers",
+	}
+}
+
+func (j *JuniperCollector) GetMetricMapping() map[string]string {
+	return map[string]string{
+		"uptime":          "system_uptime",
+		"routing_engines": "routing_engines",
+		"cpu_usage":       "routing_engine_cpu",
+		"memory_usage":    "routing_engine_memory",
+		"total_memory":    "total_memory_mb",
+		"temperature":     "temperature",
+		"interface_stats": "interface_stats",
+		"bgpPeers":        "bgpPeers",
+	}
+}
+
+func (j *JuniperCollector) createSNMPParams(device interfaces.NetworkDevice) (*gosnmp.GoSNMP, error) {
+	snmpPort, err := Utils.ParseInt(device.GetSnmpPort())
+	if err != nil {
+		return nil, err
+	}
+
+	params := &gosnmp.GoSNMP{
+		Target:    device.GetIPAddress(),
+		Port:      uint16(snmpPort),
+		Community: device.GetSnmpCommunity(),
+		Version:   gosnmp.Version2c,
+		Timeout:   2 * time.Second,
+		Retries:   1,
+	}
+
+	err = params.Connect()
+	if err != nil {
+		return nil, err
+	}
+
+	return params, nil
+}
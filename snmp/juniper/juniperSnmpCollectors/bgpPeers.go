@@ -0,0 +1,129 @@
+package junipersnmpcollectors
+
+import (
+	"net"
+	"net_monitor/interfaces"
+	"net_monitor/snmp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+func CollectJuniperBgpPeers(goSnmp *gosnmp.GoSNMP, device interfaces.NetworkDevice) ([]snmp.BgpPeer, error) {
+	baseOidPeerState := "1.3.6.1.4.1.2636.5.1.1.2.1.1.1.2"          // jnxBgpM2PeerState
+	baseOidPeerRemoteAddr := "1.3.6.1.4.1.2636.5.1.1.2.1.1.1.11"    // jnxBgpM2PeerRemoteAddr
+	baseOidPeerRemoteAs := "1.3.6.1.4.1.2636.5.1.1.2.1.1.1.13"      // jnxBgpM2PeerRemoteAs
+	baseOidPeerIndex := "1.3.6.1.4.1.2636.5.1.1.2.1.1.1.14"         // jnxBgpM2PeerIndex
+	baseOidInPrefixes := "1.3.6.1.4.1.2636.5.1.1.2.6.2.1.7"         // jnxBgpM2PrefixInPrefixes
+	baseOidInPrefixesAccepted := "1.3.6.1.4.1.2636.5.1.1.2.6.2.1.8" // jnxBgpM2PrefixInPrefixesAccepted
+
+	peers, err := snmp.CollectBgpPeers(goSnmp, device)
+	if err != nil {
+		peers = make([]snmp.BgpPeer, 0)
+	}
+
+	remoteAddrMap, err := snmp.GetTreeAsIndexMap(goSnmp, baseOidPeerRemoteAddr, true)
+	if err != nil || len(remoteAddrMap) == 0 {
+		return peers, nil
+	}
+
+	peerStateMap, err := snmp.GetTreeAsIndexMap(goSnmp, baseOidPeerState, true)
+	if err != nil {
+		return nil, err
+	}
+
+	peerRemoteAsMap, err := snmp.GetTreeAsIndexMap(goSnmp, baseOidPeerRemoteAs, true)
+	if err != nil {
+		return nil, err
+	}
+
+	peerIndexMap, err := snmp.GetTreeAsIndexMap(goSnmp, baseOidPeerIndex, true)
+	if err != nil {
+		return nil, err
+	}
+
+	inPrefixesMap, err := snmp.GetTreeAsIndexMap(goSnmp, baseOidInPrefixes, true)
+	if err != nil {
+		return nil, err
+	}
+
+	inPrefixesAcceptedMap, err := snmp.GetTreeAsIndexMap(goSnmp, baseOidInPrefixesAccepted, true)
+	if err != nil {
+		return nil, err
+	}
+
+	receivedByPeerIndex := sumPrefixCountersByPeerIndex(inPrefixesMap)
+	acceptedByPeerIndex := sumPrefixCountersByPeerIndex(inPrefixesAcceptedMap)
+
+	peersByIP := make(map[string]*snmp.BgpPeer, len(peers))
+	for i := range peers {
+		peersByIP[peers[i].PeerIP] = &peers[i]
+	}
+
+	var ipv6Peers []snmp.BgpPeer
+
+	for tableIndex, remoteAddrResult := range remoteAddrMap {
+		remoteAddr, ok := remoteAddrResult.Value.([]byte)
+		if !ok || (len(remoteAddr) != 4 && len(remoteAddr) != 16) {
+			continue
+		}
+		peerIP := net.IP(remoteAddr).String()
+
+		peer, exists := peersByIP[peerIP]
+		if !exists {
+			ipv6Peers = append(ipv6Peers, snmp.BgpPeer{PeerIP: peerIP})
+			peer = &ipv6Peers[len(ipv6Peers)-1]
+
+			if stateResult, hasState := peerStateMap[tableIndex]; hasState {
+				if state, err := stateResult.IntValue(); err == nil {
+					peer.State = state
+				}
+			}
+			peer.StateName = snmp.BgpStateName(peer.State)
+
+			if remoteAsResult, hasRemoteAs := peerRemoteAsMap[tableIndex]; hasRemoteAs {
+				if remoteAs, err := remoteAsResult.IntValue(); err == nil {
+					peer.RemoteAS = remoteAs
+				}
+			}
+		}
+
+		peerIndexResult, hasPeerIndex := peerIndexMap[tableIndex]
+		if !hasPeerIndex {
+			continue
+		}
+		peerIndex := peerIndexResult.StringValue()
+
+		if received, hasReceived := receivedByPeerIndex[peerIndex]; hasReceived {
+			peer.PrefixesReceived = &received
+		}
+		if accepted, hasAccepted := acceptedByPeerIndex[peerIndex]; hasAccepted {
+			peer.PrefixesAccepted = &accepted
+		}
+	}
+
+	peers = append(peers, ipv6Peers...)
+
+	sort.Slice(peers, func(i, j int) bool {
+		return peers[i].PeerIP < peers[j].PeerIP
+	})
+
+	return peers, nil
+}
+
+// sumPrefixCountersByPeerIndex folds the per-AFI/SAFI rows (peerIndex.afi.safi)
+// into one counter per peer.
+func sumPrefixCountersByPeerIndex(counters map[string]snmp.WalkResult) map[string]uint64 {
+	totals := make(map[string]uint64)
+	for index, result := range counters {
+		peerIndex, _, _ := strings.Cut(index, ".")
+		value, err := strconv.ParseUint(result.StringValue(), 10, 64)
+		if err != nil {
+			continue
+		}
+		totals[peerIndex] += value
+	}
+	return totals
+}
@@ -0,0 +1,95 @@
+package junipersnmpcollectors
+
+import (
+	"net_monitor/interfaces"
+	"net_monitor/snmp"
+	"strconv"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+type InterfaceStats struct {
+	Index       string `json:"index"`
+	Name        string `json:"name"`
+	Alias       string `json:"alias,omitempty"`
+	OperStatus  int    `json:"oper_status"` // 1=up, 2=down, 3=testing, 4=unknown, 5=dormant, 6=notPresent, 7=lowerLayerDown
+	SpeedMbps   uint64 `json:"speed_mbps"`
+	InOctets    uint64 `json:"in_octets"`
+	OutOctets   uint64 `json:"out_octets"`
+	InErrors    uint64 `json:"in_errors"`
+	OutErrors   uint64 `json:"out_errors"`
+	InDiscards  uint64 `json:"in_discards"`
+	OutDiscards uint64 `json:"out_discards"`
+}
+
+func CollectJuniperInterfaceStats(goSnmp *gosnmp.GoSNMP, device interfaces.NetworkDevice) ([]InterfaceStats, error) {
+	baseOidName := "1.3.6.1.2.1.31.1.1.1.1"         // ifName
+	baseOidAlias := "1.3.6.1.2.1.31.1.1.1.18"       // ifAlias
+	baseOidHighSpeed := "1.3.6.1.2.1.31.1.1.1.15"   // ifHighSpeed
+	baseOidHCInOctets := "1.3.6.1.2.1.31.1.1.1.6"   // ifHCInOctets
+	baseOidHCOutOctets := "1.3.6.1.2.1.31.1.1.1.10" // ifHCOutOctets
+	baseOidOperStatus := "1.3.6.1.2.1.2.2.1.8"      // ifOperStatus
+	baseOidInDiscards := "1.3.6.1.2.1.2.2.1.13"     // ifInDiscards
+	baseOidInErrors := "1.3.6.1.2.1.2.2.1.14"       // ifInErrors
+	baseOidOutDiscards := "1.3.6.1.2.1.2.2.1.19"    // ifOutDiscards
+	baseOidOutErrors := "1.3.6.1.2.1.2.2.1.20"      // ifOutErrors
+
+	namesMap, err := snmp.GetTreeAsIndexMap(goSnmp, baseOidName, true)
+	if err != nil {
+		return nil, err
+	}
+
+	counterMaps := make(map[string]map[string]snmp.WalkResult)
+	for _, baseOid := range []string{
+		baseOidAlias, baseOidHighSpeed, baseOidHCInOctets, baseOidHCOutOctets, baseOidOperStatus,
+		baseOidInDiscards, baseOidInErrors, baseOidOutDiscards, baseOidOutErrors,
+	} {
+		resultMap, err := snmp.GetTreeAsIndexMap(goSnmp, baseOid, true)
+		if err != nil {
+			return nil, err
+		}
+		counterMaps[baseOid] = resultMap
+	}
+
+	counter := func(baseOid, index string) uint64 {
+		result, exists := counterMaps[baseOid][index]
+		if !exists {
+			return 0
+		}
+		value, err := strconv.ParseUint(result.StringValue(), 10, 64)
+		if err != nil {
+			return 0
+		}
+		return value
+	}
+
+	stats := make([]InterfaceStats, 0, len(namesMap))
+
+	for index, nameResult := range namesMap {
+		iface := InterfaceStats{
+			Index:       index,
+			Name:        nameResult.StringValue(),
+			SpeedMbps:   counter(baseOidHighSpeed, index),
+			InOctets:    counter(baseOidHCInOctets, index),
+			OutOctets:   counter(baseOidHCOutOctets, index),
+			InErrors:    counter(baseOidInErrors, index),
+			OutErrors:   counter(baseOidOutErrors, index),
+			InDiscards:  counter(baseOidInDiscards, index),
+			OutDiscards: counter(baseOidOutDiscards, index),
+		}
+
+		if aliasResult, hasAlias := counterMaps[baseOidAlias][index]; hasAlias {
+			iface.Alias = aliasResult.StringValue()
+		}
+
+		if operStatusResult, hasOperStatus := counterMaps[baseOidOperStatus][index]; hasOperStatus {
+			if operStatus, err := operStatusResult.IntValue(); err == nil {
+				iface.OperStatus = operStatus
+			}
+		}
+
+		stats = append(stats, iface)
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,16 @@
+package junipersnmpcollectors
+
+import (
+	"net_monitor/interfaces"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+func CollectJuniperRoutingEngineCpu(goSnmp *gosnmp.GoSNMP, device interfaces.NetworkDevice) (int, error) {
+	routingEngine, err := getJuniperMasterRoutingEngine(goSnmp, device)
+	if err != nil {
+		return 0, err
+	}
+
+	return routingEngine.CpuUsage, nil
+}
@@ -0,0 +1,26 @@
+package junipersnmpcollectors
+
+import (
+	"net_monitor/interfaces"
+	Utils "net_monitor/utils"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+func CollectJuniperRoutingEngineMemory(goSnmp *gosnmp.GoSNMP, device interfaces.NetworkDevice) (float64, error) {
+	routingEngine, err := getJuniperMasterRoutingEngine(goSnmp, device)
+	if err != nil {
+		return 0, err
+	}
+
+	return Utils.ChangeFloatPrecision(routingEngine.UsedMemoryMB, 1), nil
+}
+
+func CollectJuniperRoutingEngineTotalMemory(goSnmp *gosnmp.GoSNMP, device interfaces.NetworkDevice) (int, error) {
+	routingEngine, err := getJuniperMasterRoutingEngine(goSnmp, device)
+	if err != nil {
+		return 0, err
+	}
+
+	return routingEngine.MemoryMB, nil
+}
@@ -0,0 +1,141 @@
+package junipersnmpcollectors
+
+import (
+	"fmt"
+	"net_monitor/interfaces"
+	"net_monitor/snmp"
+	"sort"
+	"strings"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+// jnxOperatingTable rows for routing engines live under container index 9.
+const juniperRoutingEngineContainer = "9."
+
+type RoutingEngine struct {
+	Index        string  `json:"index"`
+	Description  string  `json:"description"`
+	State        int     `json:"state"` // 1=unknown, 2=running, 3=ready, 4=reset, 5=runningAtFullSpeed, 6=down, 7=standby
+	CpuUsage     int     `json:"cpuUsage"`
+	BufferUsage  int     `json:"bufferUsage"`
+	MemoryMB     int     `json:"memoryMb"`
+	UsedMemoryMB float64 `json:"usedMemoryMb"`
+	Temperature  float64 `json:"temperature"`
+}
+
+func CollectJuniperRoutingEngines(goSnmp *gosnmp.GoSNMP, device interfaces.NetworkDevice) ([]RoutingEngine, error) {
+	baseOidDescr := "1.3.6.1.4.1.2636.3.1.13.1.5"   // jnxOperatingDescr
+	baseOidState := "1.3.6.1.4.1.2636.3.1.13.1.6"   // jnxOperatingState
+	baseOidTemp := "1.3.6.1.4.1.2636.3.1.13.1.7"    // jnxOperatingTemp
+	baseOidCpu := "1.3.6.1.4.1.2636.3.1.13.1.8"     // jnxOperatingCPU
+	baseOidBuffer := "1.3.6.1.4.1.2636.3.1.13.1.11" // jnxOperatingBuffer
+	baseOidMemory := "1.3.6.1.4.1.2636.3.1.13.1.15" // jnxOperatingMemory
+
+	descrMap, err := snmp.GetTreeAsIndexMap(goSnmp, baseOidDescr, true)
+	if err != nil {
+		return nil, err
+	}
+
+	stateMap, err := snmp.GetTreeAsIndexMap(goSnmp, baseOidState, true)
+	if err != nil {
+		return nil, err
+	}
+
+	tempMap, err := snmp.GetTreeAsIndexMap(goSnmp, baseOidTemp, true)
+	if err != nil {
+		return nil, err
+	}
+
+	cpuMap, err := snmp.GetTreeAsIndexMap(goSnmp, baseOidCpu, true)
+	if err != nil {
+		return nil, err
+	}
+
+	bufferMap, err := snmp.GetTreeAsIndexMap(goSnmp, baseOidBuffer, true)
+	if err != nil {
+		return nil, err
+	}
+
+	memoryMap, err := snmp.GetTreeAsIndexMap(goSnmp, baseOidMemory, true)
+	if err != nil {
+		return nil, err
+	}
+
+	routingEngines := make([]RoutingEngine, 0)
+
+	for index, descrResult := range descrMap {
+		if !strings.HasPrefix(index, juniperRoutingEngineContainer) {
+			continue
+		}
+
+		routingEngine := RoutingEngine{
+			Index:       index,
+			Description: descrResult.StringValue(),
+		}
+
+		if stateResult, hasState := stateMap[index]; hasState {
+			if state, err := stateResult.IntValue(); err == nil {
+				routingEngine.State = state
+			}
+		}
+
+		if tempResult, hasTemp := tempMap[index]; hasTemp {
+			if temp, err := tempResult.IntValue(); err == nil {
+				routingEngine.Temperature = float64(temp)
+			}
+		}
+
+		if cpuResult, hasCpu := cpuMap[index]; hasCpu {
+			if cpu, err := cpuResult.IntValue(); err == nil {
+				routingEngine.CpuUsage = cpu
+			}
+		}
+
+		if bufferResult, hasBuffer := bufferMap[index]; hasBuffer {
+			if buffer, err := bufferResult.IntValue(); err == nil {
+				routingEngine.BufferUsage = buffer
+			}
+		}
+
+		if memoryResult, hasMemory := memoryMap[index]; hasMemory {
+			if memory, err := memoryResult.IntValue(); err == nil {
+				routingEngine.MemoryMB = memory
+			}
+		}
+
+		routingEngine.UsedMemoryMB = float64(routingEngine.MemoryMB) * float64(routingEngine.BufferUsage) / 100
+
+		routingEngines = append(routingEngines, routingEngine)
+	}
+
+	sort.Slice(routingEngines, func(i, j int) bool {
+		return routingEngines[i].Index < routingEngines[j].Index
+	})
+
+	return routingEngines, nil
+}
+
+func getJuniperMasterRoutingEngine(goSnmp *gosnmp.GoSNMP, device interfaces.NetworkDevice) (*RoutingEngine, error) {
+	routingEngines, err := CollectJuniperRoutingEngines(goSnmp, device)
+	if err != nil {
+		return nil, err
+	}
+	return JuniperMasterRoutingEngine(routingEngines, device)
+}
+
+// JuniperMasterRoutingEngine escolhe o RE master (o primeiro fora de standby)
+// entre os já coletados, ou o primeiro quando todos estão em standby.
+func JuniperMasterRoutingEngine(routingEngines []RoutingEngine, device interfaces.NetworkDevice) (*RoutingEngine, error) {
+	if len(routingEngines) == 0 {
+		return nil, fmt.Errorf("No routing engine found for %v:%v", device.GetName(), device.GetIPAddress())
+	}
+
+	for i := range routingEngines {
+		if routingEngines[i].State != 7 {
+			return &routingEngines[i], nil
+		}
+	}
+
+	return &routingEngines[0], nil
+}
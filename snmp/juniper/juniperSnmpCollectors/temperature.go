@@ -0,0 +1,16 @@
+package junipersnmpcollectors
+
+import (
+	"net_monitor/interfaces"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+func CollectJuniperTemperature(goSnmp *gosnmp.GoSNMP, device interfaces.NetworkDevice) (float64, error) {
+	routingEngine, err := getJuniperMasterRoutingEngine(goSnmp, device)
+	if err != nil {
+		return 0.0, err
+	}
+
+	return routingEngine.Temperature, nil
+}
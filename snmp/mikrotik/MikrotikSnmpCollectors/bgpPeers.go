@@ -0,0 +1,12 @@
+package mikrotiksnmpcollectors
+
+import (
+	"net_monitor/interfaces"
+	snmp "net_monitor/snmp"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+func CollectMikrotikBgpPeers(goSnmp *gosnmp.GoSNMP, device interfaces.NetworkDevice) ([]snmp.BgpPeer, error) {
+	return snmp.CollectBgpPeers(goSnmp, device)
+}
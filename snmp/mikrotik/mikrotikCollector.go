@@ -87,6 +87,8 @@ func (m *MikrotikCollector) CollectMetric(device interfaces.NetworkDevice, metri
 		return mikrotiksnmpcollectors.CollectMikrotikVlans(snmpParams, device)
 	case "temperature":
 		return mikrotiksnmpcollectors.CollectMikrotikTemperature(snmpParams, device)
+	case "bgpPeers":
+		return mikrotiksnmpcollectors.CollectMikrotikBgpPeers(snmpParams, device)
 	default:
 		return nil, fmt.Errorf("Metric '%s' not supported by Mikrotik collector", metricName)
 	}
@@ -96,7 +98,7 @@ func (m *MikrotikCollector) GetSupportedMetrics() []string {
 	return []string{
 		"cpu_usage", "memory_usage", "disk_usage", "total_disk",
 		"interface_stats", "system_info", "physicalInterfaces",
-		"vlans", "temperature",
+		"vlans", "temperature", "bgpPeers",
 	}
 }
 
@@ -110,6 +112,7 @@ func (m *MikrotikCollector) GetMetricMapping() map[string]string {
 		"physicalInterfaces": "physicalInterfaces",
 		"vlans":              "vlans",
 		"temperature":        "temperature",
+		"bgpPeers":           "bgpPeers",
 	}
 }
 
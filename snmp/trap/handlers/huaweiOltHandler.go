@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"fmt"
+	"net_monitor/interfaces"
+	"net_monitor/snmp"
+	huaweisnmpcollectors "net_monitor/snmp/huawei/huaweiSnmpCollectors"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+const (
+	HUAWEI_BASE_OID                 = "1.3.6.1.4.1.2011"
+	HUAWEI_ONT_ONLINE_OID           = "1.3.6.1.4.1.2011.6.128.1.1.3.75"
+	HUAWEI_ONT_OFFLINE_OID          = "1.3.6.1.4.1.2011.6.128.1.1.3.76"
+	HUAWEI_ONT_DYING_GASP_OID       = "1.3.6.1.4.1.2011.6.128.1.1.3.39"
+	HUAWEI_ONT_SN_OID               = "1.3.6.1.4.1.2011.6.128.1.1.2.43.1.3"
+	HUAWEI_ONT_RUN_STATUS_OID       = "1.3.6.1.4.1.2011.6.128.1.1.2.46.1.15"
+	HUAWEI_ONT_LAST_DOWN_CAUSE_OID  = "1.3.6.1.4.1.2011.6.128.1.1.2.46.1.24"
+	HUAWEI_ONT_OPTICAL_RX_POWER_OID = "1.3.6.1.4.1.2011.6.128.1.1.2.51.1.4"
+	HUAWEI_ONT_OPTICAL_TX_POWER_OID = "1.3.6.1.4.1.2011.6.128.1.1.2.51.1.3"
+)
+
+type HuaweiOltTrapHandler struct {
+	rfcHandler *RFCTrapHandler
+}
+
+func NewHuaweiOltTrapHandler() *HuaweiOltTrapHandler {
+	return &HuaweiOltTrapHandler{
+		rfcHandler: NewRFCTrapHandler(),
+	}
+}
+
+func (h *HuaweiOltTrapHandler) GetVendor() string {
+	return "huawei"
+}
+
+func (h *HuaweiOltTrapHandler) GetDeviceType() string {
+	return "olt"
+}
+
+func (h *HuaweiOltTrapHandler) CanHandle(trapOID string) bool {
+	if snmp.ContainsOid(trapOID, HUAWEI_BASE_OID) {
+		return true
+	}
+
+	return h.rfcHandler.CanHandle(trapOID)
+}
+
+func (h *HuaweiOltTrapHandler) ParseTrap(packet *gosnmp.SnmpPacket, device interfaces.NetworkDevice, deviceType string) (*interfaces.TrapEvent, error) {
+	trapOID := h.rfcHandler.ExtractTrapOID(packet)
+	if trapOID == "" {
+		return nil, fmt.Errorf("trap OID not found")
+	}
+
+	if h.rfcHandler.CanHandle(trapOID) {
+		return h.rfcHandler.ParseTrap(packet, device, deviceType)
+	}
+
+	event := &interfaces.TrapEvent{
+		DeviceID:   device.GetID(),
+		DeviceName: device.GetName(),
+		DeviceIP:   device.GetIPAddress(),
+		DeviceType: deviceType,
+		Vendor:     device.GetIntegration(),
+		TrapOID:    trapOID,
+		Timestamp:  time.Now(),
+		Data:       make(map[string]interface{}),
+	}
+
+	switch trapOID {
+	case HUAWEI_ONT_ONLINE_OID:
+		return h.parseOntOnline(packet, event)
+	case HUAWEI_ONT_OFFLINE_OID:
+		return h.parseOntOffline(packet, event)
+	case HUAWEI_ONT_DYING_GASP_OID:
+		return h.parseOntDyingGasp(packet, event)
+	default:
+		return h.parseGenericHuaweiTrap(packet, event)
+	}
+}
+
+func (h *HuaweiOltTrapHandler) parseOntOnline(packet *gosnmp.SnmpPacket, event *interfaces.TrapEvent) (*interfaces.TrapEvent, error) {
+	event.EventType = "ONU_ONLINE"
+	event.Message = "ONU ficou online"
+
+	onu := h.extractHuaweiONUData(packet, event)
+	onu.OnlineStatus = 1
+	event.Data["onu"] = onu
+	event.OnuChangeEvent = interfaces.ONUChangeConfigEvent{
+		SerialNumber: onu.SerialNumber,
+		OnuStatus:    "ONU_UP",
+	}
+
+	return event, nil
+}
+
+func (h *HuaweiOltTrapHandler) parseOntOffline(packet *gosnmp.SnmpPacket, event *interfaces.TrapEvent) (*interfaces.TrapEvent, error) {
+	event.EventType = "ONU_OFFLINE"
+	event.Message = "ONU ficou offline"
+
+	onu := h.extractHuaweiONUData(packet, event)
+	onu.OnlineStatus = 2
+	if onu.LastDownCause != "" {
+		event.Message = fmt.Sprintf("ONU ficou offline: %s", onu.LastDownCause)
+	}
+	event.Data["onu"] = onu
+	event.OnuChangeEvent = interfaces.ONUChangeConfigEvent{
+		SerialNumber: onu.SerialNumber,
+		OnuStatus:    "ONU_DOWN",
+	}
+
+	return event, nil
+}
+
+func (h *HuaweiOltTrapHandler) parseOntDyingGasp(packet *gosnmp.SnmpPacket, event *interfaces.TrapEvent) (*interfaces.TrapEvent, error) {
+	event.EventType = "ONU_DYING_GASP"
+	event.Message = "ONU perdeu energia (dying gasp)"
+
+	onu := h.extractHuaweiONUData(packet, event)
+	onu.OnlineStatus = 2
+	onu.LastDownCause = "dying-gasp"
+	event.Data["onu"] = onu
+	event.OnuChangeEvent = interfaces.ONUChangeConfigEvent{
+		SerialNumber: onu.SerialNumber,
+		OnuStatus:    "ONU_DOWN",
+	}
+
+	return event, nil
+}
+
+func (h *HuaweiOltTrapHandler) parseGenericHuaweiTrap(packet *gosnmp.SnmpPacket, event *interfaces.TrapEvent) (*interfaces.TrapEvent, error) {
+	event.EventType = "huawei_generic_trap"
+	event.Message = "Trap Huawei genérica recebida"
+
+	onu := h.extractHuaweiONUData(packet, event)
+	if onu.SerialNumber != "" {
+		event.Data["onu"] = onu
+	}
+
+	return event, nil
+}
+
+func (h *HuaweiOltTrapHandler) extractHuaweiONUData(packet *gosnmp.SnmpPacket, event *interfaces.TrapEvent) snmp.OnuInfo {
+	onu := snmp.OnuInfo{}
+
+	for _, variable := range packet.Variables {
+		result := snmp.WalkResult{OID: variable.Name, Value: variable.Value}
+
+		switch {
+		case snmp.ContainsOid(variable.Name, HUAWEI_ONT_SN_OID):
+			onu.Index = result.GetIndex(HUAWEI_ONT_SN_OID)
+			onu.SerialNumber = huaweisnmpcollectors.FormatHuaweiOnuSerialNumber(variable.Value)
+		case snmp.ContainsOid(variable.Name, HUAWEI_ONT_RUN_STATUS_OID):
+			if runStatus, err := result.IntValue(); err == nil {
+				onu.OnlineStatus = runStatus
+			}
+		case snmp.ContainsOid(variable.Name, HUAWEI_ONT_LAST_DOWN_CAUSE_OID):
+			if lastDownCause, err := result.IntValue(); err == nil {
+				onu.LastDownCause = huaweisnmpcollectors.HuaweiOnuDownCause(lastDownCause)
+			}
+		case snmp.ContainsOid(variable.Name, HUAWEI_ONT_OPTICAL_RX_POWER_OID):
+			if rxPower, err := result.IntValue(); err == nil {
+				onu.RXPower = float64(rxPower) / 100.0
+			}
+		case snmp.ContainsOid(variable.Name, HUAWEI_ONT_OPTICAL_TX_POWER_OID):
+			if txPower, err := result.IntValue(); err == nil {
+				onu.TXPower = float64(txPower) / 100.0
+			}
+		}
+	}
+
+	if onu.Index != "" {
+		event.Data["onu_index"] = onu.Index
+	}
+
+	h.rfcHandler.ExtractInterfaceData(packet, event)
+
+	return onu
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"fmt"
+	"net_monitor/interfaces"
+	"net_monitor/snmp"
+	"strings"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+const (
+	JUNIPER_BASE_OID               = "1.3.6.1.4.1.2636"
+	JUNIPER_POWER_SUPPLY_FAILURE   = "1.3.6.1.4.1.2636.4.1.1"
+	JUNIPER_FAN_FAILURE            = "1.3.6.1.4.1.2636.4.1.2"
+	JUNIPER_OVER_TEMPERATURE       = "1.3.6.1.4.1.2636.4.1.3"
+	JUNIPER_POWER_SUPPLY_OK        = "1.3.6.1.4.1.2636.4.2.1"
+	JUNIPER_FAN_OK                 = "1.3.6.1.4.1.2636.4.2.2"
+	JUNIPER_TEMPERATURE_OK         = "1.3.6.1.4.1.2636.4.2.3"
+	JUNIPER_CONTENTS_CONTAINER_OID = "1.3.6.1.4.1.2636.3.1.8.1.1"
+	JUNIPER_CONTENTS_DESCR_OID     = "1.3.6.1.4.1.2636.3.1.8.1.6"
+	JUNIPER_OPERATING_TEMP_OID     = "1.3.6.1.4.1.2636.3.1.13.1.7"
+	JUNIPER_OPERATING_STATE_OID    = "1.3.6.1.4.1.2636.3.1.13.1.6"
+)
+
+type JuniperTrapHandler struct {
+	rfcHandler *RFCTrapHandler
+}
+
+func NewJuniperTrapHandler() *JuniperTrapHandler {
+	return &JuniperTrapHandler{
+		rfcHandler: NewRFCTrapHandler(),
+	}
+}
+
+func (h *JuniperTrapHandler) GetVendor() string {
+	return "juniper"
+}
+
+func (h *JuniperTrapHandler) CanHandle(trapOID string) bool {
+	if snmp.ContainsOid(trapOID, JUNIPER_BASE_OID) {
+		return true
+	}
+	return h.rfcHandler.CanHandle(trapOID)
+}
+
+func (h *JuniperTrapHandler) ParseTrap(packet *gosnmp.SnmpPacket, device interfaces.NetworkDevice, deviceType string) (*interfaces.TrapEvent, error) {
+	trapOID := h.rfcHandler.ExtractTrapOID(packet)
+	if trapOID == "" {
+		return nil, fmt.Errorf("Trap OID not found")
+	}
+
+	if h.rfcHandler.CanHandle(trapOID) {
+		return h.rfcHandler.ParseTrap(packet, device, deviceType)
+	}
+
+	event := &interfaces.TrapEvent{
+		DeviceID:   device.GetID(),
+		DeviceName: device.GetName(),
+		DeviceIP:   device.GetIPAddress(),
+		DeviceType: deviceType,
+		Vendor:     device.GetIntegration(),
+		TrapOID:    trapOID,
+		Timestamp:  time.Now(),
+		Data:       make(map[string]interface{}),
+	}
+
+	switch trapOID {
+	case JUNIPER_POWER_SUPPLY_FAILURE:
+		event.EventType = "power_supply_failure"
+		event.Message = "Juniper power supply failure"
+	case JUNIPER_FAN_FAILURE:
+		event.EventType = "fan_failure"
+		event.Message = "Juniper fan failure"
+	case JUNIPER_OVER_TEMPERATURE:
+		event.EventType = "over_temperature"
+		event.Message = "Juniper over temperature"
+	case JUNIPER_POWER_SUPPLY_OK:
+		event.EventType = "power_supply_ok"
+		event.Message = "Juniper power supply recovered"
+	case JUNIPER_FAN_OK:
+		event.EventType = "fan_ok"
+		event.Message = "Juniper fan recovered"
+	case JUNIPER_TEMPERATURE_OK:
+		event.EventType = "temperature_ok"
+		event.Message = "Juniper temperature back to normal"
+	default:
+		event.EventType = "juniper_generic_trap"
+		event.Message = "Juniper trap received"
+		h.extractVarbinds(packet, event)
+	}
+
+	h.extractJuniperChassisData(packet, event)
+
+	if component, ok := event.Data["component"].(string); ok && component != "" {
+		event.Message = fmt.Sprintf("%s: %s", event.Message, component)
+	}
+	if temperature, ok := event.Data["temperature"].(int); ok && trapOID == JUNIPER_OVER_TEMPERATURE {
+		event.Message = fmt.Sprintf("%s (%d°C)", event.Message, temperature)
+	}
+
+	return event, nil
+}
+
+// extractVarbinds guarda as varbinds das traps sem parser próprio para que
+// não se percam; sysUpTime e snmpTrapOID já estão no evento.
+func (h *JuniperTrapHandler) extractVarbinds(packet *gosnmp.SnmpPacket, event *interfaces.TrapEvent) {
+	varbinds := make(map[string]interface{})
+	for _, variable := range packet.Variables {
+		oid := strings.TrimPrefix(variable.Name, ".")
+		if oid == OID_SYS_UPTIME || oid == OID_SNMP_TRAP_OID {
+			continue
+		}
+		result := snmp.WalkResult{OID: variable.Name, Value: variable.Value}
+		varbinds[oid] = result.StringValue()
+	}
+	event.Data["varbinds"] = varbinds
+}
+
+func (h *JuniperTrapHandler) extractJuniperChassisData(packet *gosnmp.SnmpPacket, event *interfaces.TrapEvent) {
+	for _, variable := range packet.Variables {
+		result := snmp.WalkResult{OID: variable.Name, Value: variable.Value}
+
+		switch {
+		case snmp.ContainsOid(variable.Name, JUNIPER_CONTENTS_CONTAINER_OID):
+			if container, err := result.IntValue(); err == nil {
+				event.Data["container_index"] = container
+			}
+		case snmp.ContainsOid(variable.Name, JUNIPER_CONTENTS_DESCR_OID):
+			event.Data["component"] = result.StringValue()
+			event.Data["component_index"] = result.GetIndex(JUNIPER_CONTENTS_DESCR_OID)
+		case snmp.ContainsOid(variable.Name, JUNIPER_OPERATING_TEMP_OID):
+			if temperature, err := result.IntValue(); err == nil {
+				event.Data["temperature"] = temperature
+			}
+		case snmp.ContainsOid(variable.Name, JUNIPER_OPERATING_STATE_OID):
+			if state, err := result.IntValue(); err == nil {
+				event.Data["operating_state"] = state
+			}
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"net_monitor/interfaces"
+	"net_monitor/snmp"
 
 	"github.com/gosnmp/gosnmp"
 )
@@ -15,11 +16,17 @@ const (
 	OID_COLD_START      = "1.3.6.1.6.3.1.1.5.1"
 	OID_WARM_START      = "1.3.6.1.6.3.1.1.5.2"
 	OID_AUTH_FAILURE    = "1.3.6.1.6.3.1.1.5.5"
+	OID_SYS_UPTIME      = "1.3.6.1.2.1.1.3.0"
 	OID_SNMP_TRAP_OID   = "1.3.6.1.6.3.1.1.4.1.0"
 	OID_IF_INDEX        = "1.3.6.1.2.1.2.2.1.1"
 	OID_IF_DESCR        = "1.3.6.1.2.1.2.2.1.2"
 	OID_IF_ADMIN_STATUS = "1.3.6.1.2.1.2.2.1.7"
 	OID_IF_OPER_STATUS  = "1.3.6.1.2.1.2.2.1.8"
+
+	OID_BGP_ESTABLISHED                 = "1.3.6.1.2.1.15.7.1"
+	OID_BGP_BACKWARD_TRANSITION         = "1.3.6.1.2.1.15.7.2"
+	OID_BGP_ESTABLISHED_NOTIFICATION    = "1.3.6.1.2.1.15.0.1"
+	OID_BGP_BACKWARD_TRANS_NOTIFICATION = "1.3.6.1.2.1.15.0.2"
 )
 
 type RFCTrapHandler struct{}
@@ -39,6 +46,11 @@ func (h *RFCTrapHandler) CanHandle(trapOID string) bool {
 		OID_COLD_START:   true,
 		OID_WARM_START:   true,
 		OID_AUTH_FAILURE: true,
+
+		OID_BGP_ESTABLISHED:                 true,
+		OID_BGP_BACKWARD_TRANSITION:         true,
+		OID_BGP_ESTABLISHED_NOTIFICATION:    true,
+		OID_BGP_BACKWARD_TRANS_NOTIFICATION: true,
 	}
 	return standardTraps[trapOID]
 }
@@ -72,6 +84,10 @@ func (h *RFCTrapHandler) ParseTrap(packet *gosnmp.SnmpPacket, device interfaces.
 		return h.parseWarmStart(packet, event)
 	case OID_AUTH_FAILURE:
 		return h.parseAuthFailure(packet, event)
+	case OID_BGP_ESTABLISHED, OID_BGP_ESTABLISHED_NOTIFICATION:
+		return h.parseBgpEstablished(packet, event)
+	case OID_BGP_BACKWARD_TRANSITION, OID_BGP_BACKWARD_TRANS_NOTIFICATION:
+		return h.parseBgpBackwardTransition(packet, event)
 	default:
 		return nil, fmt.Errorf("trap OID RFC não suportado: %s", trapOID)
 	}
@@ -134,6 +150,55 @@ func (h *RFCTrapHandler) parseAuthFailure(packet *gosnmp.SnmpPacket, event *inte
 	return event, nil
 }
 
+func (h *RFCTrapHandler) parseBgpEstablished(packet *gosnmp.SnmpPacket, event *interfaces.TrapEvent) (*interfaces.TrapEvent, error) {
+	event.EventType = "bgp_established"
+	event.Message = "Sessão BGP estabelecida"
+
+	h.ExtractBgpPeerData(packet, event)
+	if peerIP, ok := event.Data["peer_ip"].(string); ok {
+		event.Message = fmt.Sprintf("Sessão BGP com %s estabelecida", peerIP)
+	}
+
+	return event, nil
+}
+
+func (h *RFCTrapHandler) parseBgpBackwardTransition(packet *gosnmp.SnmpPacket, event *interfaces.TrapEvent) (*interfaces.TrapEvent, error) {
+	event.EventType = "bgp_backward_transition"
+	event.Message = "Sessão BGP caiu"
+
+	h.ExtractBgpPeerData(packet, event)
+	if peerIP, ok := event.Data["peer_ip"].(string); ok {
+		event.Message = fmt.Sprintf("Sessão BGP com %s caiu", peerIP)
+		if stateName, ok := event.Data["state_name"].(string); ok {
+			event.Message = fmt.Sprintf("%s (estado atual: %s)", event.Message, stateName)
+		}
+	}
+
+	return event, nil
+}
+
+func (h *RFCTrapHandler) ExtractBgpPeerData(packet *gosnmp.SnmpPacket, event *interfaces.TrapEvent) {
+	for _, variable := range packet.Variables {
+		result := snmp.WalkResult{OID: variable.Name, Value: variable.Value}
+
+		switch {
+		case h.contains(variable.Name, snmp.OID_BGP_PEER_STATE):
+			event.Data["peer_ip"] = result.GetIndex(snmp.OID_BGP_PEER_STATE)
+			if state, err := result.IntValue(); err == nil {
+				event.Data["state"] = state
+				event.Data["state_name"] = snmp.BgpStateName(state)
+			}
+		case h.contains(variable.Name, snmp.OID_BGP_PEER_REMOTE_ADDR):
+			event.Data["peer_ip"] = result.GetIndex(snmp.OID_BGP_PEER_REMOTE_ADDR)
+		case h.contains(variable.Name, snmp.OID_BGP_PEER_LAST_ERROR):
+			if lastError, ok := variable.Value.([]byte); ok && len(lastError) >= 2 {
+				event.Data["last_error_code"] = int(lastError[0])
+				event.Data["last_error_subcode"] = int(lastError[1])
+			}
+		}
+	}
+}
+
 func (h *RFCTrapHandler) ExtractInterfaceData(packet *gosnmp.SnmpPacket, event *interfaces.TrapEvent) {
 	for _, variable := range packet.Variables {
 		oid := variable.Name
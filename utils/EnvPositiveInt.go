@@ -0,0 +1,15 @@
+package Utils
+
+import (
+	"os"
+	"strconv"
+)
+
+// EnvPositiveInt lê um inteiro positivo da variável de ambiente, ou devolve o
+// padrão quando ela está vazia ou inválida.
+func EnvPositiveInt(name string, defaultValue int) int {
+	if value, err := strconv.Atoi(os.Getenv(name)); err == nil && value > 0 {
+		return value
+	}
+	return defaultValue
+}
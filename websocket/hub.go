@@ -71,7 +71,11 @@ func NewHub(authService AuthService) *Hub {
 func (h *Hub) RegisterCollector(collector SNMPCollector) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
-	h.collectors[collector.GetVendor()] = collector
+	key := collector.GetVendor()
+	if scoped, ok := collector.(interfaces.DeviceTypeScoped); ok {
+		key = scoped.GetDeviceType() + ":" + key
+	}
+	h.collectors[key] = collector
 }
 
 func (h *Hub) Run() {